@@ -0,0 +1,190 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package acme solves ACME DNS-01 challenges using any DNS provider.
+package acme
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+const (
+	// ChallengeTTL is the TTL of challenge records, kept short so
+	// stale values are not cached for long.
+	ChallengeTTL = 120
+	// DefaultTimeout bounds each call of a ChallengeProvider.
+	DefaultTimeout = 2 * time.Minute
+
+	challengeLabel = "_acme-challenge"
+)
+
+// ChallengeName returns the name of the TXT record for the DNS-01
+// challenge of the domain. Wildcard domains share the record of their
+// base domain, and names that already are challenge names are
+// returned unchanged, all without a trailing dot.
+func ChallengeName(domain string) string {
+	domain = strings.TrimSuffix(domain, ".")
+	domain = strings.TrimPrefix(domain, "*.")
+	if strings.HasPrefix(strings.ToLower(domain), challengeLabel+".") {
+		return domain
+	}
+	return challengeLabel + "." + domain
+}
+
+// ChallengeValue returns the TXT value for the key authorization of a
+// DNS-01 challenge, as defined by RFC 8555 section 8.4.
+func ChallengeValue(keyAuth string) string {
+	sum := sha256.Sum256([]byte(keyAuth))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Present creates the challenge TXT record for the domain in the zone,
+// replacing any earlier challenge value.
+func Present(ctx context.Context, prov api.Provider, zone, domain, value string) error {
+	name := ChallengeName(domain)
+	if err := prov.CreateOrUpdateDNSRecord(ctx, zone, name, api.RecordTypeTXT, value, ChallengeTTL, false); err != nil {
+		return fmt.Errorf("cannot create challenge record %s, %w", name, err)
+	}
+	return nil
+}
+
+// CleanUp deletes the challenge TXT record for the domain in the zone.
+func CleanUp(ctx context.Context, prov api.Provider, zone, domain string) error {
+	name := ChallengeName(domain)
+	if err := prov.DeleteDNSRecord(ctx, zone, name); err != nil {
+		return fmt.Errorf("cannot delete challenge record %s, %w", name, err)
+	}
+	return nil
+}
+
+// FindZone returns the zone of the provider that the domain belongs
+// to, which is the longest matching zone name.
+func FindZone(ctx context.Context, prov api.Provider, domain string) (string, error) {
+	zones, err := prov.ListZones(ctx)
+	if err != nil {
+		return "", err
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	found := ""
+	for _, zone := range zones {
+		name := strings.ToLower(zone.Name)
+		if domain != name && !strings.HasSuffix(domain, "."+name) {
+			continue
+		}
+		if len(name) > len(found) {
+			found = zone.Name
+		}
+	}
+	if found == "" {
+		return "", fmt.Errorf("%w for %s", api.ErrZoneNotFound, domain)
+	}
+	return found, nil
+}
+
+// ChallengeProvider solves DNS-01 challenges with a DNS provider. It
+// implements the challenge.Provider interface of go-acme/lego, as well
+// as its optional timeout and sequential interfaces, without depending
+// on lego.
+type ChallengeProvider struct {
+	// Provider manages the challenge records.
+	Provider api.Provider
+	// Zone holds the challenge records. If empty, the zone is found
+	// from the zones of the provider for each domain.
+	Zone string
+	// Checker, if set, is used by Present to wait until the record
+	// is visible on the authoritative nameservers.
+	Checker *PropagationChecker
+	// PropagationTimeout is how long lego waits for the record to
+	// propagate, and also bounds each call since lego passes no
+	// context. If zero, DefaultTimeout is used.
+	PropagationTimeout time.Duration
+}
+
+// NewChallengeProvider returns a ChallengeProvider for the zone, which
+// may be empty to find the zone of each domain.
+func NewChallengeProvider(prov api.Provider, zone string) *ChallengeProvider {
+	return &ChallengeProvider{
+		Provider: prov,
+		Zone:     zone,
+	}
+}
+
+func (s *ChallengeProvider) context() (context.Context, context.CancelFunc) {
+	timeout := s.PropagationTimeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+func (s *ChallengeProvider) zone(ctx context.Context, domain string) (string, error) {
+	if s.Zone != "" {
+		return s.Zone, nil
+	}
+	return FindZone(ctx, s.Provider, domain)
+}
+
+// Present creates the challenge record for the domain.
+func (s *ChallengeProvider) Present(domain, token, keyAuth string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+	zone, err := s.zone(ctx, domain)
+	if err != nil {
+		return err
+	}
+	value := ChallengeValue(keyAuth)
+	if err := Present(ctx, s.Provider, zone, domain, value); err != nil {
+		return err
+	}
+	if s.Checker != nil {
+		return s.Checker.Wait(ctx, zone, ChallengeName(domain), value)
+	}
+	return nil
+}
+
+// CleanUp deletes the challenge record for the domain.
+func (s *ChallengeProvider) CleanUp(domain, token, keyAuth string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+	zone, err := s.zone(ctx, domain)
+	if err != nil {
+		return err
+	}
+	return CleanUp(ctx, s.Provider, zone, domain)
+}
+
+// Timeout returns how long lego should wait for the record to
+// propagate, and how often it should check.
+func (s *ChallengeProvider) Timeout() (timeout, interval time.Duration) {
+	timeout = s.PropagationTimeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return timeout, DefaultPollInterval
+}
+
+// Sequential makes lego solve one challenge at a time, waiting the
+// returned interval between them. A wildcard and its base domain share
+// one challenge record, and Present replaces its value, so they cannot
+// be solved together.
+func (s *ChallengeProvider) Sequential() time.Duration {
+	return DefaultPollInterval
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acme
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexr/dnsproviders"
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver answers from the records of the provider, each
+// nameserver only after it has been queried a number of times.
+type fakeResolver struct {
+	mu          sync.Mutex
+	prov        api.Provider
+	zone        string
+	nameservers []string
+	delays      map[string]int
+	queries     map[string]int
+}
+
+func (s *fakeResolver) LookupNS(ctx context.Context, zone string) ([]string, error) {
+	return s.nameservers, nil
+}
+
+func (s *fakeResolver) Lookup(ctx context.Context, nameserver, name, rtype string) ([]string, error) {
+	s.mu.Lock()
+	s.queries[nameserver]++
+	ready := s.queries[nameserver] > s.delays[nameserver]
+	s.mu.Unlock()
+	if !ready {
+		return nil, nil
+	}
+	records, err := s.prov.GetDNSRecords(ctx, s.zone, name)
+	if err != nil {
+		return nil, err
+	}
+	values := []string{}
+	for _, record := range records {
+		if record.Type == rtype {
+			values = append(values, record.Content...)
+		}
+	}
+	return values, nil
+}
+
+func TestChallengeName(t *testing.T) {
+	require.Equal(t, "_acme-challenge.www.example.com", ChallengeName("www.example.com."))
+	require.Equal(t, "_acme-challenge.example.com", ChallengeName("*.example.com"))
+	require.Equal(t, "_acme-challenge.example.com", ChallengeName("_acme-challenge.example.com"))
+	// unpadded base64url of the SHA-256 digest
+	require.Equal(t, "61rBZ_4knHblO0MNoxFsXZ_eTFUHum0B6IVRbhvUn5I", ChallengeValue("token.thumbprint"))
+}
+
+func TestPresentCleanUp(t *testing.T) {
+	ctx := context.Background()
+	prov := dnsproviders.NewMockProvider("example.com")
+
+	err := Present(ctx, prov, "example.com", "www.example.com", "value1")
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "_acme-challenge.www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, api.RecordTypeTXT, records[0].Type)
+	require.Equal(t, []string{"value1"}, records[0].Content)
+	require.Equal(t, ChallengeTTL, records[0].TTL)
+
+	// presenting again replaces the value
+	err = Present(ctx, prov, "example.com", "www.example.com", "value2")
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "_acme-challenge.www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []string{"value2"}, records[0].Content)
+
+	err = CleanUp(ctx, prov, "example.com", "www.example.com")
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "_acme-challenge.www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 0, len(records))
+}
+
+func TestPropagationChecker(t *testing.T) {
+	ctx := context.Background()
+	prov := dnsproviders.NewMockProvider("example.com")
+	resolver := &fakeResolver{
+		prov:        prov,
+		zone:        "example.com",
+		nameservers: []string{"ns1.example.com:53", "ns2.example.com:53"},
+		delays:      map[string]int{"ns2.example.com:53": 2},
+		queries:     map[string]int{},
+	}
+	checker := PropagationChecker{
+		Resolver: resolver,
+		Interval: time.Millisecond,
+	}
+	err := Present(ctx, prov, "example.com", "www.example.com", "value1")
+	require.Nil(t, err)
+	err = checker.Wait(ctx, "example.com", "_acme-challenge.www.example.com", "value1")
+	require.Nil(t, err)
+	// nameservers that have the value are not queried again
+	require.Equal(t, 1, resolver.queries["ns1.example.com:53"])
+	require.Equal(t, 3, resolver.queries["ns2.example.com:53"])
+
+	// a value that never appears fails when the context is done
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err = checker.Wait(ctx, "example.com", "_acme-challenge.www.example.com", "value2")
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	require.Contains(t, err.Error(), "ns1.example.com:53, ns2.example.com:53")
+}
+
+func TestChallengeProvider(t *testing.T) {
+	ctx := context.Background()
+	prov := dnsproviders.NewMockProvider("example.com")
+	_, err := prov.CreateZone(ctx, "sub.example.com")
+	require.Nil(t, err)
+
+	// the zone is found from the provider's zones
+	solver := NewChallengeProvider(prov, "")
+	solver.PropagationTimeout = 10 * time.Second
+	solver.Checker = &PropagationChecker{
+		Resolver: &fakeResolver{
+			prov:        prov,
+			zone:        "sub.example.com",
+			nameservers: []string{"ns1.example.com:53"},
+			queries:     map[string]int{},
+		},
+		Interval: time.Millisecond,
+	}
+	err = solver.Present("www.sub.example.com", "token", "keyauth")
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "sub.example.com", "_acme-challenge.www.sub.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, []string{ChallengeValue("keyauth")}, records[0].Content)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 0, len(records))
+
+	err = solver.CleanUp("www.sub.example.com", "token", "keyauth")
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "sub.example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 0, len(records))
+
+	err = solver.Present("www.example.org", "token", "keyauth")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "no zone found for www.example.org")
+
+	timeout, interval := solver.Timeout()
+	require.Equal(t, 10*time.Second, timeout)
+	require.Equal(t, DefaultPollInterval, interval)
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acme
+
+import (
+	"context"
+	"time"
+
+	"github.com/edgexr/dnsproviders"
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// DefaultPollInterval is how often the nameservers are queried while
+// waiting for a challenge record to propagate.
+const DefaultPollInterval = dnsproviders.DefaultPollInterval
+
+// PropagationChecker waits for a challenge record to be visible on
+// all authoritative nameservers of its zone.
+type PropagationChecker struct {
+	// Resolver queries the nameservers. If nil, DNS is queried
+	// using the system resolver to find the nameservers.
+	Resolver dnsproviders.Resolver
+	// Interval is the time between checks. If zero,
+	// DefaultPollInterval is used.
+	Interval time.Duration
+}
+
+// WaitForPropagation waits until every authoritative nameserver of
+// the zone answers with the value for the challenge record of the
+// domain, or the context is done.
+func WaitForPropagation(ctx context.Context, zone, domain, value string) error {
+	checker := PropagationChecker{}
+	return checker.Wait(ctx, zone, ChallengeName(domain), value)
+}
+
+// Wait waits until every authoritative nameserver of the zone returns
+// the value among the TXT values of the name, or the context is done.
+func (s *PropagationChecker) Wait(ctx context.Context, zone, name, value string) error {
+	ops := []dnsproviders.PropagationOption{}
+	if s.Resolver != nil {
+		ops = append(ops, dnsproviders.WithResolver(s.Resolver))
+	}
+	if s.Interval > 0 {
+		ops = append(ops, dnsproviders.WithPollInterval(s.Interval))
+	}
+	return dnsproviders.WaitForPropagation(ctx, zone, name, api.RecordTypeTXT, value, ops...)
+}
@@ -17,6 +17,9 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 )
 
 const (
@@ -24,6 +27,18 @@ const (
 	RecordTypeAAAA  = "AAAA"
 	RecordTypeCNAME = "CNAME"
 	RecordTypeTXT   = "TXT"
+	RecordTypeMX    = "MX"
+	RecordTypeSRV   = "SRV"
+	// RecordTypeNS delegates a subname to other name servers. The NS
+	// records at the zone apex are managed by most backends.
+	RecordTypeNS = "NS"
+	// RecordTypePTR maps an address to a host name in the reverse
+	// zones, see ReverseName.
+	RecordTypePTR = "PTR"
+	// RecordTypeALIAS is not a DNS record type, but is served by
+	// some providers as the A and AAAA records of its target, which
+	// allows a CNAME-like record at the zone apex.
+	RecordTypeALIAS = "ALIAS"
 )
 
 // Provider common interface for managing DNS entries.
@@ -32,10 +47,318 @@ type Provider interface {
 	// provided, that is used as a filter.
 	GetDNSRecords(ctx context.Context, zone, name string) ([]Record, error)
 	// CreateOrUpdateDNSRecord changes the existing record if found,
-	// or adds a new one
+	// or adds a new one. Providers other than Cloudflare cannot proxy
+	// and return ErrProxyNotSupported if proxy is set.
 	CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error
 	// DeleteDNSRecord deletes all DNS records for the name.
 	DeleteDNSRecord(ctx context.Context, zone, name string) error
+	// DeleteDNSRecordByType deletes only the DNS records of the
+	// given type for the name.
+	DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error
+	// ListZones returns the zones the credentials can manage, with
+	// names stripped of the trailing dot.
+	ListZones(ctx context.Context) ([]Zone, error)
+	// Capabilities reports the features the provider supports, so
+	// callers can check before relying on them.
+	Capabilities() Capabilities
+	// Close releases the connections and sessions held by the
+	// provider, which must not be used afterwards. It is part of
+	// the interface so callers of GetProvider can always defer it,
+	// and is a no-op for providers that hold nothing. It is safe to
+	// call more than once.
+	Close() error
+}
+
+// Zone describes a zone managed by a provider.
+type Zone struct {
+	// Name is the zone name without a trailing dot.
+	Name string `json:"name"`
+	// ID is the provider's identifier for the zone. Providers that
+	// identify zones by name use the name.
+	ID string `json:"id"`
+}
+
+// Capabilities describes what a provider supports.
+type Capabilities struct {
+	// SupportedRecordTypes are the upper case record types that can
+	// be created.
+	SupportedRecordTypes []string `json:"supportedRecordTypes"`
+	// SupportsProxy is set if records can be proxied, which only
+	// Cloudflare supports.
+	SupportsProxy bool `json:"supportsProxy"`
+	// SupportsZoneManagement is set if zones can be created and
+	// deleted.
+	SupportsZoneManagement bool `json:"supportsZoneManagement"`
+	// SupportsBatch is set if a batch of records is applied
+	// atomically in one change, rather than record by record.
+	SupportsBatch bool `json:"supportsBatch"`
+	// SupportsDNSSEC is set if the DNSSEC status of a zone can be
+	// read, and signing enabled and disabled.
+	SupportsDNSSEC bool `json:"supportsDNSSEC"`
+}
+
+// ErrUnsupported is returned by operations that the provider's
+// backend does not support.
+var ErrUnsupported = errors.New("operation not supported by provider")
+
+// ZoneManager is implemented by providers that can create and delete
+// zones. Providers whose backend cannot return ErrUnsupported.
+type ZoneManager interface {
+	// CreateZone creates the zone and returns it.
+	CreateZone(ctx context.Context, zone string) (Zone, error)
+	// DeleteZone deletes the zone. Some backends require all records
+	// other than the apex NS and SOA records to be deleted first.
+	DeleteZone(ctx context.Context, zone string) error
+}
+
+// ErrNoZonesAccessible is returned by ListZones when the listing
+// succeeds but is empty even though the provider was configured for a
+// zone, which usually means the credentials lack permission to see it.
+var ErrNoZonesAccessible = errors.New("no zones accessible")
+
+// ErrRecordTypeUnsupported is returned when a provider does not
+// support the type of record being created or updated.
+var ErrRecordTypeUnsupported = errors.New("record type not supported")
+
+// ErrInvalidContent is returned when record content is malformed,
+// such as an MX record with an out of range priority or an invalid
+// target host name.
+var ErrInvalidContent = errors.New("invalid record content")
+
+// ErrInvalidTTL is returned when the requested TTL is outside the
+// range the provider's backend allows.
+var ErrInvalidTTL = errors.New("invalid ttl")
+
+// ErrProxyNotSupported is returned when proxying is requested from a
+// provider that cannot proxy traffic, which is all but Cloudflare.
+var ErrProxyNotSupported = errors.New("proxying not supported")
+
+// ErrZoneNotFound is returned, wrapped with the zone name, when the
+// zone does not exist or is not visible to the credentials.
+var ErrZoneNotFound = errors.New("no zone found")
+
+// ErrRecordNotFound is returned, wrapped with the record name, by
+// operations that change an existing record that does not exist.
+var ErrRecordNotFound = errors.New("no record found")
+
+// ErrMultipleRecords is returned, wrapped with the record name, when
+// a single record was asked for but several match, such as MX records
+// of different priorities.
+var ErrMultipleRecords = errors.New("multiple records found")
+
+// ErrInvalidCredentials is returned when the backend rejects the
+// credentials themselves, such as an unknown or expired token.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrPermissionDenied is returned when the backend accepts the
+// credentials but they lack permission for the operation.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// ErrUnreachable is returned when the backend could not be reached,
+// in which case nothing is known about the credentials.
+var ErrUnreachable = errors.New("dns provider unreachable")
+
+// RateLimitError is returned when the backend rejected a request
+// because too many were made, either with 429 Too Many Requests or
+// with a throttling error code. Callers can find it with errors.As and
+// wait before trying again.
+type RateLimitError struct {
+	// RetryAfter is how long the backend asked to wait before
+	// trying again, or 0 if it did not say.
+	RetryAfter time.Duration
+	// Err is the error of the rejected request.
+	Err error
+}
+
+func (s *RateLimitError) Error() string {
+	if s.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited, retry after %s: %v", s.RetryAfter, s.Err)
+	}
+	return fmt.Sprintf("rate limited: %v", s.Err)
+}
+
+func (s *RateLimitError) Unwrap() error {
+	return s.Err
+}
+
+// CredentialValidator is implemented by providers that can check
+// their credentials with a lightweight authenticated call.
+type CredentialValidator interface {
+	// ValidateCredentials returns nil if the backend accepts the
+	// credentials. Failures wrap ErrInvalidCredentials,
+	// ErrPermissionDenied or ErrUnreachable where the cause is known.
+	ValidateCredentials(ctx context.Context) error
+}
+
+// RecordTypeLister is implemented by providers that report the record
+// types they can create.
+type RecordTypeLister interface {
+	// SupportedRecordTypes returns the upper case record types the
+	// provider supports.
+	SupportedRecordTypes() []string
+}
+
+// ChangeHandle identifies a change submitted to a provider that is
+// applied asynchronously.
+type ChangeHandle struct {
+	Zone string `json:"zone"`
+	ID   string `json:"id"`
+}
+
+// ChangeTracker is implemented by providers that apply changes
+// asynchronously. The Change variants of the mutating methods return
+// a handle for the submitted change, or nil if the change was applied
+// immediately or none was needed.
+type ChangeTracker interface {
+	CreateOrUpdateDNSRecordChange(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) (*ChangeHandle, error)
+	DeleteDNSRecordChange(ctx context.Context, zone, name string) (*ChangeHandle, error)
+	// WaitForChange polls the provider until the change has been
+	// fully applied, or the context is done.
+	WaitForChange(ctx context.Context, handle *ChangeHandle) error
+}
+
+// BatchUpdater is implemented by providers that can create or update
+// several records in one call.
+type BatchUpdater interface {
+	// BatchCreateOrUpdateDNSRecords creates or updates each record in
+	// the zone. All values of a record are set together, replacing
+	// any existing values of the same name and type. Providers that
+	// can submit the whole batch atomically do so, and an error means
+	// none of the records were changed. Other providers apply each
+	// record in turn, continuing past failures, and return the joined
+	// errors of the records that could not be applied.
+	BatchCreateOrUpdateDNSRecords(ctx context.Context, zone string, records []Record) error
+}
+
+// RecordResultWriter is implemented by providers whose backend returns
+// the record it stored, so it need not be read back.
+type RecordResultWriter interface {
+	// CreateOrUpdateDNSRecordResult changes the existing record if
+	// found, or adds a new one, like CreateOrUpdateDNSRecord, and
+	// returns the record as GetDNSRecords reports it, including its
+	// ID.
+	CreateOrUpdateDNSRecordResult(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) (Record, error)
+}
+
+// RecordIDManager is implemented by providers that identify each
+// record value by id, so that one of several records of the same name
+// and type can be changed or deleted. The id is the Record.ID read
+// back from GetDNSRecords.
+type RecordIDManager interface {
+	// UpdateDNSRecordByID sets the content and TTL of the record with
+	// the id, keeping its name and type. The content is given as for
+	// CreateOrUpdateDNSRecord. It returns an error wrapping
+	// ErrRecordNotFound if there is no such record.
+	UpdateDNSRecordByID(ctx context.Context, zone, id, content string, ttl int) error
+	// DeleteDNSRecordByID deletes the record with the id. It returns
+	// an error wrapping ErrRecordNotFound if there is no such record.
+	DeleteDNSRecordByID(ctx context.Context, zone, id string) error
+}
+
+// RecordSetUpdater is implemented by providers that can set all
+// values of a name and type in one call, such as the addresses of a
+// round-robin name.
+type RecordSetUpdater interface {
+	// CreateOrUpdateDNSRecordSet sets the complete list of values for
+	// the name and type, replacing any existing values, so exactly
+	// the given values remain.
+	CreateOrUpdateDNSRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) error
+}
+
+// DeleteCounter is implemented by providers that report how many
+// records a delete removed.
+type DeleteCounter interface {
+	// DeleteDNSRecordCount deletes all DNS records for the name like
+	// DeleteDNSRecord, and returns the number of record values
+	// removed, counting each value of a multi-value record.
+	DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error)
+}
+
+// TTLUpdater is implemented by providers that can change the TTL of
+// existing records without the caller resending their content.
+type TTLUpdater interface {
+	// UpdateTTL sets the TTL of the records of the name and type,
+	// keeping their content. It returns an error wrapping
+	// ErrRecordNotFound if there are none.
+	UpdateTTL(ctx context.Context, zone, name, rtype string, ttl int) error
+}
+
+// DNSSECStatus reports whether a zone is signed with DNSSEC.
+type DNSSECStatus struct {
+	Enabled bool `json:"enabled"`
+	// State is the provider's own name for the signing state, such
+	// as "pending" while DNSSEC is being enabled.
+	State string `json:"state,omitempty"`
+	// DSRecords are the delegation signer records to publish in the
+	// parent zone, in presentation format without the owner name,
+	// as "keytag algorithm digesttype digest".
+	DSRecords []string `json:"dsRecords,omitempty"`
+}
+
+// DSRecord is a delegation signer record to publish in the parent
+// zone, usually through the registrar, to complete the DNSSEC chain of
+// trust.
+type DSRecord struct {
+	KeyTag int `json:"keyTag"`
+	// Algorithm is the DNSSEC algorithm number of the key, such as
+	// 13 for ECDSA P-256 with SHA-256.
+	Algorithm int `json:"algorithm"`
+	// DigestType is the digest algorithm number, such as 2 for
+	// SHA-256.
+	DigestType int `json:"digestType"`
+	// Digest is the upper case hex digest of the key.
+	Digest string `json:"digest"`
+}
+
+// String returns the record in presentation format without the owner
+// name, as "keytag algorithm digesttype digest".
+func (s DSRecord) String() string {
+	return fmt.Sprintf("%d %d %d %s", s.KeyTag, s.Algorithm, s.DigestType, s.Digest)
+}
+
+// DNSSECStatusReader is implemented by providers that can report the
+// DNSSEC status of a zone.
+type DNSSECStatusReader interface {
+	GetDNSSECStatus(ctx context.Context, zone string) (DNSSECStatus, error)
+}
+
+// DNSSECManager is implemented by providers that can turn DNSSEC
+// signing of a zone on and off.
+type DNSSECManager interface {
+	// EnableDNSSEC signs the zone, if it is not already, and returns
+	// the DS record of its key signing key. Signing may take a while
+	// to complete after it returns, and the DS record only takes
+	// effect once published in the parent zone.
+	EnableDNSSEC(ctx context.Context, zone string) (DSRecord, error)
+	// DisableDNSSEC stops signing the zone. The DS record should be
+	// removed from the parent zone first, or resolvers will fail to
+	// validate the zone.
+	DisableDNSSEC(ctx context.Context, zone string) error
+}
+
+// ZoneInfo describes a zone with the metadata the provider reports.
+type ZoneInfo struct {
+	// Name is the zone name without a trailing dot.
+	Name string `json:"name"`
+	// ID is the provider's identifier for the zone.
+	ID string `json:"id"`
+	// NameServers are the host names, without trailing dots, of the
+	// name servers the zone is delegated to.
+	NameServers []string `json:"nameServers,omitempty"`
+	// Status is the provider's own name for the state of the zone,
+	// such as "active", or empty if it reports none.
+	Status string `json:"status,omitempty"`
+	// DNSSECEnabled is set if the zone is signed with DNSSEC. It is
+	// always false for providers that do not report it.
+	DNSSECEnabled bool `json:"dnssecEnabled,omitempty"`
+}
+
+// ZoneInfoReader is implemented by providers that can report the
+// metadata of a zone.
+type ZoneInfoReader interface {
+	// GetZone returns the metadata of the zone. It returns an error
+	// wrapping ErrZoneNotFound if the zone does not exist.
+	GetZone(ctx context.Context, zone string) (ZoneInfo, error)
 }
 
 // ProviderType enumerates the types of providers supported
@@ -45,18 +368,154 @@ const (
 	CloudflareProvider       ProviderType = "cloudflare"
 	GoogleCloudDNSProvider   ProviderType = "googleclouddns"
 	OpenTelekomCloudProvider ProviderType = "otc"
+	DigitalOceanProvider     ProviderType = "digitalocean"
+	HetznerProvider          ProviderType = "hetzner"
+	RFC2136Provider          ProviderType = "rfc2136"
+	PowerDNSProvider         ProviderType = "powerdns"
+	GandiProvider            ProviderType = "gandi"
+	NS1Provider              ProviderType = "ns1"
+	LinodeProvider           ProviderType = "linode"
+	DeSECProvider            ProviderType = "desec"
+	VultrProvider            ProviderType = "vultr"
+	ScalewayProvider         ProviderType = "scaleway"
+	OVHProvider              ProviderType = "ovh"
+	BunnyProvider            ProviderType = "bunny"
+	NamecheapProvider        ProviderType = "namecheap"
+	DNSimpleProvider         ProviderType = "dnsimple"
+	PorkbunProvider          ProviderType = "porkbun"
+	// MockProvider is an in-memory provider for tests
+	MockProvider ProviderType = "mock"
 )
 
+// ProviderConfig describes a provider to be constructed.
+type ProviderConfig struct {
+	Type            ProviderType      `json:"type"`
+	Zone            string            `json:"zone"`
+	CredentialsData map[string]string `json:"-"`
+}
+
+// ValidationResult reports whether a provider config could be used
+// to access its zone. Err is nil on success.
+type ValidationResult struct {
+	Type ProviderType `json:"type"`
+	Zone string       `json:"zone"`
+	Err  error        `json:"-"`
+}
+
 // Record represents a DNS record in a zone.
 type Record struct {
 	Type    string   `json:"type,omitempty"`
 	Name    string   `json:"name,omitempty"`
 	Content []string `json:"content,omitempty"`
 	TTL     int      `json:"ttl,omitempty"`
+	// UnicodeName is the Unicode form of an internationalized Name,
+	// which is always in punycode. It is only set on records read
+	// back whose name has a punycode ("xn--") label, and is ignored
+	// when creating or updating records.
+	UnicodeName string `json:"unicodeName,omitempty"`
+	// Priority is set for MX and SRV records, and Weight and Port
+	// for SRV records, in which case Content holds only the target
+	// names. When creating or updating these records the content is
+	// given in presentation format instead, as "priority target" for
+	// MX and "priority weight port target" for SRV.
+	Priority int `json:"priority,omitempty"`
+	Weight   int `json:"weight,omitempty"`
+	Port     int `json:"port,omitempty"`
+	// RoutingPolicy is set on each variant of a record set that is
+	// answered differently depending on the client, such as by geo
+	// location. Each variant is returned as a separate record.
+	RoutingPolicy *RoutingPolicy `json:"routingPolicy,omitempty"`
+	// Proxied is set on records whose traffic is proxied by the
+	// provider, which only Cloudflare supports.
+	Proxied bool `json:"proxied,omitempty"`
+	// System is set on records the provider manages itself, namely
+	// the NS and SOA records at the zone apex. They are read-only and
+	// are skipped on import.
+	System bool `json:"system,omitempty"`
+	// ID is the provider's identifier of the record, set on records
+	// read back from providers that identify each record value by
+	// id, namely DigitalOcean, Linode and Porkbun, and from
+	// NS1, whose id covers all values of the name and type, and the
+	// mock provider, which makes it up from the name and type. It is
+	// ignored when creating or updating records, and is what
+	// RecordIDManager takes to change or delete one record.
+	ID string `json:"id,omitempty"`
 }
 
+const (
+	RoutingPolicyGeo      = "geo"
+	RoutingPolicyWeighted = "weighted"
+)
+
+// RoutingPolicy identifies one routing variant of a record set.
+type RoutingPolicy struct {
+	// Type is RoutingPolicyGeo or RoutingPolicyWeighted.
+	Type string `json:"type"`
+	// Location is the region the variant answers for geo routing.
+	Location string `json:"location,omitempty"`
+	// Weight is the relative share of answers for weighted routing.
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// RecordPager is implemented by providers that can list the records
+// of a zone a page at a time, as returned by the backend, rather than
+// gathering the whole zone in memory first.
+type RecordPager interface {
+	// ListDNSRecordPages calls fn with each page of records in the
+	// zone. Iteration stops at the first error returned by fn.
+	ListDNSRecordPages(ctx context.Context, zone string, fn func([]Record) error) error
+}
+
+// ExportFormat enumerates the formats records can be exported in
+type ExportFormat string
+
+const (
+	// ExportFormatNDJSON writes one JSON encoded Record per line.
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	// ExportFormatCSV writes a name,type,ttl,content header followed
+	// by one row per record value.
+	ExportFormatCSV ExportFormat = "csv"
+)
+
 // Logger interface allows a logger to be used by the providers.
 // This uses a context to support opentracing span-based logging.
+// Providers log failures at error level, changes they make at info
+// level, and per-record decisions such as skipping an update at debug
+// level. A *slog.Logger satisfies the interface.
 type Logger interface {
+	DebugContext(ctx context.Context, msg string, keysAndValues ...interface{})
+	InfoContext(ctx context.Context, msg string, keysAndValues ...interface{})
+	WarnContext(ctx context.Context, msg string, keysAndValues ...interface{})
+	ErrorContext(ctx context.Context, msg string, keysAndValues ...interface{})
+}
+
+// InfoLogger is the original single-level Logger interface.
+type InfoLogger interface {
 	InfoContext(ctx context.Context, msg string, keysAndValues ...interface{})
 }
+
+// LeveledLogger returns the logger as a Logger. A logger that only
+// implements InfoLogger has every level logged at info level.
+func LeveledLogger(logger InfoLogger) Logger {
+	if leveled, ok := logger.(Logger); ok {
+		return leveled
+	}
+	return infoOnlyLogger{logger}
+}
+
+// infoOnlyLogger logs every level through InfoContext.
+type infoOnlyLogger struct {
+	InfoLogger
+}
+
+func (s infoOnlyLogger) DebugContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	s.InfoContext(ctx, msg, keysAndValues...)
+}
+
+func (s infoOnlyLogger) WarnContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	s.InfoContext(ctx, msg, keysAndValues...)
+}
+
+func (s infoOnlyLogger) ErrorContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	s.InfoContext(ctx, msg, keysAndValues...)
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"sync"
+)
+
+// UnknownOperation is the operation name used for backend calls
+// that could not be attributed to a provider method, for example
+// because the backend SDK does not propagate the context.
+const UnknownOperation = "unknown"
+
+// OtherZone is the zone label for round-trips in zones that are not
+// allow-listed for their own label.
+const OtherZone = "other"
+
+// CallCounter tallies backend API round-trips per provider operation,
+// and optionally per zone label.
+// The zero value is ready to use and it is safe for concurrent use.
+type CallCounter struct {
+	mu         sync.Mutex
+	counts     map[string]int
+	zoneCounts map[zoneCountKey]int
+}
+
+type zoneCountKey struct {
+	op   string
+	zone string
+}
+
+// Inc records a single round-trip for the operation.
+func (s *CallCounter) Inc(op string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts == nil {
+		s.counts = map[string]int{}
+	}
+	s.counts[op]++
+}
+
+// IncZone records a single round-trip for the operation, both in the
+// operation total and under the zone label.
+func (s *CallCounter) IncZone(op, zone string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts == nil {
+		s.counts = map[string]int{}
+	}
+	if s.zoneCounts == nil {
+		s.zoneCounts = map[zoneCountKey]int{}
+	}
+	s.counts[op]++
+	s.zoneCounts[zoneCountKey{op, zone}]++
+}
+
+// CountZone returns the number of round-trips made by the operation
+// under the zone label.
+func (s *CallCounter) CountZone(op, zone string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.zoneCounts[zoneCountKey{op, zone}]
+}
+
+// ZoneCounts returns a copy of the round-trip counts per zone label,
+// and within each zone per operation.
+func (s *CallCounter) ZoneCounts() map[string]map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[string]map[string]int{}
+	for key, count := range s.zoneCounts {
+		if counts[key.zone] == nil {
+			counts[key.zone] = map[string]int{}
+		}
+		counts[key.zone][key.op] = count
+	}
+	return counts
+}
+
+// Count returns the number of round-trips made by the operation.
+func (s *CallCounter) Count(op string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[op]
+}
+
+// Total returns the number of round-trips across all operations.
+func (s *CallCounter) Total() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0
+	for _, count := range s.counts {
+		total += count
+	}
+	return total
+}
+
+// Counts returns a copy of the per-operation round-trip counts.
+func (s *CallCounter) Counts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int, len(s.counts))
+	for op, count := range s.counts {
+		counts[op] = count
+	}
+	return counts
+}
+
+// Reset clears all counts.
+func (s *CallCounter) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts = nil
+	s.zoneCounts = nil
+}
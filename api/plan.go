@@ -0,0 +1,80 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"sync"
+)
+
+// PlanAction is the kind of change a dry run would have made.
+type PlanAction string
+
+const (
+	PlanActionCreate PlanAction = "create"
+	PlanActionUpdate PlanAction = "update"
+	PlanActionDelete PlanAction = "delete"
+	PlanActionNoop   PlanAction = "no-op"
+)
+
+// PlannedChange describes the change a single write would have made
+// to a record set. Content is in the form passed to
+// CreateOrUpdateDNSRecord, with MX and SRV values including their
+// priority fields.
+type PlannedChange struct {
+	Action PlanAction `json:"action"`
+	Zone   string     `json:"zone"`
+	Name   string     `json:"name"`
+	// Type is empty for a no-op delete of all records of a name.
+	Type string `json:"type,omitempty"`
+	// OldContent, OldTTL and OldProxied are the current record set,
+	// empty for a create.
+	OldContent []string `json:"oldContent,omitempty"`
+	OldTTL     int      `json:"oldTTL,omitempty"`
+	OldProxied bool     `json:"oldProxied,omitempty"`
+	// NewContent, NewTTL and NewProxied are the record set after the
+	// change, empty for a delete.
+	NewContent []string `json:"newContent,omitempty"`
+	NewTTL     int      `json:"newTTL,omitempty"`
+	NewProxied bool     `json:"newProxied,omitempty"`
+}
+
+// Plan collects the changes planned by a dry run, in the order the
+// writes were made.
+// The zero value is ready to use and it is safe for concurrent use.
+type Plan struct {
+	mu      sync.Mutex
+	changes []PlannedChange
+}
+
+// Add appends the changes to the plan.
+func (s *Plan) Add(changes ...PlannedChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changes = append(s.changes, changes...)
+}
+
+// Changes returns a copy of the planned changes.
+func (s *Plan) Changes() []PlannedChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]PlannedChange{}, s.changes...)
+}
+
+// Reset clears the plan.
+func (s *Plan) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changes = nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// ReverseName returns the name of the PTR record for the IP address,
+// in the in-addr.arpa zone for IPv4 and the ip6.arpa zone for IPv6,
+// fully qualified without a trailing dot. For example 192.0.2.1 gives
+// "1.2.0.192.in-addr.arpa". It returns "" for an invalid address.
+func ReverseName(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		labels := []string{}
+		for ii := len(ip4) - 1; ii >= 0; ii-- {
+			labels = append(labels, strconv.Itoa(int(ip4[ii])))
+		}
+		return strings.Join(labels, ".") + ".in-addr.arpa"
+	}
+	ip6 := ip.To16()
+	if ip6 == nil {
+		return ""
+	}
+	// one label per nibble, least significant first
+	labels := []string{}
+	for ii := len(ip6) - 1; ii >= 0; ii-- {
+		labels = append(labels, string(hexDigits[ip6[ii]&0xf]), string(hexDigits[ip6[ii]>>4]))
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa"
+}
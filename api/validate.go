@@ -0,0 +1,166 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// maxTXTData is the most data a TXT record can hold, since the record
+// data is limited to 65535 bytes and every character string of up to
+// 255 bytes takes a length byte.
+const maxTXTData = 65535 / 256 * 255
+
+// ValidationError is returned by ValidateRecord for a record that
+// would be rejected by any backend. It wraps ErrInvalidContent or
+// ErrInvalidTTL.
+type ValidationError struct {
+	Name string
+	Type string
+	// Content is the invalid value, or empty if the TTL is invalid.
+	Content string
+	// Reason says what is wrong with the record.
+	Reason string
+	Err    error
+}
+
+func (s *ValidationError) Error() string {
+	return fmt.Sprintf("%v, %s record %s %s", s.Err, s.Type, s.Name, s.Reason)
+}
+
+func (s *ValidationError) Unwrap() error {
+	return s.Err
+}
+
+// ValidateRecord checks the TTL and content of a record before it is
+// written: A and AAAA content must be an address of the right family,
+// CNAME, NS, PTR and ALIAS content and MX and SRV targets must be host
+// names rather than addresses, priorities, weights and ports must be
+// in range, and TXT content must fit in a record. CNAME and ALIAS
+// targets may have underscores, as used to delegate ACME challenges.
+// MX and SRV content may be given either in the "priority target"
+// form passed to CreateOrUpdateDNSRecord or as the target alone.
+// Content of other types is left to the backend.
+func ValidateRecord(rec Record) error {
+	rtype := strings.ToUpper(rec.Type)
+	if rec.TTL < 0 {
+		return &ValidationError{
+			Name:   rec.Name,
+			Type:   rtype,
+			Reason: fmt.Sprintf("ttl %d is negative", rec.TTL),
+			Err:    ErrInvalidTTL,
+		}
+	}
+	for _, content := range rec.Content {
+		if reason := contentError(rtype, content); reason != "" {
+			return &ValidationError{
+				Name:    rec.Name,
+				Type:    rtype,
+				Content: content,
+				Reason:  reason,
+				Err:     ErrInvalidContent,
+			}
+		}
+	}
+	return nil
+}
+
+// contentError returns why the content is invalid for the upper case
+// record type, or an empty string if it is valid.
+func contentError(rtype, content string) string {
+	switch rtype {
+	case RecordTypeA:
+		if ip := net.ParseIP(content); ip == nil || ip.To4() == nil || strings.Contains(content, ":") {
+			return fmt.Sprintf("content %q is not an IPv4 address", content)
+		}
+	case RecordTypeAAAA:
+		if ip := net.ParseIP(content); ip == nil || !strings.Contains(content, ":") {
+			return fmt.Sprintf("content %q is not an IPv6 address", content)
+		}
+	case RecordTypeCNAME, RecordTypeALIAS:
+		return targetError(content, true)
+	case RecordTypeNS, RecordTypePTR:
+		return targetError(content, false)
+	case RecordTypeMX:
+		return priorityError(content, "priority", 1)
+	case RecordTypeSRV:
+		return priorityError(content, "priority weight port", 3)
+	case RecordTypeTXT:
+		if len(content) > maxTXTData {
+			return fmt.Sprintf("content of %d bytes is longer than the %d allowed", len(content), maxTXTData)
+		}
+	}
+	return ""
+}
+
+// targetError returns why the target is not a valid host name, or an
+// empty string if it is.
+func targetError(target string, underscores bool) string {
+	if net.ParseIP(strings.TrimSuffix(target, ".")) != nil {
+		return fmt.Sprintf("target %q is an address, not a host name", target)
+	}
+	if !isName(target, underscores) {
+		return fmt.Sprintf("target %q is not a valid host name", target)
+	}
+	return ""
+}
+
+// priorityError checks MX or SRV content with count leading 16 bit
+// numeric fields before the target, or the target alone.
+func priorityError(content, form string, count int) string {
+	fields := strings.Fields(content)
+	if len(fields) != 1 && len(fields) != count+1 {
+		return fmt.Sprintf("content %q must be %q", content, form+" target")
+	}
+	for _, field := range fields[:len(fields)-1] {
+		if _, err := strconv.ParseUint(field, 10, 16); err != nil {
+			return fmt.Sprintf("content %q has invalid number %q", content, field)
+		}
+	}
+	return targetError(fields[len(fields)-1], false)
+}
+
+// IsHostname reports whether name is a valid host name, with or
+// without a trailing dot. The root "." is allowed, as used by null MX
+// records to indicate a domain accepts no mail.
+func IsHostname(name string) bool {
+	return isName(name, false)
+}
+
+// isName checks a host name, or a domain name with underscores in the
+// labels if allowed.
+func isName(name string, underscores bool) bool {
+	if name == "." {
+		return true
+	}
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || underscores && c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// BatchCreateOrUpdateDNSRecords creates or updates the records in the
+// zone, using the provider's own batch support if it implements
+// api.BatchUpdater. Otherwise each record is applied in turn as
+// described by applyRecords.
+func BatchCreateOrUpdateDNSRecords(ctx context.Context, prov api.Provider, zone string, records []api.Record) error {
+	if batcher, ok := prov.(api.BatchUpdater); ok {
+		return batcher.BatchCreateOrUpdateDNSRecords(ctx, zone, records)
+	}
+	return applyRecords(ctx, prov, zone, records)
+}
+
+// UpdateTTL sets the TTL of the existing records of the name and type
+// without changing their content, using the provider's own support if
+// it implements api.TTLUpdater. Otherwise the records are read back
+// and re-applied with the new TTL. It returns an error wrapping
+// api.ErrRecordNotFound if there are no such records.
+func UpdateTTL(ctx context.Context, prov api.Provider, zone, name, rtype string, ttl int) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to update")
+	}
+	if updater, ok := prov.(api.TTLUpdater); ok {
+		return updater.UpdateTTL(ctx, zone, name, rtype, ttl)
+	}
+	records, err := prov.GetDNSRecords(ctx, zone, name)
+	if err != nil {
+		return err
+	}
+	// MX and SRV records are split by priority, so the values are
+	// merged back into one set in their full form
+	set := api.Record{Name: name, Type: strings.ToUpper(rtype), TTL: ttl}
+	for _, record := range records {
+		if !strings.EqualFold(record.Type, rtype) {
+			continue
+		}
+		for _, content := range record.Content {
+			set.Content = append(set.Content, writeContent(record, content))
+		}
+		set.Proxied = record.Proxied
+	}
+	if len(set.Content) == 0 {
+		return fmt.Errorf("%w: zone %s name %s type %s", api.ErrRecordNotFound, zone, name, rtype)
+	}
+	return applyRecord(ctx, prov, zone, set)
+}
+
+// applyRecords applies each record in turn, continuing past failures,
+// and returns the joined errors of the records that failed. Records
+// with several values need a provider that can set them together.
+func applyRecords(ctx context.Context, prov api.Provider, zone string, records []api.Record) error {
+	errs := []error{}
+	for _, record := range records {
+		if err := applyRecord(ctx, prov, zone, record); err != nil {
+			errs = append(errs, fmt.Errorf("%s record %s failed, %w", record.Type, record.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func applyRecord(ctx context.Context, prov api.Provider, zone string, record api.Record) error {
+	contents := []string{}
+	for _, content := range record.Content {
+		contents = append(contents, writeContent(record, content))
+	}
+	switch {
+	case len(contents) == 0:
+		return fmt.Errorf("no content specified")
+	case len(contents) == 1:
+		return prov.CreateOrUpdateDNSRecord(ctx, zone, record.Name, record.Type, contents[0], record.TTL, record.Proxied)
+	}
+	setter, ok := prov.(api.RecordSetUpdater)
+	if !ok {
+		return fmt.Errorf("provider cannot set multiple values %v", record.Content)
+	}
+	return setter.CreateOrUpdateDNSRecordSet(ctx, zone, record.Name, record.Type, contents, record.TTL, record.Proxied)
+}
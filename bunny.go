@@ -0,0 +1,478 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+const (
+	bunnyBaseURL = "https://api.bunny.net"
+	// bunnyPerPage is the largest page size of the zones list
+	bunnyPerPage = 1000
+)
+
+// bunnyTypeCodes maps the record types Bunny shares with DNS to the
+// integer codes of the Bunny API. The other codes are Bunny features
+// such as redirects and pull zones, which are not DNS records and are
+// left out of GetDNSRecords.
+var bunnyTypeCodes = map[string]int{
+	api.RecordTypeA:     0,
+	api.RecordTypeAAAA:  1,
+	api.RecordTypeCNAME: 2,
+	api.RecordTypeTXT:   3,
+	api.RecordTypeMX:    4,
+	api.RecordTypeSRV:   8,
+	"CAA":               9,
+	api.RecordTypePTR:   10,
+	api.RecordTypeNS:    12,
+}
+
+// bunnyTypeCode returns the Bunny code of the record type.
+func bunnyTypeCode(rtype string) (int, bool) {
+	code, ok := bunnyTypeCodes[strings.ToUpper(rtype)]
+	return code, ok
+}
+
+// bunnyTypeName returns the record type of the Bunny code, or false
+// for codes of Bunny specific records.
+func bunnyTypeName(code int) (string, bool) {
+	for rtype, c := range bunnyTypeCodes {
+		if c == code {
+			return rtype, true
+		}
+	}
+	return "", false
+}
+
+// Bunny manages DNS records via the bunny.net API. Bunny stores record
+// names relative to the zone, with an empty name for the apex, and
+// identifies record types by integer codes.
+type Bunny struct {
+	client    *http.Client
+	baseURL   string
+	accessKey string
+	logger    api.Logger
+	zone      string // zone the provider was configured for, if any
+	// shared zone ID cache, nil if disabled
+	zoneCache *zoneIDCache
+}
+
+type bunnyZone struct {
+	ID      int64         `json:"Id,omitempty"`
+	Domain  string        `json:"Domain"`
+	Records []bunnyRecord `json:"Records,omitempty"`
+}
+
+// bunnyRecord is a record of the Bunny API. MX and SRV targets are
+// kept in Value, with the priority, weight and port in their own
+// fields.
+type bunnyRecord struct {
+	ID       int64  `json:"Id,omitempty"`
+	Type     int    `json:"Type"`
+	Name     string `json:"Name"`
+	Value    string `json:"Value"`
+	TTL      int    `json:"Ttl"`
+	Priority int    `json:"Priority"`
+	Weight   int    `json:"Weight"`
+	Port     int    `json:"Port"`
+}
+
+// NewBunnyProvider creates a new Bunny DNS provider.
+func NewBunnyProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*Bunny, error) {
+	return NewBunnyProviderWithCredentials(ctx, zone, bunnyCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewBunnyProviderWithCredentials creates a new Bunny DNS provider
+// from typed credentials.
+func NewBunnyProviderWithCredentials(ctx context.Context, zone string, creds BunnyCredentials, logger api.Logger, ops ...Option) (*Bunny, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	opts := getOptions(ops)
+	client := opts.httpClient()
+	return &Bunny{
+		client:    client,
+		baseURL:   bunnyBaseURL,
+		accessKey: creds.AccessKey,
+		logger:    logger,
+		zone:      zone,
+		zoneCache: opts.zoneCache,
+	}, nil
+}
+
+func (s *Bunny) do(ctx context.Context, method, path string, in, out interface{}) error {
+	header := http.Header{}
+	header.Set("AccessKey", s.accessKey)
+	return doJSON(ctx, s.client, method, s.baseURL+path, header, in, out)
+}
+
+func bunnyZonePath(zoneID string, elems ...string) string {
+	path := "/dnszone/" + url.PathEscape(zoneID)
+	for _, elem := range elems {
+		path += "/" + url.PathEscape(elem)
+	}
+	return path
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *Bunny) Close() error {
+	return nil
+}
+
+// ValidateCredentials lists the first page of zones.
+func (s *Bunny) ValidateCredentials(ctx context.Context) error {
+	return credentialsError(s.do(ctx, http.MethodGet, "/dnszone?page=1&perPage=5", nil, nil))
+}
+
+// listZones returns the zones of the account, or only those matching
+// the search term if given.
+func (s *Bunny) listZones(ctx context.Context, search string) ([]bunnyZone, error) {
+	zones := []bunnyZone{}
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("perPage", strconv.Itoa(bunnyPerPage))
+		if search != "" {
+			query.Set("search", search)
+		}
+		resp := struct {
+			Items        []bunnyZone `json:"Items"`
+			HasMoreItems bool        `json:"HasMoreItems"`
+		}{}
+		if err := s.do(ctx, http.MethodGet, "/dnszone?"+query.Encode(), nil, &resp); err != nil {
+			return nil, err
+		}
+		zones = append(zones, resp.Items...)
+		if !resp.HasMoreItems || len(resp.Items) == 0 {
+			return zones, nil
+		}
+	}
+}
+
+// ListZones returns the zones of the account.
+func (s *Bunny) ListZones(ctx context.Context) ([]api.Zone, error) {
+	bzones, err := s.listZones(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	zones := []api.Zone{}
+	for _, z := range bzones {
+		zones = append(zones, newZone(z.Domain, strconv.FormatInt(z.ID, 10)))
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone creates the zone.
+func (s *Bunny) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	created := bunnyZone{}
+	if err := s.do(ctx, http.MethodPost, "/dnszone", &bunnyZone{Domain: zone}, &created); err != nil {
+		return api.Zone{}, fmt.Errorf("cannot create zone %s, %v", zone, err)
+	}
+	return newZone(created.Domain, strconv.FormatInt(created.ID, 10)), nil
+}
+
+// DeleteZone deletes the zone and all its records.
+func (s *Bunny) DeleteZone(ctx context.Context, zone string) error {
+	zoneID, err := s.getZoneID(ctx, zone)
+	if err != nil {
+		return err
+	}
+	if err := s.do(ctx, http.MethodDelete, bunnyZonePath(zoneID), nil, nil); err != nil {
+		return fmt.Errorf("cannot delete zone %s, %v", zone, err)
+	}
+	s.zoneCache.remove(zoneCacheKey(string(api.BunnyProvider), zone))
+	return nil
+}
+
+// getZoneID returns the ID of the zone, using the zone cache if
+// enabled. Bunny zone IDs are globally unique.
+func (s *Bunny) getZoneID(ctx context.Context, zone string) (string, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	key := zoneCacheKey(string(api.BunnyProvider), zone)
+	if id, ok := s.zoneCache.get(key); ok {
+		return id, nil
+	}
+	zones, err := s.listZones(ctx, zone)
+	if err != nil {
+		return "", err
+	}
+	for _, z := range zones {
+		if strings.EqualFold(z.Domain, zone) {
+			id := strconv.FormatInt(z.ID, 10)
+			s.zoneCache.put(key, id)
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+}
+
+func (s *Bunny) listRecords(ctx context.Context, zoneID string) ([]bunnyRecord, error) {
+	zone := bunnyZone{}
+	if err := s.do(ctx, http.MethodGet, bunnyZonePath(zoneID), nil, &zone); err != nil {
+		return nil, err
+	}
+	return zone.Records, nil
+}
+
+// bunnyName returns the name relative to the zone as Bunny stores it,
+// with an empty name for the apex.
+func bunnyName(name, zone string) string {
+	relName := relativeName(name, zone)
+	if relName == apexName {
+		return ""
+	}
+	return relName
+}
+
+// bunnyRecordToRecord converts a Bunny record of a DNS record type.
+func bunnyRecordToRecord(brec bunnyRecord, rtype, zone string) api.Record {
+	recName := absoluteName(brec.Name, zone)
+	record := api.Record{
+		Type:    rtype,
+		Name:    recName,
+		Content: []string{brec.Value},
+		TTL:     brec.TTL,
+		System:  isSystemRecord(zone, recName, rtype),
+	}
+	switch rtype {
+	case api.RecordTypeMX:
+		record.Priority = brec.Priority
+	case api.RecordTypeSRV:
+		record.Priority = brec.Priority
+		record.Weight = brec.Weight
+		record.Port = brec.Port
+	}
+	return canonicalTargets(withUnicodeName(record))
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *Bunny) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	zoneID, err := s.getZoneID(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	brecords, err := s.listRecords(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, brec := range brecords {
+		rtype, ok := bunnyTypeName(brec.Type)
+		if !ok {
+			continue
+		}
+		record := bunnyRecordToRecord(brec, rtype, zone)
+		if name != "" && !strings.EqualFold(name, record.Name) {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *Bunny) SupportedRecordTypes() []string {
+	return slices.Clone(bunnyRecordTypes)
+}
+
+// Capabilities reports that Bunny can manage zones.
+func (s *Bunny) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// newBunnyRecord builds the Bunny record for content as passed to
+// CreateOrUpdateDNSRecord, splitting MX and SRV content into their
+// fields. Host name targets are stored without the trailing dot.
+func newBunnyRecord(code int, rtype, name, content string, ttl int) (bunnyRecord, error) {
+	brec := bunnyRecord{
+		Type:  code,
+		Name:  name,
+		Value: content,
+		TTL:   ttl,
+	}
+	switch rtype {
+	case api.RecordTypeMX:
+		priority, target, err := parseMXContent(content)
+		if err != nil {
+			return bunnyRecord{}, err
+		}
+		brec.Priority = priority
+		brec.Value = target
+	case api.RecordTypeSRV:
+		priority, weight, port, target, err := parseSRVContent(content)
+		if err != nil {
+			return bunnyRecord{}, err
+		}
+		brec.Priority = priority
+		brec.Weight = weight
+		brec.Port = port
+		brec.Value = target
+	case api.RecordTypeTXT:
+		brec.Value = txtValue(content)
+	}
+	if hasHostTarget(rtype) {
+		brec.Value = strings.TrimSuffix(brec.Value, ".")
+	}
+	return brec, nil
+}
+
+// CreateOrUpdateDNSRecord changes the existing record of the name and
+// type if found, or adds a new one. If there are several records, the
+// one already holding the content is kept, or else the first, and the
+// others are deleted.
+func (s *Bunny) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.BunnyProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, bunnyRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	rtype = strings.ToUpper(rtype)
+	// checkRecord only passes types that have a code
+	code, _ := bunnyTypeCode(rtype)
+	newRecord, err := newBunnyRecord(code, rtype, bunnyName(name, zone), content, ttl)
+	if err != nil {
+		return err
+	}
+	zoneID, err := s.getZoneID(ctx, zone)
+	if err != nil {
+		return err
+	}
+	brecords, err := s.listRecords(ctx, zoneID)
+	if err != nil {
+		return err
+	}
+
+	existing := []bunnyRecord{}
+	keep := -1
+	for _, r := range brecords {
+		if r.Type != code || !strings.EqualFold(r.Name, newRecord.Name) {
+			continue
+		}
+		if keep < 0 && strings.EqualFold(r.Value, newRecord.Value) {
+			keep = len(existing)
+		}
+		existing = append(existing, r)
+	}
+	if len(existing) == 0 {
+		// Bunny adds records with PUT and updates them with POST
+		err := s.do(ctx, http.MethodPut, bunnyZonePath(zoneID, "records"), &newRecord, nil)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+			return fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+		}
+		return nil
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	r := existing[keep]
+	if strings.EqualFold(r.Value, newRecord.Value) && r.TTL == ttl && r.Priority == newRecord.Priority &&
+		r.Weight == newRecord.Weight && r.Port == newRecord.Port {
+		s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", content)
+	} else {
+		s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+		update := newRecord
+		update.ID = r.ID
+		err := s.do(ctx, http.MethodPost, bunnyZonePath(zoneID, "records", strconv.FormatInt(r.ID, 10)), &update, nil)
+		if err != nil {
+			return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+		}
+	}
+	for ii, other := range existing {
+		if ii == keep {
+			continue
+		}
+		err := s.do(ctx, http.MethodDelete, bunnyZonePath(zoneID, "records", strconv.FormatInt(other.ID, 10)), nil, nil)
+		if err != nil {
+			return fmt.Errorf("delete DNS record %v failed, %v", other, err)
+		}
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *Bunny) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *Bunny) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *Bunny) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+// deleteRecords deletes the DNS records of the name, and of the type
+// if given. Bunny specific records of the name are left alone.
+func (s *Bunny) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	zoneID, err := s.getZoneID(ctx, zone)
+	if err != nil {
+		return 0, err
+	}
+	brecords, err := s.listRecords(ctx, zoneID)
+	if err != nil {
+		return 0, err
+	}
+	relName := bunnyName(name, zone)
+	deleted := 0
+	for _, rec := range brecords {
+		if !strings.EqualFold(rec.Name, relName) {
+			continue
+		}
+		recType, ok := bunnyTypeName(rec.Type)
+		if !ok || (rtype != "" && !strings.EqualFold(recType, rtype)) {
+			continue
+		}
+		err := s.do(ctx, http.MethodDelete, bunnyZonePath(zoneID, "records", strconv.FormatInt(rec.ID, 10)), nil, nil)
+		if err != nil {
+			return deleted, fmt.Errorf("delete DNS record %v failed, %v", rec, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
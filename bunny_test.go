@@ -0,0 +1,252 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// bunnyStub is a minimal in-memory implementation of the Bunny DNS
+// zone API.
+type bunnyStub struct {
+	mu     sync.Mutex
+	zones  []*bunnyZone
+	nextID int64
+}
+
+func newBunnyStub(domains ...string) *bunnyStub {
+	s := &bunnyStub{}
+	for _, domain := range domains {
+		s.nextID++
+		s.zones = append(s.zones, &bunnyZone{ID: s.nextID, Domain: domain, Records: []bunnyRecord{}})
+	}
+	return s
+}
+
+func (s *bunnyStub) error(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"ErrorKey": "dnszone.not_found", "Message": message})
+}
+
+func (s *bunnyStub) zone(r *http.Request) *bunnyZone {
+	for _, z := range s.zones {
+		if strconv.FormatInt(z.ID, 10) == r.PathValue("id") {
+			return z
+		}
+	}
+	return nil
+}
+
+func (s *bunnyStub) find(z *bunnyZone, id string) int {
+	for ii, rec := range z.Records {
+		if strconv.FormatInt(rec.ID, 10) == id {
+			return ii
+		}
+	}
+	return -1
+}
+
+func (s *bunnyStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /dnszone", func(w http.ResponseWriter, r *http.Request) {
+		items := []bunnyZone{}
+		for _, z := range s.zones {
+			if search := r.URL.Query().Get("search"); search == "" || strings.Contains(z.Domain, search) {
+				items = append(items, bunnyZone{ID: z.ID, Domain: z.Domain})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"Items": items, "HasMoreItems": false})
+	})
+	mux.HandleFunc("GET /dnszone/{id}", func(w http.ResponseWriter, r *http.Request) {
+		z := s.zone(r)
+		if z == nil {
+			s.error(w, http.StatusNotFound, "The requested DNS zone was not found")
+			return
+		}
+		json.NewEncoder(w).Encode(z)
+	})
+	mux.HandleFunc("PUT /dnszone/{id}/records", func(w http.ResponseWriter, r *http.Request) {
+		z := s.zone(r)
+		if z == nil {
+			s.error(w, http.StatusNotFound, "The requested DNS zone was not found")
+			return
+		}
+		rec := bunnyRecord{}
+		json.NewDecoder(r.Body).Decode(&rec)
+		s.nextID++
+		rec.ID = s.nextID
+		z.Records = append(z.Records, rec)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rec)
+	})
+	mux.HandleFunc("POST /dnszone/{id}/records/{recordID}", func(w http.ResponseWriter, r *http.Request) {
+		z := s.zone(r)
+		ii := -1
+		if z != nil {
+			ii = s.find(z, r.PathValue("recordID"))
+		}
+		if ii < 0 {
+			s.error(w, http.StatusNotFound, "The requested DNS record was not found")
+			return
+		}
+		rec := bunnyRecord{}
+		json.NewDecoder(r.Body).Decode(&rec)
+		rec.ID = z.Records[ii].ID
+		z.Records[ii] = rec
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /dnszone/{id}/records/{recordID}", func(w http.ResponseWriter, r *http.Request) {
+		z := s.zone(r)
+		ii := -1
+		if z != nil {
+			ii = s.find(z, r.PathValue("recordID"))
+		}
+		if ii < 0 {
+			s.error(w, http.StatusNotFound, "The requested DNS record was not found")
+			return
+		}
+		z.Records = append(z.Records[:ii], z.Records[ii+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("AccessKey") != "test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func TestBunnyStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newBunnyStub("example.com")
+	client := newStubClient(t, stub.handler())
+	prov, err := GetProvider(ctx, api.BunnyProvider, "", BunnyCredentials{AccessKey: "test"}.ToMap(), nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com")
+	duplicateRecordsTest(t, ctx, prov, "example.com", "dup.example.com", func(content string) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.nextID++
+		stub.zones[0].Records = append(stub.zones[0].Records, bunnyRecord{ID: stub.nextID, Type: bunnyTypeCodes[api.RecordTypeA], Name: "dup", Value: content, TTL: 300})
+	})
+
+	// the apex has an empty name, priorities have their own fields,
+	// and types are sent as codes
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com.", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "_sip._tcp.example.com", "SRV", "10 20 5060 sip.example.com", 300, false)
+	require.Nil(t, err)
+	records := stub.zones[0].Records
+	require.Equal(t, bunnyRecord{ID: records[len(records)-2].ID, Type: 4, Name: "", Value: "mail.example.com", TTL: 300, Priority: 10}, records[len(records)-2])
+	require.Equal(t, bunnyRecord{ID: records[len(records)-1].ID, Type: 8, Name: "_sip._tcp", Value: "sip.example.com", TTL: 300, Priority: 10, Weight: 20, Port: 5060}, records[len(records)-1])
+
+	got, err := prov.GetDNSRecords(ctx, "example.com", "_sip._tcp.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:     "_sip._tcp.example.com",
+		Type:     "SRV",
+		Content:  []string{"sip.example.com."},
+		TTL:      300,
+		Priority: 10,
+		Weight:   20,
+		Port:     5060,
+	}}, got)
+
+	// Bunny specific records, here a redirect, are neither returned
+	// nor deleted
+	stub.zones[0].Records = append(stub.zones[0].Records, bunnyRecord{ID: 1000, Type: 5, Name: "redir", Value: "https://example.org"})
+	got, err = prov.GetDNSRecords(ctx, "example.com", "redir.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{}, got)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "redir.example.com", "TXT", "hello", 300, false)
+	require.Nil(t, err)
+	count, err := prov.(api.DeleteCounter).DeleteDNSRecordCount(ctx, "example.com", "redir.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+	require.Equal(t, int64(1000), stub.zones[0].Records[len(stub.zones[0].Records)-1].ID)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "1"}}, zones)
+}
+
+func TestBunnyTypeCodes(t *testing.T) {
+	tests := []struct {
+		rtype string
+		code  int
+	}{
+		{"A", 0},
+		{"AAAA", 1},
+		{"CNAME", 2},
+		{"TXT", 3},
+		{"MX", 4},
+		{"SRV", 8},
+		{"CAA", 9},
+		{"PTR", 10},
+		{"NS", 12},
+	}
+	for _, test := range tests {
+		code, ok := bunnyTypeCode(test.rtype)
+		require.True(t, ok, test.rtype)
+		require.Equal(t, test.code, code, test.rtype)
+		code, ok = bunnyTypeCode(strings.ToLower(test.rtype))
+		require.True(t, ok, test.rtype)
+		require.Equal(t, test.code, code, test.rtype)
+		rtype, ok := bunnyTypeName(test.code)
+		require.True(t, ok, test.rtype)
+		require.Equal(t, test.rtype, rtype)
+	}
+	require.Equal(t, len(tests), len(bunnyTypeCodes))
+
+	// every supported type has a code
+	for _, rtype := range bunnyRecordTypes {
+		_, ok := bunnyTypeCode(rtype)
+		require.True(t, ok, rtype)
+	}
+
+	// redirect, flatten, pull zone and script records are Bunny
+	// specific
+	for _, code := range []int{5, 6, 7, 11} {
+		_, ok := bunnyTypeName(code)
+		require.False(t, ok, code)
+	}
+	_, ok := bunnyTypeCode("SSHFP")
+	require.False(t, ok)
+}
+
+func TestBunnyCredentials(t *testing.T) {
+	ctx := context.Background()
+	client := newStubClient(t, newBunnyStub("example.com").handler())
+
+	err := ValidateCredentials(ctx, api.BunnyProvider, BunnyCredentials{AccessKey: "test"}.ToMap(), WithHTTPClient(client))
+	require.Nil(t, err)
+	err = ValidateCredentials(ctx, api.BunnyProvider, BunnyCredentials{AccessKey: "wrong"}.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// recordCacheSweepSize is the number of cached reads above which
+// expired entries are removed when a new one is added.
+const recordCacheSweepSize = 1024
+
+// CachingProvider caches the results of GetDNSRecords per zone and
+// name filter for a TTL, to save backend calls for callers that read
+// the same records repeatedly. Writes through the provider invalidate
+// the cached reads of the name and of the whole zone before returning,
+// so a read after a write sees the change. Changes made outside the
+// provider are seen once the cached reads expire.
+type CachingProvider struct {
+	api.Provider
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[recordCacheKey]recordCacheEntry
+	// generations count the invalidations of each zone, so that a
+	// read that overlaps a write is not cached
+	generations map[string]uint64
+}
+
+type recordCacheKey struct {
+	zone string
+	name string
+}
+
+type recordCacheEntry struct {
+	records []api.Record
+	expires time.Time
+}
+
+// NewCachingProvider wraps the provider with a read-through cache of
+// GetDNSRecords results that expire after the TTL.
+func NewCachingProvider(inner api.Provider, ttl time.Duration) *CachingProvider {
+	return &CachingProvider{
+		Provider:    inner,
+		ttl:         ttl,
+		now:         time.Now,
+		entries:     map[recordCacheKey]recordCacheEntry{},
+		generations: map[string]uint64{},
+	}
+}
+
+func recordCacheZone(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(zone, "."))
+}
+
+// recordCacheName returns the cache key of the name in the zone. Names
+// relative to the zone, which some providers accept, are made absolute
+// so that they share the cached reads of the fully qualified name. An
+// empty name, which reads the whole zone, is kept as is.
+func recordCacheName(zone, name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.ToLower(absoluteName(name, zone))
+}
+
+// copyRecords returns a copy of the records that shares no content
+// slices, so callers cannot change the cached records.
+func copyRecords(records []api.Record) []api.Record {
+	if records == nil {
+		return nil
+	}
+	copied := make([]api.Record, len(records))
+	for ii, record := range records {
+		record.Content = slices.Clone(record.Content)
+		copied[ii] = record
+	}
+	return copied
+}
+
+// GetDNSRecords returns the cached records of the zone and name if
+// they have not expired, or reads and caches them.
+func (s *CachingProvider) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	key := recordCacheKey{zone: recordCacheZone(zone), name: recordCacheName(zone, name)}
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	if ok && s.now().Before(entry.expires) {
+		s.mu.Unlock()
+		return copyRecords(entry.records), nil
+	}
+	delete(s.entries, key)
+	generation := s.generations[key.zone]
+	s.mu.Unlock()
+
+	records, err := s.Provider.GetDNSRecords(ctx, zone, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generations[key.zone] == generation {
+		now := s.now()
+		if len(s.entries) >= recordCacheSweepSize {
+			for k, e := range s.entries {
+				if !now.Before(e.expires) {
+					delete(s.entries, k)
+				}
+			}
+		}
+		s.entries[key] = recordCacheEntry{
+			records: copyRecords(records),
+			expires: now.Add(s.ttl),
+		}
+	}
+	return records, nil
+}
+
+// invalidate removes the cached reads of the name and of the whole
+// zone. Reads of other names are not affected by a write to the name.
+func (s *CachingProvider) invalidate(zone, name string) {
+	key := recordCacheKey{zone: recordCacheZone(zone), name: recordCacheName(zone, name)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	delete(s.entries, recordCacheKey{zone: key.zone})
+	s.generations[key.zone]++
+}
+
+// CreateOrUpdateDNSRecord writes the record and invalidates the
+// cached reads it affects, even if the write failed, since it may
+// have been partly applied.
+func (s *CachingProvider) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	defer s.invalidate(zone, name)
+	return s.Provider.CreateOrUpdateDNSRecord(ctx, zone, name, rtype, content, ttl, proxy)
+}
+
+// DeleteDNSRecord deletes the records of the name and invalidates the
+// cached reads it affects.
+func (s *CachingProvider) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	defer s.invalidate(zone, name)
+	return s.Provider.DeleteDNSRecord(ctx, zone, name)
+}
+
+// DeleteDNSRecordByType deletes the records of the name and type and
+// invalidates the cached reads it affects.
+func (s *CachingProvider) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	defer s.invalidate(zone, name)
+	return s.Provider.DeleteDNSRecordByType(ctx, zone, name, rtype)
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// readCountingProvider counts the GetDNSRecords calls that reach the
+// provider.
+type readCountingProvider struct {
+	api.Provider
+	reads atomic.Int32
+}
+
+func (s *readCountingProvider) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	s.reads.Add(1)
+	return s.Provider.GetDNSRecords(ctx, zone, name)
+}
+
+func TestCachingProvider(t *testing.T) {
+	ctx := context.Background()
+	inner := &readCountingProvider{Provider: NewMockProvider("example.com")}
+	prov := NewCachingProvider(inner, time.Minute)
+	now := time.Now()
+	prov.now = func() time.Time { return now }
+	ProviderTest(t, ctx, prov, "example.com")
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "mail.example.com", "A", "10.0.0.2", 300, false)
+	require.Nil(t, err)
+
+	// repeated reads of the same zone and name are served from the
+	// cache, with names matched case insensitively
+	inner.reads.Store(0)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.1"}, records[0].Content)
+	records[0].Content[0] = "changed by the caller"
+	records, err = prov.GetDNSRecords(ctx, "example.com.", "WWW.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.1"}, records[0].Content)
+	require.Equal(t, int32(1), inner.reads.Load())
+	_, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	_, err = prov.GetDNSRecords(ctx, "example.com", "mail.example.com")
+	require.Nil(t, err)
+	require.Equal(t, int32(3), inner.reads.Load())
+
+	// a write invalidates the name and the whole zone, but not other
+	// names
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.3", 300, false)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.3"}, records[0].Content)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	_, err = prov.GetDNSRecords(ctx, "example.com", "mail.example.com")
+	require.Nil(t, err)
+	require.Equal(t, int32(5), inner.reads.Load())
+
+	// as do deletes
+	err = prov.DeleteDNSRecordByType(ctx, "example.com", "mail.example.com", "A")
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "mail.example.com")
+	require.Nil(t, err)
+	require.Empty(t, records)
+	err = prov.DeleteDNSRecord(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Empty(t, records)
+	require.Equal(t, int32(7), inner.reads.Load())
+
+	// changes made outside the cache are seen once the reads expire
+	inner.Provider.(*MockProvider).Seed("example.com", api.Record{Name: "www.example.com", Type: "A", Content: []string{"10.0.0.4"}, TTL: 300})
+	records, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Empty(t, records)
+	now = now.Add(time.Minute)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, int32(8), inner.reads.Load())
+
+	// errors are not cached
+	_, err = prov.GetDNSRecords(ctx, "missing.com", "")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+	_, err = prov.GetDNSRecords(ctx, "missing.com", "")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+	require.Equal(t, int32(10), inner.reads.Load())
+}
+
+// relativeNameProvider accepts names relative to the zone, as some
+// backends do, by making them absolute for the mock provider.
+type relativeNameProvider struct {
+	api.Provider
+}
+
+func (s *relativeNameProvider) name(zone, name string) string {
+	if name == "" {
+		return ""
+	}
+	return absoluteName(name, zone)
+}
+
+func (s *relativeNameProvider) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	return s.Provider.GetDNSRecords(ctx, zone, s.name(zone, name))
+}
+
+func (s *relativeNameProvider) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	return s.Provider.CreateOrUpdateDNSRecord(ctx, zone, s.name(zone, name), rtype, content, ttl, proxy)
+}
+
+func (s *relativeNameProvider) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	return s.Provider.DeleteDNSRecord(ctx, zone, s.name(zone, name))
+}
+
+func TestCachingProviderRelativeNames(t *testing.T) {
+	ctx := context.Background()
+	inner := &readCountingProvider{Provider: &relativeNameProvider{Provider: NewMockProvider("example.com")}}
+	prov := NewCachingProvider(inner, time.Minute)
+
+	// a write to the relative name invalidates the read of the fully
+	// qualified name, and the other way around
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.1"}, records[0].Content)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www", "A", "10.0.0.2", 300, false)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "www.example.com.")
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.2"}, records[0].Content)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "WWW.example.com", "A", "10.0.0.3", 300, false)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "www")
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.3"}, records[0].Content)
+
+	// both forms share the cached read
+	inner.reads.Store(0)
+	_, err = prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, int32(0), inner.reads.Load())
+
+	// @ is the zone apex, which is cached apart from the whole zone
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "A", "10.0.0.4", 300, false)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.4"}, records[0].Content)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "@", "A", "10.0.0.5", 300, false)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.5"}, records[0].Content)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+
+	err = prov.DeleteDNSRecord(ctx, "example.com", "www")
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Empty(t, records)
+}
+
+// blockingReadProvider blocks GetDNSRecords until released, to
+// overlap a read with a write.
+type blockingReadProvider struct {
+	api.Provider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingReadProvider) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	records, err := s.Provider.GetDNSRecords(ctx, zone, name)
+	s.started <- struct{}{}
+	<-s.release
+	return records, err
+}
+
+func TestCachingProviderOverlappingWrite(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockProvider("example.com")
+	inner := &blockingReadProvider{
+		Provider: mock,
+		started:  make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+	prov := NewCachingProvider(inner, time.Minute)
+
+	// a read that returns records from before a write is not cached
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+		require.Nil(t, err)
+		require.Empty(t, records)
+	}()
+	<-inner.started
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	close(inner.release)
+	<-done
+
+	go func() {
+		<-inner.started
+	}()
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+}
+
+func TestCachingProviderConcurrent(t *testing.T) {
+	ctx := context.Background()
+	prov := NewCachingProvider(NewMockProvider("example.com"), time.Minute)
+	wg := sync.WaitGroup{}
+	for ii := 0; ii < 8; ii++ {
+		wg.Add(1)
+		go func(ii int) {
+			defer wg.Done()
+			name := fmt.Sprintf("host%d.example.com", ii)
+			for jj := 0; jj < 20; jj++ {
+				content := fmt.Sprintf("10.0.%d.%d", ii, jj)
+				err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", name, "A", content, 300, false)
+				require.Nil(t, err)
+				// every read after a write sees it
+				records, err := prov.GetDNSRecords(ctx, "example.com", name)
+				require.Nil(t, err)
+				require.Equal(t, []string{content}, records[0].Content)
+				_, err = prov.GetDNSRecords(ctx, "example.com", "")
+				require.Nil(t, err)
+			}
+		}(ii)
+	}
+	wg.Wait()
+	records, err := prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 8, len(records))
+}
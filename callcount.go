@@ -0,0 +1,109 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+type operationKey struct{}
+type zoneLabelKey struct{}
+
+// contextWithOperation tags the context with the provider operation
+// so that backend round-trips can be attributed to it.
+func contextWithOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+func operationFromContext(ctx context.Context) string {
+	if op, ok := ctx.Value(operationKey{}).(string); ok {
+		return op
+	}
+	return api.UnknownOperation
+}
+
+func contextWithZoneLabel(ctx context.Context, zone string) context.Context {
+	return context.WithValue(ctx, zoneLabelKey{}, zone)
+}
+
+func zoneLabelFromContext(ctx context.Context) string {
+	zone, _ := ctx.Value(zoneLabelKey{}).(string)
+	return zone
+}
+
+// zoneLabels maps zones to metric labels. Allow-listed zones are
+// labeled with their own name and all others share api.OtherZone, to
+// bound the number of series. A nil zoneLabels disables zone labels.
+type zoneLabels map[string]bool
+
+func newZoneLabels(allowlist []string) zoneLabels {
+	labels := zoneLabels{}
+	for _, zone := range allowlist {
+		labels[zoneLabelName(zone)] = true
+	}
+	return labels
+}
+
+func zoneLabelName(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(zone, "."))
+}
+
+func (s zoneLabels) label(zone string) string {
+	if s == nil {
+		return ""
+	}
+	if zone = zoneLabelName(zone); s[zone] {
+		return zone
+	}
+	return api.OtherZone
+}
+
+// callCountingTransport counts each round-trip against the operation
+// found in the request context.
+type callCountingTransport struct {
+	base    http.RoundTripper
+	counter *api.CallCounter
+}
+
+func (s *callCountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if zone := zoneLabelFromContext(ctx); zone != "" {
+		s.counter.IncZone(operationFromContext(ctx), zone)
+	} else {
+		s.counter.Inc(operationFromContext(ctx))
+	}
+	return s.base.RoundTrip(req)
+}
+
+// callCountingOperation tags the context of each operation with the
+// operation name, and the zone label if enabled. Operations that are
+// not zone specific, such as ListZones, carry no zone label. Backends
+// that do not propagate the context to their HTTP requests have their
+// calls counted as api.UnknownOperation.
+func callCountingOperation(labels zoneLabels) operationFunc {
+	return func(ctx context.Context, op, zone, name, rtype string, fn func(ctx context.Context) error) error {
+		ctx = contextWithOperation(ctx, op)
+		if zone != "" {
+			if label := labels.label(zone); label != "" {
+				ctx = contextWithZoneLabel(ctx, label)
+			}
+		}
+		return fn(ctx)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallCounting(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	client := newStubClient(t, stub.handler())
+	counter := &api.CallCounter{}
+
+	creds := map[string]string{projectID: "test-project"}
+	prov, err := GetProvider(ctx, api.GoogleCloudDNSProvider, "", creds, nil, WithHTTPClient(client), WithCallCounting(counter))
+	require.Nil(t, err)
+	// listing the managed zones
+	require.Equal(t, 1, counter.Count("GetProvider"))
+
+	desired := []api.Record{{
+		Type:    api.RecordTypeA,
+		Name:    "a.example.com",
+		Content: []string{"10.0.0.1"},
+		TTL:     300,
+	}, {
+		Type:    api.RecordTypeAAAA,
+		Name:    "a.example.com",
+		Content: []string{"fd00::1"},
+		TTL:     300,
+	}, {
+		Type:    api.RecordTypeCNAME,
+		Name:    "www.example.com",
+		Content: []string{"a.example.com."},
+		TTL:     300,
+	}}
+	apply := func() {
+		for _, rec := range desired {
+			err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", rec.Name, rec.Type, rec.Content[0], rec.TTL, false)
+			require.Nil(t, err)
+		}
+	}
+
+	// each create is a list plus a change
+	counter.Reset()
+	apply()
+	require.Equal(t, 6, counter.Count("CreateOrUpdateDNSRecord"))
+	require.Equal(t, 6, counter.Total())
+
+	// a single read is a single list
+	counter.Reset()
+	records, err := prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 3, len(records))
+	require.Equal(t, map[string]int{"GetDNSRecords": 1}, counter.Counts())
+
+	counter.Reset()
+	err = prov.DeleteDNSRecord(ctx, "example.com", "a.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, counter.Count("DeleteDNSRecord"))
+
+	// the backend's own batch support is used, so the batch is one
+	// list and one change rather than one of each per record
+	counter.Reset()
+	err = BatchCreateOrUpdateDNSRecords(ctx, prov, "example.com", desired)
+	require.Nil(t, err)
+	require.Equal(t, map[string]int{"BatchCreateOrUpdateDNSRecords": 2}, counter.Counts())
+}
+
+func TestCallCountingZoneLabel(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com", "example.org", "example.net")
+	client := newStubClient(t, stub.handler())
+	counter := &api.CallCounter{}
+
+	creds := map[string]string{projectID: "test-project"}
+	prov, err := GetProvider(ctx, api.GoogleCloudDNSProvider, "", creds, nil, WithHTTPClient(client), WithCallCounting(counter), WithMetricsZoneLabel([]string{"Example.com."}))
+	require.Nil(t, err)
+
+	counter.Reset()
+	for _, zone := range []string{"example.com", "example.org", "example.net"} {
+		_, err := prov.GetDNSRecords(ctx, zone, "")
+		require.Nil(t, err)
+	}
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "a.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+
+	require.Equal(t, map[string]map[string]int{
+		"example.com": {
+			"GetDNSRecords":           1,
+			"CreateOrUpdateDNSRecord": 2,
+		},
+		api.OtherZone: {
+			"GetDNSRecords": 2,
+		},
+	}, counter.ZoneCounts())
+	require.Equal(t, 1, counter.CountZone("GetDNSRecords", "example.com"))
+	// the operation totals include every zone
+	require.Equal(t, 3, counter.Count("GetDNSRecords"))
+}
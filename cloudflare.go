@@ -16,7 +16,11 @@ package dnsproviders
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	cloudflare "github.com/cloudflare/cloudflare-go"
@@ -25,46 +29,195 @@ import (
 
 const Cloudflare = "cloudflare"
 
+// cloudflareAutoTTL is the TTL Cloudflare uses to mean automatic,
+// which is 300 seconds for records that are not proxied.
+const cloudflareAutoTTL = 1
+
+// cloudflareTTLs are the TTLs Cloudflare accepts other than automatic.
+// Enterprise zones allow down to 30 seconds, which is not detected.
+var cloudflareTTLs = ttlRange{min: 60, max: 86400}
+
+// CloudflareAPI manages records in Cloudflare zones. Cloudflare keeps
+// each value of a name and type as a separate record. The NS records
+// at the zone apex are assigned by Cloudflare and cannot be changed,
+// so NS records can only be set on subnames, to delegate them.
 type CloudflareAPI struct {
-	api    *cloudflare.API
-	logger api.Logger
+	api       *cloudflare.API
+	logger    api.Logger
+	comment   string
+	zone      string // zone the provider was configured for, if any
+	accountID string // account zones are created in, if configured
+	zoneCache *zoneIDCache
+	ttlPolicy TTLPolicy
+	// groupRecords groups values of the same name and type on read
+	groupRecords bool
 }
 
 // NewCloudflareProvider creates a new Cloudflare DNS provider.
 func NewCloudflareProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*CloudflareAPI, error) {
-	token, ok := credentialsData["token"]
-	if !ok {
-		return nil, fmt.Errorf("missing token key from cloudflare dns provider credentials data")
+	return NewCloudflareProviderWithCredentials(ctx, zone, cloudflareCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewCloudflareProviderWithCredentials creates a new Cloudflare DNS provider
+// from typed credentials.
+func NewCloudflareProviderWithCredentials(ctx context.Context, zone string, creds CloudflareCredentials, logger api.Logger, ops ...Option) (*CloudflareAPI, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
 	}
+	token := creds.Token
 	opts := getOptions(ops)
-	apiOptions := []cloudflare.Option{}
-	if opts.client != nil {
-		apiOptions = append(apiOptions, cloudflare.HTTPClient(opts.client))
-	}
-	api, err := cloudflare.NewWithAPIToken(token, apiOptions...)
+	api, err := cloudflare.NewWithAPIToken(token, cloudflare.HTTPClient(opts.httpClient()))
 	if err != nil {
 		return nil, err
 	}
 	return &CloudflareAPI{
-		api:    api,
-		logger: logger,
+		api:          api,
+		logger:       logger,
+		comment:      opts.changeComment(),
+		zone:         zone,
+		accountID:    creds.AccountID,
+		zoneCache:    opts.zoneCache,
+		ttlPolicy:    opts.ttlPolicy,
+		groupRecords: opts.groupRecords,
 	}, nil
 }
 
-// GetDNSRecords returns a list of DNS records for the given domain name. Error returned otherwise.
-// if name is provided, that is used as a filter
-func (s *CloudflareAPI) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
-	zoneID, err := s.api.ZoneIDByName(zone)
+// ttl maps no TTL, or 1, to automatic, and applies the TTL policy to
+// other TTLs outside the range Cloudflare accepts.
+func (s *CloudflareAPI) ttl(ttl int) (int, error) {
+	if ttl == 0 || ttl == cloudflareAutoTTL {
+		return cloudflareAutoTTL, nil
+	}
+	return s.ttlPolicy.normalize(api.CloudflareProvider, ttl, cloudflareTTLs)
+}
+
+// withZoneID calls fn with the ID of the zone, using the zone cache if
+// enabled, and retries once with the zone looked up again if a cached
+// ID turns out to be stale. Cloudflare zone IDs are globally unique.
+func (s *CloudflareAPI) withZoneID(ctx context.Context, zone string, fn func(zoneID string) error) error {
+	key := zoneCacheKey(string(api.CloudflareProvider), zone)
+	return s.zoneCache.withZoneID(ctx, key, func() (string, error) {
+		return s.lookupZoneID(ctx, zone)
+	}, fn)
+}
+
+// lookupZoneID finds the ID of the zone by its name.
+func (s *CloudflareAPI) lookupZoneID(ctx context.Context, zone string) (string, error) {
+	resp, err := s.api.ListZonesContext(ctx, cloudflare.WithZoneFilters(zone, "", ""))
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	switch len(resp.Result) {
+	case 0:
+		return "", fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	case 1:
+	default:
+		return "", fmt.Errorf("zone name %s is ambiguous", zone)
 	}
+	return resp.Result[0].ID, nil
+}
+
+// cloudflareNewZone is the body of a zone creation request. The
+// CreateZone of cloudflare-go sends the account as "organization"
+// rather than as the "account" the API documents, so the request is
+// made directly.
+type cloudflareNewZone struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Account struct {
+		ID string `json:"id"`
+	} `json:"account"`
+}
 
-	queryRecord := cloudflare.DNSRecord{}
+// CreateZone adds the zone to the account given by the accountID
+// credential, which API tokens need to create zones.
+func (s *CloudflareAPI) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	if s.accountID == "" {
+		return api.Zone{}, fmt.Errorf("create zone %s needs the %s of the Cloudflare account in the credentials", zone, CredentialKeyAccountID)
+	}
+	req := cloudflareNewZone{
+		Name: zone,
+		Type: "full",
+	}
+	req.Account.ID = s.accountID
+	s.logger.InfoContext(ctx, "create zone", "zone", zone, "account", s.accountID)
+	resp, err := s.api.Raw(ctx, http.MethodPost, "/zones", req, nil)
+	if err != nil {
+		return api.Zone{}, fmt.Errorf("create zone %s failed, %v", zone, err)
+	}
+	created := cloudflare.Zone{}
+	if err := json.Unmarshal(resp.Result, &created); err != nil {
+		return api.Zone{}, fmt.Errorf("create zone %s failed, %v", zone, err)
+	}
+	return newZone(created.Name, created.ID), nil
+}
+
+// DeleteZone deletes the zone and all its records.
+func (s *CloudflareAPI) DeleteZone(ctx context.Context, zone string) error {
+	err := s.withZoneID(ctx, zone, func(zoneID string) error {
+		s.logger.InfoContext(ctx, "delete zone", "zone", zone, "id", zoneID)
+		if _, err := s.api.DeleteZone(ctx, zoneID); err != nil {
+			return fmt.Errorf("delete zone %s failed, %v", zone, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.zoneCache.remove(zoneCacheKey(string(api.CloudflareProvider), zone))
+	return nil
+}
+
+// createRecord adds the record, setting the change comment if one is
+// configured.
+func (s *CloudflareAPI) createRecord(ctx context.Context, zoneID string, rec cloudflare.CreateDNSRecordParams) error {
+	rec.Comment = s.comment
+	_, err := s.api.CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneID), rec)
+	return err
+}
+
+// updateRecord patches the record, setting the change comment if one
+// is configured. Otherwise the current comment is kept.
+func (s *CloudflareAPI) updateRecord(ctx context.Context, zoneID string, rec cloudflare.UpdateDNSRecordParams) error {
+	if s.comment != "" {
+		rec.Comment = &s.comment
+	}
+	_, err := s.api.UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneID), rec)
+	return err
+}
+
+// listRecords returns all records of the name and type, either of
+// which may be empty to match any. The SDK fetches every page.
+func (s *CloudflareAPI) listRecords(ctx context.Context, zoneID, name, rtype string) ([]cloudflare.DNSRecord, error) {
+	params := cloudflare.ListDNSRecordsParams{
+		Type: strings.ToUpper(rtype),
+	}
 	if name != "" {
-		queryRecord.Name = name
+		params.Name = cloudflareName(name)
 	}
+	records, _, err := s.api.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zoneID), params)
+	return records, err
+}
+
+// cloudflarePriority returns the priority of an MX or SRV record, or
+// 0 if the record has none.
+func cloudflarePriority(rec cloudflare.DNSRecord) int {
+	return int(cloudflare.Uint16(rec.Priority))
+}
 
-	cfrecords, err := s.api.DNSRecords(zoneID, queryRecord)
+// GetDNSRecords returns a list of DNS records for the given domain name. Error returned otherwise.
+// if name is provided, that is used as a filter.
+// Cloudflare keeps each value as a separate record, which is returned
+// as its own api.Record unless the provider was created with
+// WithGroupedRecords.
+func (s *CloudflareAPI) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	var cfrecords []cloudflare.DNSRecord
+	err := s.withZoneID(ctx, zone, func(zoneID string) (err error) {
+		cfrecords, err = s.listRecords(ctx, zoneID, name, "")
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -75,86 +228,522 @@ func (s *CloudflareAPI) GetDNSRecords(ctx context.Context, zone, name string) ([
 			Name:    cfrec.Name,
 			Content: []string{cfrec.Content},
 			TTL:     cfrec.TTL,
+			Proxied: cloudflare.Bool(cfrec.Proxied),
+			System:  isSystemRecord(zone, cfrec.Name, cfrec.Type),
+		}
+		switch cfrec.Type {
+		case api.RecordTypeMX:
+			record.Priority = cloudflarePriority(cfrec)
+		case api.RecordTypeSRV:
+			// content is "weight port target"
+			values, target, err := parseUint16Fields(api.RecordTypeSRV, cfrec.Content, "weight port target", 2)
+			if err == nil {
+				record.Priority = cloudflarePriority(cfrec)
+				record.Weight = values[0]
+				record.Port = values[1]
+				record.Content = []string{target}
+			}
+		case api.RecordTypeTXT:
+			record.Content = []string{parseTXTRRData(cfrec.Content)}
 		}
-		records = append(records, record)
+		records = append(records, canonicalTargets(withUnicodeName(record)))
+	}
+	if s.groupRecords {
+		return groupCloudflareRecords(records), nil
 	}
 	return records, nil
 }
 
+// groupCloudflareRecords groups the values of the same name and type
+// into one record, as the other providers return them, with the lowest
+// of their TTLs. MX and SRV values are only grouped if their priority
+// fields match, and proxied values are kept apart from unproxied ones.
+func groupCloudflareRecords(records []api.Record) []api.Record {
+	grouped := []api.Record{}
+	index := map[cloudflareRecordKey]int{}
+	for _, record := range records {
+		key := cloudflareRecordKey{
+			name:     strings.ToLower(record.Name),
+			rtype:    record.Type,
+			priority: record.Priority,
+			weight:   record.Weight,
+			port:     record.Port,
+			proxied:  record.Proxied,
+		}
+		if ii, ok := index[key]; ok {
+			grouped[ii].Content = append(grouped[ii].Content, record.Content...)
+			grouped[ii].TTL = min(grouped[ii].TTL, record.TTL)
+			continue
+		}
+		index[key] = len(grouped)
+		grouped = append(grouped, record)
+	}
+	return grouped
+}
+
+// cloudflareRecordKey identifies the records grouped into one by
+// groupCloudflareRecords.
+type cloudflareRecordKey struct {
+	name     string
+	rtype    string
+	priority int
+	weight   int
+	port     int
+	proxied  bool
+}
+
+// cloudflareName returns the name in the form Cloudflare stores and
+// matches it, lower case without a trailing dot. Wildcard names such
+// as *.example.com are stored as given.
+func cloudflareName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// cloudflareSRVData returns the structured data Cloudflare requires
+// to create SRV records. The name has already been checked to be of
+// the form _service._proto.name.
+func cloudflareSRVData(name string, priority, weight, port int, target string) map[string]interface{} {
+	labels := strings.SplitN(strings.TrimSuffix(name, "."), ".", 3)
+	return map[string]interface{}{
+		"service":  labels[0],
+		"proto":    labels[1],
+		"name":     labels[2],
+		"priority": priority,
+		"weight":   weight,
+		"port":     port,
+		"target":   target,
+	}
+}
+
+// GetDNSSECStatus returns the DNSSEC status of the zone. DNSSEC is
+// enabled once the status is "active".
+func (s *CloudflareAPI) GetDNSSECStatus(ctx context.Context, zone string) (api.DNSSECStatus, error) {
+	status := api.DNSSECStatus{}
+	var setting cloudflare.ZoneDNSSEC
+	err := s.withZoneID(ctx, zone, func(zoneID string) (err error) {
+		setting, err = s.api.ZoneDNSSECSetting(ctx, zoneID)
+		if err != nil {
+			return fmt.Errorf("get DNSSEC status for zone %s failed, %v", zone, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return status, err
+	}
+	status.State = setting.Status
+	status.Enabled = setting.Status == "active"
+	if setting.Digest != "" {
+		ds, err := cloudflareDSRecord(setting)
+		if err != nil {
+			return status, fmt.Errorf("get DNSSEC status for zone %s failed, %v", zone, err)
+		}
+		status.DSRecords = []string{ds.String()}
+	}
+	return status, nil
+}
+
+// EnableDNSSEC turns on DNSSEC for the zone. Cloudflare reports the
+// status as "pending" until the DS record is found in the parent
+// zone, but the record is returned straight away.
+func (s *CloudflareAPI) EnableDNSSEC(ctx context.Context, zone string) (api.DSRecord, error) {
+	s.logger.InfoContext(ctx, "enable DNSSEC", "zone", zone)
+	var setting cloudflare.ZoneDNSSEC
+	err := s.withZoneID(ctx, zone, func(zoneID string) (err error) {
+		setting, err = s.api.UpdateZoneDNSSEC(ctx, zoneID, cloudflare.ZoneDNSSECUpdateOptions{Status: "active"})
+		if err != nil {
+			return fmt.Errorf("enable DNSSEC for zone %s failed, %v", zone, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return api.DSRecord{}, err
+	}
+	if setting.Digest == "" {
+		return api.DSRecord{}, fmt.Errorf("enable DNSSEC for zone %s returned no DS record, status is %s", zone, setting.Status)
+	}
+	ds, err := cloudflareDSRecord(setting)
+	if err != nil {
+		return api.DSRecord{}, fmt.Errorf("enable DNSSEC for zone %s failed, %v", zone, err)
+	}
+	return ds, nil
+}
+
+// DisableDNSSEC turns off DNSSEC for the zone.
+func (s *CloudflareAPI) DisableDNSSEC(ctx context.Context, zone string) error {
+	s.logger.InfoContext(ctx, "disable DNSSEC", "zone", zone)
+	return s.withZoneID(ctx, zone, func(zoneID string) error {
+		if _, err := s.api.UpdateZoneDNSSEC(ctx, zoneID, cloudflare.ZoneDNSSECUpdateOptions{Status: "disabled"}); err != nil {
+			return fmt.Errorf("disable DNSSEC for zone %s failed, %v", zone, err)
+		}
+		return nil
+	})
+}
+
+// cloudflareDSRecord returns the DS record of the DNSSEC setting,
+// whose algorithm and digest type are given as decimal strings.
+func cloudflareDSRecord(setting cloudflare.ZoneDNSSEC) (api.DSRecord, error) {
+	algorithm, err := strconv.Atoi(setting.Algorithm)
+	if err != nil {
+		return api.DSRecord{}, fmt.Errorf("invalid DNSSEC algorithm %q", setting.Algorithm)
+	}
+	digestType, err := strconv.Atoi(setting.DigestType)
+	if err != nil {
+		return api.DSRecord{}, fmt.Errorf("invalid DS digest type %q", setting.DigestType)
+	}
+	return api.DSRecord{
+		KeyTag:     setting.KeyTag,
+		Algorithm:  algorithm,
+		DigestType: digestType,
+		Digest:     strings.ToUpper(setting.Digest),
+	}, nil
+}
+
+// GetZone returns the zone's details, including the Cloudflare name
+// servers assigned to it, and whether DNSSEC is active.
+func (s *CloudflareAPI) GetZone(ctx context.Context, zone string) (api.ZoneInfo, error) {
+	var details cloudflare.Zone
+	var setting cloudflare.ZoneDNSSEC
+	err := s.withZoneID(ctx, zone, func(zoneID string) (err error) {
+		details, err = s.api.ZoneDetails(ctx, zoneID)
+		if err != nil {
+			return fmt.Errorf("get details of zone %s failed, %v", zone, err)
+		}
+		setting, err = s.api.ZoneDNSSECSetting(ctx, zoneID)
+		if err != nil {
+			return fmt.Errorf("get DNSSEC status for zone %s failed, %v", zone, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return api.ZoneInfo{}, err
+	}
+	return api.ZoneInfo{
+		Name:          details.Name,
+		ID:            details.ID,
+		NameServers:   nameServers(details.NameServers),
+		Status:        details.Status,
+		DNSSECEnabled: setting.Status == "active",
+	}, nil
+}
+
+// Close is a no-op, since the Cloudflare client holds no sessions and
+// its connections belong to the shared or caller supplied HTTP client.
+func (s *CloudflareAPI) Close() error {
+	return nil
+}
+
+// ValidateCredentials checks the API token with the token verify
+// endpoint. A token that is active may still lack DNS permissions for
+// a particular zone.
+func (s *CloudflareAPI) ValidateCredentials(ctx context.Context) error {
+	result, err := s.api.VerifyAPIToken(ctx)
+	if err != nil {
+		return credentialsError(err)
+	}
+	if result.Status != "active" {
+		return fmt.Errorf("%w, cloudflare token is %s", api.ErrInvalidCredentials, result.Status)
+	}
+	return nil
+}
+
+// ListZones returns the zones the token can access.
+func (s *CloudflareAPI) ListZones(ctx context.Context) ([]api.Zone, error) {
+	// the SDK fetches every page
+	resp, err := s.api.ListZonesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	zones := []api.Zone{}
+	for _, zone := range resp.Result {
+		zones = append(zones, newZone(zone.Name, zone.ID))
+	}
+	return listedZones(s.zone, zones)
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *CloudflareAPI) SupportedRecordTypes() []string {
+	return slices.Clone(cloudflareRecordTypes)
+}
+
+// Capabilities reports that Cloudflare can proxy records, manage
+// zones and manage DNSSEC. Batches are applied record by record.
+func (s *CloudflareAPI) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsProxy:          true,
+		SupportsZoneManagement: true,
+		SupportsDNSSEC:         true,
+	}
+}
+
 // CreateOrUpdateDNSRecord changes the existing record if found, or adds a new one
 func (s *CloudflareAPI) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
-	zoneID, err := s.api.ZoneIDByName(zone)
-	if err != nil {
-		return err
+	return s.CreateOrUpdateDNSRecordSet(ctx, zone, name, rtype, []string{content}, ttl, proxy)
+}
+
+// cloudflareValue is one value of a record set in the form Cloudflare
+// stores it.
+type cloudflareValue struct {
+	content  string
+	priority int
+	data     interface{}
+}
+
+// toCloudflareValue converts content as passed to
+// CreateOrUpdateDNSRecord into the form Cloudflare stores.
+func toCloudflareValue(name, rtype, content string) (cloudflareValue, error) {
+	value := cloudflareValue{content: content}
+	switch strings.ToUpper(rtype) {
+	case api.RecordTypeMX:
+		priority, target, err := parseMXContent(content)
+		if err != nil {
+			return value, err
+		}
+		// Cloudflare stores host names without the trailing dot
+		value.priority = priority
+		value.content = strings.TrimRight(target, ".")
+	case api.RecordTypeSRV:
+		priority, weight, port, target, err := parseSRVContent(content)
+		if err != nil {
+			return value, err
+		}
+		target = strings.TrimRight(target, ".")
+		value.priority = priority
+		value.data = cloudflareSRVData(name, priority, weight, port, target)
+		// the content Cloudflare reports for SRV records
+		value.content = fmt.Sprintf("%d %d %s", weight, port, target)
+	case api.RecordTypeTXT:
+		// Cloudflare splits long values itself
+		value.content = txtValue(content)
+	case api.RecordTypeCNAME, api.RecordTypeNS, api.RecordTypePTR:
+		value.content = strings.TrimRight(content, ".")
 	}
+	return value, nil
+}
 
-	queryRecord := cloudflare.DNSRecord{
-		Name: strings.ToLower(name),
-		Type: strings.ToUpper(rtype),
+// CreateOrUpdateDNSRecordSet sets the complete list of values for the
+// name and type. Cloudflare keeps each value as a separate record, so
+// existing records that already hold a value are kept, others are
+// reused for the new values, and any left over are deleted.
+func (s *CloudflareAPI) CreateOrUpdateDNSRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) error {
+	if err := checkRecord(name, rtype, cloudflareRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, contents...); err != nil {
+		return err
+	}
+	if err := checkDelegation(zone, name, rtype); err != nil {
+		return err
 	}
-	records, err := s.api.DNSRecords(zoneID, queryRecord)
+	if len(contents) == 0 {
+		return fmt.Errorf("no content specified for %s", name)
+	}
+	ttl, err := s.ttl(ttl)
 	if err != nil {
 		return err
 	}
-	found := false
-	for _, r := range records {
-		found = true
-		if r.Content == content {
-			s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", content)
-		} else {
-			s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
-
-			updateRecord := cloudflare.DNSRecord{
-				Name:    strings.ToLower(name),
-				Type:    strings.ToUpper(rtype),
-				Content: content,
-				TTL:     ttl,
-				Proxied: proxy,
+	rtype = strings.ToUpper(rtype)
+	values := []cloudflareValue{}
+	for _, content := range contents {
+		value, err := toCloudflareValue(name, rtype, content)
+		if err != nil {
+			return err
+		}
+		values = append(values, value)
+	}
+	return s.withZoneID(ctx, zone, func(zoneID string) error {
+		records, err := s.listRecords(ctx, zoneID, name, rtype)
+		if err != nil {
+			return err
+		}
+		// keep the records that already hold one of the values
+		pending := []cloudflareValue{}
+		for _, value := range values {
+			ii := slices.IndexFunc(records, func(r cloudflare.DNSRecord) bool {
+				return cloudflareSameValue(r, value)
+			})
+			if ii < 0 {
+				pending = append(pending, value)
+				continue
 			}
-			err := s.api.UpdateDNSRecord(zoneID, r.ID, updateRecord)
-			if err != nil {
+			r := records[ii]
+			records = slices.Delete(records, ii, ii+1)
+			if cloudflareUpToDate(r, ttl, proxy) {
+				s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", value.content, "ttl", ttl)
+				continue
+			}
+			if err := s.updateValue(ctx, zoneID, r, name, rtype, value, ttl, proxy); err != nil {
 				return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
 			}
 		}
-	}
-	if !found {
-		addRecord := cloudflare.DNSRecord{
-			Name:    strings.ToLower(name),
-			Type:    strings.ToUpper(rtype),
-			Content: content,
-			TTL:     ttl,
-			Proxied: false,
+		// the remaining records are reused for new values before any
+		// are created, and deleted if not needed
+		for _, value := range pending {
+			if len(records) > 0 {
+				r := records[0]
+				records = records[1:]
+				if err := s.updateValue(ctx, zoneID, r, name, rtype, value, ttl, proxy); err != nil {
+					return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+				}
+				continue
+			}
+			addRecord := cloudflare.CreateDNSRecordParams{
+				Name:     cloudflareName(name),
+				Type:     rtype,
+				Content:  value.content,
+				TTL:      ttl,
+				Proxied:  cloudflare.BoolPtr(proxy),
+				Priority: cloudflarePriorityPtr(rtype, value.priority),
+				Data:     value.data,
+			}
+			if err := s.createRecord(ctx, zoneID, addRecord); err != nil {
+				s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+				return fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+			}
 		}
-		_, err := s.api.CreateDNSRecord(zoneID, addRecord)
-		if err != nil {
-			s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
-			return fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+		for _, r := range records {
+			s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord deleting", "name", name, "content", r.Content)
+			if err := s.api.DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneID), r.ID); err != nil {
+				return fmt.Errorf("cannot delete DNS record for zone %s name %s, %v", zone, name, err)
+			}
 		}
+		return nil
+	})
+}
+
+// updateValue updates the existing record to the value.
+func (s *CloudflareAPI) updateValue(ctx context.Context, zoneID string, r cloudflare.DNSRecord, name, rtype string, value cloudflareValue, ttl int, proxy bool) error {
+	s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", value.content)
+	return s.updateRecord(ctx, zoneID, cloudflare.UpdateDNSRecordParams{
+		ID:       r.ID,
+		Name:     cloudflareName(name),
+		Type:     rtype,
+		Content:  value.content,
+		TTL:      ttl,
+		Proxied:  cloudflare.BoolPtr(proxy),
+		Priority: cloudflarePriorityPtr(rtype, value.priority),
+		Data:     value.data,
+		Tags:     r.Tags,
+	})
+}
+
+// cloudflarePriorityPtr returns the priority to send for the record
+// type. Only MX and SRV records have a priority, which may be 0.
+func cloudflarePriorityPtr(rtype string, priority int) *uint16 {
+	if rtype != api.RecordTypeMX && rtype != api.RecordTypeSRV {
+		return nil
 	}
-	return nil
+	return cloudflare.Uint16Ptr(uint16(priority))
+}
+
+// cloudflareSameValue reports whether the existing record holds the
+// value, regardless of its TTL and proxy setting.
+func cloudflareSameValue(r cloudflare.DNSRecord, value cloudflareValue) bool {
+	current := r.Content
+	if r.Type == api.RecordTypeTXT {
+		current = parseTXTRRData(current)
+	}
+	return current == value.content && cloudflarePriority(r) == value.priority
+}
+
+// cloudflareUpToDate reports whether the existing record, which holds
+// the value, already has the TTL and proxy setting, so updating it
+// would change nothing. Cloudflare sets the TTL of proxied records to
+// 1, meaning automatic, so the TTL of those is not compared.
+func cloudflareUpToDate(r cloudflare.DNSRecord, ttl int, proxy bool) bool {
+	if cloudflare.Bool(r.Proxied) != proxy {
+		return false
+	}
+	return r.TTL == ttl || proxy && r.TTL == 1
+}
+
+// BatchCreateOrUpdateDNSRecords applies each record in turn, since
+// Cloudflare has no batch API. Failed records do not stop the batch,
+// and their errors are joined in the result.
+func (s *CloudflareAPI) BatchCreateOrUpdateDNSRecords(ctx context.Context, zone string, records []api.Record) error {
+	return applyRecords(ctx, s, zone, records)
 }
 
 // DeleteDNSRecord deletes DNS record specified by recordID in zone.
 func (s *CloudflareAPI) DeleteDNSRecord(ctx context.Context, zone, name string) error {
-	zoneID, err := s.api.ZoneIDByName(zone)
-	if err != nil {
-		return err
-	}
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
 
-	queryRecord := cloudflare.DNSRecord{}
-	if name != "" {
-		queryRecord.Name = name
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *CloudflareAPI) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name, leaving records of other types in place.
+func (s *CloudflareAPI) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
 	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
 
-	cfrecords, err := s.api.DNSRecords(zoneID, queryRecord)
+func (s *CloudflareAPI) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	deleted := 0
+	err := s.withZoneID(ctx, zone, func(zoneID string) error {
+		cfrecords, err := s.listRecords(ctx, zoneID, name, rtype)
+		if err != nil {
+			return err
+		}
+		deleted = 0
+		for _, rec := range cfrecords {
+			err := s.api.DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneID), rec.ID)
+			if err != nil {
+				return fmt.Errorf("delete DNS record %v failed, %v", rec, err)
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// UpdateTTL changes only the TTL of the existing records matching the
+// name and type. The current content and proxy state are re-sent
+// unchanged, since Cloudflare requires them on update.
+func (s *CloudflareAPI) UpdateTTL(ctx context.Context, zone, name, rtype string, ttl int) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to update")
+	}
+	ttl, err := s.ttl(ttl)
 	if err != nil {
 		return err
 	}
-	for _, rec := range cfrecords {
-		err := s.api.DeleteDNSRecord(zoneID, rec.ID)
+	return s.withZoneID(ctx, zone, func(zoneID string) error {
+		records, err := s.listRecords(ctx, zoneID, name, rtype)
 		if err != nil {
-			return fmt.Errorf("delete DNS record %v failed, %v", rec, err)
+			return err
 		}
-	}
-	return nil
+		if len(records) == 0 {
+			return fmt.Errorf("%w: zone %s name %s type %s", api.ErrRecordNotFound, zone, name, rtype)
+		}
+		for _, r := range records {
+			if r.TTL == ttl {
+				s.logger.DebugContext(ctx, "UpdateTTL existing record matches", "name", name, "ttl", ttl)
+				continue
+			}
+			s.logger.InfoContext(ctx, "UpdateTTL updating", "name", name, "ttl", ttl)
+			updateRecord := cloudflare.UpdateDNSRecordParams{
+				ID:       r.ID,
+				Content:  r.Content,
+				TTL:      ttl,
+				Proxied:  r.Proxied,
+				Priority: r.Priority,
+				Tags:     r.Tags,
+			}
+			err := s.updateRecord(ctx, zoneID, updateRecord)
+			if err != nil {
+				return fmt.Errorf("cannot update DNS record TTL for zone %s name %s, %v", zone, name, err)
+			}
+		}
+		return nil
+	})
 }
@@ -16,9 +16,20 @@ package dnsproviders
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	cloudflare "github.com/cloudflare/cloudflare-go"
 	"github.com/edgexr/dnsproviders/api"
 	"github.com/stretchr/testify/require"
 )
@@ -43,3 +54,866 @@ func TestCloudflareDNS(t *testing.T) {
 	require.Nil(t, err)
 	ProviderTest(t, ctx, prov, domain)
 }
+
+// cfStub is a minimal in-memory implementation of the Cloudflare
+// zones and DNS records API.
+type cfStub struct {
+	mu       sync.Mutex
+	zones    map[string]string // zone name to ID
+	accounts map[string]string // created zone name to account ID
+	records  []cloudflare.DNSRecord
+	comments map[string]string // record ID to comment
+	nextID   int
+	pageSize int // 0 returns everything in one page
+	requests []string
+	dnssec   map[string]cloudflare.ZoneDNSSEC // zone ID to DNSSEC setting
+	delay    time.Duration                    // added to each response
+}
+
+func newCFStub(zones ...string) *cfStub {
+	s := &cfStub{
+		zones:    map[string]string{},
+		accounts: map[string]string{},
+		comments: map[string]string{},
+	}
+	for ii, zone := range zones {
+		s.zones[zone] = "zone" + strconv.Itoa(ii+1)
+	}
+	return s
+}
+
+// count returns the number of requests received with the given
+// method whose path contains substr.
+func (s *cfStub) count(method, substr string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, req := range s.requests {
+		if strings.HasPrefix(req, method+" ") && strings.Contains(req, substr) {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *cfStub) getRecord(id string) (int, bool) {
+	for ii, rec := range s.records {
+		if rec.ID == id {
+			return ii, true
+		}
+	}
+	return 0, false
+}
+
+// hasZoneID reports whether a zone has the ID.
+func (s *cfStub) hasZoneID(id string) bool {
+	for _, zoneID := range s.zones {
+		if zoneID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func cfWrite(w http.ResponseWriter, result interface{}, info *cloudflare.ResultInfo) {
+	resp := map[string]interface{}{
+		"success":  true,
+		"errors":   []string{},
+		"messages": []string{},
+		"result":   result,
+	}
+	if info != nil {
+		resp["result_info"] = info
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// cfSRVContent sets the content and priority from the structured
+// data of an SRV record, as Cloudflare does.
+func cfSRVContent(rec *cloudflare.DNSRecord) {
+	data, ok := rec.Data.(map[string]interface{})
+	if rec.Type != "SRV" || !ok {
+		return
+	}
+	rec.Priority = cloudflare.Uint16Ptr(uint16(data["priority"].(float64)))
+	rec.Content = fmt.Sprintf("%v %v %v", data["weight"], data["port"], data["target"])
+}
+
+// cfProxiedTTL sets the TTL of proxied records to automatic, as
+// Cloudflare does.
+func cfProxiedTTL(rec *cloudflare.DNSRecord) {
+	if cloudflare.Bool(rec.Proxied) {
+		rec.TTL = 1
+	}
+}
+
+func (s *cfStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	prefix := "/client/v4/zones"
+	mux.HandleFunc("GET "+prefix, func(w http.ResponseWriter, r *http.Request) {
+		zones := []cloudflare.Zone{}
+		name := r.URL.Query().Get("name")
+		for zoneName, id := range s.zones {
+			if name == "" || name == zoneName {
+				zones = append(zones, cloudflare.Zone{ID: id, Name: zoneName})
+			}
+		}
+		cfWrite(w, zones, &cloudflare.ResultInfo{Page: 1, TotalPages: 1, Count: len(zones)})
+	})
+	mux.HandleFunc("POST "+prefix, func(w http.ResponseWriter, r *http.Request) {
+		in := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&in)
+		account, _ := in["account"].(map[string]interface{})
+		if account["id"] == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		name := in["name"].(string)
+		s.zones[name] = "zone" + strconv.Itoa(len(s.zones)+1)
+		s.accounts[name] = account["id"].(string)
+		cfWrite(w, cloudflare.Zone{ID: s.zones[name], Name: name}, nil)
+	})
+	mux.HandleFunc("GET "+prefix+"/{zone}", func(w http.ResponseWriter, r *http.Request) {
+		for zoneName, id := range s.zones {
+			if id == r.PathValue("zone") {
+				cfWrite(w, cloudflare.Zone{
+					ID:          id,
+					Name:        zoneName,
+					Status:      "active",
+					NameServers: []string{"ada.ns.cloudflare.com", "bob.ns.cloudflare.com"},
+				}, nil)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET "+prefix+"/{zone}/dns_records", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		matches := []cloudflare.DNSRecord{}
+		for _, rec := range s.records {
+			if rec.ZoneID != r.PathValue("zone") {
+				continue
+			}
+			if name := query.Get("name"); name != "" && name != rec.Name {
+				continue
+			}
+			if rtype := query.Get("type"); rtype != "" && rtype != rec.Type {
+				continue
+			}
+			if content := query.Get("content"); content != "" && content != rec.Content {
+				continue
+			}
+			matches = append(matches, rec)
+		}
+		page, _ := strconv.Atoi(query.Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		pageSize := s.pageSize
+		if pageSize == 0 {
+			pageSize = len(matches) + 1
+		}
+		start := (page - 1) * pageSize
+		end := start + pageSize
+		if start > len(matches) {
+			start = len(matches)
+		}
+		if end > len(matches) {
+			end = len(matches)
+		}
+		info := &cloudflare.ResultInfo{
+			Page:       page,
+			PerPage:    pageSize,
+			TotalPages: (len(matches) + pageSize - 1) / pageSize,
+			Count:      end - start,
+			Total:      len(matches),
+		}
+		cfWrite(w, matches[start:end], info)
+	})
+	mux.HandleFunc("POST "+prefix+"/{zone}/dns_records", func(w http.ResponseWriter, r *http.Request) {
+		rec := cloudflare.DNSRecord{}
+		json.NewDecoder(r.Body).Decode(&rec)
+		s.nextID++
+		rec.ID = "rec" + strconv.Itoa(s.nextID)
+		rec.ZoneID = r.PathValue("zone")
+		cfSRVContent(&rec)
+		cfProxiedTTL(&rec)
+		s.records = append(s.records, rec)
+		if rec.Comment != "" {
+			s.comments[rec.ID] = rec.Comment
+		}
+		cfWrite(w, rec, nil)
+	})
+	mux.HandleFunc("GET "+prefix+"/{zone}/dns_records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ii, ok := s.getRecord(r.PathValue("id"))
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		cfWrite(w, s.records[ii], nil)
+	})
+	mux.HandleFunc("PATCH "+prefix+"/{zone}/dns_records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ii, ok := s.getRecord(r.PathValue("id"))
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// only overwrite fields that were sent
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &s.records[ii])
+		cfSRVContent(&s.records[ii])
+		cfProxiedTTL(&s.records[ii])
+		comment := struct {
+			Comment *string `json:"comment"`
+		}{}
+		json.Unmarshal(data, &comment)
+		if comment.Comment != nil {
+			s.comments[s.records[ii].ID] = *comment.Comment
+		}
+		cfWrite(w, s.records[ii], nil)
+	})
+	mux.HandleFunc("GET "+prefix+"/{zone}/dnssec", func(w http.ResponseWriter, r *http.Request) {
+		setting, ok := s.dnssec[r.PathValue("zone")]
+		if !ok {
+			setting.Status = "disabled"
+		}
+		cfWrite(w, setting, nil)
+	})
+	mux.HandleFunc("PATCH "+prefix+"/{zone}/dnssec", func(w http.ResponseWriter, r *http.Request) {
+		opts := cloudflare.ZoneDNSSECUpdateOptions{}
+		json.NewDecoder(r.Body).Decode(&opts)
+		if s.dnssec == nil {
+			s.dnssec = map[string]cloudflare.ZoneDNSSEC{}
+		}
+		setting := cloudflare.ZoneDNSSEC{Status: opts.Status}
+		if opts.Status == "active" {
+			// active once the DS record is seen in the parent zone
+			setting = cloudflare.ZoneDNSSEC{
+				Status:     "pending",
+				Algorithm:  "13",
+				KeyTag:     2371,
+				DigestType: "2",
+				Digest:     "abcdef0123",
+			}
+		}
+		s.dnssec[r.PathValue("zone")] = setting
+		cfWrite(w, setting, nil)
+	})
+	mux.HandleFunc("DELETE "+prefix+"/{zone}/dns_records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ii, ok := s.getRecord(r.PathValue("id"))
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		id := s.records[ii].ID
+		s.records = append(s.records[:ii], s.records[ii+1:]...)
+		cfWrite(w, map[string]string{"id": id}, nil)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		if s.delay > 0 {
+			time.Sleep(s.delay)
+		}
+		if rest, ok := strings.CutPrefix(r.URL.Path, prefix+"/"); ok && !s.hasZoneID(strings.Split(rest, "/")[0]) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"errors":[{"code":7003,"message":"Could not route to /zones, perhaps your object identifier is invalid?"}],"messages":[],"result":null}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// newCFTestProvider creates a Cloudflare provider backed by the stub,
+// without client side rate limiting or retries.
+func newCFTestProvider(t *testing.T, stub *cfStub) *CloudflareAPI {
+	client := newStubClient(t, stub.handler())
+	cfapi, err := cloudflare.NewWithAPIToken("test",
+		cloudflare.HTTPClient(client),
+		cloudflare.UsingRateLimit(10000),
+		cloudflare.UsingRetryPolicy(0, 0, 0))
+	require.Nil(t, err)
+	return &CloudflareAPI{
+		api:    cfapi,
+		logger: slog.Default(),
+	}
+}
+
+func TestCloudflareStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+}
+
+func TestCloudflareUpdateTTL(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	name := "ttl.example.com"
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", name, "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", name, "AAAA", "fd00::1", 300, false)
+	require.Nil(t, err)
+
+	err = prov.UpdateTTL(ctx, "example.com", name, "A", 600)
+	require.Nil(t, err)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", name)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	for _, rec := range records {
+		if rec.Type == "A" {
+			require.Equal(t, []string{"10.0.0.1"}, rec.Content)
+			require.Equal(t, 600, rec.TTL)
+		} else {
+			require.Equal(t, []string{"fd00::1"}, rec.Content)
+			require.Equal(t, 300, rec.TTL)
+		}
+	}
+
+	// unchanged TTL sends no update
+	patches := stub.count(http.MethodPatch, "/dns_records/")
+	err = prov.UpdateTTL(ctx, "example.com", name, "A", 600)
+	require.Nil(t, err)
+	require.Equal(t, patches, stub.count(http.MethodPatch, "/dns_records/"))
+
+	err = prov.UpdateTTL(ctx, "example.com", "missing.example.com", "A", 600)
+	require.True(t, errors.Is(err, ErrRecordNotFound))
+
+	// like DeleteDNSRecordByType, the type is required
+	err = prov.UpdateTTL(ctx, "example.com", name, "", 600)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "no record type specified")
+	require.Equal(t, patches, stub.count(http.MethodPatch, "/dns_records/"))
+}
+
+func TestCloudflareChangeAuthor(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	name := "audit.example.com"
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", name, "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	require.Empty(t, stub.comments)
+
+	prov.comment = getOptions([]Option{WithChangeAuthor("alice")}).changeComment()
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", name, "A", "10.0.0.2", 300, false)
+	require.Nil(t, err)
+	id := stub.records[0].ID
+	require.Equal(t, "last changed by alice", stub.comments[id])
+
+	prov.comment = getOptions([]Option{WithChangeAuthor("bob")}).changeComment()
+	err = prov.UpdateTTL(ctx, "example.com", name, "A", 600)
+	require.Nil(t, err)
+	require.Equal(t, "last changed by bob", stub.comments[id])
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", name)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, []string{"10.0.0.2"}, records[0].Content)
+	require.Equal(t, 600, records[0].TTL)
+
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "new.example.com", "A", "10.0.0.3", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, "last changed by bob", stub.comments[stub.records[1].ID])
+}
+
+func TestCloudflareMX(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, uint16(10), *stub.records[0].Priority)
+	require.Equal(t, "mail.example.com", stub.records[0].Content)
+
+	// a priority change alone is an update
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "20 mail.example.com", 300, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, 20, records[0].Priority)
+	require.Equal(t, []string{"mail.example.com."}, records[0].Content)
+
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "mail.example.com", 300, false)
+	require.NotNil(t, err)
+}
+
+func TestCloudflareTXT(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	// quoted input is stored and read back as the logical value
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "TXT", `"v=spf1 -all"`, 300, false)
+	require.Nil(t, err)
+	require.Equal(t, "v=spf1 -all", stub.records[0].Content)
+
+	patches := stub.count(http.MethodPatch, "/dns_records/")
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "TXT", "v=spf1 -all", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, patches, stub.count(http.MethodPatch, "/dns_records/"))
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, []string{"v=spf1 -all"}, records[0].Content)
+}
+
+func TestCloudflareSRV(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	name := "_sip._tcp.example.com"
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", name, "SRV", "10 5 5060 sip.example.com.", 300, false)
+	require.Nil(t, err)
+	data := stub.records[0].Data.(map[string]interface{})
+	require.Equal(t, "_sip", data["service"])
+	require.Equal(t, "_tcp", data["proto"])
+	require.Equal(t, "example.com", data["name"])
+	require.Equal(t, "sip.example.com", data["target"])
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", name)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, 10, records[0].Priority)
+	require.Equal(t, 5, records[0].Weight)
+	require.Equal(t, 5060, records[0].Port)
+	require.Equal(t, []string{"sip.example.com."}, records[0].Content)
+
+	// re-applying the same record is a no-op
+	patches := stub.count(http.MethodPatch, "/dns_records/")
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", name, "SRV", "10 5 5060 sip.example.com", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, patches, stub.count(http.MethodPatch, "/dns_records/"))
+
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "sip.example.com", "SRV", "10 5 5060 sip.example.com", 300, false)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "_service._proto")
+}
+
+func TestCloudflareDNSSECStatus(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com", "example.org")
+	stub.dnssec = map[string]cloudflare.ZoneDNSSEC{
+		"zone1": {
+			Status:     "active",
+			Algorithm:  "13",
+			KeyTag:     2371,
+			DigestType: "2",
+			Digest:     "ABCDEF0123",
+		},
+	}
+	prov := newCFTestProvider(t, stub)
+
+	status, err := prov.GetDNSSECStatus(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, api.DNSSECStatus{
+		Enabled:   true,
+		State:     "active",
+		DSRecords: []string{"2371 13 2 ABCDEF0123"},
+	}, status)
+
+	status, err = prov.GetDNSSECStatus(ctx, "example.org")
+	require.Nil(t, err)
+	require.Equal(t, api.DNSSECStatus{State: "disabled"}, status)
+}
+
+func TestCloudflareEnableDNSSEC(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	ds, err := EnableDNSSEC(ctx, prov, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, api.DSRecord{
+		KeyTag:     2371,
+		Algorithm:  13,
+		DigestType: 2,
+		Digest:     "ABCDEF0123",
+	}, ds)
+	status, err := prov.GetDNSSECStatus(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, api.DNSSECStatus{
+		State:     "pending",
+		DSRecords: []string{"2371 13 2 ABCDEF0123"},
+	}, status)
+
+	err = DisableDNSSEC(ctx, prov, "example.com")
+	require.Nil(t, err)
+	status, err = prov.GetDNSSECStatus(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, api.DNSSECStatus{State: "disabled"}, status)
+
+	_, err = prov.EnableDNSSEC(ctx, "example.net")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+}
+
+func TestCloudflareGetZone(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com", "example.org")
+	stub.dnssec = map[string]cloudflare.ZoneDNSSEC{
+		"zone1": {Status: "active"},
+	}
+	prov := newCFTestProvider(t, stub)
+
+	info, err := prov.GetZone(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, api.ZoneInfo{
+		Name:          "example.com",
+		ID:            "zone1",
+		NameServers:   []string{"ada.ns.cloudflare.com", "bob.ns.cloudflare.com"},
+		Status:        "active",
+		DNSSECEnabled: true,
+	}, info)
+
+	info, err = prov.GetZone(ctx, "example.org")
+	require.Nil(t, err)
+	require.Equal(t, "zone2", info.ID)
+	require.False(t, info.DNSSECEnabled)
+
+	_, err = prov.GetZone(ctx, "example.net")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+}
+
+func TestCloudflareDeleteByType(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "AAAA", "fd00::1", 300, false)
+	require.Nil(t, err)
+
+	err = prov.DeleteDNSRecordByType(ctx, "example.com", "www.example.com", "A")
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "AAAA", records[0].Type)
+
+	err = prov.DeleteDNSRecordByType(ctx, "example.com", "www.example.com", "")
+	require.NotNil(t, err)
+}
+
+func TestCloudflarePagination(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	stub.pageSize = 2
+	for ii := 0; ii < 5; ii++ {
+		stub.records = append(stub.records, cloudflare.DNSRecord{
+			ID:      "host" + strconv.Itoa(ii),
+			ZoneID:  "zone1",
+			Name:    "host" + strconv.Itoa(ii) + ".example.com",
+			Type:    "A",
+			Content: "10.0.0." + strconv.Itoa(ii),
+			TTL:     300,
+		}, cloudflare.DNSRecord{
+			ID:      "www" + strconv.Itoa(ii),
+			ZoneID:  "zone1",
+			Name:    "www.example.com",
+			Type:    "TXT",
+			Content: "value" + strconv.Itoa(ii),
+			TTL:     300,
+		})
+	}
+	prov := newCFTestProvider(t, stub)
+
+	// every page is read
+	records, err := prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	values := 0
+	for _, record := range records {
+		values += len(record.Content)
+	}
+	require.Equal(t, 10, values)
+	require.Equal(t, 5, stub.count(http.MethodGet, "/dns_records"))
+
+	// records past the first page are deleted too
+	count, err := prov.DeleteDNSRecordCount(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 5, count)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Empty(t, records)
+	require.Equal(t, 5, len(stub.records))
+}
+
+func TestCloudflareContext(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+
+	// a cancelled context fails before any request is sent
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	sent := len(stub.requests)
+	_, err = prov.GetDNSRecords(cancelledCtx, "example.com", "www.example.com")
+	require.True(t, errors.Is(err, context.Canceled), err)
+	require.Equal(t, sent, len(stub.requests))
+
+	// slow requests are abandoned once the deadline passes
+	stub.delay = 200 * time.Millisecond
+	ops := map[string]func(ctx context.Context) error{
+		"get": func(ctx context.Context) error {
+			_, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+			return err
+		},
+		"update": func(ctx context.Context) error {
+			return prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.2", 300, false)
+		},
+		"delete": func(ctx context.Context) error {
+			return prov.DeleteDNSRecord(ctx, "example.com", "www.example.com")
+		},
+	}
+	for name, op := range ops {
+		shortCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		start := time.Now()
+		err := op(shortCtx)
+		cancel()
+		require.True(t, errors.Is(err, context.DeadlineExceeded), "%s: %v", name, err)
+		require.Less(t, time.Since(start), 150*time.Millisecond, name)
+	}
+}
+
+func TestCloudflareBatch(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+
+	// the unsupported record fails without stopping the others
+	err = BatchCreateOrUpdateDNSRecords(ctx, prov, "example.com", []api.Record{{
+		Name:    "www.example.com",
+		Type:    "A",
+		Content: []string{"10.0.0.2"},
+		TTL:     300,
+	}, {
+		Name:    "caa.example.com",
+		Type:    "CAA",
+		Content: []string{`0 issue "letsencrypt.org"`},
+		TTL:     300,
+	}, {
+		Name:    "www.example.com",
+		Type:    "AAAA",
+		Content: []string{"fd00::1"},
+		TTL:     300,
+	}, {
+		Name:    "alias.example.com",
+		Type:    "CNAME",
+		Content: []string{"www.example.com"},
+		TTL:     300,
+	}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "CAA record caa.example.com failed")
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	contents := map[string]string{}
+	for _, record := range records {
+		contents[record.Name+"/"+record.Type] = record.Content[0]
+	}
+	require.Equal(t, map[string]string{
+		"www.example.com/A":       "10.0.0.2",
+		"www.example.com/AAAA":    "fd00::1",
+		"alias.example.com/CNAME": "www.example.com.",
+	}, contents)
+}
+
+func TestCloudflareListZones(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.org", "example.com")
+	prov := newCFTestProvider(t, stub)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{
+		{Name: "example.com", ID: "zone2"},
+		{Name: "example.org", ID: "zone1"},
+	}, zones)
+
+	// an empty listing is only an error if a zone was configured,
+	// which means the token should have been able to see it
+	stub = newCFStub()
+	prov = newCFTestProvider(t, stub)
+	zones, err = prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{}, zones)
+
+	prov.zone = "example.com"
+	_, err = prov.ListZones(ctx)
+	require.True(t, errors.Is(err, api.ErrNoZonesAccessible))
+	require.Contains(t, err.Error(), "example.com")
+}
+
+func TestCloudflareCreateZone(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	// an API token can only create zones in a given account
+	_, err := prov.CreateZone(ctx, "example.org")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "accountID")
+	require.Equal(t, 0, stub.count(http.MethodPost, "/zones"))
+
+	creds := CloudflareCredentials{Token: "test", AccountID: "acct1"}
+	require.Equal(t, creds, cloudflareCredentialsFromMap(creds.ToMap()))
+	prov.accountID = creds.AccountID
+	zone, err := prov.CreateZone(ctx, "example.org.")
+	require.Nil(t, err)
+	require.Equal(t, api.Zone{Name: "example.org", ID: "zone2"}, zone)
+	require.Equal(t, "acct1", stub.accounts["example.org"])
+}
+
+func TestCloudflareProxied(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, true)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.True(t, records[0].Proxied)
+
+	// already proxied as desired, so no update is issued
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, true)
+	require.Nil(t, err)
+	require.Equal(t, 0, stub.count("PATCH", "/dns_records/"))
+
+	// a change of the proxied state alone is applied
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.count("PATCH", "/dns_records/"))
+	records, err = prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.False(t, records[0].Proxied)
+}
+
+func TestCloudflareNoopUpdate(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	apply := []struct {
+		name, rtype, content string
+		ttl                  int
+		proxy                bool
+	}{
+		{"www.example.com", "A", "10.0.0.1", 300, false},
+		{"proxied.example.com", "A", "10.0.0.2", 300, true},
+		{"example.com", "MX", "10 mail.example.com.", 300, false},
+		{"example.com", "TXT", `"v=spf1 -all"`, 300, false},
+		{"_sip._tcp.example.com", "SRV", "10 60 5060 sip.example.com", 300, false},
+	}
+	for _, rec := range apply {
+		err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", rec.name, rec.rtype, rec.content, rec.ttl, rec.proxy)
+		require.Nil(t, err)
+	}
+
+	// re-applying identical records changes nothing
+	for _, rec := range apply {
+		err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", rec.name, rec.rtype, rec.content, rec.ttl, rec.proxy)
+		require.Nil(t, err)
+	}
+	require.Equal(t, 0, stub.count(http.MethodPatch, "/dns_records/"))
+
+	// a change of the TTL alone is applied
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 600, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.count(http.MethodPatch, "/dns_records/"))
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 600, records[0].TTL)
+}
+
+func TestCloudflareGroupedRecords(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	for ii, rec := range []cloudflare.DNSRecord{
+		{Name: "www.example.com", Type: "A", Content: "10.0.0.1", TTL: 600},
+		{Name: "www.example.com", Type: "A", Content: "10.0.0.2", TTL: 300},
+		{Name: "example.com", Type: "MX", Content: "mail1.example.com", TTL: 300, Priority: cloudflare.Uint16Ptr(10)},
+		{Name: "example.com", Type: "MX", Content: "mail2.example.com", TTL: 300, Priority: cloudflare.Uint16Ptr(20)},
+	} {
+		rec.ID = "rec" + strconv.Itoa(ii)
+		rec.ZoneID = "zone1"
+		stub.records = append(stub.records, rec)
+	}
+	prov := newCFTestProvider(t, stub)
+
+	// by default each Cloudflare record is read as its own record
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:    "www.example.com",
+		Type:    "A",
+		Content: []string{"10.0.0.1"},
+		TTL:     600,
+	}, {
+		Name:    "www.example.com",
+		Type:    "A",
+		Content: []string{"10.0.0.2"},
+		TTL:     300,
+	}}, records)
+
+	// grouped, with the lowest TTL
+	prov.groupRecords = getOptions([]Option{WithGroupedRecords()}).groupRecords
+	records, err = prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:    "www.example.com",
+		Type:    "A",
+		Content: []string{"10.0.0.1", "10.0.0.2"},
+		TTL:     300,
+	}}, records)
+
+	// MX values of different priorities stay separate
+	records, err = prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	require.Equal(t, []string{"mail1.example.com."}, records[0].Content)
+	require.Equal(t, 10, records[0].Priority)
+	require.Equal(t, []string{"mail2.example.com."}, records[1].Content)
+	require.Equal(t, 20, records[1].Priority)
+}
+
+func TestCloudflareRecordSet(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+	recordSetTest(t, ctx, prov, "example.com", "rr.example.com")
+	delegationTest(t, ctx, prov, "example.com", "sub.example.com")
+
+	// kept values are left alone and records are reused for new ones
+	stub.requests = nil
+	err := prov.CreateOrUpdateDNSRecordSet(ctx, "example.com", "rr.example.com", "A", []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 3, stub.count(http.MethodPost, "/dns_records"))
+	err = prov.CreateOrUpdateDNSRecordSet(ctx, "example.com", "rr.example.com", "A", []string{"10.0.0.4", "10.0.0.3"}, 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 3, stub.count(http.MethodPost, "/dns_records"))
+	require.Equal(t, 1, stub.count(http.MethodPatch, "/dns_records/"))
+	require.Equal(t, 1, stub.count(http.MethodDelete, "/dns_records/"))
+}
+
+func TestCloudflareWildcard(t *testing.T) {
+	ctx := context.Background()
+	prov := newCFTestProvider(t, newCFStub("example.com"))
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com")
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.Example.com.")
+}
@@ -0,0 +1,788 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+	"github.com/digitalocean/godo"
+	"github.com/dnsimple/dnsimple-go/dnsimple"
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/linode/linodego"
+	golangsdk "github.com/opentelekomcloud/gophertelekomcloud"
+	"github.com/ovh/go-ovh/ovh"
+	"github.com/scaleway/scaleway-sdk-go/scw"
+	"google.golang.org/api/googleapi"
+	ns1 "gopkg.in/ns1/ns1-go.v2/rest"
+)
+
+// CredentialKeyToken is the credentials data key of the API token of
+// the token based providers.
+const CredentialKeyToken = "token"
+
+// credentialField is a credential value with the key it has in the
+// credentials data.
+type credentialField struct {
+	key   string
+	value string
+}
+
+// requireCredentials returns an error listing the keys of the fields
+// that are empty.
+func requireCredentials(provider string, fields ...credentialField) error {
+	missing := []string{}
+	for _, field := range fields {
+		if field.value == "" {
+			missing = append(missing, field.key)
+		}
+	}
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("missing %s key from %s dns provider credentials data", missing[0], provider)
+	}
+	return fmt.Errorf("missing %s keys from %s dns provider credentials data", strings.Join(missing, ", "), provider)
+}
+
+// CloudflareCredentials are the credentials of the Cloudflare provider.
+type CloudflareCredentials struct {
+	// Token is an API token with DNS edit permission.
+	Token string
+	// AccountID is optional, and is the ID of the account that zones
+	// are created in. It is only needed by CreateZone.
+	AccountID string
+}
+
+func cloudflareCredentialsFromMap(data map[string]string) CloudflareCredentials {
+	return CloudflareCredentials{
+		Token:     data[CredentialKeyToken],
+		AccountID: data[CredentialKeyAccountID],
+	}
+}
+
+// Validate checks that all required fields are set.
+func (s CloudflareCredentials) Validate() error {
+	return requireCredentials("cloudflare", credentialField{CredentialKeyToken, s.Token})
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+// Empty optional fields are left out.
+func (s CloudflareCredentials) ToMap() map[string]string {
+	data := map[string]string{CredentialKeyToken: s.Token}
+	if s.AccountID != "" {
+		data[CredentialKeyAccountID] = s.AccountID
+	}
+	return data
+}
+
+// DigitalOceanCredentials are the credentials of the DigitalOcean
+// provider.
+type DigitalOceanCredentials struct {
+	// Token is a personal access token with write scope.
+	Token string
+}
+
+func digitalOceanCredentialsFromMap(data map[string]string) DigitalOceanCredentials {
+	return DigitalOceanCredentials{Token: data[CredentialKeyToken]}
+}
+
+// Validate checks that all required fields are set.
+func (s DigitalOceanCredentials) Validate() error {
+	return requireCredentials("digitalocean", credentialField{CredentialKeyToken, s.Token})
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s DigitalOceanCredentials) ToMap() map[string]string {
+	return map[string]string{CredentialKeyToken: s.Token}
+}
+
+// HetznerCredentials are the credentials of the Hetzner provider.
+type HetznerCredentials struct {
+	// Token is a Hetzner DNS API token.
+	Token string
+}
+
+func hetznerCredentialsFromMap(data map[string]string) HetznerCredentials {
+	return HetznerCredentials{Token: data[CredentialKeyToken]}
+}
+
+// Validate checks that all required fields are set.
+func (s HetznerCredentials) Validate() error {
+	return requireCredentials("hetzner", credentialField{CredentialKeyToken, s.Token})
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s HetznerCredentials) ToMap() map[string]string {
+	return map[string]string{CredentialKeyToken: s.Token}
+}
+
+// GandiCredentials are the credentials of the Gandi provider.
+type GandiCredentials struct {
+	// Token is a Gandi personal access token.
+	Token string
+}
+
+func gandiCredentialsFromMap(data map[string]string) GandiCredentials {
+	return GandiCredentials{Token: data[CredentialKeyToken]}
+}
+
+// Validate checks that all required fields are set.
+func (s GandiCredentials) Validate() error {
+	return requireCredentials("gandi", credentialField{CredentialKeyToken, s.Token})
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s GandiCredentials) ToMap() map[string]string {
+	return map[string]string{CredentialKeyToken: s.Token}
+}
+
+// GoogleCloudCredentials are the credentials of the Google Cloud DNS
+// provider, which are the fields of a service account key file.
+type GoogleCloudCredentials struct {
+	Type                    string `json:"type"`
+	ProjectID               string `json:"project_id"`
+	PrivateKeyID            string `json:"private_key_id"`
+	PrivateKey              string `json:"private_key"`
+	ClientEmail             string `json:"client_email"`
+	ClientID                string `json:"client_id"`
+	AuthURI                 string `json:"auth_uri"`
+	TokenURI                string `json:"token_uri"`
+	AuthProviderX509CertURL string `json:"auth_provider_x509_cert_url"`
+	ClientX509CertURL       string `json:"client_x509_cert_url"`
+	// Other holds any further fields of the key file, which are
+	// passed on to the Google client unchanged.
+	Other map[string]string `json:"-"`
+}
+
+// fields returns the named fields by their key file names.
+func (s *GoogleCloudCredentials) fields() map[string]*string {
+	return map[string]*string{
+		"type":                        &s.Type,
+		projectID:                     &s.ProjectID,
+		"private_key_id":              &s.PrivateKeyID,
+		"private_key":                 &s.PrivateKey,
+		"client_email":                &s.ClientEmail,
+		"client_id":                   &s.ClientID,
+		"auth_uri":                    &s.AuthURI,
+		"token_uri":                   &s.TokenURI,
+		"auth_provider_x509_cert_url": &s.AuthProviderX509CertURL,
+		"client_x509_cert_url":        &s.ClientX509CertURL,
+	}
+}
+
+func googleCloudCredentialsFromMap(data map[string]string) GoogleCloudCredentials {
+	creds := GoogleCloudCredentials{}
+	fields := creds.fields()
+	for key, value := range data {
+		if field, ok := fields[key]; ok {
+			*field = value
+			continue
+		}
+		if creds.Other == nil {
+			creds.Other = map[string]string{}
+		}
+		creds.Other[key] = value
+	}
+	return creds
+}
+
+// Validate checks that all required fields are set. Only the project
+// is required here, since the Google client validates the key itself
+// and supports several types of credentials.
+func (s GoogleCloudCredentials) Validate() error {
+	return requireCredentials("google cloud", credentialField{projectID, s.ProjectID})
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+// Empty fields are left out.
+func (s GoogleCloudCredentials) ToMap() map[string]string {
+	data := map[string]string{}
+	for key, value := range s.Other {
+		data[key] = value
+	}
+	for key, field := range s.fields() {
+		if *field != "" {
+			data[key] = *field
+		}
+	}
+	return data
+}
+
+// OTCCredentials are the credentials of the Open Telekom Cloud
+// provider.
+type OTCCredentials struct {
+	Region     string `json:"region"`
+	DomainName string `json:"domainName"`
+	TenantName string `json:"tenantName"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+}
+
+func otcCredentialsFromMap(data map[string]string) OTCCredentials {
+	return OTCCredentials{
+		Region:     data[CredentialKeyRegion],
+		DomainName: data[CredentialKeyDomainName],
+		TenantName: data[CredentialKeyTenantName],
+		Username:   data[CredentialKeyUsername],
+		Password:   data[CredentialKeyPassword],
+	}
+}
+
+// Validate checks that all required fields are set.
+func (s OTCCredentials) Validate() error {
+	return requireCredentials("otc",
+		credentialField{CredentialKeyRegion, s.Region},
+		credentialField{CredentialKeyDomainName, s.DomainName},
+		credentialField{CredentialKeyTenantName, s.TenantName},
+		credentialField{CredentialKeyUsername, s.Username},
+		credentialField{CredentialKeyPassword, s.Password},
+	)
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s OTCCredentials) ToMap() map[string]string {
+	return map[string]string{
+		CredentialKeyRegion:     s.Region,
+		CredentialKeyDomainName: s.DomainName,
+		CredentialKeyTenantName: s.TenantName,
+		CredentialKeyUsername:   s.Username,
+		CredentialKeyPassword:   s.Password,
+	}
+}
+
+// NS1Credentials are the credentials of the NS1 provider.
+type NS1Credentials struct {
+	// APIKey is sent as the X-NSONE-Key header.
+	APIKey string
+}
+
+func ns1CredentialsFromMap(data map[string]string) NS1Credentials {
+	return NS1Credentials{APIKey: data[CredentialKeyAPIKey]}
+}
+
+// Validate checks that all required fields are set.
+func (s NS1Credentials) Validate() error {
+	return requireCredentials("ns1", credentialField{CredentialKeyAPIKey, s.APIKey})
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s NS1Credentials) ToMap() map[string]string {
+	return map[string]string{CredentialKeyAPIKey: s.APIKey}
+}
+
+// LinodeCredentials are the credentials of the Linode provider.
+type LinodeCredentials struct {
+	// Token is a personal access token with the domains scope.
+	Token string
+}
+
+func linodeCredentialsFromMap(data map[string]string) LinodeCredentials {
+	return LinodeCredentials{Token: data[CredentialKeyToken]}
+}
+
+// Validate checks that all required fields are set.
+func (s LinodeCredentials) Validate() error {
+	return requireCredentials("linode", credentialField{CredentialKeyToken, s.Token})
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s LinodeCredentials) ToMap() map[string]string {
+	return map[string]string{CredentialKeyToken: s.Token}
+}
+
+// DeSECCredentials are the credentials of the deSEC provider.
+type DeSECCredentials struct {
+	// Token is a deSEC API token.
+	Token string
+}
+
+func desecCredentialsFromMap(data map[string]string) DeSECCredentials {
+	return DeSECCredentials{Token: data[CredentialKeyToken]}
+}
+
+// Validate checks that all required fields are set.
+func (s DeSECCredentials) Validate() error {
+	return requireCredentials("desec", credentialField{CredentialKeyToken, s.Token})
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s DeSECCredentials) ToMap() map[string]string {
+	return map[string]string{CredentialKeyToken: s.Token}
+}
+
+// VultrCredentials are the credentials of the Vultr provider.
+type VultrCredentials struct {
+	// APIKey is a Vultr API key.
+	APIKey string
+}
+
+func vultrCredentialsFromMap(data map[string]string) VultrCredentials {
+	return VultrCredentials{APIKey: data[CredentialKeyAPIKey]}
+}
+
+// Validate checks that all required fields are set.
+func (s VultrCredentials) Validate() error {
+	return requireCredentials("vultr", credentialField{CredentialKeyAPIKey, s.APIKey})
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s VultrCredentials) ToMap() map[string]string {
+	return map[string]string{CredentialKeyAPIKey: s.APIKey}
+}
+
+// ScalewayCredentials are the credentials of the Scaleway provider.
+type ScalewayCredentials struct {
+	// AccessKey and SecretKey are a Scaleway API key.
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	// ProjectID is the project that owns the DNS zones.
+	ProjectID string `json:"projectID"`
+	// OrganizationID is optional.
+	OrganizationID string `json:"organizationID,omitempty"`
+}
+
+func scalewayCredentialsFromMap(data map[string]string) ScalewayCredentials {
+	return ScalewayCredentials{
+		AccessKey:      data[CredentialKeyAccessKey],
+		SecretKey:      data[CredentialKeySecretKey],
+		ProjectID:      data[CredentialKeyProjectID],
+		OrganizationID: data[CredentialKeyOrganizationID],
+	}
+}
+
+// Validate checks that all required fields are set.
+func (s ScalewayCredentials) Validate() error {
+	return requireCredentials("scaleway",
+		credentialField{CredentialKeyAccessKey, s.AccessKey},
+		credentialField{CredentialKeySecretKey, s.SecretKey},
+		credentialField{CredentialKeyProjectID, s.ProjectID},
+	)
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s ScalewayCredentials) ToMap() map[string]string {
+	data := map[string]string{
+		CredentialKeyAccessKey: s.AccessKey,
+		CredentialKeySecretKey: s.SecretKey,
+		CredentialKeyProjectID: s.ProjectID,
+	}
+	if s.OrganizationID != "" {
+		data[CredentialKeyOrganizationID] = s.OrganizationID
+	}
+	return data
+}
+
+// OVHCredentials are the credentials of the OVH provider.
+type OVHCredentials struct {
+	// Endpoint is the name of the API endpoint, such as ovh-eu or
+	// ovh-ca, or its URL.
+	Endpoint string `json:"endpoint"`
+	// ApplicationKey and ApplicationSecret identify the application,
+	// and ConsumerKey is the key the account validated for it.
+	ApplicationKey    string `json:"applicationKey"`
+	ApplicationSecret string `json:"applicationSecret"`
+	ConsumerKey       string `json:"consumerKey"`
+}
+
+func ovhCredentialsFromMap(data map[string]string) OVHCredentials {
+	return OVHCredentials{
+		Endpoint:          data[CredentialKeyEndpoint],
+		ApplicationKey:    data[CredentialKeyApplicationKey],
+		ApplicationSecret: data[CredentialKeyApplicationSecret],
+		ConsumerKey:       data[CredentialKeyConsumerKey],
+	}
+}
+
+// Validate checks that all required fields are set.
+func (s OVHCredentials) Validate() error {
+	return requireCredentials("ovh",
+		credentialField{CredentialKeyEndpoint, s.Endpoint},
+		credentialField{CredentialKeyApplicationKey, s.ApplicationKey},
+		credentialField{CredentialKeyApplicationSecret, s.ApplicationSecret},
+		credentialField{CredentialKeyConsumerKey, s.ConsumerKey},
+	)
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s OVHCredentials) ToMap() map[string]string {
+	return map[string]string{
+		CredentialKeyEndpoint:          s.Endpoint,
+		CredentialKeyApplicationKey:    s.ApplicationKey,
+		CredentialKeyApplicationSecret: s.ApplicationSecret,
+		CredentialKeyConsumerKey:       s.ConsumerKey,
+	}
+}
+
+// BunnyCredentials are the credentials of the Bunny provider.
+type BunnyCredentials struct {
+	// AccessKey is the API key of the bunny.net account.
+	AccessKey string `json:"accessKey"`
+}
+
+func bunnyCredentialsFromMap(data map[string]string) BunnyCredentials {
+	return BunnyCredentials{
+		AccessKey: data[CredentialKeyAccessKey],
+	}
+}
+
+// Validate checks that all required fields are set.
+func (s BunnyCredentials) Validate() error {
+	return requireCredentials("bunny",
+		credentialField{CredentialKeyAccessKey, s.AccessKey},
+	)
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s BunnyCredentials) ToMap() map[string]string {
+	return map[string]string{
+		CredentialKeyAccessKey: s.AccessKey,
+	}
+}
+
+// NamecheapCredentials are the credentials of the Namecheap provider.
+type NamecheapCredentials struct {
+	// APIUser and APIKey identify the API access, and Username is
+	// the account the commands act for, usually the same as APIUser.
+	APIUser  string `json:"apiUser"`
+	APIKey   string `json:"apiKey"`
+	Username string `json:"username"`
+	// ClientIP is the public IP address requests come from, which
+	// must be whitelisted for API access in the Namecheap account.
+	ClientIP string `json:"clientIP"`
+	// APIURL is optional, and defaults to the production API. Set it
+	// to https://api.sandbox.namecheap.com/xml.response to use the
+	// sandbox.
+	APIURL string `json:"apiURL,omitempty"`
+}
+
+func namecheapCredentialsFromMap(data map[string]string) NamecheapCredentials {
+	return NamecheapCredentials{
+		APIUser:  data[CredentialKeyAPIUser],
+		APIKey:   data[CredentialKeyAPIKey],
+		Username: data[CredentialKeyUsername],
+		ClientIP: data[CredentialKeyClientIP],
+		APIURL:   data[CredentialKeyAPIURL],
+	}
+}
+
+// Validate checks that all required fields are set.
+func (s NamecheapCredentials) Validate() error {
+	return requireCredentials("namecheap",
+		credentialField{CredentialKeyAPIUser, s.APIUser},
+		credentialField{CredentialKeyAPIKey, s.APIKey},
+		credentialField{CredentialKeyUsername, s.Username},
+		credentialField{CredentialKeyClientIP, s.ClientIP},
+	)
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s NamecheapCredentials) ToMap() map[string]string {
+	data := map[string]string{
+		CredentialKeyAPIUser:  s.APIUser,
+		CredentialKeyAPIKey:   s.APIKey,
+		CredentialKeyUsername: s.Username,
+		CredentialKeyClientIP: s.ClientIP,
+	}
+	if s.APIURL != "" {
+		data[CredentialKeyAPIURL] = s.APIURL
+	}
+	return data
+}
+
+// DNSimpleCredentials are the credentials of the DNSimple provider.
+type DNSimpleCredentials struct {
+	// Token is an OAuth access token, either an account token or a
+	// user token with access to the account.
+	Token string `json:"token"`
+	// AccountID is the ID of the account that owns the zones.
+	AccountID string `json:"accountID"`
+	// APIURL is optional, and defaults to the production API. Set it
+	// to https://api.sandbox.dnsimple.com to use the sandbox.
+	APIURL string `json:"apiURL,omitempty"`
+}
+
+func dnsimpleCredentialsFromMap(data map[string]string) DNSimpleCredentials {
+	return DNSimpleCredentials{
+		Token:     data[CredentialKeyToken],
+		AccountID: data[CredentialKeyAccountID],
+		APIURL:    data[CredentialKeyAPIURL],
+	}
+}
+
+// Validate checks that all required fields are set.
+func (s DNSimpleCredentials) Validate() error {
+	return requireCredentials("dnsimple",
+		credentialField{CredentialKeyToken, s.Token},
+		credentialField{CredentialKeyAccountID, s.AccountID},
+	)
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s DNSimpleCredentials) ToMap() map[string]string {
+	data := map[string]string{
+		CredentialKeyToken:     s.Token,
+		CredentialKeyAccountID: s.AccountID,
+	}
+	if s.APIURL != "" {
+		data[CredentialKeyAPIURL] = s.APIURL
+	}
+	return data
+}
+
+// PorkbunCredentials are the credentials of the Porkbun provider.
+type PorkbunCredentials struct {
+	// APIKey and SecretAPIKey are a Porkbun API key pair. API access
+	// must also be enabled for each domain.
+	APIKey       string `json:"apiKey"`
+	SecretAPIKey string `json:"secretAPIKey"`
+}
+
+func porkbunCredentialsFromMap(data map[string]string) PorkbunCredentials {
+	return PorkbunCredentials{
+		APIKey:       data[CredentialKeyAPIKey],
+		SecretAPIKey: data[CredentialKeySecretAPIKey],
+	}
+}
+
+// Validate checks that all required fields are set.
+func (s PorkbunCredentials) Validate() error {
+	return requireCredentials("porkbun",
+		credentialField{CredentialKeyAPIKey, s.APIKey},
+		credentialField{CredentialKeySecretAPIKey, s.SecretAPIKey},
+	)
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s PorkbunCredentials) ToMap() map[string]string {
+	return map[string]string{
+		CredentialKeyAPIKey:       s.APIKey,
+		CredentialKeySecretAPIKey: s.SecretAPIKey,
+	}
+}
+
+// PowerDNSCredentials are the credentials of the PowerDNS provider.
+type PowerDNSCredentials struct {
+	// APIURL is the base URL of the API, for example
+	// http://pdns:8081.
+	APIURL string
+	APIKey string
+	// ServerID defaults to localhost.
+	ServerID string
+}
+
+func powerDNSCredentialsFromMap(data map[string]string) PowerDNSCredentials {
+	return PowerDNSCredentials{
+		APIURL:   data[CredentialKeyAPIURL],
+		APIKey:   data[CredentialKeyAPIKey],
+		ServerID: data[CredentialKeyServerID],
+	}
+}
+
+// Validate checks that all required fields are set.
+func (s PowerDNSCredentials) Validate() error {
+	return requireCredentials("powerdns",
+		credentialField{CredentialKeyAPIURL, s.APIURL},
+		credentialField{CredentialKeyAPIKey, s.APIKey},
+	)
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+func (s PowerDNSCredentials) ToMap() map[string]string {
+	data := map[string]string{
+		CredentialKeyAPIURL: s.APIURL,
+		CredentialKeyAPIKey: s.APIKey,
+	}
+	if s.ServerID != "" {
+		data[CredentialKeyServerID] = s.ServerID
+	}
+	return data
+}
+
+// RFC2136Credentials are the credentials of the RFC 2136 provider.
+type RFC2136Credentials struct {
+	// Nameserver is the host, with an optional port, that accepts
+	// dynamic updates.
+	Nameserver string
+	// TSIGKeyName, if set, signs requests with the TSIG key, which
+	// then requires the secret.
+	TSIGKeyName string
+	TSIGSecret  string
+	// TSIGAlgorithm defaults to hmac-sha256.
+	TSIGAlgorithm string
+	// Transport is "tcp" (the default) or "udp".
+	Transport string
+}
+
+func rfc2136CredentialsFromMap(data map[string]string) RFC2136Credentials {
+	return RFC2136Credentials{
+		Nameserver:    data[CredentialKeyNameserver],
+		TSIGKeyName:   data[CredentialKeyTSIGKeyName],
+		TSIGSecret:    data[CredentialKeyTSIGSecret],
+		TSIGAlgorithm: data[CredentialKeyTSIGAlgorithm],
+		Transport:     data[CredentialKeyTransport],
+	}
+}
+
+// Validate checks that all required fields are set.
+func (s RFC2136Credentials) Validate() error {
+	fields := []credentialField{{CredentialKeyNameserver, s.Nameserver}}
+	if s.TSIGKeyName != "" {
+		fields = append(fields, credentialField{CredentialKeyTSIGSecret, s.TSIGSecret})
+	}
+	return requireCredentials("rfc2136", fields...)
+}
+
+// ToMap returns the credentials as credentials data for GetProvider.
+// Empty optional fields are left out.
+func (s RFC2136Credentials) ToMap() map[string]string {
+	data := map[string]string{CredentialKeyNameserver: s.Nameserver}
+	for key, value := range map[string]string{
+		CredentialKeyTSIGKeyName:   s.TSIGKeyName,
+		CredentialKeyTSIGSecret:    s.TSIGSecret,
+		CredentialKeyTSIGAlgorithm: s.TSIGAlgorithm,
+		CredentialKeyTransport:     s.Transport,
+	} {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	return data
+}
+
+// ValidateCredentials checks that the credentials are well formed and
+// accepted by the backend before the provider is used, without needing
+// a zone. Failures wrap api.ErrInvalidCredentials if the credentials
+// are rejected, api.ErrPermissionDenied if they lack permissions, or
+// api.ErrUnreachable if the backend could not be reached. Providers
+// that do not implement api.CredentialValidator are checked by
+// listing their zones.
+func ValidateCredentials(ctx context.Context, typ api.ProviderType, credentialsData map[string]string, ops ...Option) error {
+	prov, err := newProvider(ctx, typ, "", credentialsData, slog.Default(), ops...)
+	if err != nil {
+		// some providers authenticate when they are created
+		return credentialsError(err)
+	}
+	defer prov.Close()
+	if validator, ok := prov.(api.CredentialValidator); ok {
+		return validator.ValidateCredentials(ctx)
+	}
+	if _, err := prov.ListZones(ctx); err != nil {
+		return credentialsError(err)
+	}
+	return nil
+}
+
+// credentialsError classifies the error of an authenticated call as
+// rejected credentials, missing permissions or an unreachable
+// backend. Other errors are returned unchanged.
+func credentialsError(err error) error {
+	if err == nil ||
+		errors.Is(err, api.ErrInvalidCredentials) ||
+		errors.Is(err, api.ErrPermissionDenied) ||
+		errors.Is(err, api.ErrUnreachable) {
+		return err
+	}
+	switch errorStatusCode(err) {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w, %v", api.ErrInvalidCredentials, err)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w, %v", api.ErrPermissionDenied, err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return fmt.Errorf("%w, %v", api.ErrUnreachable, err)
+	}
+	return err
+}
+
+// errorStatusCode returns the HTTP status code of an error returned by
+// one of the backend clients, or 0 if there is none.
+func errorStatusCode(err error) int {
+	var herr *httpError
+	if errors.As(err, &herr) {
+		return herr.StatusCode
+	}
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		return gerr.Code
+	}
+	var ns1Err *ns1.Error
+	if errors.As(err, &ns1Err) && ns1Err.Resp != nil {
+		return ns1Err.Resp.StatusCode
+	}
+	var linodeErr *linodego.Error
+	if errors.As(err, &linodeErr) {
+		return linodeErr.StatusCode()
+	}
+	var vultrErr *vultrError
+	if errors.As(err, &vultrErr) {
+		return vultrErr.Status
+	}
+	var scwErr *scw.ResponseError
+	if errors.As(err, &scwErr) {
+		return scwErr.StatusCode
+	}
+	var scwAuthErr *scw.DeniedAuthenticationError
+	if errors.As(err, &scwAuthErr) {
+		return http.StatusUnauthorized
+	}
+	var scwPermErr *scw.PermissionsDeniedError
+	if errors.As(err, &scwPermErr) {
+		return http.StatusForbidden
+	}
+	var ovhErr *ovh.APIError
+	if errors.As(err, &ovhErr) {
+		return ovhErr.Code
+	}
+	var dnsimpleErr *dnsimple.ErrorResponse
+	if errors.As(err, &dnsimpleErr) && dnsimpleErr.HTTPResponse != nil {
+		return dnsimpleErr.HTTPResponse.StatusCode
+	}
+	var doErr *godo.ErrorResponse
+	if errors.As(err, &doErr) && doErr.Response != nil {
+		return doErr.Response.StatusCode
+	}
+	var otc401 golangsdk.ErrDefault401
+	var otcReauth *golangsdk.ErrUnableToReauthenticate
+	if errors.As(err, &otc401) || errors.As(err, &otcReauth) {
+		return http.StatusUnauthorized
+	}
+	var otc403 golangsdk.ErrDefault403
+	if errors.As(err, &otc403) {
+		return http.StatusForbidden
+	}
+	var otcErr golangsdk.ErrUnexpectedResponseCode
+	if errors.As(err, &otcErr) {
+		return otcErr.Actual
+	}
+	var cfAuthn *cloudflare.AuthorizationError
+	if errors.As(err, &cfAuthn) {
+		// the SDK names the 401 error an authorization error
+		return http.StatusUnauthorized
+	}
+	var cfAuthz *cloudflare.AuthenticationError
+	if errors.As(err, &cfAuthz) {
+		return http.StatusForbidden
+	}
+	return 0
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialsValidate(t *testing.T) {
+	err := CloudflareCredentials{}.Validate()
+	require.EqualError(t, err, "missing token key from cloudflare dns provider credentials data")
+	require.NoError(t, CloudflareCredentials{Token: "test"}.Validate())
+
+	err = OTCCredentials{Region: "eu-de", Username: "user"}.Validate()
+	require.EqualError(t, err, "missing domainName, tenantName, password keys from otc dns provider credentials data")
+
+	err = PowerDNSCredentials{APIURL: "http://pdns:8081"}.Validate()
+	require.EqualError(t, err, "missing apiKey key from powerdns dns provider credentials data")
+
+	require.NoError(t, RFC2136Credentials{Nameserver: "ns1"}.Validate())
+	err = RFC2136Credentials{Nameserver: "ns1", TSIGKeyName: "key"}.Validate()
+	require.EqualError(t, err, "missing tsigSecret key from rfc2136 dns provider credentials data")
+
+	err = GoogleCloudCredentials{ClientEmail: "dns@example.iam"}.Validate()
+	require.EqualError(t, err, "missing project_id key from google cloud dns provider credentials data")
+}
+
+func TestCredentialsMapRoundTrip(t *testing.T) {
+	otc := OTCCredentials{
+		Region:     "eu-de",
+		DomainName: "domain",
+		TenantName: "tenant",
+		Username:   "user",
+		Password:   "secret",
+	}
+	require.Equal(t, otc, otcCredentialsFromMap(otc.ToMap()))
+
+	rfc := RFC2136Credentials{
+		Nameserver:  "ns1:5353",
+		TSIGKeyName: "key",
+		TSIGSecret:  "c2VjcmV0",
+	}
+	require.Equal(t, map[string]string{
+		CredentialKeyNameserver:  "ns1:5353",
+		CredentialKeyTSIGKeyName: "key",
+		CredentialKeyTSIGSecret:  "c2VjcmV0",
+	}, rfc.ToMap())
+	require.Equal(t, rfc, rfc2136CredentialsFromMap(rfc.ToMap()))
+
+	// unknown key file fields are kept
+	data := map[string]string{
+		"type":                  "service_account",
+		projectID:               "project",
+		"universe_domain":       "googleapis.com",
+		"client_email":          "dns@project.iam.gserviceaccount.com",
+		"private_key":           "key",
+		"auth_provider_ignored": "",
+	}
+	google := googleCloudCredentialsFromMap(data)
+	require.Equal(t, "project", google.ProjectID)
+	require.Equal(t, "googleapis.com", google.Other["universe_domain"])
+	require.Equal(t, data, google.ToMap())
+}
+
+func TestNewProviderWithCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewHetznerProviderWithCredentials(ctx, "", HetznerCredentials{}, nil)
+	require.ErrorContains(t, err, "missing token")
+	prov, err := NewHetznerProviderWithCredentials(ctx, "", HetznerCredentials{Token: "test"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "test", prov.token)
+
+	// map based constructors report missing and empty values alike
+	_, err = NewPowerDNSProvider(ctx, "", map[string]string{CredentialKeyAPIURL: ""}, nil)
+	require.EqualError(t, err, "missing apiURL, apiKey keys from powerdns dns provider credentials data")
+}
+
+func TestValidateCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		typ   api.ProviderType
+		creds map[string]string
+		ok    string // body returned with 200
+	}{{
+		typ:   api.CloudflareProvider,
+		creds: map[string]string{CredentialKeyToken: "test"},
+		ok:    `{"success":true,"result":{"id":"abc","status":"active"}}`,
+	}, {
+		typ:   api.GoogleCloudDNSProvider,
+		creds: map[string]string{projectID: "test-project"},
+		ok:    `{"managedZones":[]}`,
+	}, {
+		typ:   api.HetznerProvider,
+		creds: map[string]string{CredentialKeyToken: "test"},
+		ok:    `{"zones":[]}`,
+	}, {
+		typ:   api.PowerDNSProvider,
+		creds: map[string]string{CredentialKeyAPIURL: "http://pdns:8081", CredentialKeyAPIKey: "test"},
+		ok:    `{"id":"localhost"}`,
+	}}
+	for _, test := range tests {
+		status := http.StatusOK
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			if status == http.StatusOK {
+				w.Write([]byte(test.ok))
+				return
+			}
+			w.Write([]byte(`{"success":false,"errors":[{"code":10000,"message":"denied"}]}`))
+		})
+		client := newStubClient(t, handler)
+
+		err := ValidateCredentials(ctx, test.typ, test.creds, WithHTTPClient(client))
+		require.NoError(t, err, test.typ)
+
+		status = http.StatusUnauthorized
+		err = ValidateCredentials(ctx, test.typ, test.creds, WithHTTPClient(client))
+		require.ErrorIs(t, err, api.ErrInvalidCredentials, test.typ)
+
+		status = http.StatusForbidden
+		err = ValidateCredentials(ctx, test.typ, test.creds, WithHTTPClient(client))
+		require.ErrorIs(t, err, api.ErrPermissionDenied, test.typ)
+	}
+
+	// nothing listens on the discard port
+	client := &http.Client{
+		Transport: redirectTransport{host: "127.0.0.1:9"},
+	}
+	err := ValidateCredentials(ctx, api.HetznerProvider, map[string]string{CredentialKeyToken: "test"}, WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrUnreachable)
+
+	// malformed credentials are reported as they are
+	err = ValidateCredentials(ctx, api.HetznerProvider, map[string]string{})
+	require.EqualError(t, err, "missing token key from hetzner dns provider credentials data")
+
+	err = ValidateCredentials(ctx, api.MockProvider, nil)
+	require.NoError(t, err)
+}
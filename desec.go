@@ -0,0 +1,326 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+const (
+	desecBaseURL = "https://desec.io/api/v1"
+	// desecMinTTL is the lowest TTL deSEC accepts for domains on the
+	// default plan. It is also used when no TTL is given, since
+	// deSEC requires one.
+	desecMinTTL = 3600
+)
+
+// desecTTLs are the TTLs deSEC accepts for domains on the default
+// plan.
+var desecTTLs = ttlRange{min: desecMinTTL, max: 86400}
+
+// DeSEC manages DNS records via the deSEC REST API. deSEC stores
+// record sets by their name relative to the domain, with an empty
+// name for the apex, and takes record content in presentation format.
+// Listing a domain with more than 500 record sets requires
+// pagination, which is not supported.
+type DeSEC struct {
+	client    *http.Client
+	baseURL   string
+	token     string
+	logger    api.Logger
+	zone      string // zone the provider was configured for, if any
+	ttlPolicy TTLPolicy
+}
+
+type desecRRset struct {
+	Subname string   `json:"subname"`
+	Type    string   `json:"type"`
+	TTL     int      `json:"ttl,omitempty"`
+	Records []string `json:"records"`
+}
+
+// NewDeSECProvider creates a new deSEC provider.
+func NewDeSECProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*DeSEC, error) {
+	return NewDeSECProviderWithCredentials(ctx, zone, desecCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewDeSECProviderWithCredentials creates a new deSEC provider from
+// typed credentials. TTLs below the deSEC minimum are rejected unless
+// WithTTLPolicy(TTLClamp) is given.
+func NewDeSECProviderWithCredentials(ctx context.Context, zone string, creds DeSECCredentials, logger api.Logger, ops ...Option) (*DeSEC, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	opts := getOptions(ops)
+	client := opts.httpClient()
+	return &DeSEC{
+		client:    client,
+		baseURL:   desecBaseURL,
+		token:     creds.Token,
+		logger:    logger,
+		zone:      zone,
+		ttlPolicy: opts.ttlPolicy,
+	}, nil
+}
+
+func (s *DeSEC) do(ctx context.Context, method, path string, in, out interface{}) error {
+	header := http.Header{}
+	header.Set("Authorization", "Token "+s.token)
+	return doJSON(ctx, s.client, method, s.baseURL+path, header, in, out)
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *DeSEC) Close() error {
+	return nil
+}
+
+// ValidateCredentials lists the domains of the account.
+func (s *DeSEC) ValidateCredentials(ctx context.Context) error {
+	return credentialsError(s.do(ctx, http.MethodGet, "/domains/", nil, nil))
+}
+
+// ListZones returns the domains of the account. deSEC identifies
+// domains by name.
+func (s *DeSEC) ListZones(ctx context.Context) ([]api.Zone, error) {
+	domains := []struct {
+		Name string `json:"name"`
+	}{}
+	if err := s.do(ctx, http.MethodGet, "/domains/", nil, &domains); err != nil {
+		return nil, err
+	}
+	zones := []api.Zone{}
+	for _, domain := range domains {
+		zones = append(zones, newZone(domain.Name, ""))
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone creates the domain. deSEC adds the apex NS records and
+// signs the zone itself.
+func (s *DeSEC) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	domain := struct {
+		Name string `json:"name"`
+	}{Name: zone}
+	if err := s.do(ctx, http.MethodPost, "/domains/", &domain, &domain); err != nil {
+		return api.Zone{}, fmt.Errorf("cannot create zone %s, %v", zone, err)
+	}
+	return newZone(domain.Name, ""), nil
+}
+
+// DeleteZone deletes the domain and all its records.
+func (s *DeSEC) DeleteZone(ctx context.Context, zone string) error {
+	err := s.do(ctx, http.MethodDelete, desecDomainPath(zone), nil, nil)
+	if isHTTPStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete zone %s, %v", zone, err)
+	}
+	return nil
+}
+
+func desecDomainPath(zone string, elems ...string) string {
+	path := "/domains/" + url.PathEscape(strings.TrimSuffix(zone, ".")) + "/"
+	for _, elem := range elems {
+		path += url.PathEscape(elem) + "/"
+	}
+	return path
+}
+
+// desecSubname returns the name relative to the zone as deSEC stores
+// it, with an empty name for the apex.
+func desecSubname(name, zone string) string {
+	subname := relativeName(name, zone)
+	if subname == apexName {
+		return ""
+	}
+	return subname
+}
+
+// getRRsets returns the record sets of the zone, or only those of the
+// name if one is given.
+func (s *DeSEC) getRRsets(ctx context.Context, zone, name string) ([]desecRRset, error) {
+	path := desecDomainPath(zone, "rrsets")
+	if name != "" {
+		path += "?subname=" + url.QueryEscape(desecSubname(name, zone))
+	}
+	rrsets := []desecRRset{}
+	err := s.do(ctx, http.MethodGet, path, nil, &rrsets)
+	if isHTTPStatus(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rrsets, nil
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *DeSEC) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	rrsets, err := s.getRRsets(ctx, zone, name)
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, rrset := range rrsets {
+		recName := absoluteName(rrset.Subname, zone)
+		records = append(records, fromPresentation(api.Record{
+			Type:    rrset.Type,
+			Name:    recName,
+			Content: rrset.Records,
+			TTL:     rrset.TTL,
+			System:  isSystemRecord(zone, recName, rrset.Type),
+		})...)
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *DeSEC) SupportedRecordTypes() []string {
+	return slices.Clone(presentationRecordTypes)
+}
+
+// Capabilities reports that deSEC can manage zones.
+func (s *DeSEC) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// CreateOrUpdateDNSRecord replaces all values of the name and type
+// with the new record.
+func (s *DeSEC) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	return s.CreateOrUpdateDNSRecordSet(ctx, zone, name, rtype, []string{content}, ttl, proxy)
+}
+
+// CreateOrUpdateDNSRecordSet replaces the record set of the name and
+// type with the contents, creating it if needed. A TTL outside the
+// range deSEC allows is handled according to the TTL policy.
+func (s *DeSEC) CreateOrUpdateDNSRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) error {
+	if err := checkProxy(api.DeSECProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, presentationRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, contents...); err != nil {
+		return err
+	}
+	if len(contents) == 0 {
+		return fmt.Errorf("no content specified for %s record %s", rtype, name)
+	}
+	ttl, err := s.ttlPolicy.normalize(api.DeSECProvider, ttl, desecTTLs)
+	if err != nil {
+		return err
+	}
+	if ttl == 0 {
+		ttl = desecMinTTL
+	}
+	rrset := desecRRset{
+		Subname: desecSubname(name, zone),
+		Type:    strings.ToUpper(rtype),
+		TTL:     ttl,
+	}
+	for _, content := range contents {
+		rdata, err := toPresentation(rtype, content)
+		if err != nil {
+			return err
+		}
+		rrset.Records = append(rrset.Records, rdata)
+	}
+	s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", rrset.Records)
+	// the bulk endpoint creates the record set if it does not exist,
+	// unlike a PUT to the URL of the record set itself
+	err = s.do(ctx, http.MethodPut, desecDomainPath(zone, "rrsets"), []desecRRset{rrset}, nil)
+	if isHTTPStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+		return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *DeSEC) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.DeleteDNSRecordCount(ctx, zone, name)
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted. The record sets of every type are
+// emptied in one bulk request, which deSEC applies atomically.
+func (s *DeSEC) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	rrsets, err := s.getRRsets(ctx, zone, name)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	deletes := []desecRRset{}
+	for _, rrset := range rrsets {
+		count += len(rrset.Records)
+		deletes = append(deletes, desecRRset{
+			Subname: rrset.Subname,
+			Type:    rrset.Type,
+			Records: []string{},
+		})
+	}
+	if len(deletes) == 0 {
+		return 0, nil
+	}
+	if err := s.do(ctx, http.MethodPatch, desecDomainPath(zone, "rrsets"), deletes, nil); err != nil {
+		return 0, fmt.Errorf("cannot delete DNS records for zone %s name %s, %v", zone, name, err)
+	}
+	return count, nil
+}
+
+// DeleteDNSRecordByType deletes only the record set of the given type
+// for the name.
+func (s *DeSEC) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if name == "" {
+		return fmt.Errorf("no name specified to delete")
+	}
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	subname := desecSubname(name, zone)
+	if subname == "" {
+		subname = apexName
+	}
+	err := s.do(ctx, http.MethodDelete, desecDomainPath(zone, "rrsets", subname, strings.ToUpper(rtype)), nil, nil)
+	if isHTTPStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete %s DNS records for zone %s name %s, %v", rtype, zone, name, err)
+	}
+	return nil
+}
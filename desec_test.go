@@ -0,0 +1,234 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// desecStub is a minimal in-memory implementation of the deSEC
+// domains and rrsets API, which enforces the minimum TTL.
+type desecStub struct {
+	mu       sync.Mutex
+	domains  map[string][]desecRRset
+	requests int
+}
+
+func newDeSECStub(domains ...string) *desecStub {
+	s := &desecStub{domains: map[string][]desecRRset{}}
+	for _, domain := range domains {
+		s.domains[domain] = []desecRRset{}
+	}
+	return s
+}
+
+func (s *desecStub) notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"detail": "Not found."}`))
+}
+
+// write applies a bulk update, where a record set with no records is
+// deleted.
+func (s *desecStub) write(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("name")
+	if _, ok := s.domains[domain]; !ok {
+		s.notFound(w)
+		return
+	}
+	updates := []desecRRset{}
+	json.NewDecoder(r.Body).Decode(&updates)
+	for _, update := range updates {
+		if len(update.Records) > 0 && update.TTL < desecMinTTL {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`[{"ttl": ["Ensure this value is greater than or equal to 3600."]}]`))
+			return
+		}
+	}
+	for _, update := range updates {
+		kept := []desecRRset{}
+		for _, rrset := range s.domains[domain] {
+			if rrset.Subname != update.Subname || rrset.Type != update.Type {
+				kept = append(kept, rrset)
+			}
+		}
+		if len(update.Records) > 0 {
+			kept = append(kept, update)
+		}
+		s.domains[domain] = kept
+	}
+	json.NewEncoder(w).Encode(updates)
+}
+
+func (s *desecStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/domains/{$}", func(w http.ResponseWriter, r *http.Request) {
+		domains := []map[string]string{}
+		for name := range s.domains {
+			domains = append(domains, map[string]string{"name": name})
+		}
+		json.NewEncoder(w).Encode(domains)
+	})
+	mux.HandleFunc("GET /api/v1/domains/{name}/rrsets/{$}", func(w http.ResponseWriter, r *http.Request) {
+		all, ok := s.domains[r.PathValue("name")]
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		rrsets := []desecRRset{}
+		for _, rrset := range all {
+			if !r.URL.Query().Has("subname") || r.URL.Query().Get("subname") == rrset.Subname {
+				rrsets = append(rrsets, rrset)
+			}
+		}
+		json.NewEncoder(w).Encode(rrsets)
+	})
+	mux.HandleFunc("PUT /api/v1/domains/{name}/rrsets/{$}", s.write)
+	mux.HandleFunc("PATCH /api/v1/domains/{name}/rrsets/{$}", s.write)
+	mux.HandleFunc("DELETE /api/v1/domains/{name}/rrsets/{subname}/{type}/{$}", func(w http.ResponseWriter, r *http.Request) {
+		domain := r.PathValue("name")
+		if _, ok := s.domains[domain]; !ok {
+			s.notFound(w)
+			return
+		}
+		subname := r.PathValue("subname")
+		if subname == apexName {
+			subname = ""
+		}
+		kept := []desecRRset{}
+		for _, rrset := range s.domains[domain] {
+			if rrset.Subname != subname || rrset.Type != r.PathValue("type") {
+				kept = append(kept, rrset)
+			}
+		}
+		s.domains[domain] = kept
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Token test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"detail": "Invalid token."}`))
+			return
+		}
+		s.requests++
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func newDeSECTestProvider(t *testing.T, stub *desecStub, ops ...Option) api.Provider {
+	client := newStubClient(t, stub.handler())
+	ops = append(ops, WithHTTPClient(client))
+	prov, err := GetProvider(context.Background(), api.DeSECProvider, "", DeSECCredentials{Token: "test"}.ToMap(), nil, ops...)
+	require.Nil(t, err)
+	return prov
+}
+
+func TestDeSECStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newDeSECStub("example.com")
+	// the shared tests use TTLs below the deSEC minimum
+	prov := newDeSECTestProvider(t, stub, WithTTLPolicy(TTLClamp))
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com")
+	recordSetTest(t, ctx, prov.(api.RecordSetUpdater), "example.com", "set.example.com")
+
+	// the apex has an empty subname, and content is in presentation
+	// format
+	err := prov.(api.RecordSetUpdater).CreateOrUpdateDNSRecordSet(ctx, "example.com", "example.com", "MX", []string{"10 mx1.example.com", "20 mx2.example.com."}, 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "TXT", "v=spf1 -all", 7200, false)
+	require.Nil(t, err)
+	require.Equal(t, []desecRRset{{
+		Subname: "",
+		Type:    "MX",
+		TTL:     desecMinTTL,
+		Records: []string{"10 mx1.example.com.", "20 mx2.example.com."},
+	}, {
+		Subname: "",
+		Type:    "TXT",
+		TTL:     7200,
+		Records: []string{`"v=spf1 -all"`},
+	}}, stub.domains["example.com"][1:])
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 3, len(records))
+	require.Equal(t, []string{"mx1.example.com."}, records[0].Content)
+	require.Equal(t, 10, records[0].Priority)
+	require.Equal(t, []string{"v=spf1 -all"}, records[2].Content)
+
+	err = prov.DeleteDNSRecordByType(ctx, "example.com", "example.com", "MX")
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "TXT", records[0].Type)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "example.com"}}, zones)
+}
+
+func TestDeSECTTL(t *testing.T) {
+	ctx := context.Background()
+	stub := newDeSECStub("example.com")
+	prov := newDeSECTestProvider(t, stub)
+
+	// a TTL below the minimum is rejected before any request
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.ErrorIs(t, err, api.ErrInvalidTTL)
+	require.Equal(t, 0, stub.requests)
+
+	// no TTL selects the minimum
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 0, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, desecMinTTL, records[0].TTL)
+
+	ttl, err := TTLClamp.normalize(api.DeSECProvider, 60, desecTTLs)
+	require.Nil(t, err)
+	require.Equal(t, desecMinTTL, ttl)
+	ttl, err = TTLReject.normalize(api.DeSECProvider, 86400, desecTTLs)
+	require.Nil(t, err)
+	require.Equal(t, 86400, ttl)
+	_, err = TTLReject.normalize(api.DeSECProvider, 60, desecTTLs)
+	require.EqualError(t, err, "invalid ttl, desec dns provider requires a ttl of at least 3600, not 60")
+	ttl, err = TTLClamp.normalize(api.DeSECProvider, 86401, desecTTLs)
+	require.Nil(t, err)
+	require.Equal(t, 86400, ttl)
+	_, err = TTLReject.normalize(api.DeSECProvider, 86401, desecTTLs)
+	require.EqualError(t, err, "invalid ttl, desec dns provider requires a ttl of at most 86400, not 86401")
+}
+
+func TestDeSECCredentials(t *testing.T) {
+	ctx := context.Background()
+	client := newStubClient(t, newDeSECStub("example.com").handler())
+
+	err := ValidateCredentials(ctx, api.DeSECProvider, DeSECCredentials{Token: "test"}.ToMap(), WithHTTPClient(client))
+	require.Nil(t, err)
+	err = ValidateCredentials(ctx, api.DeSECProvider, DeSECCredentials{Token: "wrong"}.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+}
@@ -0,0 +1,471 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/edgexr/dnsproviders/api"
+	"golang.org/x/oauth2"
+)
+
+// digitalOceanTTLs are the TTLs DigitalOcean accepts.
+var digitalOceanTTLs = ttlRange{min: 30}
+
+// DigitalOcean manages DNS records via the DigitalOcean domains API.
+// DigitalOcean stores record names relative to the zone, so names are
+// converted to and from the fully qualified form used by this package.
+type DigitalOcean struct {
+	api    *godo.Client
+	logger api.Logger
+	zone   string // zone the provider was configured for, if any
+	// applied to TTLs outside digitalOceanTTLs
+	ttlPolicy TTLPolicy
+}
+
+// NewDigitalOceanProvider creates a new DigitalOcean DNS provider.
+func NewDigitalOceanProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*DigitalOcean, error) {
+	return NewDigitalOceanProviderWithCredentials(ctx, zone, digitalOceanCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewDigitalOceanProviderWithCredentials creates a new DigitalOcean DNS provider
+// from typed credentials.
+func NewDigitalOceanProviderWithCredentials(ctx context.Context, zone string, creds DigitalOceanCredentials, logger api.Logger, ops ...Option) (*DigitalOcean, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	token := creds.Token
+	opts := getOptions(ops)
+	// oauth2 uses the client in the context as its base transport
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, opts.httpClient())
+	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	client := godo.NewClient(oauth2.NewClient(ctx, tokenSource))
+	return &DigitalOcean{
+		api:       client,
+		logger:    logger,
+		zone:      zone,
+		ttlPolicy: opts.ttlPolicy,
+	}, nil
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *DigitalOcean) Close() error {
+	return nil
+}
+
+// ValidateCredentials reads the account the token belongs to.
+func (s *DigitalOcean) ValidateCredentials(ctx context.Context) error {
+	_, _, err := s.api.Account.Get(ctx)
+	return credentialsError(err)
+}
+
+// ListZones returns the domains of the account. DigitalOcean
+// identifies domains by name.
+func (s *DigitalOcean) ListZones(ctx context.Context) ([]api.Zone, error) {
+	zones := []api.Zone{}
+	opt := &godo.ListOptions{PerPage: 200}
+	for {
+		domains, resp, err := s.api.Domains.List(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, domain := range domains {
+			zones = append(zones, newZone(domain.Name, ""))
+		}
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		cur, err := resp.Links.CurrentPage()
+		if err != nil {
+			return nil, err
+		}
+		opt.Page = cur + 1
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone adds the domain to the account.
+func (s *DigitalOcean) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	domain, _, err := s.api.Domains.Create(ctx, &godo.DomainCreateRequest{
+		Name: strings.TrimSuffix(zone, "."),
+	})
+	if err != nil {
+		return api.Zone{}, fmt.Errorf("cannot create domain %s, %v", zone, err)
+	}
+	return newZone(domain.Name, ""), nil
+}
+
+// DeleteZone deletes the domain and all its records.
+func (s *DigitalOcean) DeleteZone(ctx context.Context, zone string) error {
+	resp, err := s.api.Domains.Delete(ctx, strings.TrimSuffix(zone, "."))
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete domain %s, %v", zone, err)
+	}
+	return nil
+}
+
+// listRecords returns all records in the zone, following pagination.
+func (s *DigitalOcean) listRecords(ctx context.Context, zone string) ([]godo.DomainRecord, error) {
+	records := []godo.DomainRecord{}
+	err := s.listRecordPages(ctx, zone, func(page []godo.DomainRecord) error {
+		records = append(records, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *DigitalOcean) listRecordPages(ctx context.Context, zone string, fn func([]godo.DomainRecord) error) error {
+	opt := &godo.ListOptions{PerPage: 200}
+	for {
+		page, resp, err := s.api.Domains.Records(ctx, zone, opt)
+		if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return nil
+		}
+		cur, err := resp.Links.CurrentPage()
+		if err != nil {
+			return err
+		}
+		opt.Page = cur + 1
+	}
+}
+
+func doRecordToRecord(dorec godo.DomainRecord, zone string) api.Record {
+	record := api.Record{
+		Type:    dorec.Type,
+		Name:    absoluteName(dorec.Name, zone),
+		Content: []string{dorec.Data},
+		TTL:     dorec.TTL,
+		System:  isSystemRecord(zone, absoluteName(dorec.Name, zone), dorec.Type),
+		ID:      strconv.Itoa(dorec.ID),
+	}
+	switch dorec.Type {
+	case api.RecordTypeMX:
+		record.Priority = dorec.Priority
+	case api.RecordTypeSRV:
+		record.Priority = dorec.Priority
+		record.Weight = dorec.Weight
+		record.Port = dorec.Port
+	case api.RecordTypeTXT:
+		record.Content = []string{parseTXTRRData(dorec.Data)}
+	}
+	if hasHostTarget(dorec.Type) && dorec.Data == apexName {
+		record.Content = []string{absoluteName(dorec.Data, zone)}
+	}
+	return canonicalTargets(withUnicodeName(record))
+}
+
+// ListDNSRecordPages calls fn with each page of records in the zone.
+func (s *DigitalOcean) ListDNSRecordPages(ctx context.Context, zone string, fn func([]api.Record) error) error {
+	return s.listRecordPages(ctx, zone, func(page []godo.DomainRecord) error {
+		records := []api.Record{}
+		for _, dorec := range page {
+			records = append(records, doRecordToRecord(dorec, zone))
+		}
+		return fn(records)
+	})
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *DigitalOcean) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	dorecords, err := s.listRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, dorec := range dorecords {
+		record := doRecordToRecord(dorec, zone)
+		if name != "" && !strings.EqualFold(name, record.Name) {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *DigitalOcean) SupportedRecordTypes() []string {
+	return slices.Clone(digitalOceanRecordTypes)
+}
+
+// Capabilities reports that DigitalOcean can manage zones.
+func (s *DigitalOcean) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// CreateOrUpdateDNSRecord changes the existing record if found, or adds a new one
+func (s *DigitalOcean) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	_, err := s.CreateOrUpdateDNSRecordResult(ctx, zone, name, rtype, content, ttl, proxy)
+	return err
+}
+
+// CreateOrUpdateDNSRecordResult changes the existing record of the
+// name and type if found, or adds a new one, and returns the record as
+// stored, with its DigitalOcean record ID. If there are several
+// records, the one already holding the content is kept, or else the
+// first, and the others are deleted.
+func (s *DigitalOcean) CreateOrUpdateDNSRecordResult(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) (api.Record, error) {
+	if err := checkProxy(api.DigitalOceanProvider, proxy); err != nil {
+		return api.Record{}, err
+	}
+	if err := checkRecord(name, rtype, digitalOceanRecordTypes); err != nil {
+		return api.Record{}, err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return api.Record{}, err
+	}
+	ttl, err := s.ttlPolicy.normalize(api.DigitalOceanProvider, ttl, digitalOceanTTLs)
+	if err != nil {
+		return api.Record{}, err
+	}
+	content, priority, weight, port, err := doData(rtype, content)
+	if err != nil {
+		return api.Record{}, err
+	}
+	dorecords, err := s.listRecords(ctx, zone)
+	if err != nil {
+		return api.Record{}, err
+	}
+	relName := relativeName(name, zone)
+	rtype = strings.ToUpper(rtype)
+	editRecord := godo.DomainRecordEditRequest{
+		Type:     rtype,
+		Name:     relName,
+		Data:     content,
+		TTL:      ttl,
+		Priority: priority,
+		Weight:   weight,
+		Port:     port,
+	}
+
+	existing := []godo.DomainRecord{}
+	keep := -1
+	for _, r := range dorecords {
+		if r.Type != rtype || !strings.EqualFold(r.Name, relName) {
+			continue
+		}
+		if keep < 0 && doContent(r) == content {
+			keep = len(existing)
+		}
+		existing = append(existing, r)
+	}
+	if len(existing) == 0 {
+		created, _, err := s.api.Domains.CreateRecord(ctx, zone, &editRecord)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+			return api.Record{}, fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+		}
+		return doRecordToRecord(*created, zone), nil
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	stored := &existing[keep]
+	if doContent(*stored) == content && stored.TTL == ttl && stored.Priority == priority && stored.Weight == weight && stored.Port == port {
+		s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", content)
+	} else {
+		s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+		stored, _, err = s.api.Domains.EditRecord(ctx, zone, stored.ID, &editRecord)
+		if err != nil {
+			return api.Record{}, fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+		}
+	}
+	for ii, other := range existing {
+		if ii == keep {
+			continue
+		}
+		resp, err := s.api.Domains.DeleteRecord(ctx, zone, other.ID)
+		if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			return api.Record{}, fmt.Errorf("delete DNS record %v failed, %v", other, err)
+		}
+	}
+	return doRecordToRecord(*stored, zone), nil
+}
+
+// doContent returns the data of the record as passed to
+// CreateOrUpdateDNSRecord, after doData.
+func doContent(r godo.DomainRecord) string {
+	if r.Type == api.RecordTypeTXT {
+		return parseTXTRRData(r.Data)
+	} else if hasHostTarget(r.Type) {
+		return fqdnTarget(r.Data)
+	}
+	return r.Data
+}
+
+// doData splits content as passed to CreateOrUpdateDNSRecord into the
+// data, priority, weight and port of a DigitalOcean record.
+func doData(rtype, content string) (string, int, int, int, error) {
+	priority, weight, port := 0, 0, 0
+	var err error
+	rtype = strings.ToUpper(rtype)
+	switch rtype {
+	case api.RecordTypeMX:
+		priority, content, err = parseMXContent(content)
+		if err != nil {
+			return "", 0, 0, 0, err
+		}
+	case api.RecordTypeSRV:
+		priority, weight, port, content, err = parseSRVContent(content)
+		if err != nil {
+			return "", 0, 0, 0, err
+		}
+	case api.RecordTypeTXT:
+		content = txtValue(content)
+	}
+	if hasHostTarget(rtype) {
+		// DigitalOcean requires fully qualified host names
+		content = fqdnTarget(content)
+	}
+	return content, priority, weight, port, nil
+}
+
+// recordByID returns the record of the zone with the id.
+func (s *DigitalOcean) recordByID(ctx context.Context, zone, id string) (godo.DomainRecord, error) {
+	dorecords, err := s.listRecords(ctx, zone)
+	if err != nil {
+		return godo.DomainRecord{}, err
+	}
+	for _, rec := range dorecords {
+		if strconv.Itoa(rec.ID) == id {
+			return rec, nil
+		}
+	}
+	return godo.DomainRecord{}, fmt.Errorf("%w: zone %s record id %s", api.ErrRecordNotFound, zone, id)
+}
+
+// UpdateDNSRecordByID sets the content and TTL of the record with the
+// id, keeping its name and type.
+func (s *DigitalOcean) UpdateDNSRecordByID(ctx context.Context, zone, id, content string, ttl int) error {
+	rec, err := s.recordByID(ctx, zone, id)
+	if err != nil {
+		return err
+	}
+	if err := validateContent(absoluteName(rec.Name, zone), rec.Type, ttl, content); err != nil {
+		return err
+	}
+	ttl, err = s.ttlPolicy.normalize(api.DigitalOceanProvider, ttl, digitalOceanTTLs)
+	if err != nil {
+		return err
+	}
+	data, priority, weight, port, err := doData(rec.Type, content)
+	if err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "UpdateDNSRecordByID updating", "zone", zone, "id", id, "content", content)
+	_, _, err = s.api.Domains.EditRecord(ctx, zone, rec.ID, &godo.DomainRecordEditRequest{
+		Type:     rec.Type,
+		Name:     rec.Name,
+		Data:     data,
+		TTL:      ttl,
+		Priority: priority,
+		Weight:   weight,
+		Port:     port,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot update DNS record %s for zone %s, %v", id, zone, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecordByID deletes the record with the id.
+func (s *DigitalOcean) DeleteDNSRecordByID(ctx context.Context, zone, id string) error {
+	rec, err := s.recordByID(ctx, zone, id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.api.Domains.DeleteRecord(ctx, zone, rec.ID); err != nil {
+		return fmt.Errorf("delete DNS record %s for zone %s failed, %v", id, zone, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *DigitalOcean) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *DigitalOcean) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *DigitalOcean) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+func (s *DigitalOcean) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	dorecords, err := s.listRecords(ctx, zone)
+	if err != nil {
+		return 0, err
+	}
+	relName := relativeName(name, zone)
+	deleted := 0
+	for _, rec := range dorecords {
+		if !strings.EqualFold(rec.Name, relName) {
+			continue
+		}
+		if rtype != "" && !strings.EqualFold(rec.Type, rtype) {
+			continue
+		}
+		resp, err := s.api.Domains.DeleteRecord(ctx, zone, rec.ID)
+		if err != nil {
+			if resp == nil || resp.StatusCode != http.StatusNotFound {
+				return deleted, fmt.Errorf("delete DNS record %v failed, %v", rec, err)
+			}
+			// already deleted by someone else
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
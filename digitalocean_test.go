@@ -0,0 +1,189 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigitalOceanDNS(t *testing.T) {
+	// skip unless needed to debug
+	t.Skip("skipping digitalocean DNS test")
+
+	ctx := context.Background()
+
+	token := os.Getenv("DOTOKEN")
+	domain := os.Getenv("DOMAIN")
+	require.NotEmpty(t, token, "DOTOKEN env var must be set")
+	require.NotEmpty(t, domain, "DOMAIN env var must be set")
+
+	prov, err := GetProvider(ctx, api.DigitalOceanProvider, "", map[string]string{"token": token}, nil)
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, domain)
+}
+
+// doStub is a minimal in-memory implementation of the DigitalOcean
+// domain records API.
+type doStub struct {
+	mu      sync.Mutex
+	zone    string
+	records map[int]godo.DomainRecord
+	nextID  int
+	// gone are records still listed but already deleted by another
+	// writer, so deleting them fails with 404
+	gone map[int]bool
+}
+
+func newDOStub() *doStub {
+	return &doStub{
+		zone:    "example.com",
+		records: map[int]godo.DomainRecord{},
+		nextID:  1,
+		gone:    map[int]bool{},
+	}
+}
+
+func (s *doStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/domains/{zone}/records", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		list := []godo.DomainRecord{}
+		for id := 1; id < s.nextID; id++ {
+			if rec, ok := s.records[id]; ok {
+				list = append(list, rec)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"domain_records": list,
+			"meta":           map[string]int{"total": len(list)},
+		})
+	})
+	mux.HandleFunc("POST /v2/domains/{zone}/records", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		req := godo.DomainRecordEditRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+		rec := godo.DomainRecord{
+			ID:   s.nextID,
+			Type: req.Type,
+			Name: req.Name,
+			Data: req.Data,
+			TTL:  req.TTL,
+		}
+		s.records[rec.ID] = rec
+		s.nextID++
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"domain_record": rec})
+	})
+	mux.HandleFunc("PUT /v2/domains/{zone}/records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		rec, ok := s.records[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		req := godo.DomainRecordEditRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+		rec.Data = req.Data
+		rec.TTL = req.TTL
+		s.records[id] = rec
+		json.NewEncoder(w).Encode(map[string]interface{}{"domain_record": rec})
+	})
+	mux.HandleFunc("DELETE /v2/domains/{zone}/records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		if _, ok := s.records[id]; !ok || s.gone[id] {
+			delete(s.records, id)
+			http.Error(w, `{"id":"not_found","message":"The resource you were accessing could not be found."}`, http.StatusNotFound)
+			return
+		}
+		delete(s.records, id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := "/v2/domains/"
+		if strings.HasPrefix(r.URL.Path, prefix) && !strings.HasPrefix(r.URL.Path, prefix+s.zone+"/") {
+			http.Error(w, `{"id":"not_found","message":"The resource you were accessing could not be found."}`, http.StatusNotFound)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func TestDigitalOceanStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newDOStub()
+	client := newStubClient(t, stub.handler())
+
+	prov, err := GetProvider(ctx, api.DigitalOceanProvider, "", map[string]string{"token": "test"}, nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	recordIDTest(t, ctx, prov.(api.RecordIDManager), "example.com", "id1.example.com", "id2.example.com")
+	duplicateRecordsTest(t, ctx, prov, "example.com", "dup.example.com", func(content string) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.records[stub.nextID] = godo.DomainRecord{ID: stub.nextID, Type: "A", Name: "dup", Data: content, TTL: 300}
+		stub.nextID++
+	})
+
+	// a duplicate already deleted by another writer is not an error
+	stub.mu.Lock()
+	for _, content := range []string{"10.0.0.7", "10.0.0.8"} {
+		stub.records[stub.nextID] = godo.DomainRecord{ID: stub.nextID, Type: "A", Name: "gone", Data: content, TTL: 300}
+		stub.nextID++
+	}
+	stub.gone[stub.nextID-1] = true
+	stub.mu.Unlock()
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "gone.example.com", "A", "10.0.0.7", 300, false)
+	require.Nil(t, err)
+	kept, err := prov.GetDNSRecords(ctx, "example.com", "gone.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(kept))
+	require.Equal(t, []string{"10.0.0.7"}, kept[0].Content)
+	err = prov.DeleteDNSRecord(ctx, "example.com", "gone.example.com")
+	require.Nil(t, err)
+
+	// names are stored relative to the zone
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "A", "10.0.0.2", 300, false)
+	require.Nil(t, err)
+	stored := map[string]string{}
+	for _, rec := range stub.records {
+		stored[rec.Name] = rec.Data
+	}
+	require.Equal(t, map[string]string{"www": "10.0.0.1", "@": "10.0.0.2"}, stored)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "example.com", records[0].Name)
+}
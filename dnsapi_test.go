@@ -16,6 +16,11 @@ package dnsproviders
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/edgexr/dnsproviders/api"
@@ -50,6 +55,16 @@ func ProviderTest(t *testing.T, ctx context.Context, prov api.Provider, domain s
 	require.Equal(t, 1, len(records[0].Content))
 	require.Equal(t, ip, records[0].Content[0])
 
+	// deleting by type leaves records of other types in place
+	err = prov.CreateOrUpdateDNSRecord(ctx, domain, testEntry, "TXT", "unittest", 3000, false)
+	require.Nil(t, err)
+	err = prov.DeleteDNSRecordByType(ctx, domain, testEntry, "TXT")
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, domain, testEntry)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "A", records[0].Type)
+
 	// delete the entry
 	err = prov.DeleteDNSRecord(ctx, domain, testEntry)
 	require.Nil(t, err)
@@ -58,4 +73,245 @@ func ProviderTest(t *testing.T, ctx context.Context, prov api.Provider, domain s
 	records, err = prov.GetDNSRecords(ctx, domain, testEntry)
 	require.Nil(t, err)
 	require.Equal(t, 0, len(records))
+
+	counter, ok := prov.(api.DeleteCounter)
+	if !ok {
+		return
+	}
+	// a single record is counted once
+	err = prov.CreateOrUpdateDNSRecord(ctx, domain, testEntry, "A", ip, 3000, false)
+	require.Nil(t, err)
+	count, err := counter.DeleteDNSRecordCount(ctx, domain, testEntry)
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+
+	// every value of every type for the name is counted
+	expected := 2
+	err = prov.CreateOrUpdateDNSRecord(ctx, domain, testEntry, "TXT", "unittest", 3000, false)
+	require.Nil(t, err)
+	if setter, ok := prov.(api.RecordSetUpdater); ok {
+		err = setter.CreateOrUpdateDNSRecordSet(ctx, domain, testEntry, "A", []string{"10.0.0.1", "10.0.0.2"}, 3000, false)
+		expected = 3
+	} else {
+		err = prov.CreateOrUpdateDNSRecord(ctx, domain, testEntry, "A", ip, 3000, false)
+	}
+	require.Nil(t, err)
+	count, err = counter.DeleteDNSRecordCount(ctx, domain, testEntry)
+	require.Nil(t, err)
+	require.Equal(t, expected, count)
+	records, err = prov.GetDNSRecords(ctx, domain, testEntry)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(records))
+}
+
+// zoneNotFoundTest checks that operations on a zone the provider does
+// not have fail with api.ErrZoneNotFound.
+func zoneNotFoundTest(t *testing.T, ctx context.Context, prov api.Provider) {
+	_, err := prov.GetDNSRecords(ctx, "missing.com", "")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "missing.com", "www.missing.com", "A", "10.0.0.1", 300, false)
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+}
+
+// wildcardTest creates, reads back and deletes a wildcard A record
+// next to a www record in the domain. The name is the form of
+// *.domain under test.
+func wildcardTest(t *testing.T, ctx context.Context, prov api.Provider, zone, domain, name string) {
+	err := prov.CreateOrUpdateDNSRecord(ctx, zone, name, "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, zone, "www."+domain, "A", "10.0.0.2", 300, false)
+	require.Nil(t, err)
+
+	records, err := prov.GetDNSRecords(ctx, zone, name)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "*", relativeName(records[0].Name, domain))
+	require.Equal(t, []string{"10.0.0.1"}, records[0].Content)
+
+	// updating the wildcard leaves the other record alone
+	err = prov.CreateOrUpdateDNSRecord(ctx, zone, name, "A", "10.0.0.3", 300, false)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, zone, name)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, []string{"10.0.0.3"}, records[0].Content)
+	records, err = prov.GetDNSRecords(ctx, zone, "www."+domain)
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.2"}, records[0].Content)
+
+	err = prov.DeleteDNSRecord(ctx, zone, name)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, zone, name)
+	require.Nil(t, err)
+	require.Empty(t, records)
+	records, err = prov.GetDNSRecords(ctx, zone, "www."+domain)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+}
+
+// recordSetTest checks that CreateOrUpdateDNSRecordSet leaves exactly
+// the given values, with none left over from earlier sets.
+func recordSetTest(t *testing.T, ctx context.Context, prov api.RecordSetUpdater, zone, name string) {
+	reader := prov.(api.Provider)
+	values := func() []string {
+		records, err := reader.GetDNSRecords(ctx, zone, name)
+		require.Nil(t, err)
+		values := []string{}
+		for _, record := range records {
+			require.Equal(t, "A", record.Type)
+			values = append(values, record.Content...)
+		}
+		slices.Sort(values)
+		return values
+	}
+
+	err := prov.CreateOrUpdateDNSRecordSet(ctx, zone, name, "A", []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, 300, false)
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, values())
+
+	// values no longer in the set are removed
+	err = prov.CreateOrUpdateDNSRecordSet(ctx, zone, name, "A", []string{"10.0.0.4", "10.0.0.3"}, 300, false)
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.3", "10.0.0.4"}, values())
+
+	// as they are by a single value update
+	err = reader.CreateOrUpdateDNSRecord(ctx, zone, name, "A", "10.0.0.5", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.5"}, values())
+
+	err = prov.CreateOrUpdateDNSRecordSet(ctx, zone, name, "A", nil, 300, false)
+	require.NotNil(t, err)
+
+	err = reader.DeleteDNSRecord(ctx, zone, name)
+	require.Nil(t, err)
+	require.Empty(t, values())
+}
+
+// recordIDTest changes and deletes records by the IDs read back from
+// GetDNSRecords, checking that other records are left alone.
+func recordIDTest(t *testing.T, ctx context.Context, prov api.RecordIDManager, zone, name1, name2 string) {
+	reader := prov.(api.Provider)
+	record := func(name string) api.Record {
+		records, err := reader.GetDNSRecords(ctx, zone, name)
+		require.Nil(t, err)
+		require.Equal(t, 1, len(records))
+		require.NotEmpty(t, records[0].ID)
+		return records[0]
+	}
+
+	err := reader.CreateOrUpdateDNSRecord(ctx, zone, name1, "A", "10.0.0.1", 3600, false)
+	require.Nil(t, err)
+	err = reader.CreateOrUpdateDNSRecord(ctx, zone, name2, "A", "10.0.0.2", 3600, false)
+	require.Nil(t, err)
+	record1, record2 := record(name1), record(name2)
+	require.NotEqual(t, record1.ID, record2.ID)
+
+	// the name and type are kept
+	err = prov.UpdateDNSRecordByID(ctx, zone, record1.ID, "10.0.0.3", 3600)
+	require.Nil(t, err)
+	record1.Content = []string{"10.0.0.3"}
+	require.Equal(t, record1, record(name1))
+	require.Equal(t, record2, record(name2))
+	err = prov.UpdateDNSRecordByID(ctx, zone, record1.ID, "not-an-address", 3600)
+	require.NotNil(t, err)
+
+	err = prov.DeleteDNSRecordByID(ctx, zone, record2.ID)
+	require.Nil(t, err)
+	records, err := reader.GetDNSRecords(ctx, zone, name2)
+	require.Nil(t, err)
+	require.Empty(t, records)
+	require.Equal(t, record1, record(name1))
+
+	err = prov.UpdateDNSRecordByID(ctx, zone, record2.ID, "10.0.0.4", 3600)
+	require.ErrorIs(t, err, api.ErrRecordNotFound)
+	err = prov.DeleteDNSRecordByID(ctx, zone, record2.ID)
+	require.ErrorIs(t, err, api.ErrRecordNotFound)
+
+	err = prov.DeleteDNSRecordByID(ctx, zone, record1.ID)
+	require.Nil(t, err)
+}
+
+// duplicateRecordsTest checks that CreateOrUpdateDNSRecord leaves a
+// single record of the name and type when several exist. add stores an
+// A record in the backend directly, as another client might.
+func duplicateRecordsTest(t *testing.T, ctx context.Context, prov api.Provider, zone, name string, add func(content string)) {
+	check := func(content string) {
+		result, err := CreateOrUpdateDNSRecordResult(ctx, prov, zone, name, "A", content, 3600, false)
+		require.Nil(t, err)
+		records, err := prov.GetDNSRecords(ctx, zone, name)
+		require.Nil(t, err)
+		require.Equal(t, 1, len(records))
+		require.Equal(t, []string{content}, records[0].Content)
+		require.Equal(t, records[0].ID, result.ID)
+	}
+
+	// the record already holding the content is kept
+	add("10.0.0.1")
+	add("10.0.0.2")
+	add("10.0.0.3")
+	check("10.0.0.2")
+
+	// otherwise one of them is changed
+	add("10.0.0.4")
+	check("10.0.0.5")
+
+	err := prov.DeleteDNSRecord(ctx, zone, name)
+	require.Nil(t, err)
+}
+
+// delegationTest delegates a subname with an NS record set, and checks
+// that the NS records at the zone apex cannot be set.
+func delegationTest(t *testing.T, ctx context.Context, prov api.RecordSetUpdater, zone, name string) {
+	reader := prov.(api.Provider)
+	values := func() []string {
+		records, err := reader.GetDNSRecords(ctx, zone, name)
+		require.Nil(t, err)
+		values := []string{}
+		for _, record := range records {
+			require.Equal(t, "NS", record.Type)
+			values = append(values, record.Content...)
+		}
+		slices.Sort(values)
+		return values
+	}
+
+	err := prov.CreateOrUpdateDNSRecordSet(ctx, zone, name, api.RecordTypeNS, []string{"ns1.example.net", "ns2.example.net."}, 3600, false)
+	require.Nil(t, err)
+	require.Equal(t, []string{"ns1.example.net.", "ns2.example.net."}, values())
+
+	err = prov.CreateOrUpdateDNSRecordSet(ctx, zone, name, api.RecordTypeNS, []string{"ns3.example.net"}, 3600, false)
+	require.Nil(t, err)
+	require.Equal(t, []string{"ns3.example.net."}, values())
+
+	err = prov.CreateOrUpdateDNSRecordSet(ctx, zone, zone, api.RecordTypeNS, []string{"ns1.example.net"}, 3600, false)
+	require.ErrorIs(t, err, api.ErrUnsupported)
+	err = reader.CreateOrUpdateDNSRecord(ctx, zone, strings.TrimSuffix(zone, ".")+".", "ns", "ns1.example.net", 3600, false)
+	require.ErrorIs(t, err, api.ErrUnsupported)
+
+	err = reader.DeleteDNSRecordByType(ctx, zone, name, api.RecordTypeNS)
+	require.Nil(t, err)
+	require.Empty(t, values())
+}
+
+// newStubClient returns an http.Client that sends every request to
+// the given handler, regardless of the host the provider targets.
+func newStubClient(t *testing.T, handler http.Handler) *http.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	require.Nil(t, err)
+	return &http.Client{
+		Transport: redirectTransport{host: u.Host},
+	}
+}
+
+type redirectTransport struct {
+	host string
+}
+
+func (s redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "http"
+	req.URL.Host = s.host
+	return http.DefaultTransport.RoundTrip(req)
 }
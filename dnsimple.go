@@ -0,0 +1,382 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/dnsimple/dnsimple-go/dnsimple"
+	"github.com/edgexr/dnsproviders/api"
+	"golang.org/x/oauth2"
+)
+
+const CredentialKeyAccountID = "accountID"
+
+// dnsimplePerPage is the largest page size of DNSimple lists
+const dnsimplePerPage = 100
+
+// DNSimple manages DNS records via the DNSimple API. DNSimple stores
+// record names relative to the zone, with an empty name for the apex,
+// and keeps the MX and SRV priority in its own field. Besides the
+// standard types it supports ALIAS records, which resolve a host name
+// at the apex like a CNAME.
+type DNSimple struct {
+	api       *dnsimple.Client
+	accountID string
+	logger    api.Logger
+	zone      string // zone the provider was configured for, if any
+}
+
+// NewDNSimpleProvider creates a new DNSimple provider.
+func NewDNSimpleProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*DNSimple, error) {
+	return NewDNSimpleProviderWithCredentials(ctx, zone, dnsimpleCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewDNSimpleProviderWithCredentials creates a new DNSimple provider
+// from typed credentials.
+func NewDNSimpleProviderWithCredentials(ctx context.Context, zone string, creds DNSimpleCredentials, logger api.Logger, ops ...Option) (*DNSimple, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	opts := getOptions(ops)
+	// oauth2 uses the client in the context as its base transport
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, opts.httpClient())
+	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: creds.Token})
+	client := dnsimple.NewClient(oauth2.NewClient(ctx, tokenSource))
+	if creds.APIURL != "" {
+		client.BaseURL = strings.TrimSuffix(creds.APIURL, "/")
+	}
+	return &DNSimple{
+		api:       client,
+		accountID: creds.AccountID,
+		logger:    logger,
+		zone:      zone,
+	}, nil
+}
+
+func isDNSimpleStatus(err error, code int) bool {
+	var derr *dnsimple.ErrorResponse
+	return errors.As(err, &derr) && derr.HTTPResponse != nil && derr.HTTPResponse.StatusCode == code
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *DNSimple) Close() error {
+	return nil
+}
+
+// ValidateCredentials reads the account the token belongs to.
+func (s *DNSimple) ValidateCredentials(ctx context.Context) error {
+	_, err := s.api.Identity.Whoami(ctx)
+	return credentialsError(err)
+}
+
+// ListZones returns the zones of the account. DNSimple identifies
+// zones by name.
+func (s *DNSimple) ListZones(ctx context.Context) ([]api.Zone, error) {
+	zones := []api.Zone{}
+	opts := &dnsimple.ZoneListOptions{}
+	opts.PerPage = dnsimple.Int(dnsimplePerPage)
+	for page := 1; ; page++ {
+		opts.Page = dnsimple.Int(page)
+		resp, err := s.api.Zones.ListZones(ctx, s.accountID, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, z := range resp.Data {
+			zones = append(zones, newZone(z.Name, ""))
+		}
+		if resp.Pagination == nil || page >= resp.Pagination.TotalPages {
+			break
+		}
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone adds the domain to the account, which creates its zone.
+func (s *DNSimple) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	resp, err := s.api.Domains.CreateDomain(ctx, s.accountID, dnsimple.Domain{Name: zone})
+	if err != nil {
+		return api.Zone{}, fmt.Errorf("cannot create zone %s, %v", zone, err)
+	}
+	return newZone(resp.Data.Name, ""), nil
+}
+
+// DeleteZone removes the domain from the account, which deletes its
+// zone and all its records.
+func (s *DNSimple) DeleteZone(ctx context.Context, zone string) error {
+	zone = strings.TrimSuffix(zone, ".")
+	_, err := s.api.Domains.DeleteDomain(ctx, s.accountID, zone)
+	if isDNSimpleStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete zone %s, %v", zone, err)
+	}
+	return nil
+}
+
+// dnsimpleName returns the name relative to the zone as DNSimple
+// stores it, with an empty name for the apex.
+func dnsimpleName(name, zone string) string {
+	relName := relativeName(name, zone)
+	if relName == apexName {
+		return ""
+	}
+	return relName
+}
+
+// listRecords returns the records of the zone, or only those of the
+// name and type if given. The apex cannot be filtered for by name, so
+// names are also matched here.
+func (s *DNSimple) listRecords(ctx context.Context, zone, name, rtype string) ([]dnsimple.ZoneRecord, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	relName := dnsimpleName(name, zone)
+	opts := &dnsimple.ZoneRecordListOptions{}
+	if relName != "" {
+		opts.Name = dnsimple.String(relName)
+	}
+	if rtype != "" {
+		opts.Type = dnsimple.String(strings.ToUpper(rtype))
+	}
+	opts.PerPage = dnsimple.Int(dnsimplePerPage)
+	records := []dnsimple.ZoneRecord{}
+	for page := 1; ; page++ {
+		opts.Page = dnsimple.Int(page)
+		resp, err := s.api.Zones.ListRecords(ctx, s.accountID, zone, opts)
+		if isDNSimpleStatus(err, http.StatusNotFound) {
+			return nil, fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range resp.Data {
+			if name != "" && !strings.EqualFold(rec.Name, relName) {
+				continue
+			}
+			records = append(records, rec)
+		}
+		if resp.Pagination == nil || page >= resp.Pagination.TotalPages {
+			return records, nil
+		}
+	}
+}
+
+func dnsimpleRecordToRecord(drec dnsimple.ZoneRecord, zone string) api.Record {
+	recName := absoluteName(drec.Name, zone)
+	record := api.Record{
+		Type:    drec.Type,
+		Name:    recName,
+		Content: []string{drec.Content},
+		TTL:     drec.TTL,
+		System:  drec.SystemRecord || isSystemRecord(zone, recName, drec.Type),
+	}
+	switch drec.Type {
+	case api.RecordTypeMX:
+		record.Priority = drec.Priority
+	case api.RecordTypeSRV:
+		// the content of SRV records is "weight port target"
+		record.Priority = drec.Priority
+		if values, target, err := parseUint16Fields(api.RecordTypeSRV, drec.Content, "weight port target", 2); err == nil {
+			record.Weight = values[0]
+			record.Port = values[1]
+			record.Content = []string{target}
+		}
+	case api.RecordTypeTXT:
+		record.Content = []string{parseTXTRRData(drec.Content)}
+	}
+	return canonicalTargets(withUnicodeName(record))
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *DNSimple) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	drecords, err := s.listRecords(ctx, zone, name, "")
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, drec := range drecords {
+		records = append(records, dnsimpleRecordToRecord(drec, zone))
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *DNSimple) SupportedRecordTypes() []string {
+	return slices.Clone(dnsimpleRecordTypes)
+}
+
+// Capabilities reports that DNSimple can manage zones.
+func (s *DNSimple) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// dnsimpleContent splits content as passed to CreateOrUpdateDNSRecord
+// into the content and priority fields of a DNSimple record. Host
+// name targets are stored without the trailing dot.
+func dnsimpleContent(rtype, content string) (string, int, error) {
+	switch rtype {
+	case api.RecordTypeMX:
+		priority, target, err := parseMXContent(content)
+		if err != nil {
+			return "", 0, err
+		}
+		return strings.TrimSuffix(target, "."), priority, nil
+	case api.RecordTypeSRV:
+		priority, weight, port, target, err := parseSRVContent(content)
+		if err != nil {
+			return "", 0, err
+		}
+		return fmt.Sprintf("%d %d %s", weight, port, strings.TrimSuffix(target, ".")), priority, nil
+	case api.RecordTypeTXT:
+		return txtValue(content), 0, nil
+	}
+	if hasHostTarget(rtype) {
+		return strings.TrimSuffix(content, "."), 0, nil
+	}
+	return content, 0, nil
+}
+
+// CreateOrUpdateDNSRecord changes the existing record of the name and
+// type if found, or adds a new one. If there are several records, the
+// one already holding the content is kept, or else the first, and the
+// others are deleted, except for system records.
+func (s *DNSimple) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.DNSimpleProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, dnsimpleRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	rtype = strings.ToUpper(rtype)
+	data, priority, err := dnsimpleContent(rtype, content)
+	if err != nil {
+		return err
+	}
+	drecords, err := s.listRecords(ctx, zone, name, rtype)
+	if err != nil {
+		return err
+	}
+	zone = strings.TrimSuffix(zone, ".")
+	attrs := dnsimple.ZoneRecordAttributes{
+		Name:     dnsimple.String(dnsimpleName(name, zone)),
+		Type:     rtype,
+		Content:  data,
+		TTL:      ttl,
+		Priority: priority,
+	}
+
+	if len(drecords) == 0 {
+		if _, err := s.api.Zones.CreateRecord(ctx, s.accountID, zone, attrs); err != nil {
+			s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+			return fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+		}
+		return nil
+	}
+	keep := -1
+	for ii, r := range drecords {
+		if keep < 0 && r.Content == data {
+			keep = ii
+		}
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	r := drecords[keep]
+	if r.Content == data && r.TTL == ttl && r.Priority == priority {
+		s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", content)
+	} else {
+		s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+		if _, err := s.api.Zones.UpdateRecord(ctx, s.accountID, zone, r.ID, attrs); err != nil {
+			return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+		}
+	}
+	for ii, other := range drecords {
+		if ii == keep || other.SystemRecord {
+			continue
+		}
+		_, err := s.api.Zones.DeleteRecord(ctx, s.accountID, zone, other.ID)
+		if err != nil && !isDNSimpleStatus(err, http.StatusNotFound) {
+			return fmt.Errorf("delete DNS record %d failed, %v", other.ID, err)
+		}
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *DNSimple) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *DNSimple) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *DNSimple) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+// deleteRecords deletes the records of the name, and of the type if
+// given. System records, such as the apex NS records, are left alone
+// since DNSimple does not allow deleting them.
+func (s *DNSimple) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	drecords, err := s.listRecords(ctx, zone, name, rtype)
+	if err != nil {
+		return 0, err
+	}
+	zone = strings.TrimSuffix(zone, ".")
+	deleted := 0
+	for _, rec := range drecords {
+		if rec.SystemRecord {
+			continue
+		}
+		_, err := s.api.Zones.DeleteRecord(ctx, s.accountID, zone, rec.ID)
+		if isDNSimpleStatus(err, http.StatusNotFound) {
+			// already deleted by someone else
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("delete DNS record %d failed, %v", rec.ID, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
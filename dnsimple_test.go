@@ -0,0 +1,252 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dnsimple/dnsimple-go/dnsimple"
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// dnsimpleStub is a minimal in-memory implementation of the DNSimple
+// zone records API for account 1234.
+type dnsimpleStub struct {
+	mu     sync.Mutex
+	zones  map[string][]dnsimple.ZoneRecord
+	nextID int64
+}
+
+func newDNSimpleStub(zones ...string) *dnsimpleStub {
+	s := &dnsimpleStub{zones: map[string][]dnsimple.ZoneRecord{}}
+	for _, zone := range zones {
+		s.nextID++
+		s.zones[zone] = []dnsimple.ZoneRecord{{
+			ID:           s.nextID,
+			ZoneID:       zone,
+			Type:         "NS",
+			Content:      "ns1.dnsimple.com",
+			TTL:          3600,
+			SystemRecord: true,
+		}}
+	}
+	return s
+}
+
+func (s *dnsimpleStub) error(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+func (s *dnsimpleStub) find(zone, id string) int {
+	for ii, rec := range s.zones[zone] {
+		if strconv.FormatInt(rec.ID, 10) == id {
+			return ii
+		}
+	}
+	return -1
+}
+
+func (s *dnsimpleStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/whoami", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"user": null, "account": {"id": 1234}}}`))
+	})
+	mux.HandleFunc("GET /v2/1234/zones", func(w http.ResponseWriter, r *http.Request) {
+		zones := []dnsimple.Zone{}
+		for name := range s.zones {
+			zones = append(zones, dnsimple.Zone{Name: name})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":       zones,
+			"pagination": dnsimple.Pagination{CurrentPage: 1, TotalPages: 1},
+		})
+	})
+	mux.HandleFunc("GET /v2/1234/zones/{zone}/records", func(w http.ResponseWriter, r *http.Request) {
+		records, ok := s.zones[r.PathValue("zone")]
+		if !ok {
+			s.error(w, http.StatusNotFound, "Zone `"+r.PathValue("zone")+"` not found")
+			return
+		}
+		query := r.URL.Query()
+		matched := []dnsimple.ZoneRecord{}
+		for _, rec := range records {
+			if query.Has("name") && rec.Name != query.Get("name") {
+				continue
+			}
+			if query.Has("type") && rec.Type != query.Get("type") {
+				continue
+			}
+			matched = append(matched, rec)
+		}
+		// one record per page, to test paging
+		page, _ := strconv.Atoi(query.Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		data := []dnsimple.ZoneRecord{}
+		if page <= len(matched) {
+			data = matched[page-1 : page]
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":       data,
+			"pagination": dnsimple.Pagination{CurrentPage: page, TotalPages: len(matched), TotalEntries: len(matched)},
+		})
+	})
+	mux.HandleFunc("POST /v2/1234/zones/{zone}/records", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		if _, ok := s.zones[zone]; !ok {
+			s.error(w, http.StatusNotFound, "Zone `"+zone+"` not found")
+			return
+		}
+		attrs := dnsimple.ZoneRecordAttributes{}
+		json.NewDecoder(r.Body).Decode(&attrs)
+		s.nextID++
+		rec := dnsimple.ZoneRecord{
+			ID:       s.nextID,
+			ZoneID:   zone,
+			Type:     attrs.Type,
+			Name:     *attrs.Name,
+			Content:  attrs.Content,
+			TTL:      attrs.TTL,
+			Priority: attrs.Priority,
+		}
+		s.zones[zone] = append(s.zones[zone], rec)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": rec})
+	})
+	mux.HandleFunc("PATCH /v2/1234/zones/{zone}/records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		ii := s.find(zone, r.PathValue("id"))
+		if ii < 0 {
+			s.error(w, http.StatusNotFound, "Record not found")
+			return
+		}
+		attrs := dnsimple.ZoneRecordAttributes{}
+		json.NewDecoder(r.Body).Decode(&attrs)
+		rec := &s.zones[zone][ii]
+		rec.Content = attrs.Content
+		rec.TTL = attrs.TTL
+		rec.Priority = attrs.Priority
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": rec})
+	})
+	mux.HandleFunc("DELETE /v2/1234/zones/{zone}/records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		ii := s.find(zone, r.PathValue("id"))
+		if ii < 0 {
+			s.error(w, http.StatusNotFound, "Record not found")
+			return
+		}
+		s.zones[zone] = append(s.zones[zone][:ii], s.zones[zone][ii+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer test" {
+			s.error(w, http.StatusUnauthorized, "Authentication failed")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func TestDNSimpleStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newDNSimpleStub("example.com")
+	client := newStubClient(t, stub.handler())
+	prov, err := GetProvider(ctx, api.DNSimpleProvider, "", DNSimpleCredentials{Token: "test", AccountID: "1234"}.ToMap(), nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com")
+	duplicateRecordsTest(t, ctx, prov, "example.com", "dup.example.com", func(content string) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.nextID++
+		stub.zones["example.com"] = append(stub.zones["example.com"], dnsimple.ZoneRecord{ID: stub.nextID, ZoneID: "example.com", Type: "A", Name: "dup", Content: content, TTL: 300})
+	})
+
+	// ALIAS records are allowed at the apex, which has an empty name
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", api.RecordTypeALIAS, "lb.example.net.", 300, false)
+	require.Nil(t, err)
+	records := stub.zones["example.com"]
+	require.Equal(t, "", records[len(records)-1].Name)
+	require.Equal(t, "ALIAS", records[len(records)-1].Type)
+	require.Equal(t, "lb.example.net", records[len(records)-1].Content)
+
+	// the apex NS record is a system record, which is never deleted
+	got, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:    "example.com",
+		Type:    "NS",
+		Content: []string{"ns1.dnsimple.com."},
+		TTL:     3600,
+		System:  true,
+	}, {
+		Name:    "example.com",
+		Type:    "ALIAS",
+		Content: []string{"lb.example.net."},
+		TTL:     300,
+	}}, got)
+	count, err := prov.(api.DeleteCounter).DeleteDNSRecordCount(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+	require.Equal(t, "NS", stub.zones["example.com"][0].Type)
+
+	// priorities have their own field
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "_sip._tcp.example.com", "SRV", "10 20 5060 sip.example.com", 300, false)
+	require.Nil(t, err)
+	records = stub.zones["example.com"]
+	require.Equal(t, "20 5060 sip.example.com", records[len(records)-1].Content)
+	require.Equal(t, 10, records[len(records)-1].Priority)
+	got, err = prov.GetDNSRecords(ctx, "example.com", "_sip._tcp.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:     "_sip._tcp.example.com",
+		Type:     "SRV",
+		Content:  []string{"sip.example.com."},
+		TTL:      300,
+		Priority: 10,
+		Weight:   20,
+		Port:     5060,
+	}}, got)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "example.com"}}, zones)
+}
+
+func TestDNSimpleCredentials(t *testing.T) {
+	ctx := context.Background()
+	client := newStubClient(t, newDNSimpleStub("example.com").handler())
+
+	err := ValidateCredentials(ctx, api.DNSimpleProvider, DNSimpleCredentials{Token: "test", AccountID: "1234"}.ToMap(), WithHTTPClient(client))
+	require.Nil(t, err)
+	err = ValidateCredentials(ctx, api.DNSimpleProvider, DNSimpleCredentials{Token: "wrong", AccountID: "1234"}.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+
+	err = DNSimpleCredentials{Token: "test"}.Validate()
+	require.True(t, strings.Contains(err.Error(), "missing accountID key"))
+}
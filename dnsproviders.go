@@ -17,17 +17,197 @@ package dnsproviders
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/edgexr/dnsproviders/api"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// every provider reports how many records a delete removed
+var (
+	_ api.DeleteCounter = (*CloudDNS)(nil)
+	_ api.DeleteCounter = (*CloudflareAPI)(nil)
+	_ api.DeleteCounter = OTC{}
+	_ api.DeleteCounter = (*DigitalOcean)(nil)
+	_ api.DeleteCounter = (*Hetzner)(nil)
+	_ api.DeleteCounter = (*PowerDNS)(nil)
+	_ api.DeleteCounter = (*Gandi)(nil)
+	_ api.DeleteCounter = (*NS1)(nil)
+	_ api.DeleteCounter = (*Linode)(nil)
+	_ api.DeleteCounter = (*DeSEC)(nil)
+	_ api.DeleteCounter = (*Vultr)(nil)
+	_ api.DeleteCounter = (*Scaleway)(nil)
+	_ api.DeleteCounter = (*OVH)(nil)
+	_ api.DeleteCounter = (*Bunny)(nil)
+	_ api.DeleteCounter = (*Namecheap)(nil)
+	_ api.DeleteCounter = (*DNSimple)(nil)
+	_ api.DeleteCounter = (*Porkbun)(nil)
+	_ api.DeleteCounter = (*RFC2136)(nil)
+	_ api.DeleteCounter = (*MockProvider)(nil)
+)
+
+var (
+	_ api.RecordSetUpdater = (*CloudDNS)(nil)
+	_ api.RecordSetUpdater = (*CloudflareAPI)(nil)
+	_ api.RecordSetUpdater = OTC{}
+	_ api.RecordSetUpdater = (*NS1)(nil)
+	_ api.RecordSetUpdater = (*DeSEC)(nil)
+	_ api.RecordSetUpdater = (*Scaleway)(nil)
+	_ api.RecordSetUpdater = (*MultiProvider)(nil)
+)
+
+var (
+	_ api.TTLUpdater = (*CloudDNS)(nil)
+	_ api.TTLUpdater = (*CloudflareAPI)(nil)
+	_ api.TTLUpdater = OTC{}
+)
+
+var (
+	_ api.RecordResultWriter = (*DigitalOcean)(nil)
+	_ api.RecordResultWriter = (*Linode)(nil)
+)
+
+// NS1 ids identify a whole record set, so only providers with an id
+// per record value can change or delete records by id
+var (
+	_ api.RecordIDManager = (*DigitalOcean)(nil)
+	_ api.RecordIDManager = (*Linode)(nil)
+	_ api.RecordIDManager = (*Porkbun)(nil)
+	_ api.RecordIDManager = (*MockProvider)(nil)
+)
+
+var (
+	_ api.DNSSECManager = (*CloudDNS)(nil)
+	_ api.DNSSECManager = (*CloudflareAPI)(nil)
+)
+
+// every provider except RFC 2136, which has no authenticated call
+// that works without a zone, can check its credentials directly
+var (
+	_ api.CredentialValidator = (*CloudDNS)(nil)
+	_ api.CredentialValidator = (*CloudflareAPI)(nil)
+	_ api.CredentialValidator = OTC{}
+	_ api.CredentialValidator = (*DigitalOcean)(nil)
+	_ api.CredentialValidator = (*Hetzner)(nil)
+	_ api.CredentialValidator = (*PowerDNS)(nil)
+	_ api.CredentialValidator = (*Gandi)(nil)
+	_ api.CredentialValidator = (*NS1)(nil)
+	_ api.CredentialValidator = (*Linode)(nil)
+	_ api.CredentialValidator = (*DeSEC)(nil)
+	_ api.CredentialValidator = (*Vultr)(nil)
+	_ api.CredentialValidator = (*Scaleway)(nil)
+	_ api.CredentialValidator = (*OVH)(nil)
+	_ api.CredentialValidator = (*Bunny)(nil)
+	_ api.CredentialValidator = (*Namecheap)(nil)
+	_ api.CredentialValidator = (*DNSimple)(nil)
+	_ api.CredentialValidator = (*Porkbun)(nil)
+	_ api.CredentialValidator = (*MockProvider)(nil)
 )
 
 func GetProvider(ctx context.Context, typ api.ProviderType, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (api.Provider, error) {
+	logger = defaultLogger(logger)
+	opts := getOptions(ops)
+	if opts.callCounter != nil {
+		ctx = contextWithOperation(ctx, "GetProvider")
+		if label := opts.zoneLabels.label(zone); label != "" {
+			ctx = contextWithZoneLabel(ctx, label)
+		}
+	}
+	prov, err := newProvider(ctx, typ, zone, credentialsData, logger, ops...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.plan != nil {
+		prov = &dryRunProvider{
+			Provider: prov,
+			plan:     opts.plan,
+		}
+	}
+	if opts.callCounter != nil {
+		prov = &wrappedProvider{
+			Provider: prov,
+			around:   callCountingOperation(opts.zoneLabels),
+		}
+	}
+	if opts.timeout > 0 {
+		prov = &wrappedProvider{
+			Provider: prov,
+			around:   timeoutOperation(opts.timeout),
+		}
+	}
+	if opts.tracerProvider != nil {
+		prov = &wrappedProvider{
+			Provider: prov,
+			around:   tracingOperation(opts.tracerProvider.Tracer(tracerName), typ),
+		}
+	}
+	return prov, nil
+}
+
+// defaultLogger returns the logger, or slog.Default() if it is nil,
+// so providers can always log.
+func defaultLogger(logger api.Logger) api.Logger {
 	if logger == nil {
-		logger = slog.Default()
+		return slog.Default()
 	}
+	return logger
+}
+
+// timeoutOperation bounds each operation by the timeout configured
+// with WithTimeout. A deadline already on the caller's context that
+// is sooner still applies, since a derived context never outlives its
+// parent.
+func timeoutOperation(timeout time.Duration) operationFunc {
+	return func(ctx context.Context, op, zone, name, rtype string, fn func(ctx context.Context) error) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return fn(ctx)
+	}
+}
+
+// ValidateAll constructs each configured provider concurrently and
+// checks that it can read its zone. The results are in the same order
+// as the configs. The options are applied to every provider.
+func ValidateAll(ctx context.Context, configs []api.ProviderConfig, ops ...Option) []api.ValidationResult {
+	results := make([]api.ValidationResult, len(configs))
+	wg := sync.WaitGroup{}
+	for ii, config := range configs {
+		wg.Add(1)
+		go func(ii int, config api.ProviderConfig) {
+			defer wg.Done()
+			results[ii] = api.ValidationResult{
+				Type: config.Type,
+				Zone: config.Zone,
+				Err:  validateProvider(ctx, config, ops...),
+			}
+		}(ii, config)
+	}
+	wg.Wait()
+	return results
+}
+
+func validateProvider(ctx context.Context, config api.ProviderConfig, ops ...Option) error {
+	if config.Zone == "" {
+		return fmt.Errorf("no zone specified for %s dns provider", config.Type)
+	}
+	prov, err := GetProvider(ctx, config.Type, config.Zone, config.CredentialsData, nil, ops...)
+	if err != nil {
+		return err
+	}
+	defer prov.Close()
+	// filtering on the apex keeps the response small while still
+	// checking the credentials grant access to the zone
+	if _, err := prov.GetDNSRecords(ctx, config.Zone, config.Zone); err != nil {
+		return fmt.Errorf("cannot read zone %s from %s dns provider, %v", config.Zone, config.Type, err)
+	}
+	return nil
+}
 
+func newProvider(ctx context.Context, typ api.ProviderType, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (api.Provider, error) {
 	switch typ {
 	case api.CloudflareProvider:
 		return NewCloudflareProvider(ctx, zone, credentialsData, logger, ops...)
@@ -35,12 +215,57 @@ func GetProvider(ctx context.Context, typ api.ProviderType, zone string, credent
 		return NewGoogleCloudDNSProvider(ctx, zone, credentialsData, logger, ops...)
 	case api.OpenTelekomCloudProvider:
 		return NewOtcProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.DigitalOceanProvider:
+		return NewDigitalOceanProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.HetznerProvider:
+		return NewHetznerProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.RFC2136Provider:
+		return NewRFC2136Provider(ctx, zone, credentialsData, logger, ops...)
+	case api.PowerDNSProvider:
+		return NewPowerDNSProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.GandiProvider:
+		return NewGandiProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.NS1Provider:
+		return NewNS1Provider(ctx, zone, credentialsData, logger, ops...)
+	case api.LinodeProvider:
+		return NewLinodeProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.DeSECProvider:
+		return NewDeSECProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.VultrProvider:
+		return NewVultrProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.ScalewayProvider:
+		return NewScalewayProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.OVHProvider:
+		return NewOVHProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.BunnyProvider:
+		return NewBunnyProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.NamecheapProvider:
+		return NewNamecheapProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.DNSimpleProvider:
+		return NewDNSimpleProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.PorkbunProvider:
+		return NewPorkbunProvider(ctx, zone, credentialsData, logger, ops...)
+	case api.MockProvider:
+		return NewMockProvider(zone), nil
 	}
 	return nil, errors.New("unknown dns provider " + string(typ))
 }
 
 type options struct {
-	client *http.Client
+	client       *http.Client
+	callCounter  *api.CallCounter
+	zoneLabels   zoneLabels
+	transports   []func(http.RoundTripper) http.RoundTripper
+	changeAuthor string
+	// listing limits, currently applied by the OTC provider
+	maxListPages    int
+	maxListDuration time.Duration
+	zoneCache       *zoneIDCache
+	timeout         time.Duration
+	tracerProvider  trace.TracerProvider
+	plan            *api.Plan
+	ttlPolicy       TTLPolicy
+	groupRecords    bool
 }
 
 type Option func(opts *options)
@@ -51,6 +276,135 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithCallCounting tallies the backend API round-trips made by each
+// provider operation into the counter. Operations of the optional api
+// interfaces are counted under their own names, including those the
+// backend does not implement and that fall back to other calls.
+func WithCallCounting(counter *api.CallCounter) Option {
+	return func(opts *options) {
+		opts.callCounter = counter
+		opts.transports = append(opts.transports, func(base http.RoundTripper) http.RoundTripper {
+			return &callCountingTransport{
+				base:    base,
+				counter: counter,
+			}
+		})
+	}
+}
+
+// WithMetricsZoneLabel additionally counts the round-trips of
+// WithCallCounting per zone. Only the allow-listed zones are counted
+// under their own name; all others are counted under api.OtherZone so
+// the number of zone labels stays bounded.
+func WithMetricsZoneLabel(allowlist []string) Option {
+	return func(opts *options) {
+		opts.zoneLabels = newZoneLabels(allowlist)
+	}
+}
+
+// WithChangeAuthor records the author in the comment field of every
+// record created or updated, on providers that support record
+// comments (Cloudflare and PowerDNS). It is ignored by other providers.
+func WithChangeAuthor(author string) Option {
+	return func(opts *options) {
+		opts.changeAuthor = author
+	}
+}
+
+// WithGroupedRecords makes GetDNSRecords group the values of the same
+// name and type into one record, with the lowest of their TTLs, on
+// providers that keep each value as a separate record (Cloudflare).
+// By default those return one record per value. It is ignored by
+// other providers, which always group values.
+func WithGroupedRecords() Option {
+	return func(opts *options) {
+		opts.groupRecords = true
+	}
+}
+
+// WithListLimits bounds how many pages, and for how long, a single
+// listing of zones or records may fetch before it is aborted with an
+// error. Zero means no limit. It is currently used by the OTC provider.
+func WithListLimits(maxPages int, maxDuration time.Duration) Option {
+	return func(opts *options) {
+		opts.maxListPages = maxPages
+		opts.maxListDuration = maxDuration
+	}
+}
+
+// WithSharedZoneCache caches the zone IDs resolved by the provider in
+// a bounded LRU cache shared by all provider instances in the process
+// that use this option, so short-lived instances do not each resolve
+// them again. Entries expire after ten minutes. Providers created
+// without this option keep no shared state.
+func WithSharedZoneCache() Option {
+	return func(opts *options) {
+		opts.zoneCache = sharedZoneCache
+	}
+}
+
+// WithZoneCache caches the zone IDs resolved by the provider for the
+// lifetime of the provider instance, so each operation does not look
+// up its zone again. Entries expire after the TTL so deleted and
+// recreated zones are resolved again.
+func WithZoneCache(ttl time.Duration) Option {
+	return func(opts *options) {
+		opts.zoneCache = newZoneIDCache(zoneCacheSize, ttl)
+	}
+}
+
+// WithTimeout bounds each operation of the provider returned by
+// GetProvider by the timeout, unless the caller's context has a
+// sooner deadline. The operations of the optional api interfaces are
+// bounded too. It does not apply to creating the provider, since some
+// backends keep the construction context for later token refreshes.
+func WithTimeout(timeout time.Duration) Option {
+	return func(opts *options) {
+		opts.timeout = timeout
+	}
+}
+
+// WithDryRun makes the provider returned by GetProvider add the
+// changes its writes would make to the plan, without making them.
+// Reads still go to the backend. Optional interfaces such as
+// api.BatchUpdater are not available on the dry-run provider, so
+// helpers like BatchCreateOrUpdateDNSRecords plan each record in turn.
+func WithDryRun(plan *api.Plan) Option {
+	return func(opts *options) {
+		opts.plan = plan
+	}
+}
+
+// WithTracer starts an OpenTelemetry span from the tracer provider for
+// each operation of the provider returned by GetProvider, with the
+// zone, name and record type as attributes. The operations of the
+// optional api interfaces are traced too. Failed operations record the
+// error on the span.
+func WithTracer(tp trace.TracerProvider) Option {
+	return func(opts *options) {
+		opts.tracerProvider = tp
+	}
+}
+
+// WithRetry retries backend requests that fail with 429 Too Many
+// Requests, or with a 5xx status for idempotent methods, up to
+// maxAttempts attempts in total. The delay between attempts starts at
+// baseDelay and doubles each time, with jitter, unless the response
+// gives a Retry-After. Waiting stops when the request context is
+// done. Requests still rate limited after the last attempt fail with
+// an *api.RateLimitError. It applies to all HTTP based providers.
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	return func(opts *options) {
+		opts.transports = append(opts.transports, func(base http.RoundTripper) http.RoundTripper {
+			return &retryTransport{
+				base:        base,
+				maxAttempts: maxAttempts,
+				baseDelay:   baseDelay,
+			}
+		})
+	}
+}
+
 func getOptions(ops []Option) options {
 	opts := options{}
 	for _, op := range ops {
@@ -58,3 +412,37 @@ func getOptions(ops []Option) options {
 	}
 	return opts
 }
+
+// httpClient returns the HTTP client the backend should use, which
+// is a copy of the configured client, if any, with the transport
+// wrappers applied.
+func (s options) httpClient() *http.Client {
+	client := http.Client{}
+	if s.client != nil {
+		client = *s.client
+	}
+	client.Transport = s.wrapTransport(client.Transport)
+	return &client
+}
+
+// changeComment returns the record comment identifying the change
+// author, or an empty string if no author was configured.
+func (s options) changeComment() string {
+	if s.changeAuthor == "" {
+		return ""
+	}
+	return "last changed by " + s.changeAuthor
+}
+
+// wrapTransport applies the configured transport wrappers to base,
+// and reports rate limited responses that are not retried as an
+// *api.RateLimitError.
+func (s options) wrapTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for _, wrap := range s.transports {
+		base = wrap(base)
+	}
+	return &rateLimitTransport{base: base}
+}
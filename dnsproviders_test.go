@@ -0,0 +1,266 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAll(t *testing.T) {
+	ctx := context.Background()
+	stub := &hetznerStub{
+		zones: []hetznerZone{{ID: "z1", Name: "example.com"}},
+	}
+	client := newStubClient(t, stub.handler())
+
+	configs := []api.ProviderConfig{{
+		Type:            api.HetznerProvider,
+		Zone:            "example.com",
+		CredentialsData: map[string]string{"token": "test"},
+	}, {
+		// rejected by the backend
+		Type:            api.HetznerProvider,
+		Zone:            "example.com",
+		CredentialsData: map[string]string{"token": "wrong"},
+	}, {
+		// zone not accessible
+		Type:            api.HetznerProvider,
+		Zone:            "example.org",
+		CredentialsData: map[string]string{"token": "test"},
+	}, {
+		// missing credentials
+		Type:            api.HetznerProvider,
+		Zone:            "example.com",
+		CredentialsData: map[string]string{},
+	}, {
+		Type: api.MockProvider,
+		Zone: "example.net",
+	}, {
+		Type: api.MockProvider,
+	}, {
+		Type: "unknown",
+		Zone: "example.com",
+	}}
+	results := ValidateAll(ctx, configs, WithHTTPClient(client))
+	require.Equal(t, len(configs), len(results))
+	for ii, config := range configs {
+		require.Equal(t, config.Type, results[ii].Type)
+		require.Equal(t, config.Zone, results[ii].Zone)
+	}
+	require.Nil(t, results[0].Err)
+	require.NotNil(t, results[1].Err)
+	require.Contains(t, results[1].Err.Error(), "401")
+	require.NotNil(t, results[2].Err)
+	require.Contains(t, results[2].Err.Error(), "no zone found")
+	require.NotNil(t, results[3].Err)
+	require.Contains(t, results[3].Err.Error(), "missing token")
+	require.Nil(t, results[4].Err)
+	require.NotNil(t, results[5].Err)
+	require.Contains(t, results[5].Err.Error(), "no zone specified")
+	require.NotNil(t, results[6].Err)
+	require.Contains(t, results[6].Err.Error(), "unknown dns provider")
+
+	require.Empty(t, ValidateAll(ctx, nil))
+}
+
+// deadlineProvider records the deadline of the context each call
+// receives.
+type deadlineProvider struct {
+	*MockProvider
+	deadline time.Time
+}
+
+func (s *deadlineProvider) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	s.deadline, _ = ctx.Deadline()
+	return s.MockProvider.GetDNSRecords(ctx, zone, name)
+}
+
+func (s *deadlineProvider) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	s.deadline, _ = ctx.Deadline()
+	return s.MockProvider.DeleteDNSRecordCount(ctx, zone, name)
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	// a hung backend call is aborted by the timeout
+	client := newStubClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	creds := map[string]string{"token": "test"}
+	prov, err := GetProvider(ctx, api.HetznerProvider, "example.com", creds, nil, WithHTTPClient(client), WithTimeout(20*time.Millisecond))
+	require.Nil(t, err)
+	start := time.Now()
+	_, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	// the timeout applies to calls without a deadline
+	mock := &deadlineProvider{MockProvider: NewMockProvider("example.com")}
+	prov = &wrappedProvider{Provider: mock, around: timeoutOperation(time.Hour)}
+	_, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), mock.deadline, time.Minute)
+
+	// but a tighter deadline from the caller wins
+	parent, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	expected, _ := parent.Deadline()
+	_, err = prov.GetDNSRecords(parent, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, expected, mock.deadline)
+
+	// the optional interfaces of the backend are forwarded, and
+	// bounded by the timeout too
+	mock.deadline = time.Time{}
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	deleted, err := prov.(api.DeleteCounter).DeleteDNSRecordCount(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, deleted)
+	require.WithinDuration(t, time.Now().Add(time.Hour), mock.deadline, time.Minute)
+
+	// those it does not implement fall back or are unsupported
+	prov, err = GetProvider(ctx, api.MockProvider, "", nil, nil, WithTimeout(time.Second))
+	require.Nil(t, err)
+	_, err = prov.(api.ZoneManager).CreateZone(ctx, "example.org")
+	require.Nil(t, err)
+	err = prov.(api.RecordSetUpdater).CreateOrUpdateDNSRecordSet(ctx, "example.org", "www.example.org", "A", []string{"10.0.0.1"}, 300, false)
+	require.Nil(t, err)
+	err = prov.(api.RecordSetUpdater).CreateOrUpdateDNSRecordSet(ctx, "example.org", "www.example.org", "A", []string{"10.0.0.1", "10.0.0.2"}, 300, false)
+	require.NotNil(t, err)
+	_, err = prov.(api.DNSSECManager).EnableDNSSEC(ctx, "example.org")
+	require.ErrorIs(t, err, api.ErrUnsupported)
+}
+
+func TestCapabilities(t *testing.T) {
+	ctx := context.Background()
+
+	cf := newCFTestProvider(t, newCFStub("example.com")).Capabilities()
+	require.True(t, cf.SupportsProxy)
+	require.True(t, cf.SupportsZoneManagement)
+	require.False(t, cf.SupportsBatch)
+	require.Contains(t, cf.SupportedRecordTypes, "A")
+
+	google := newGCDNSTestProvider(t, newGCDNSStub("example.com")).Capabilities()
+	require.False(t, google.SupportsProxy)
+	require.True(t, google.SupportsBatch)
+	require.True(t, google.SupportsDNSSEC)
+
+	otc := newOTCTestProvider(t, newOTCStub("example.com.")).Capabilities()
+	require.False(t, otc.SupportsProxy)
+	require.True(t, otc.SupportsZoneManagement)
+	require.False(t, otc.SupportsBatch)
+	require.Equal(t, otcRecordTypes, otc.SupportedRecordTypes)
+
+	// the decorators added by GetProvider pass the call through
+	prov, err := GetProvider(ctx, api.MockProvider, "", nil, nil, WithTimeout(time.Second))
+	require.Nil(t, err)
+	require.False(t, prov.Capabilities().SupportsProxy)
+	require.True(t, prov.Capabilities().SupportsZoneManagement)
+}
+
+// infoLogger implements only the original api.InfoLogger interface.
+type infoLogger struct {
+	msgs []string
+}
+
+func (s *infoLogger) InfoContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	s.msgs = append(s.msgs, msg)
+}
+
+// levelHandler records the level and message of each log record.
+type levelHandler struct {
+	slog.Handler
+	msgs []string
+}
+
+func (s *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (s *levelHandler) Handle(ctx context.Context, rec slog.Record) error {
+	s.msgs = append(s.msgs, fmt.Sprintf("%s %s", rec.Level, rec.Message))
+	return nil
+}
+
+func TestLeveledLogger(t *testing.T) {
+	ctx := context.Background()
+
+	// a slog logger already has every level
+	logger := slog.Default()
+	require.Equal(t, api.Logger(logger), api.LeveledLogger(logger))
+
+	// an info-only logger gets every level at info
+	info := &infoLogger{}
+	leveled := api.LeveledLogger(info)
+	leveled.DebugContext(ctx, "debug")
+	leveled.InfoContext(ctx, "info")
+	leveled.WarnContext(ctx, "warn")
+	leveled.ErrorContext(ctx, "error")
+	require.Equal(t, []string{"debug", "info", "warn", "error"}, info.msgs)
+
+	// providers log skipped updates at debug level
+	handler := &levelHandler{}
+	prov := newCFTestProvider(t, newCFStub("example.com"))
+	prov.logger = slog.New(handler)
+	for ii := 0; ii < 2; ii++ {
+		err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+		require.Nil(t, err)
+	}
+	require.Equal(t, []string{"DEBUG CreateOrUpdateDNSRecord existing record matches"}, handler.msgs)
+}
+
+func TestNilLogger(t *testing.T) {
+	ctx := context.Background()
+
+	// each constructor logs through slog.Default() if given no logger
+	cf, err := NewCloudflareProvider(ctx, "", map[string]string{"token": "test"}, nil,
+		WithHTTPClient(newStubClient(t, newCFStub("example.com").handler())))
+	require.Nil(t, err)
+	for ii := 0; ii < 2; ii++ {
+		err = cf.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+		require.Nil(t, err)
+	}
+
+	gcdns, err := NewGoogleCloudDNSProvider(ctx, "", map[string]string{projectID: "test-project"}, nil,
+		WithHTTPClient(newStubClient(t, newGCDNSStub("example.com").handler())))
+	require.Nil(t, err)
+	err = gcdns.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+
+	hetzner, err := NewHetznerProvider(ctx, "", map[string]string{"token": "test"}, nil,
+		WithHTTPClient(newStubClient(t, (&hetznerStub{
+			zones: []hetznerZone{{ID: "z1", Name: "example.com"}},
+		}).handler())))
+	require.Nil(t, err)
+	err = hetzner.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+
+	do, err := NewDigitalOceanProvider(ctx, "", map[string]string{"token": "test"}, nil,
+		WithHTTPClient(newStubClient(t, newDOStub().handler())))
+	require.Nil(t, err)
+	err = do.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+}
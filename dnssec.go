@@ -0,0 +1,44 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// EnableDNSSEC signs the zone and returns the DS record to publish in
+// the parent zone, if the provider implements api.DNSSECManager.
+// Otherwise it returns an error wrapping api.ErrUnsupported.
+func EnableDNSSEC(ctx context.Context, prov api.Provider, zone string) (api.DSRecord, error) {
+	manager, ok := prov.(api.DNSSECManager)
+	if !ok {
+		return api.DSRecord{}, fmt.Errorf("%w, cannot enable DNSSEC for zone %s", api.ErrUnsupported, zone)
+	}
+	return manager.EnableDNSSEC(ctx, zone)
+}
+
+// DisableDNSSEC stops signing the zone if the provider implements
+// api.DNSSECManager. Otherwise it returns an error wrapping
+// api.ErrUnsupported.
+func DisableDNSSEC(ctx context.Context, prov api.Provider, zone string) error {
+	manager, ok := prov.(api.DNSSECManager)
+	if !ok {
+		return fmt.Errorf("%w, cannot disable DNSSEC for zone %s", api.ErrUnsupported, zone)
+	}
+	return manager.DisableDNSSEC(ctx, zone)
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// dryRunProvider adds the changes its writes would make to the plan
+// configured with WithDryRun, instead of making them. Reads are passed
+// to the backend so each change is planned against the current
+// records.
+type dryRunProvider struct {
+	api.Provider
+	plan *api.Plan
+}
+
+func (s *dryRunProvider) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	change, err := PlanCreateOrUpdate(ctx, s.Provider, zone, name, rtype, content, ttl, proxy)
+	if err != nil {
+		return err
+	}
+	s.plan.Add(change)
+	return nil
+}
+
+func (s *dryRunProvider) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	if name == "" {
+		return fmt.Errorf("no name specified to delete")
+	}
+	changes, err := planDelete(ctx, s.Provider, zone, name, "")
+	if err != nil {
+		return err
+	}
+	s.plan.Add(changes...)
+	return nil
+}
+
+func (s *dryRunProvider) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if name == "" {
+		return fmt.Errorf("no name specified to delete")
+	}
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	changes, err := planDelete(ctx, s.Provider, zone, name, rtype)
+	if err != nil {
+		return err
+	}
+	s.plan.Add(changes...)
+	return nil
+}
+
+// PlanCreateOrUpdate returns the change that CreateOrUpdateDNSRecord
+// would make with the same arguments, without making it. The record
+// set of the name and type is replaced, so the change is a no-op only
+// if the set already holds just the content, with the same TTL and
+// proxying. Host names are compared fully qualified and TXT values
+// unquoted, so content in either form matches.
+func PlanCreateOrUpdate(ctx context.Context, prov api.Provider, zone, name, rtype, content string, ttl int, proxy bool) (api.PlannedChange, error) {
+	caps := prov.Capabilities()
+	if proxy && !caps.SupportsProxy {
+		return api.PlannedChange{}, api.ErrProxyNotSupported
+	}
+	if err := checkRecord(name, rtype, caps.SupportedRecordTypes); err != nil {
+		return api.PlannedChange{}, err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return api.PlannedChange{}, err
+	}
+	rtype = strings.ToUpper(rtype)
+	newValue, err := toPresentation(rtype, content)
+	if err != nil {
+		return api.PlannedChange{}, err
+	}
+	records, err := prov.GetDNSRecords(ctx, zone, name)
+	if err != nil {
+		return api.PlannedChange{}, err
+	}
+	change := api.PlannedChange{
+		Action:     api.PlanActionCreate,
+		Zone:       zone,
+		Name:       name,
+		Type:       rtype,
+		NewContent: []string{content},
+		NewTTL:     ttl,
+		NewProxied: proxy,
+	}
+	for _, set := range plannedSets(records, rtype) {
+		change.OldContent = set.Content
+		change.OldTTL = set.TTL
+		change.OldProxied = set.Proxied
+		change.Action = api.PlanActionUpdate
+		if set.TTL == ttl && set.Proxied == proxy && len(set.Content) == 1 && presentationValue(rtype, set.Content[0]) == newValue {
+			change.Action = api.PlanActionNoop
+		}
+	}
+	return change, nil
+}
+
+// planDelete returns the changes that deleting the records of the
+// name, and of the type if given, would make. A delete that matches
+// no records is planned as a no-op.
+func planDelete(ctx context.Context, prov api.Provider, zone, name, rtype string) ([]api.PlannedChange, error) {
+	records, err := prov.GetDNSRecords(ctx, zone, name)
+	if err != nil {
+		return nil, err
+	}
+	changes := []api.PlannedChange{}
+	for _, set := range plannedSets(records, strings.ToUpper(rtype)) {
+		changes = append(changes, api.PlannedChange{
+			Action:     api.PlanActionDelete,
+			Zone:       zone,
+			Name:       name,
+			Type:       set.Type,
+			OldContent: set.Content,
+			OldTTL:     set.TTL,
+			OldProxied: set.Proxied,
+		})
+	}
+	if len(changes) == 0 {
+		changes = append(changes, api.PlannedChange{
+			Action: api.PlanActionNoop,
+			Zone:   zone,
+			Name:   name,
+			Type:   strings.ToUpper(rtype),
+		})
+	}
+	return changes, nil
+}
+
+// plannedSets merges the records read back into one set per type,
+// with the content in the form passed to CreateOrUpdateDNSRecord. If
+// rtype is set only that type is returned. The sets are sorted by
+// type.
+func plannedSets(records []api.Record, rtype string) []api.Record {
+	sets := []api.Record{}
+	index := map[string]int{}
+	for _, record := range records {
+		recType := strings.ToUpper(record.Type)
+		if rtype != "" && recType != rtype {
+			continue
+		}
+		ii, ok := index[recType]
+		if !ok {
+			ii = len(sets)
+			index[recType] = ii
+			sets = append(sets, api.Record{
+				Name:    record.Name,
+				Type:    recType,
+				TTL:     record.TTL,
+				Proxied: record.Proxied,
+			})
+		}
+		for _, content := range record.Content {
+			sets[ii].Content = append(sets[ii].Content, writeContent(record, content))
+		}
+	}
+	slices.SortFunc(sets, func(a, b api.Record) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+	return sets
+}
+
+// presentationValue returns the content in presentation format for
+// comparison, or the content itself if it cannot be converted.
+func presentationValue(rtype, content string) string {
+	value, err := toPresentation(rtype, content)
+	if err != nil {
+		return content
+	}
+	return value
+}
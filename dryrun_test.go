@@ -0,0 +1,140 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	plan := &api.Plan{}
+	prov, err := GetProvider(ctx, api.MockProvider, "example.com", nil, nil, WithDryRun(plan))
+	require.Nil(t, err)
+	mock := prov.(*dryRunProvider).Provider.(*MockProvider)
+	mock.Seed("example.com", api.Record{
+		Name:    "www.example.com",
+		Type:    api.RecordTypeA,
+		Content: []string{"10.0.0.1"},
+		TTL:     300,
+	}, api.Record{
+		Name:     "example.com",
+		Type:     api.RecordTypeMX,
+		Content:  []string{"mail.example.com."},
+		TTL:      300,
+		Priority: 10,
+	}, api.Record{
+		Name:    "example.com",
+		Type:    api.RecordTypeTXT,
+		Content: []string{"v=spf1 -all"},
+		TTL:     300,
+	}, api.Record{
+		Name:    "multi.example.com",
+		Type:    api.RecordTypeA,
+		Content: []string{"10.0.0.1", "10.0.0.2"},
+		TTL:     300,
+	})
+
+	tests := []struct {
+		desc    string
+		name    string
+		rtype   string
+		content string
+		ttl     int
+		action  api.PlanAction
+		old     []string
+	}{
+		{"new name", "new.example.com", "A", "10.0.0.9", 300, api.PlanActionCreate, nil},
+		{"new type", "www.example.com", "AAAA", "fd00::1", 300, api.PlanActionCreate, nil},
+		{"same", "www.example.com", "a", "10.0.0.1", 300, api.PlanActionNoop, []string{"10.0.0.1"}},
+		{"content", "www.example.com", "A", "10.0.0.2", 300, api.PlanActionUpdate, []string{"10.0.0.1"}},
+		{"ttl", "www.example.com", "A", "10.0.0.1", 600, api.PlanActionUpdate, []string{"10.0.0.1"}},
+		{"same mx", "example.com", "MX", "10 mail.example.com", 300, api.PlanActionNoop, []string{"10 mail.example.com."}},
+		{"mx priority", "example.com", "MX", "20 mail.example.com", 300, api.PlanActionUpdate, []string{"10 mail.example.com."}},
+		{"quoted txt", "example.com", "TXT", `"v=spf1 -all"`, 300, api.PlanActionNoop, []string{"v=spf1 -all"}},
+		{"multi", "multi.example.com", "A", "10.0.0.1", 300, api.PlanActionUpdate, []string{"10.0.0.1", "10.0.0.2"}},
+	}
+	for _, test := range tests {
+		plan.Reset()
+		before := mock.Dump("example.com")
+		err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", test.name, test.rtype, test.content, test.ttl, false)
+		require.Nil(t, err, test.desc)
+		require.Equal(t, before, mock.Dump("example.com"), test.desc)
+		changes := plan.Changes()
+		require.Equal(t, 1, len(changes), test.desc)
+		change := changes[0]
+		require.Equal(t, test.action, change.Action, test.desc)
+		require.Equal(t, test.old, change.OldContent, test.desc)
+		require.Equal(t, []string{test.content}, change.NewContent, test.desc)
+
+		// the plan matches what the provider then does
+		err = mock.CreateOrUpdateDNSRecord(ctx, "example.com", test.name, test.rtype, test.content, test.ttl, false)
+		require.Nil(t, err, test.desc)
+		if test.action == api.PlanActionNoop {
+			require.Equal(t, before, mock.Dump("example.com"), test.desc)
+		} else {
+			require.NotEqual(t, before, mock.Dump("example.com"), test.desc)
+		}
+		again, err := PlanCreateOrUpdate(ctx, mock, "example.com", test.name, test.rtype, test.content, test.ttl, false)
+		require.Nil(t, err, test.desc)
+		require.Equal(t, api.PlanActionNoop, again.Action, test.desc)
+		require.Equal(t, test.ttl, again.OldTTL, test.desc)
+
+		// put the seeded records back for the next case
+		mock.Seed("example.com", before...)
+	}
+
+	// deletes
+	plan.Reset()
+	before := mock.Dump("example.com")
+	err = prov.DeleteDNSRecord(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	err = prov.DeleteDNSRecordByType(ctx, "example.com", "www.example.com", "CNAME")
+	require.Nil(t, err)
+	require.Equal(t, before, mock.Dump("example.com"))
+	require.Equal(t, []api.PlannedChange{{
+		Action:     api.PlanActionDelete,
+		Zone:       "example.com",
+		Name:       "example.com",
+		Type:       api.RecordTypeMX,
+		OldContent: []string{"10 mail.example.com."},
+		OldTTL:     300,
+	}, {
+		Action:     api.PlanActionDelete,
+		Zone:       "example.com",
+		Name:       "example.com",
+		Type:       api.RecordTypeTXT,
+		OldContent: []string{"v=spf1 -all"},
+		OldTTL:     300,
+	}, {
+		Action: api.PlanActionNoop,
+		Zone:   "example.com",
+		Name:   "www.example.com",
+		Type:   api.RecordTypeCNAME,
+	}}, plan.Changes())
+
+	// invalid writes fail as they would for real
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, true)
+	require.True(t, errors.Is(err, api.ErrProxyNotSupported), err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "MX", "mail.example.com", 300, false)
+	require.NotNil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.net", "www.example.net", "A", "10.0.0.1", 300, false)
+	require.True(t, errors.Is(err, api.ErrZoneNotFound), err)
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// csvHeader is the header row of the CSV export format.
+var csvHeader = []string{"name", "type", "ttl", "content"}
+
+// writeContent returns one value of the record in the form passed to
+// CreateOrUpdateDNSRecord. MX and SRV values may be given either as
+// the target alone, with the priority fields set in the record, or
+// already in "priority target" or "priority weight port target" form.
+func writeContent(record api.Record, content string) string {
+	switch strings.ToUpper(record.Type) {
+	case api.RecordTypeMX:
+		if len(strings.Fields(content)) == 2 {
+			return content
+		}
+		return fmt.Sprintf("%d %s", record.Priority, content)
+	case api.RecordTypeSRV:
+		if len(strings.Fields(content)) == 4 {
+			return content
+		}
+		return fmt.Sprintf("%d %d %d %s", record.Priority, record.Weight, record.Port, content)
+	}
+	return content
+}
+
+// StreamExport writes all records in the zone to w in the given
+// format. Records are written as each page arrives from the backend
+// if the provider implements api.RecordPager, so large zones are never
+// held in memory. Other providers are exported from a single
+// GetDNSRecords call.
+func StreamExport(ctx context.Context, prov api.Provider, zone string, w io.Writer, format api.ExportFormat) error {
+	var writePage func([]api.Record) error
+	var flush func() error
+
+	switch format {
+	case api.ExportFormatNDJSON:
+		enc := json.NewEncoder(w)
+		writePage = func(records []api.Record) error {
+			for _, record := range records {
+				if err := enc.Encode(record); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		flush = func() error { return nil }
+	case api.ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		writePage = func(records []api.Record) error {
+			for _, record := range records {
+				for _, content := range record.Content {
+					err := cw.Write([]string{record.Name, record.Type, strconv.Itoa(record.TTL), writeContent(record, content)})
+					if err != nil {
+						return err
+					}
+				}
+			}
+			// flush each page so it is not buffered until the end
+			cw.Flush()
+			return cw.Error()
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+
+	if pager, ok := prov.(api.RecordPager); ok {
+		if err := pager.ListDNSRecordPages(ctx, zone, writePage); err != nil {
+			return err
+		}
+		return flush()
+	}
+	records, err := prov.GetDNSRecords(ctx, zone, "")
+	if err != nil {
+		return err
+	}
+	if err := writePage(records); err != nil {
+		return err
+	}
+	return flush()
+}
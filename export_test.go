@@ -0,0 +1,89 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+	dns "google.golang.org/api/dns/v1"
+)
+
+// pageTrackingWriter records how many pages had been requested from
+// the stub when each write happened.
+type pageTrackingWriter struct {
+	bytes.Buffer
+	stub       *gcdnsStub
+	writePages []int
+}
+
+func (s *pageTrackingWriter) Write(p []byte) (int, error) {
+	s.writePages = append(s.writePages, s.stub.count(http.MethodGet, "/rrsets"))
+	return s.Buffer.Write(p)
+}
+
+func TestStreamExport(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	stub.pageSize = 2
+	for ii := 0; ii < 5; ii++ {
+		stub.rrsets["example-com"] = append(stub.rrsets["example-com"], &dns.ResourceRecordSet{
+			Name:    "host" + strconv.Itoa(ii) + ".example.com.",
+			Type:    "A",
+			Ttl:     300,
+			Rrdatas: []string{"10.0.0." + strconv.Itoa(ii), "10.0.1." + strconv.Itoa(ii)},
+		})
+	}
+	prov := newGCDNSTestProvider(t, stub)
+
+	w := &pageTrackingWriter{stub: stub}
+	err := StreamExport(ctx, prov, "example.com", w, api.ExportFormatNDJSON)
+	require.Nil(t, err)
+	require.Equal(t, 3, stub.count(http.MethodGet, "/rrsets"))
+	// the first records were written before the next page was fetched
+	require.Equal(t, 1, w.writePages[0])
+	require.Equal(t, 3, w.writePages[len(w.writePages)-1])
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	require.Equal(t, 5, len(lines))
+	for ii, line := range lines {
+		record := api.Record{}
+		err := json.Unmarshal([]byte(line), &record)
+		require.Nil(t, err)
+		require.Equal(t, "host"+strconv.Itoa(ii)+".example.com", record.Name)
+		require.Equal(t, 2, len(record.Content))
+	}
+
+	w = &pageTrackingWriter{stub: stub}
+	err = StreamExport(ctx, prov, "example.com", w, api.ExportFormatCSV)
+	require.Nil(t, err)
+	lines = strings.Split(strings.TrimSpace(w.String()), "\n")
+	// header plus one row per value
+	require.Equal(t, 11, len(lines))
+	require.Equal(t, "name,type,ttl,content", lines[0])
+	require.Equal(t, "host0.example.com,A,300,10.0.0.0", lines[1])
+	// the header and first page are flushed after the first request
+	require.Equal(t, 3+1, w.writePages[0])
+
+	err = StreamExport(ctx, prov, "example.com", w, "xml")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,269 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+const gandiBaseURL = "https://api.gandi.net/v5/livedns"
+
+// gandiTTLs are the TTLs LiveDNS accepts.
+var gandiTTLs = ttlRange{min: 300, max: 2592000}
+
+// Gandi manages DNS records via the Gandi LiveDNS v5 API. LiveDNS
+// stores record names relative to the zone with "@" for the apex, so
+// names are converted to and from the fully qualified form used by
+// this package.
+type Gandi struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	logger  api.Logger
+	zone    string // zone the provider was configured for, if any
+	// applied to TTLs outside gandiTTLs
+	ttlPolicy TTLPolicy
+}
+
+type gandiRRset struct {
+	Name   string   `json:"rrset_name,omitempty"`
+	Type   string   `json:"rrset_type,omitempty"`
+	TTL    int      `json:"rrset_ttl,omitempty"`
+	Values []string `json:"rrset_values"`
+}
+
+// NewGandiProvider creates a new Gandi LiveDNS provider. The token is
+// a Gandi personal access token.
+func NewGandiProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*Gandi, error) {
+	return NewGandiProviderWithCredentials(ctx, zone, gandiCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewGandiProviderWithCredentials creates a new Gandi DNS provider
+// from typed credentials.
+func NewGandiProviderWithCredentials(ctx context.Context, zone string, creds GandiCredentials, logger api.Logger, ops ...Option) (*Gandi, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	token := creds.Token
+	opts := getOptions(ops)
+	client := opts.httpClient()
+	return &Gandi{
+		client:    client,
+		baseURL:   gandiBaseURL,
+		token:     token,
+		logger:    logger,
+		zone:      zone,
+		ttlPolicy: opts.ttlPolicy,
+	}, nil
+}
+
+func (s *Gandi) do(ctx context.Context, method, path string, in, out interface{}) error {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+s.token)
+	return doJSON(ctx, s.client, method, s.baseURL+path, header, in, out)
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *Gandi) Close() error {
+	return nil
+}
+
+// ValidateCredentials lists at most one domain.
+func (s *Gandi) ValidateCredentials(ctx context.Context) error {
+	return credentialsError(s.do(ctx, http.MethodGet, "/domains?per_page=1", nil, nil))
+}
+
+// ListZones returns the domains the token can manage. LiveDNS
+// identifies domains by name.
+func (s *Gandi) ListZones(ctx context.Context) ([]api.Zone, error) {
+	domains := []struct {
+		FQDN string `json:"fqdn"`
+	}{}
+	if err := s.do(ctx, http.MethodGet, "/domains", nil, &domains); err != nil {
+		return nil, err
+	}
+	zones := []api.Zone{}
+	for _, domain := range domains {
+		zones = append(zones, newZone(domain.FQDN, ""))
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone is not supported, since LiveDNS zones exist for domains
+// registered or transferred through Gandi.
+func (s *Gandi) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	return api.Zone{}, fmt.Errorf("%w, gandi zones are created with the domain registration", api.ErrUnsupported)
+}
+
+// DeleteZone is not supported, since LiveDNS zones exist for domains
+// registered or transferred through Gandi.
+func (s *Gandi) DeleteZone(ctx context.Context, zone string) error {
+	return fmt.Errorf("%w, gandi zones are deleted with the domain registration", api.ErrUnsupported)
+}
+
+func gandiRecordsPath(zone string, elems ...string) string {
+	path := "/domains/" + url.PathEscape(strings.TrimSuffix(zone, ".")) + "/records"
+	for _, elem := range elems {
+		path += "/" + url.PathEscape(elem)
+	}
+	return path
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *Gandi) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	path := gandiRecordsPath(zone)
+	if name != "" {
+		path = gandiRecordsPath(zone, relativeName(name, zone))
+	}
+	rrsets := []gandiRRset{}
+	err := s.do(ctx, http.MethodGet, path, nil, &rrsets)
+	// a name with no records is not found either, so only a listing
+	// of the whole zone tells that the zone is missing
+	if name != "" && isHTTPStatus(err, http.StatusNotFound) {
+		return []api.Record{}, nil
+	}
+	if isHTTPStatus(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, rrset := range rrsets {
+		records = append(records, fromPresentation(api.Record{
+			Type:    rrset.Type,
+			Name:    absoluteName(rrset.Name, zone),
+			Content: rrset.Values,
+			TTL:     rrset.TTL,
+			System:  isSystemRecord(zone, absoluteName(rrset.Name, zone), rrset.Type),
+		})...)
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *Gandi) SupportedRecordTypes() []string {
+	return slices.Clone(presentationRecordTypes)
+}
+
+// Capabilities reports only the record types, since Gandi zones come
+// with the domain registration.
+func (s *Gandi) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes: s.SupportedRecordTypes(),
+	}
+}
+
+// CreateOrUpdateDNSRecord replaces all values of the name and type
+// with the new record.
+func (s *Gandi) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.GandiProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, presentationRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	ttl, err := s.ttlPolicy.normalize(api.GandiProvider, ttl, gandiTTLs)
+	if err != nil {
+		return err
+	}
+	content, err = toPresentation(rtype, content)
+	if err != nil {
+		return err
+	}
+	rrset := gandiRRset{
+		TTL:    ttl,
+		Values: []string{content},
+	}
+	s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+	path := gandiRecordsPath(zone, relativeName(name, zone), strings.ToUpper(rtype))
+	err = s.do(ctx, http.MethodPut, path, &rrset, nil)
+	if isHTTPStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *Gandi) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	if name == "" {
+		return fmt.Errorf("no name specified to delete")
+	}
+	err := s.do(ctx, http.MethodDelete, gandiRecordsPath(zone, relativeName(name, zone)), nil, nil)
+	if err != nil && !isHTTPStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("cannot delete DNS records for zone %s name %s, %v", zone, name, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted. LiveDNS does not report what it
+// deleted, so the records are read first.
+func (s *Gandi) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	rrsets := []gandiRRset{}
+	err := s.do(ctx, http.MethodGet, gandiRecordsPath(zone, relativeName(name, zone)), nil, &rrsets)
+	if isHTTPStatus(err, http.StatusNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, rrset := range rrsets {
+		count += len(rrset.Values)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	if err := s.DeleteDNSRecord(ctx, zone, name); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteDNSRecordByType deletes only the rrset of the given type for
+// the name.
+func (s *Gandi) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if name == "" {
+		return fmt.Errorf("no name specified to delete")
+	}
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	err := s.do(ctx, http.MethodDelete, gandiRecordsPath(zone, relativeName(name, zone), strings.ToUpper(rtype)), nil, nil)
+	if err != nil && !isHTTPStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("cannot delete %s DNS records for zone %s name %s, %v", rtype, zone, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// gandiStub is a minimal in-memory implementation of the LiveDNS
+// records API for a single domain.
+type gandiStub struct {
+	mu     sync.Mutex
+	domain string
+	rrsets []gandiRRset
+}
+
+func (s *gandiStub) list(w http.ResponseWriter, name string) {
+	rrsets := []gandiRRset{}
+	for _, rrset := range s.rrsets {
+		if name == "" || rrset.Name == name {
+			rrsets = append(rrsets, rrset)
+		}
+	}
+	if name != "" && len(rrsets) == 0 {
+		http.Error(w, `{"code": 404, "message": "Can't find the DNS record"}`, http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(rrsets)
+}
+
+func (s *gandiStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v5/livedns/domains/{fqdn}/records", func(w http.ResponseWriter, r *http.Request) {
+		s.list(w, "")
+	})
+	mux.HandleFunc("GET /v5/livedns/domains/{fqdn}/records/{name}", func(w http.ResponseWriter, r *http.Request) {
+		s.list(w, r.PathValue("name"))
+	})
+	mux.HandleFunc("PUT /v5/livedns/domains/{fqdn}/records/{name}/{type}", func(w http.ResponseWriter, r *http.Request) {
+		update := gandiRRset{}
+		json.NewDecoder(r.Body).Decode(&update)
+		update.Name = r.PathValue("name")
+		update.Type = r.PathValue("type")
+		for ii, rrset := range s.rrsets {
+			if rrset.Name == update.Name && rrset.Type == update.Type {
+				s.rrsets[ii] = update
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+		}
+		s.rrsets = append(s.rrsets, update)
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("DELETE /v5/livedns/domains/{fqdn}/records/{name}/{type}", func(w http.ResponseWriter, r *http.Request) {
+		kept := []gandiRRset{}
+		for _, rrset := range s.rrsets {
+			if rrset.Name != r.PathValue("name") || rrset.Type != r.PathValue("type") {
+				kept = append(kept, rrset)
+			}
+		}
+		s.rrsets = kept
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /v5/livedns/domains/{fqdn}/records/{name}", func(w http.ResponseWriter, r *http.Request) {
+		kept := []gandiRRset{}
+		for _, rrset := range s.rrsets {
+			if rrset.Name != r.PathValue("name") {
+				kept = append(kept, rrset)
+			}
+		}
+		s.rrsets = kept
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		prefix := "/v5/livedns/domains/"
+		if strings.HasPrefix(r.URL.Path, prefix) && !strings.HasPrefix(r.URL.Path, prefix+s.domain+"/") {
+			http.Error(w, `{"code": 404, "message": "Domain not found"}`, http.StatusNotFound)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func TestGandiStub(t *testing.T) {
+	ctx := context.Background()
+	stub := &gandiStub{domain: "example.com"}
+	client := newStubClient(t, stub.handler())
+
+	prov, err := GetProvider(ctx, api.GandiProvider, "", map[string]string{"token": "test"}, nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+
+	// apex is stored as "@", other names relative to the zone
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "CNAME", "example.com.", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, "@", stub.rrsets[0].Name)
+	require.Equal(t, "www", stub.rrsets[1].Name)
+
+	// multi-value rrsets map directly into content
+	stub.rrsets[0].Values = []string{"10.0.0.1", "10.0.0.2"}
+	records, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "example.com", records[0].Name)
+	require.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, records[0].Content)
+
+	// replacing the rrset replaces all values
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "A", "10.0.0.3", 300, false)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	require.Equal(t, []string{"10.0.0.3"}, records[0].Content)
+	require.Equal(t, "www.example.com", records[1].Name)
+}
@@ -20,31 +20,61 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/edgexr/dnsproviders/api"
 	dns "google.golang.org/api/dns/v1"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 )
 
 const projectID = "project_id"
 const GoogleCloudDNS = "googleclouddns"
+const googleChangePollInterval = 2 * time.Second
 
+// googleChangeDone is the status of a change that has been applied
+// to all authoritative servers.
+const googleChangeDone = "done"
+
+// CloudDNS manages records in Google Cloud DNS managed zones. Each
+// name and type is one record set holding all of its values. The NS
+// record set at the zone apex is managed by Google Cloud, so NS
+// records can only be set on subnames, to delegate them.
 type CloudDNS struct {
-	api        *dns.Service
-	project    string
-	zoneToName map[string]string // map DNS zone to GCP name
-	logger     api.Logger
+	api          *dns.Service
+	project      string
+	mu           sync.RWMutex      // guards zoneToName
+	zoneToName   map[string]string // map DNS zone to GCP name
+	logger       api.Logger
+	pollInterval time.Duration
+	zone         string // zone the provider was configured for, if any
+	// shared zone ID cache, nil if disabled
+	zoneCache *zoneIDCache
+	// transport owned by the provider, nil if the caller supplied
+	// the HTTP client
+	transport *http.Transport
 }
 
+var _ api.ChangeTracker = (*CloudDNS)(nil)
+
 // NewGoogleCloudDNS creates a new Google Cloud DNS provider
 func NewGoogleCloudDNSProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*CloudDNS, error) {
-	project, ok := credentialsData[projectID]
-	if !ok {
-		return nil, fmt.Errorf("google cloud DNS credentials missing " + projectID)
+	return NewGoogleCloudDNSProviderWithCredentials(ctx, zone, googleCloudCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewGoogleCloudDNSProviderWithCredentials creates a new Google Cloud
+// DNS provider from typed service account credentials.
+func NewGoogleCloudDNSProviderWithCredentials(ctx context.Context, zone string, creds GoogleCloudCredentials, logger api.Logger, ops ...Option) (*CloudDNS, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
 	}
-	jsonData, err := json.Marshal(credentialsData)
+	project := creds.ProjectID
+	jsonData, err := json.Marshal(creds.ToMap())
 	if err != nil {
 		return nil, err
 	}
@@ -53,8 +83,19 @@ func NewGoogleCloudDNSProvider(ctx context.Context, zone string, credentialsData
 	}
 
 	opts := getOptions(ops)
+	var baseTransport *http.Transport
 	if opts.client != nil {
-		apiOptions = append(apiOptions, option.WithHTTPClient(opts.client))
+		apiOptions = append(apiOptions, option.WithHTTPClient(opts.httpClient()))
+	} else {
+		// A custom http client bypasses the credentials, so build
+		// the authenticated transport on top of our own, which
+		// lets Close release its connections.
+		baseTransport = http.DefaultTransport.(*http.Transport).Clone()
+		transport, err := htransport.NewTransport(ctx, opts.wrapTransport(baseTransport), append(apiOptions, option.WithScopes(dns.NdevClouddnsReadwriteScope))...)
+		if err != nil {
+			return nil, err
+		}
+		apiOptions = append(apiOptions, option.WithHTTPClient(&http.Client{Transport: transport}))
 	}
 
 	logger.InfoContext(ctx, "initializing google cloud DNS", "project", project)
@@ -63,19 +104,52 @@ func NewGoogleCloudDNSProvider(ctx context.Context, zone string, credentialsData
 		return nil, err
 	}
 	cloudDNS := &CloudDNS{
-		api:        api,
-		project:    project,
-		zoneToName: map[string]string{},
-		logger:     logger,
+		api:          api,
+		project:      project,
+		zoneToName:   map[string]string{},
+		logger:       logger,
+		pollInterval: googleChangePollInterval,
+		zone:         zone,
+		zoneCache:    opts.zoneCache,
+		transport:    baseTransport,
 	}
-	err = cloudDNS.setManagedZones(ctx)
-	if err != nil {
-		return nil, err
+	// with a zone cache the managed zones are resolved on first use,
+	// often from the cache, rather than listed up front
+	if cloudDNS.zoneCache == nil {
+		err = cloudDNS.setManagedZones(ctx)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return cloudDNS, nil
 }
 
+// Close closes the idle connections of the provider's own transport.
+// A client passed in with WithHTTPClient is left to the caller.
+func (s *CloudDNS) Close() error {
+	if s.transport != nil {
+		s.transport.CloseIdleConnections()
+	}
+	return nil
+}
+
+// ValidateCredentials lists at most one managed zone of the project.
+func (s *CloudDNS) ValidateCredentials(ctx context.Context) error {
+	_, err := s.api.ManagedZones.List(s.project).MaxResults(1).Context(ctx).Do()
+	return credentialsError(err)
+}
+
+// RefreshZones lists the managed zones of the project again, so that
+// zones created or deleted since the provider was created, or last
+// refreshed, are seen. Record operations refresh automatically when
+// they do not find a zone, so this is only needed to pick up deleted
+// or renamed zones early.
+func (s *CloudDNS) RefreshZones(ctx context.Context) error {
+	return s.setManagedZones(ctx)
+}
+
 func (s *CloudDNS) setManagedZones(ctx context.Context) error {
+	zoneToName := map[string]string{}
 	req := s.api.ManagedZones.List(s.project)
 	err := req.Pages(ctx, func(page *dns.ManagedZonesListResponse) error {
 		if page.HTTPStatusCode < 200 || page.HTTPStatusCode >= 300 {
@@ -83,37 +157,132 @@ func (s *CloudDNS) setManagedZones(ctx context.Context) error {
 		}
 		for _, mz := range page.ManagedZones {
 			dnsName := strings.TrimSuffix(mz.DnsName, ".")
-			s.zoneToName[dnsName] = mz.Name
+			zoneToName[dnsName] = mz.Name
+			s.zoneCache.put(s.cacheKey(dnsName), mz.Name)
 		}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
-	s.logger.InfoContext(ctx, "google cloud DNS", "managedZones", s.zoneToName)
+	// replace rather than merge so deleted zones are dropped
+	s.mu.Lock()
+	s.zoneToName = zoneToName
+	s.mu.Unlock()
+	s.logger.DebugContext(ctx, "google cloud DNS", "managedZones", zoneToName)
 	return nil
 }
 
-func (s *CloudDNS) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+// lookupZone returns the managed zone name of the zone, if known.
+func (s *CloudDNS) lookupZone(zone string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	mz, ok := s.zoneToName[zone]
-	if !ok {
-		return nil, fmt.Errorf("no managed zone found for %s", zone)
+	return mz, ok
+}
+
+// ListZones refreshes and returns the managed zones of the project.
+// The ID of each zone is its managed zone name.
+func (s *CloudDNS) ListZones(ctx context.Context) ([]api.Zone, error) {
+	if err := s.setManagedZones(ctx); err != nil {
+		return nil, err
 	}
-	records := []api.Record{}
+	zones := []api.Zone{}
+	s.mu.RLock()
+	for name, mz := range s.zoneToName {
+		zones = append(zones, newZone(name, mz))
+	}
+	s.mu.RUnlock()
+	return listedZones(s.zone, zones)
+}
 
-	req := s.api.ResourceRecordSets.List(s.project, mz)
-	err := req.Pages(ctx, func(page *dns.ResourceRecordSetsListResponse) error {
-		for _, rrset := range page.Rrsets {
-			rrsetName := strings.TrimSuffix(rrset.Name, ".")
-			if name != "" && name != rrsetName {
+// cacheKey returns the shared zone cache key of the zone. Managed zone
+// names are unique within the project.
+func (s *CloudDNS) cacheKey(zone string) string {
+	return zoneCacheKey(string(api.GoogleCloudDNSProvider), s.project, zone)
+}
+
+// googleManagedZoneName derives a managed zone name from the DNS name.
+// Managed zone names must start with a letter and may only contain
+// lower case letters, digits and dashes.
+func googleManagedZoneName(zone string) string {
+	name := strings.ToLower(strings.TrimSuffix(zone, "."))
+	name = strings.NewReplacer(".", "-", "_", "-").Replace(name)
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		name = "zone-" + name
+	}
+	return name
+}
+
+// CreateZone creates a public managed zone for the zone and refreshes
+// the managed zones so records can be managed in it straight away.
+func (s *CloudDNS) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	mz := &dns.ManagedZone{
+		Name:        googleManagedZoneName(zone),
+		DnsName:     zone + ".",
+		Description: "managed by dnsproviders",
+		Visibility:  "public",
+	}
+	s.logger.InfoContext(ctx, "create managed zone", "zone", zone, "name", mz.Name)
+	created, err := s.api.ManagedZones.Create(s.project, mz).Context(ctx).Do()
+	if err != nil {
+		return api.Zone{}, fmt.Errorf("create managed zone for %s failed, %s", zone, err)
+	}
+	if err := s.setManagedZones(ctx); err != nil {
+		return api.Zone{}, fmt.Errorf("refresh of managed zones failed, %v", err)
+	}
+	return newZone(created.DnsName, created.Name), nil
+}
+
+// DeleteZone deletes the managed zone for the zone. Cloud DNS requires
+// all records other than the apex NS and SOA records to be deleted
+// first.
+func (s *CloudDNS) DeleteZone(ctx context.Context, zone string) error {
+	zone = strings.TrimSuffix(zone, ".")
+	mz, err := s.managedZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "delete managed zone", "zone", zone, "name", mz)
+	if err := s.api.ManagedZones.Delete(s.project, mz).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("delete managed zone %s failed, %s", mz, err)
+	}
+	s.mu.Lock()
+	delete(s.zoneToName, zone)
+	s.mu.Unlock()
+	s.zoneCache.remove(s.cacheKey(zone))
+	return nil
+}
+
+// managedZone returns the name of the managed zone for the zone. On a
+// miss the shared zone cache is checked if enabled, and failing that
+// the managed zones are refreshed once, since the zone may have been
+// created or renamed after they were last listed.
+func (s *CloudDNS) managedZone(ctx context.Context, zone string) (string, error) {
+	if mz, ok := s.lookupZone(zone); ok {
+		return mz, nil
+	}
+	if mz, ok := s.zoneCache.get(s.cacheKey(zone)); ok {
+		return mz, nil
+	}
+	s.logger.DebugContext(ctx, "zone not found, refreshing managed zones", "zone", zone)
+	if err := s.setManagedZones(ctx); err != nil {
+		return "", fmt.Errorf("refresh of managed zones failed, %v", err)
+	}
+	if mz, ok := s.lookupZone(zone); ok {
+		return mz, nil
+	}
+	return "", fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+}
+
+func (s *CloudDNS) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	records := []api.Record{}
+	err := s.ListDNSRecordPages(ctx, zone, func(page []api.Record) error {
+		for _, record := range page {
+			if name != "" && !strings.EqualFold(strings.TrimSuffix(name, "."), record.Name) {
 				continue
 			}
-			record := api.Record{
-				Type:    rrset.Type,
-				Name:    rrsetName,
-				Content: rrset.Rrdatas,
-				TTL:     int(rrset.Ttl),
-			}
 			records = append(records, record)
 		}
 		return nil
@@ -124,56 +293,339 @@ func (s *CloudDNS) GetDNSRecords(ctx context.Context, zone, name string) ([]api.
 	return records, nil
 }
 
-func (s *CloudDNS) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
-	if !strings.HasSuffix(name, ".") {
-		name += "."
-	}
-	mz, ok := s.zoneToName[zone]
-	if !ok {
-		return fmt.Errorf("no managed zone found for %s", zone)
+// ListDNSRecordPages calls fn with each page of records in the zone.
+func (s *CloudDNS) ListDNSRecordPages(ctx context.Context, zone string, fn func([]api.Record) error) error {
+	mz, err := s.managedZone(ctx, zone)
+	if err != nil {
+		return err
 	}
-	var existing *dns.ResourceRecordSet
-	noUpdateNeeded := false
 	req := s.api.ResourceRecordSets.List(s.project, mz)
-	err := req.Pages(ctx, func(page *dns.ResourceRecordSetsListResponse) error {
+	return req.Pages(ctx, func(page *dns.ResourceRecordSetsListResponse) error {
+		records := []api.Record{}
 		for _, rrset := range page.Rrsets {
-			if name == rrset.Name && rtype == rrset.Type {
-				existing = rrset
-				if len(rrset.Rrdatas) > 0 && content == rrset.Rrdatas[0] && int64(ttl) == rrset.Ttl {
-					noUpdateNeeded = true
-				}
-				break
+			for _, record := range rrsetToRecords(rrset) {
+				record.System = isSystemRecord(zone, record.Name, record.Type)
+				records = append(records, fromPresentation(record)...)
+			}
+		}
+		return fn(records)
+	})
+}
+
+// rrsetToRecords converts the rrset into records, with one record per
+// routing variant if the rrset has a geo or weighted routing policy.
+func rrsetToRecords(rrset *dns.ResourceRecordSet) []api.Record {
+	record := api.Record{
+		Type:    rrset.Type,
+		Name:    strings.TrimSuffix(rrset.Name, "."),
+		Content: rrset.Rrdatas,
+		TTL:     int(rrset.Ttl),
+	}
+	policy := rrset.RoutingPolicy
+	if policy == nil {
+		return []api.Record{record}
+	}
+	records := []api.Record{}
+	if policy.Geo != nil {
+		for _, item := range policy.Geo.Items {
+			variant := record
+			variant.Content = item.Rrdatas
+			variant.RoutingPolicy = &api.RoutingPolicy{
+				Type:     api.RoutingPolicyGeo,
+				Location: item.Location,
+			}
+			records = append(records, variant)
+		}
+	}
+	if policy.Wrr != nil {
+		for _, item := range policy.Wrr.Items {
+			variant := record
+			variant.Content = item.Rrdatas
+			variant.RoutingPolicy = &api.RoutingPolicy{
+				Type:   api.RoutingPolicyWeighted,
+				Weight: item.Weight,
+			}
+			records = append(records, variant)
+		}
+	}
+	if len(records) == 0 {
+		// other policies, such as primary/backup, are not expanded
+		return []api.Record{record}
+	}
+	return records
+}
+
+// googleDNSSECAlgorithms maps the key algorithm names used by Google
+// Cloud DNS to their DNSSEC algorithm numbers.
+var googleDNSSECAlgorithms = map[string]int{
+	"rsasha1":         5,
+	"rsasha256":       8,
+	"rsasha512":       10,
+	"ecdsap256sha256": 13,
+	"ecdsap384sha384": 14,
+}
+
+// googleDSDigestTypes maps the digest names used by Google Cloud DNS
+// to their DS digest type numbers.
+var googleDSDigestTypes = map[string]int{
+	"sha1":   1,
+	"sha256": 2,
+	"sha384": 4,
+}
+
+// GetDNSSECStatus returns the DNSSEC status of the zone, with DS
+// records for each digest of the active key signing keys.
+func (s *CloudDNS) GetDNSSECStatus(ctx context.Context, zone string) (api.DNSSECStatus, error) {
+	status := api.DNSSECStatus{}
+	mz, err := s.managedZone(ctx, zone)
+	if err != nil {
+		return status, err
+	}
+	managedZone, err := s.api.ManagedZones.Get(s.project, mz).Context(ctx).Do()
+	if err != nil {
+		return status, fmt.Errorf("get managed zone %s failed, %s", mz, err)
+	}
+	if managedZone.DnssecConfig == nil || managedZone.DnssecConfig.State == "" {
+		status.State = "off"
+		return status, nil
+	}
+	status.State = managedZone.DnssecConfig.State
+	status.Enabled = status.State != "off"
+	if !status.Enabled {
+		return status, nil
+	}
+	dsRecords, err := s.dsRecords(ctx, mz)
+	if err != nil {
+		return status, err
+	}
+	for _, ds := range dsRecords {
+		status.DSRecords = append(status.DSRecords, ds.String())
+	}
+	return status, nil
+}
+
+// dsRecords returns the DS records for each digest of the active key
+// signing keys of the managed zone.
+func (s *CloudDNS) dsRecords(ctx context.Context, mz string) ([]api.DSRecord, error) {
+	dsRecords := []api.DSRecord{}
+	err := s.api.DnsKeys.List(s.project, mz).Pages(ctx, func(page *dns.DnsKeysListResponse) error {
+		for _, key := range page.DnsKeys {
+			if key.Type != "keySigning" || !key.IsActive {
+				continue
+			}
+			for _, digest := range key.Digests {
+				dsRecords = append(dsRecords, api.DSRecord{
+					KeyTag:     int(key.KeyTag),
+					Algorithm:  googleDNSSECAlgorithms[key.Algorithm],
+					DigestType: googleDSDigestTypes[digest.Type],
+					Digest:     strings.ToUpper(digest.Digest),
+				})
 			}
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, fmt.Errorf("list DNS keys for managed zone %s failed, %s", mz, err)
+	}
+	return dsRecords, nil
+}
+
+// EnableDNSSEC turns on DNSSEC for the managed zone and waits for the
+// key signing key to be generated. It returns the DS record with the
+// SHA-256 digest if there is one, as most registrars expect.
+func (s *CloudDNS) EnableDNSSEC(ctx context.Context, zone string) (api.DSRecord, error) {
+	mz, err := s.managedZone(ctx, zone)
+	if err != nil {
+		return api.DSRecord{}, err
+	}
+	if err := s.setDNSSECState(ctx, zone, mz, "on"); err != nil {
+		return api.DSRecord{}, err
+	}
+	dsRecords, err := s.dsRecords(ctx, mz)
+	if err != nil {
+		return api.DSRecord{}, err
+	}
+	if len(dsRecords) == 0 {
+		return api.DSRecord{}, fmt.Errorf("managed zone %s has no active key signing key", mz)
+	}
+	for _, ds := range dsRecords {
+		if ds.DigestType == googleDSDigestTypes["sha256"] {
+			return ds, nil
+		}
+	}
+	return dsRecords[0], nil
+}
+
+// DisableDNSSEC turns off DNSSEC for the managed zone and waits for
+// the change to complete.
+func (s *CloudDNS) DisableDNSSEC(ctx context.Context, zone string) error {
+	mz, err := s.managedZone(ctx, zone)
 	if err != nil {
 		return err
 	}
-	if noUpdateNeeded {
-		s.logger.InfoContext(ctx, "update dns record not needed", "record", *existing)
+	return s.setDNSSECState(ctx, zone, mz, "off")
+}
+
+// setDNSSECState patches the DNSSEC state of the managed zone, unless
+// it is already in that state, and polls the operation until it is
+// done.
+func (s *CloudDNS) setDNSSECState(ctx context.Context, zone, mz, state string) error {
+	managedZone, err := s.api.ManagedZones.Get(s.project, mz).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("get managed zone %s failed, %s", mz, err)
+	}
+	current := "off"
+	if managedZone.DnssecConfig != nil && managedZone.DnssecConfig.State != "" {
+		current = managedZone.DnssecConfig.State
+	}
+	if current == state {
+		return nil
+	}
+	s.logger.InfoContext(ctx, "set DNSSEC state", "zone", zone, "managedZone", mz, "state", state)
+	op, err := s.api.ManagedZones.Patch(s.project, mz, &dns.ManagedZone{
+		DnssecConfig: &dns.ManagedZoneDnsSecConfig{State: state},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("set DNSSEC state of managed zone %s to %s failed, %s", mz, state, err)
+	}
+	for op.Status != googleChangeDone {
+		s.logger.DebugContext(ctx, "waiting for managed zone operation", "zone", zone, "id", op.Id, "status", op.Status)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.pollInterval):
+		}
+		id := op.Id
+		op, err = s.api.ManagedZoneOperations.Get(s.project, mz, id).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("get operation %s failed, %s", id, err)
+		}
 	}
+	return nil
+}
 
-	if existing != nil {
-		// update existing
-		existing.Rrdatas = []string{content}
-		existing.Ttl = int64(ttl)
-		s.logger.InfoContext(ctx, "update dns record", "new", existing)
-		resp, err := s.api.ResourceRecordSets.Patch(s.project, mz, name, rtype, existing).Context(ctx).Do()
-		if err != nil && !googleapi.IsNotModified(err) {
-			return fmt.Errorf("update existing dns record failed, %s", err)
+// GetZone returns the managed zone's name servers and whether it is
+// signed with DNSSEC. The ID is the managed zone name, as returned by
+// ListZones. Google Cloud DNS reports no zone status.
+func (s *CloudDNS) GetZone(ctx context.Context, zone string) (api.ZoneInfo, error) {
+	mz, err := s.managedZone(ctx, zone)
+	if err != nil {
+		return api.ZoneInfo{}, err
+	}
+	managedZone, err := s.api.ManagedZones.Get(s.project, mz).Context(ctx).Do()
+	if err != nil {
+		return api.ZoneInfo{}, fmt.Errorf("get managed zone %s failed, %s", mz, err)
+	}
+	info := api.ZoneInfo{
+		Name:        strings.TrimSuffix(managedZone.DnsName, "."),
+		ID:          managedZone.Name,
+		NameServers: nameServers(managedZone.NameServers),
+	}
+	if config := managedZone.DnssecConfig; config != nil {
+		info.DNSSECEnabled = config.State != "" && config.State != "off"
+	}
+	return info, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *CloudDNS) SupportedRecordTypes() []string {
+	return slices.Clone(presentationRecordTypes)
+}
+
+// Capabilities reports that Google Cloud DNS can manage zones, apply
+// batches atomically and manage DNSSEC.
+func (s *CloudDNS) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+		SupportsBatch:          true,
+		SupportsDNSSEC:         true,
+	}
+}
+
+func (s *CloudDNS) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	_, err := s.setRecordSet(ctx, zone, name, rtype, []string{content}, ttl, proxy)
+	return err
+}
+
+// CreateOrUpdateDNSRecordChange is CreateOrUpdateDNSRecord, returning
+// the handle of the change if one was submitted. Creates and updates
+// are both submitted as changes, so either can be waited for. The
+// handle is nil if no change was needed or it was already done.
+func (s *CloudDNS) CreateOrUpdateDNSRecordChange(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) (*api.ChangeHandle, error) {
+	return s.setRecordSet(ctx, zone, name, rtype, []string{content}, ttl, proxy)
+}
+
+// CreateOrUpdateDNSRecordSet sets the complete list of values for the
+// name and type, replacing any existing values. No change is made if
+// the existing values, in any order, and TTL already match.
+func (s *CloudDNS) CreateOrUpdateDNSRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) error {
+	_, err := s.setRecordSet(ctx, zone, name, rtype, contents, ttl, proxy)
+	return err
+}
+
+func (s *CloudDNS) setRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) (*api.ChangeHandle, error) {
+	if err := checkProxy(api.GoogleCloudDNSProvider, proxy); err != nil {
+		return nil, err
+	}
+	if err := checkRecord(name, rtype, presentationRecordTypes); err != nil {
+		return nil, err
+	}
+	if err := validateContent(name, rtype, ttl, contents...); err != nil {
+		return nil, err
+	}
+	if err := checkDelegation(zone, name, rtype); err != nil {
+		return nil, err
+	}
+	if len(contents) == 0 {
+		return nil, fmt.Errorf("no content specified for %s", name)
+	}
+	// record sets are keyed by name and upper case type, so other
+	// types of the same name are never matched or changed
+	rtype = strings.ToUpper(rtype)
+	rrdatas := []string{}
+	for _, content := range contents {
+		rrdata, err := toPresentation(rtype, content)
+		if err != nil {
+			return nil, err
 		}
-		if err := responseError(&resp.ServerResponse); err != nil {
-			return fmt.Errorf("update existing dns record failed, %s", err)
+		rrdatas = append(rrdatas, rrdata)
+	}
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	mz, err := s.managedZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.findRecordSet(ctx, mz, name, rtype)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && sameValues(existing.Rrdatas, rrdatas) && int64(ttl) == existing.Ttl {
+		s.logger.DebugContext(ctx, "update dns record not needed", "record", *existing)
+		return nil, nil
+	}
+
+	if existing != nil {
+		// update existing by replacing the record set in one change,
+		// which unlike a patch can be tracked until it is done
+		updated := *existing
+		updated.Rrdatas = rrdatas
+		updated.Ttl = int64(ttl)
+		s.logger.InfoContext(ctx, "update dns record", "new", updated)
+		handle, err := s.replaceRecordSet(ctx, zone, existing, &updated)
+		if err != nil {
+			return nil, fmt.Errorf("update existing dns record failed, %s", err)
 		}
-		return nil
+		return handle, nil
 	}
 
 	// create new
 	rrset := dns.ResourceRecordSet{
 		Name:    name,
 		Type:    rtype,
-		Rrdatas: []string{content},
+		Rrdatas: rrdatas,
 		Ttl:     int64(ttl),
 	}
 	s.logger.InfoContext(ctx, "create dns record", "new", rrset)
@@ -181,67 +633,300 @@ func (s *CloudDNS) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtyp
 	change.Additions = []*dns.ResourceRecordSet{
 		&rrset,
 	}
-	err = s.changeDNSRecords(ctx, zone, &change)
+	handle, err := s.changeDNSRecords(ctx, zone, &change)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dns entry for %s, %s", name, err)
+	}
+	return handle, nil
+}
+
+// findRecordSet returns the record set of the fully qualified name and
+// type in the managed zone, or nil if there is none.
+func (s *CloudDNS) findRecordSet(ctx context.Context, mz, name, rtype string) (*dns.ResourceRecordSet, error) {
+	var existing *dns.ResourceRecordSet
+	req := s.api.ResourceRecordSets.List(s.project, mz)
+	err := req.Pages(ctx, func(page *dns.ResourceRecordSetsListResponse) error {
+		for _, rrset := range page.Rrsets {
+			if existing == nil && strings.EqualFold(name, rrset.Name) && strings.EqualFold(rtype, rrset.Type) {
+				existing = rrset
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// replaceRecordSet submits a change replacing the existing record set
+// with the updated one. The deletion must match the existing record
+// set exactly.
+func (s *CloudDNS) replaceRecordSet(ctx context.Context, zone string, existing, updated *dns.ResourceRecordSet) (*api.ChangeHandle, error) {
+	change := dns.Change{
+		Deletions: []*dns.ResourceRecordSet{existing},
+		Additions: []*dns.ResourceRecordSet{updated},
+	}
+	return s.changeDNSRecords(ctx, zone, &change)
+}
+
+// UpdateTTL changes only the TTL of the record set of the name and
+// type, keeping its Rrdatas.
+func (s *CloudDNS) UpdateTTL(ctx context.Context, zone, name, rtype string, ttl int) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to update")
+	}
+	rtype = strings.ToUpper(rtype)
+	name = strings.TrimSuffix(name, ".") + "."
+	mz, err := s.managedZone(ctx, zone)
 	if err != nil {
-		return fmt.Errorf("failed to create dns entry for %s, %s", name, err)
+		return err
+	}
+	existing, err := s.findRecordSet(ctx, mz, name, rtype)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("%w: zone %s name %s type %s", api.ErrRecordNotFound, zone, name, rtype)
+	}
+	if existing.Ttl == int64(ttl) {
+		s.logger.DebugContext(ctx, "update dns record ttl not needed", "name", name, "ttl", ttl)
+		return nil
+	}
+	updated := *existing
+	updated.Ttl = int64(ttl)
+	s.logger.InfoContext(ctx, "update dns record ttl", "name", name, "type", rtype, "ttl", ttl)
+	if _, err := s.replaceRecordSet(ctx, zone, existing, &updated); err != nil {
+		return fmt.Errorf("update dns record ttl failed, %s", err)
 	}
 	return nil
 }
 
-func (s *CloudDNS) changeDNSRecords(ctx context.Context, zone string, change *dns.Change) error {
-	mz, ok := s.zoneToName[zone]
-	if !ok {
-		return fmt.Errorf("no managed zone found for %s", zone)
+// BatchCreateOrUpdateDNSRecords submits all of the records as a single
+// change, so either every record is applied or none are. Records of
+// the same name and type are merged into one record set. Record sets
+// whose values and TTL already match are left out of the change.
+func (s *CloudDNS) BatchCreateOrUpdateDNSRecords(ctx context.Context, zone string, records []api.Record) error {
+	mz, err := s.managedZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	desired := []*dns.ResourceRecordSet{}
+	index := map[string]int{}
+	for _, record := range records {
+		if err := checkProxy(api.GoogleCloudDNSProvider, record.Proxied); err != nil {
+			return err
+		}
+		if err := checkRecord(record.Name, record.Type, presentationRecordTypes); err != nil {
+			return err
+		}
+		if err := checkDelegation(zone, record.Name, record.Type); err != nil {
+			return err
+		}
+		if err := api.ValidateRecord(record); err != nil {
+			return err
+		}
+		if len(record.Content) == 0 {
+			return fmt.Errorf("no content specified for %s", record.Name)
+		}
+		record.Type = strings.ToUpper(record.Type)
+		rrdatas := []string{}
+		for _, content := range record.Content {
+			rrdata, err := toPresentation(record.Type, writeContent(record, content))
+			if err != nil {
+				return err
+			}
+			rrdatas = append(rrdatas, rrdata)
+		}
+		name := strings.TrimSuffix(record.Name, ".") + "."
+		key := strings.ToLower(name) + "/" + record.Type
+		if ii, ok := index[key]; ok {
+			desired[ii].Rrdatas = append(desired[ii].Rrdatas, rrdatas...)
+			continue
+		}
+		index[key] = len(desired)
+		desired = append(desired, &dns.ResourceRecordSet{
+			Name:    name,
+			Type:    record.Type,
+			Rrdatas: rrdatas,
+			Ttl:     int64(record.TTL),
+		})
+	}
+
+	existing := map[string]*dns.ResourceRecordSet{}
+	req := s.api.ResourceRecordSets.List(s.project, mz)
+	err = req.Pages(ctx, func(page *dns.ResourceRecordSetsListResponse) error {
+		for _, rrset := range page.Rrsets {
+			existing[strings.ToLower(rrset.Name)+"/"+rrset.Type] = rrset
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	change := dns.Change{}
+	for _, rrset := range desired {
+		cur, ok := existing[strings.ToLower(rrset.Name)+"/"+rrset.Type]
+		if ok {
+			if sameValues(cur.Rrdatas, rrset.Rrdatas) && cur.Ttl == rrset.Ttl {
+				continue
+			}
+			change.Deletions = append(change.Deletions, cur)
+		}
+		change.Additions = append(change.Additions, rrset)
+	}
+	if len(change.Additions) == 0 {
+		s.logger.DebugContext(ctx, "batch update dns records not needed", "zone", zone)
+		return nil
+	}
+	s.logger.InfoContext(ctx, "batch update dns records", "zone", zone, "additions", len(change.Additions), "deletions", len(change.Deletions))
+	if _, err := s.changeDNSRecords(ctx, zone, &change); err != nil {
+		return fmt.Errorf("batch update of dns records in %s failed, %s", zone, err)
+	}
+	return nil
+}
+
+// sameValues reports whether the two lists hold the same values,
+// ignoring order.
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = slices.Clone(a)
+	b = slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// changeDNSRecords submits the change, returning its handle if it
+// is still pending.
+func (s *CloudDNS) changeDNSRecords(ctx context.Context, zone string, change *dns.Change) (*api.ChangeHandle, error) {
+	mz, err := s.managedZone(ctx, zone)
+	if err != nil {
+		return nil, err
 	}
 	resp, err := s.api.Changes.Create(s.project, mz, change).Context(ctx).Do()
 	if err != nil {
 		if googleapi.IsNotModified(err) {
-			return nil
+			return nil, nil
 		}
-		return err
+		return nil, err
 	}
 	if err := responseError(&resp.ServerResponse); err != nil {
+		return nil, err
+	}
+	if resp.Status == googleChangeDone {
+		return nil, nil
+	}
+	return &api.ChangeHandle{
+		Zone: zone,
+		ID:   resp.Id,
+	}, nil
+}
+
+// WaitForChange polls the change until its status is done.
+func (s *CloudDNS) WaitForChange(ctx context.Context, handle *api.ChangeHandle) error {
+	if handle == nil {
+		return nil
+	}
+	mz, err := s.managedZone(ctx, handle.Zone)
+	if err != nil {
 		return err
 	}
-	return nil
+	for {
+		change, err := s.api.Changes.Get(s.project, mz, handle.ID).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("get change %s failed, %s", handle.ID, err)
+		}
+		if change.Status == googleChangeDone {
+			return nil
+		}
+		s.logger.DebugContext(ctx, "waiting for dns change", "zone", handle.Zone, "id", handle.ID, "status", change.Status)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.pollInterval):
+		}
+	}
 }
 
 func (s *CloudDNS) DeleteDNSRecord(ctx context.Context, zone, name string) error {
-	mz, ok := s.zoneToName[zone]
-	if !ok {
-		return fmt.Errorf("no managed zone found for %s", zone)
+	_, err := s.DeleteDNSRecordChange(ctx, zone, name)
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number of values in the deleted record sets.
+func (s *CloudDNS) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	_, count, err := s.deleteRecords(ctx, zone, name, "")
+	return count, err
+}
+
+// DeleteDNSRecordChange is DeleteDNSRecord, returning the handle of
+// the change if one was submitted.
+func (s *CloudDNS) DeleteDNSRecordChange(ctx context.Context, zone, name string) (*api.ChangeHandle, error) {
+	handle, _, err := s.deleteRecords(ctx, zone, name, "")
+	return handle, err
+}
+
+// DeleteDNSRecordByType deletes only the record set of the given type
+// for the name.
+func (s *CloudDNS) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, _, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+// deleteRecords deletes the matching record sets, returning the change
+// and the number of values deleted.
+func (s *CloudDNS) deleteRecords(ctx context.Context, zone, name, rtype string) (*api.ChangeHandle, int, error) {
+	mz, err := s.managedZone(ctx, zone)
+	if err != nil {
+		return nil, 0, err
 	}
 	if name == "" {
-		return fmt.Errorf("no name specified to delete")
+		return nil, 0, fmt.Errorf("no name specified to delete")
 	}
 	if !strings.HasSuffix(name, ".") {
 		name += "."
 	}
 
 	change := dns.Change{}
+	count := 0
 
 	req := s.api.ResourceRecordSets.List(s.project, mz)
-	err := req.Pages(ctx, func(page *dns.ResourceRecordSetsListResponse) error {
+	err = req.Pages(ctx, func(page *dns.ResourceRecordSetsListResponse) error {
 		for _, rrset := range page.Rrsets {
-			if name != "" && name != rrset.Name {
+			if !strings.EqualFold(name, rrset.Name) {
+				continue
+			}
+			if rtype != "" && !strings.EqualFold(rtype, rrset.Type) {
 				continue
 			}
 			// Note: ResourceRecordSet must match exactly to delete
 			change.Deletions = append(change.Deletions, rrset)
+			count += len(rrset.Rrdatas)
 		}
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
-	err = s.changeDNSRecords(ctx, zone, &change)
+	handle, err := s.changeDNSRecords(ctx, zone, &change)
 	if err != nil {
-		return fmt.Errorf("failed to delete dns entries for %s, %s", name, err)
+		return nil, 0, fmt.Errorf("failed to delete dns entries for %s, %s", name, err)
 	}
-	return nil
+	return handle, count, nil
 }
 
 func responseError(resp *googleapi.ServerResponse) error {
+	if resp == nil {
+		return nil
+	}
 	code := resp.HTTPStatusCode
 	if code >= 200 && code < 300 {
 		return nil
@@ -17,11 +17,19 @@ package dnsproviders
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
+	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/edgexr/dnsproviders/api"
 	"github.com/stretchr/testify/require"
+	dns "google.golang.org/api/dns/v1"
 )
 
 func TestGoogleCloudDNS(t *testing.T) {
@@ -44,3 +52,883 @@ func TestGoogleCloudDNS(t *testing.T) {
 
 	ProviderTest(t, ctx, prov, domain)
 }
+
+// gcdnsStub is a minimal in-memory implementation of the Cloud DNS API.
+type gcdnsStub struct {
+	mu          sync.Mutex
+	zones       []*dns.ManagedZone
+	rrsets      map[string][]*dns.ResourceRecordSet // keyed by managed zone name
+	pageSize    int                                 // 0 returns everything in one page
+	notModified bool                                // answer changes with 304 Not Modified
+	requests    []string
+	// if set, changes are pending until polled this many times
+	pendingPolls int
+	polls        map[string]int
+	dnsKeys      map[string][]*dns.DnsKey // keyed by managed zone name
+}
+
+func newGCDNSStub(zones ...string) *gcdnsStub {
+	s := &gcdnsStub{
+		rrsets: map[string][]*dns.ResourceRecordSet{},
+	}
+	for _, zone := range zones {
+		s.addZone(zone)
+	}
+	return s
+}
+
+func (s *gcdnsStub) addZone(zone string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.zones = append(s.zones, &dns.ManagedZone{
+		Name:        strings.ReplaceAll(zone, ".", "-"),
+		DnsName:     zone + ".",
+		NameServers: []string{"ns-cloud-a1.googledomains.com.", "ns-cloud-a2.googledomains.com."},
+	})
+}
+
+// count returns the number of requests received with the given
+// method whose path contains substr.
+func (s *gcdnsStub) count(method, substr string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, req := range s.requests {
+		if strings.HasPrefix(req, method+" ") && strings.Contains(req, substr) {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *gcdnsStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	prefix := "/dns/v1/projects/{project}/managedZones"
+	mux.HandleFunc("GET "+prefix, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(dns.ManagedZonesListResponse{
+			ManagedZones: s.zones,
+		})
+	})
+	mux.HandleFunc("POST "+prefix, func(w http.ResponseWriter, r *http.Request) {
+		mz := dns.ManagedZone{}
+		json.NewDecoder(r.Body).Decode(&mz)
+		s.zones = append(s.zones, &mz)
+		json.NewEncoder(w).Encode(mz)
+	})
+	mux.HandleFunc("DELETE "+prefix+"/{mz}", func(w http.ResponseWriter, r *http.Request) {
+		for ii, mz := range s.zones {
+			if mz.Name == r.PathValue("mz") {
+				s.zones = append(s.zones[:ii], s.zones[ii+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET "+prefix+"/{mz}", func(w http.ResponseWriter, r *http.Request) {
+		for _, mz := range s.zones {
+			if mz.Name == r.PathValue("mz") {
+				json.NewEncoder(w).Encode(mz)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("PATCH "+prefix+"/{mz}", func(w http.ResponseWriter, r *http.Request) {
+		patch := dns.ManagedZone{}
+		json.NewDecoder(r.Body).Decode(&patch)
+		name := r.PathValue("mz")
+		for _, mz := range s.zones {
+			if mz.Name != name {
+				continue
+			}
+			if patch.DnssecConfig != nil {
+				mz.DnssecConfig = patch.DnssecConfig
+				if s.dnsKeys == nil {
+					s.dnsKeys = map[string][]*dns.DnsKey{}
+				}
+				// signing generates the keys, which are deleted again
+				// once it is turned off
+				s.dnsKeys[name] = nil
+				if patch.DnssecConfig.State == "on" {
+					s.dnsKeys[name] = []*dns.DnsKey{{
+						Type:      "keySigning",
+						IsActive:  true,
+						Algorithm: "rsasha256",
+						KeyTag:    4321,
+						Digests: []*dns.DnsKeyDigest{{
+							Type:   "sha1",
+							Digest: "0a1b2c",
+						}, {
+							Type:   "sha256",
+							Digest: "3d4e5f",
+						}},
+					}}
+				}
+			}
+			op := dns.Operation{
+				Id:     "op-" + name,
+				Status: "done",
+			}
+			if s.pendingPolls > 0 {
+				op.Status = "pending"
+			}
+			json.NewEncoder(w).Encode(op)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET "+prefix+"/{mz}/operations/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if s.polls == nil {
+			s.polls = map[string]int{}
+		}
+		s.polls[id]++
+		op := dns.Operation{
+			Id:     id,
+			Status: "done",
+		}
+		if s.polls[id] < s.pendingPolls {
+			op.Status = "pending"
+		}
+		json.NewEncoder(w).Encode(op)
+	})
+	mux.HandleFunc("GET "+prefix+"/{mz}/dnsKeys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(dns.DnsKeysListResponse{
+			DnsKeys: s.dnsKeys[r.PathValue("mz")],
+		})
+	})
+	mux.HandleFunc("GET "+prefix+"/{mz}/rrsets", func(w http.ResponseWriter, r *http.Request) {
+		rrsets := s.rrsets[r.PathValue("mz")]
+		resp := dns.ResourceRecordSetsListResponse{}
+		start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		end := len(rrsets)
+		if s.pageSize > 0 && start+s.pageSize < end {
+			end = start + s.pageSize
+			resp.NextPageToken = strconv.Itoa(end)
+		}
+		resp.Rrsets = rrsets[start:end]
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("POST "+prefix+"/{mz}/changes", func(w http.ResponseWriter, r *http.Request) {
+		mz := r.PathValue("mz")
+		if s.notModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		change := dns.Change{}
+		json.NewDecoder(r.Body).Decode(&change)
+		// a record set deleted and added again in the same change
+		// keeps its position in the listing
+		replaced := map[string]int{}
+		for _, del := range change.Deletions {
+			kept := []*dns.ResourceRecordSet{}
+			found := false
+			for ii, rrset := range s.rrsets[mz] {
+				if rrset.Name == del.Name && rrset.Type == del.Type {
+					replaced[del.Name+"/"+del.Type] = ii
+					found = true
+					continue
+				}
+				kept = append(kept, rrset)
+			}
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			s.rrsets[mz] = kept
+		}
+		for _, add := range change.Additions {
+			for _, rrset := range s.rrsets[mz] {
+				if rrset.Name == add.Name && rrset.Type == add.Type {
+					w.WriteHeader(http.StatusConflict)
+					return
+				}
+			}
+			if ii, ok := replaced[add.Name+"/"+add.Type]; ok && ii <= len(s.rrsets[mz]) {
+				s.rrsets[mz] = slices.Insert(s.rrsets[mz], ii, add)
+				continue
+			}
+			s.rrsets[mz] = append(s.rrsets[mz], add)
+		}
+		change.Id = strconv.Itoa(len(s.requests))
+		change.Status = "done"
+		if s.pendingPolls > 0 {
+			change.Status = "pending"
+		}
+		json.NewEncoder(w).Encode(change)
+	})
+	mux.HandleFunc("GET "+prefix+"/{mz}/changes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if s.polls == nil {
+			s.polls = map[string]int{}
+		}
+		s.polls[id]++
+		change := dns.Change{
+			Id:     id,
+			Status: "done",
+		}
+		if s.polls[id] < s.pendingPolls {
+			change.Status = "pending"
+		}
+		json.NewEncoder(w).Encode(change)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// newGCDNSTestProvider creates a Google Cloud DNS provider backed by
+// the stub.
+func newGCDNSTestProvider(t *testing.T, stub *gcdnsStub, ops ...Option) *CloudDNS {
+	ctx := context.Background()
+	client := newStubClient(t, stub.handler())
+	ops = append(ops, WithHTTPClient(client))
+	creds := map[string]string{
+		projectID: "test-project",
+	}
+	prov, err := NewGoogleCloudDNSProvider(ctx, "", creds, slog.Default(), ops...)
+	require.Nil(t, err)
+	return prov
+}
+
+func TestGoogleCloudDNSStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+}
+
+func TestGoogleCloudDNSProxy(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, true)
+	require.ErrorIs(t, err, api.ErrProxyNotSupported)
+	err = BatchCreateOrUpdateDNSRecords(ctx, prov, "example.com", []api.Record{{
+		Type:    "A",
+		Name:    "www.example.com",
+		Content: []string{"10.0.0.1"},
+		TTL:     300,
+		Proxied: true,
+	}})
+	require.ErrorIs(t, err, api.ErrProxyNotSupported)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Empty(t, records)
+}
+
+func TestGoogleCloudDNSMX(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, []string{"10 mail.example.com."}, stub.rrsets["example-com"][0].Rrdatas)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, 10, records[0].Priority)
+	require.Equal(t, []string{"mail.example.com."}, records[0].Content)
+}
+
+func TestGoogleCloudDNSTXT(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	long := "v=DKIM1; k=rsa; p=" + strings.Repeat("A", 300)
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "dkim.example.com", "TXT", long, 300, false)
+	require.Nil(t, err)
+	rrdata := stub.rrsets["example-com"][0].Rrdatas[0]
+	require.True(t, strings.HasPrefix(rrdata, `"v=DKIM1; k=rsa; p=`))
+	require.Equal(t, 2, strings.Count(rrdata, `" "`)+1)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "dkim.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, []string{long}, records[0].Content)
+}
+
+func TestGoogleCloudDNSSRV(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	name := "_ldap._tcp.example.com"
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", name, "SRV", "0 100 389 ldap.example.com", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, []string{"0 100 389 ldap.example.com."}, stub.rrsets["example-com"][0].Rrdatas)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", name)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, 0, records[0].Priority)
+	require.Equal(t, 100, records[0].Weight)
+	require.Equal(t, 389, records[0].Port)
+	require.Equal(t, []string{"ldap.example.com."}, records[0].Content)
+
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "ldap.example.com", "SRV", "0 100 389 ldap.example.com", 300, false)
+	require.NotNil(t, err)
+}
+
+func TestGoogleCloudDNSRoutingPolicy(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	stub.rrsets["example-com"] = []*dns.ResourceRecordSet{{
+		Name: "geo.example.com.",
+		Type: "A",
+		Ttl:  300,
+		RoutingPolicy: &dns.RRSetRoutingPolicy{
+			Geo: &dns.RRSetRoutingPolicyGeoPolicy{
+				Items: []*dns.RRSetRoutingPolicyGeoPolicyGeoPolicyItem{{
+					Location: "us-east1",
+					Rrdatas:  []string{"10.0.0.1"},
+				}, {
+					Location: "europe-west1",
+					Rrdatas:  []string{"10.0.1.1"},
+				}},
+			},
+		},
+	}}
+	prov := newGCDNSTestProvider(t, stub)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "geo.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	require.Equal(t, []string{"10.0.0.1"}, records[0].Content)
+	require.Equal(t, &api.RoutingPolicy{Type: api.RoutingPolicyGeo, Location: "us-east1"}, records[0].RoutingPolicy)
+	require.Equal(t, []string{"10.0.1.1"}, records[1].Content)
+	require.Equal(t, &api.RoutingPolicy{Type: api.RoutingPolicyGeo, Location: "europe-west1"}, records[1].RoutingPolicy)
+
+	// variants are not duplicates of each other
+	require.Empty(t, ValidateDesired(records))
+}
+
+func TestGoogleCloudDNSNoopUpdate(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.count("POST", "/changes"))
+
+	// same content and TTL does not submit a change
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.count("POST", "/changes"))
+
+	// a TTL change does
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 600, false)
+	require.Nil(t, err)
+	require.Equal(t, 2, stub.count("POST", "/changes"))
+}
+
+func TestGoogleCloudDNSRecordSet(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+	recordSetTest(t, ctx, prov, "example.com", "rr.example.com")
+	delegationTest(t, ctx, prov, "example.com", "sub.example.com")
+}
+
+func TestGoogleCloudDNSSameNameTypes(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "AAAA", "fd00::1", 300, false)
+	require.Nil(t, err)
+
+	// updating one type, given in any case, leaves the other alone
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "a", "10.0.0.2", 600, false)
+	require.Nil(t, err)
+	require.Equal(t, 3, stub.count(http.MethodPost, "/changes"))
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:    "www.example.com",
+		Type:    "A",
+		Content: []string{"10.0.0.2"},
+		TTL:     600,
+	}, {
+		Name:    "www.example.com",
+		Type:    "AAAA",
+		Content: []string{"fd00::1"},
+		TTL:     300,
+	}}, records)
+
+	// as does deleting one type
+	err = prov.DeleteDNSRecordByType(ctx, "example.com", "www.example.com", "aaaa")
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "A", records[0].Type)
+	require.Equal(t, []string{"10.0.0.2"}, records[0].Content)
+}
+
+func TestGoogleCloudDNSNotModified(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+
+	// a Not Modified change has no response, which is not an error
+	stub.notModified = true
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.2", 300, false)
+	require.Nil(t, err)
+	err = prov.UpdateTTL(ctx, "example.com", "www.example.com", "A", 600)
+	require.Nil(t, err)
+	require.Equal(t, 3, stub.count(http.MethodPost, "/changes"))
+	require.Nil(t, responseError(nil))
+}
+
+func TestGoogleCloudDNSMultiValue(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	addrs := []string{"10.0.0.1", "10.0.0.2"}
+	err := prov.CreateOrUpdateDNSRecordSet(ctx, "example.com", "rr.example.com", "A", addrs, 300, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "rr.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, addrs, records[0].Content)
+
+	// re-applying in any order is a no-op and keeps both addresses
+	err = prov.CreateOrUpdateDNSRecordSet(ctx, "example.com", "rr.example.com", "A", []string{"10.0.0.2", "10.0.0.1"}, 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.count("POST", "/changes"))
+	records, err = prov.GetDNSRecords(ctx, "example.com", "rr.example.com")
+	require.Nil(t, err)
+	require.Equal(t, addrs, records[0].Content)
+
+	// a single value is not mistaken for a match of the first value
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "rr.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 2, stub.count("POST", "/changes"))
+	records, err = prov.GetDNSRecords(ctx, "example.com", "rr.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.1"}, records[0].Content)
+
+	err = prov.CreateOrUpdateDNSRecordSet(ctx, "example.com", "rr.example.com", "A", nil, 300, false)
+	require.NotNil(t, err)
+}
+
+func TestGoogleCloudDNSUpdateTTL(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	addrs := []string{"10.0.0.1", "10.0.0.2"}
+	err := prov.CreateOrUpdateDNSRecordSet(ctx, "example.com", "ttl.example.com", "A", addrs, 300, false)
+	require.Nil(t, err)
+
+	err = UpdateTTL(ctx, prov, "example.com", "ttl.example.com", "a", 600)
+	require.Nil(t, err)
+	require.Equal(t, 2, stub.count("POST", "/changes"))
+	records, err := prov.GetDNSRecords(ctx, "example.com", "ttl.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, addrs, records[0].Content)
+	require.Equal(t, 600, records[0].TTL)
+
+	// unchanged TTL sends no update
+	err = prov.UpdateTTL(ctx, "example.com", "ttl.example.com", "A", 600)
+	require.Nil(t, err)
+	require.Equal(t, 2, stub.count("POST", "/changes"))
+
+	err = prov.UpdateTTL(ctx, "example.com", "ttl.example.com", "AAAA", 600)
+	require.ErrorIs(t, err, api.ErrRecordNotFound)
+}
+
+func TestGoogleCloudDNSWaitForChange(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	stub.pendingPolls = 3
+	prov := newGCDNSTestProvider(t, stub)
+	prov.pollInterval = time.Millisecond
+
+	handle, err := prov.CreateOrUpdateDNSRecordChange(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	require.NotNil(t, handle)
+	require.Equal(t, "example.com", handle.Zone)
+	err = prov.WaitForChange(ctx, handle)
+	require.Nil(t, err)
+	require.Equal(t, 3, stub.polls[handle.ID])
+
+	// an update of the existing record set can be waited for too
+	handle, err = prov.CreateOrUpdateDNSRecordChange(ctx, "example.com", "www.example.com", "A", "10.0.0.2", 300, false)
+	require.Nil(t, err)
+	require.NotNil(t, handle)
+	err = prov.WaitForChange(ctx, handle)
+	require.Nil(t, err)
+	require.Equal(t, 3, stub.polls[handle.ID])
+
+	// no change needed, nothing to wait for
+	handle, err = prov.CreateOrUpdateDNSRecordChange(ctx, "example.com", "www.example.com", "A", "10.0.0.2", 300, false)
+	require.Nil(t, err)
+	require.Nil(t, handle)
+	require.Nil(t, prov.WaitForChange(ctx, handle))
+
+	// waiting gives up when the context is done
+	handle, err = prov.DeleteDNSRecordChange(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.NotNil(t, handle)
+	stub.pendingPolls = 1000
+	prov.pollInterval = 10 * time.Millisecond
+	shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	err = prov.WaitForChange(shortCtx, handle)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestGoogleCloudDNSDNSSECStatus(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com", "example.org")
+	stub.zones[0].DnssecConfig = &dns.ManagedZoneDnsSecConfig{
+		State: "on",
+	}
+	stub.dnsKeys = map[string][]*dns.DnsKey{
+		"example-com": {{
+			Type:      "keySigning",
+			IsActive:  true,
+			Algorithm: "rsasha256",
+			KeyTag:    12345,
+			Digests: []*dns.DnsKeyDigest{{
+				Type:   "sha256",
+				Digest: "abcdef0123",
+			}},
+		}, {
+			Type:      "zoneSigning",
+			IsActive:  true,
+			Algorithm: "rsasha256",
+			KeyTag:    23456,
+		}, {
+			Type:      "keySigning",
+			IsActive:  false,
+			Algorithm: "rsasha256",
+			KeyTag:    34567,
+			Digests: []*dns.DnsKeyDigest{{
+				Type:   "sha256",
+				Digest: "0123456789",
+			}},
+		}},
+	}
+	prov := newGCDNSTestProvider(t, stub)
+
+	status, err := prov.GetDNSSECStatus(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, api.DNSSECStatus{
+		Enabled:   true,
+		State:     "on",
+		DSRecords: []string{"12345 8 2 ABCDEF0123"},
+	}, status)
+
+	status, err = prov.GetDNSSECStatus(ctx, "example.org")
+	require.Nil(t, err)
+	require.Equal(t, api.DNSSECStatus{State: "off"}, status)
+
+	_, err = prov.GetDNSSECStatus(ctx, "example.net")
+	require.NotNil(t, err)
+}
+
+func TestGoogleCloudDNSEnableDNSSEC(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	stub.pendingPolls = 2
+	prov := newGCDNSTestProvider(t, stub)
+	prov.pollInterval = time.Millisecond
+
+	ds, err := EnableDNSSEC(ctx, prov, "example.com")
+	require.Nil(t, err)
+	// the SHA-256 digest is preferred
+	require.Equal(t, api.DSRecord{
+		KeyTag:     4321,
+		Algorithm:  8,
+		DigestType: 2,
+		Digest:     "3D4E5F",
+	}, ds)
+	require.Equal(t, "4321 8 2 3D4E5F", ds.String())
+	require.Equal(t, 2, stub.count(http.MethodGet, "/operations/"))
+	status, err := prov.GetDNSSECStatus(ctx, "example.com")
+	require.Nil(t, err)
+	require.True(t, status.Enabled)
+	require.Equal(t, []string{"4321 8 1 0A1B2C", "4321 8 2 3D4E5F"}, status.DSRecords)
+
+	// enabling again does not patch the zone
+	_, err = prov.EnableDNSSEC(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.count(http.MethodPatch, "/managedZones/example-com"))
+
+	err = DisableDNSSEC(ctx, prov, "example.com")
+	require.Nil(t, err)
+	status, err = prov.GetDNSSECStatus(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, api.DNSSECStatus{State: "off"}, status)
+	err = prov.DisableDNSSEC(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, stub.count(http.MethodPatch, "/managedZones/example-com"))
+
+	_, err = prov.EnableDNSSEC(ctx, "example.net")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+}
+
+func TestGoogleCloudDNSGetZone(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com", "example.org")
+	stub.zones[0].DnssecConfig = &dns.ManagedZoneDnsSecConfig{
+		State: "on",
+	}
+	prov := newGCDNSTestProvider(t, stub)
+
+	info, err := prov.GetZone(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, api.ZoneInfo{
+		Name:          "example.com",
+		ID:            "example-com",
+		NameServers:   []string{"ns-cloud-a1.googledomains.com", "ns-cloud-a2.googledomains.com"},
+		DNSSECEnabled: true,
+	}, info)
+
+	info, err = prov.GetZone(ctx, "example.org")
+	require.Nil(t, err)
+	require.Equal(t, "example-org", info.ID)
+	require.False(t, info.DNSSECEnabled)
+
+	_, err = prov.GetZone(ctx, "example.net")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+}
+
+func TestGoogleCloudDNSBatch(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "api.example.com", "A", "10.0.0.3", 300, false)
+	require.Nil(t, err)
+
+	// one change updates, creates and leaves unchanged records
+	batch := []api.Record{{
+		Name:    "www.example.com",
+		Type:    "A",
+		Content: []string{"10.0.0.2"},
+		TTL:     300,
+	}, {
+		Name:    "www.example.com",
+		Type:    "AAAA",
+		Content: []string{"fd00::1", "fd00::2"},
+		TTL:     300,
+	}, {
+		Name:    "alias.example.com",
+		Type:    "CNAME",
+		Content: []string{"www.example.com"},
+		TTL:     300,
+	}, {
+		Name:    "api.example.com",
+		Type:    "A",
+		Content: []string{"10.0.0.3"},
+		TTL:     300,
+	}}
+	err = BatchCreateOrUpdateDNSRecords(ctx, prov, "example.com", batch)
+	require.Nil(t, err)
+	require.Equal(t, 3, stub.count("POST", "/changes"))
+	rrsets := map[string][]string{}
+	for _, rrset := range stub.rrsets["example-com"] {
+		rrsets[rrset.Name+"/"+rrset.Type] = rrset.Rrdatas
+	}
+	require.Equal(t, map[string][]string{
+		"www.example.com./A":       {"10.0.0.2"},
+		"www.example.com./AAAA":    {"fd00::1", "fd00::2"},
+		"alias.example.com./CNAME": {"www.example.com."},
+		"api.example.com./A":       {"10.0.0.3"},
+	}, rrsets)
+
+	// an invalid record fails the whole batch before anything is sent
+	batch[0].Content = []string{"10.0.0.4"}
+	batch = append(batch, api.Record{
+		Name:    "bad.example.com",
+		Type:    "HINFO",
+		Content: []string{"cpu os"},
+	})
+	err = prov.BatchCreateOrUpdateDNSRecords(ctx, "example.com", batch)
+	require.NotNil(t, err)
+	require.Equal(t, 3, stub.count("POST", "/changes"))
+}
+
+func TestGoogleCloudDNSZoneRefresh(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+	listZones := func() int {
+		count := 0
+		for _, req := range stub.requests {
+			if strings.HasSuffix(req, "/managedZones") {
+				count++
+			}
+		}
+		return count
+	}
+	require.Equal(t, 1, listZones())
+
+	// a zone created after the cache was populated is found by a
+	// single refresh
+	stub.addZone("example.org")
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.org", "www.example.org", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 2, listZones())
+	records, err := prov.GetDNSRecords(ctx, "example.org", "www.example.org")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, 2, listZones())
+
+	// an unknown zone refreshes once per operation, then fails
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.net", "www.example.net", "A", "10.0.0.1", 300, false)
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+	require.Contains(t, err.Error(), "no zone found for example.net")
+	require.Equal(t, 3, listZones())
+}
+
+func TestGoogleCloudDNSRefreshZones(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+	listZones := func() int {
+		count := 0
+		for _, req := range stub.requests {
+			if strings.HasSuffix(req, "/managedZones") {
+				count++
+			}
+		}
+		return count
+	}
+	require.Equal(t, 1, listZones())
+
+	// an explicit refresh picks up a zone added after construction
+	stub.addZone("example.org")
+	require.Nil(t, prov.RefreshZones(ctx))
+	require.Equal(t, 2, listZones())
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.org", "www.example.org", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.org", "www.example.org")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	err = prov.DeleteDNSRecord(ctx, "example.org", "www.example.org")
+	require.Nil(t, err)
+	require.Equal(t, 2, listZones())
+}
+
+func TestGoogleCloudDNSConcurrentRefresh(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+
+	// reads run concurrently with explicit refreshes and with
+	// refreshes triggered by lookups of a zone added meanwhile, and
+	// must be clean under -race
+	wg := sync.WaitGroup{}
+	for ii := 0; ii < 4; ii++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for jj := 0; jj < 10; jj++ {
+				records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+				require.Nil(t, err)
+				require.Equal(t, 1, len(records))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for jj := 0; jj < 5; jj++ {
+				require.Nil(t, prov.RefreshZones(ctx))
+				_, err := prov.ListZones(ctx)
+				require.Nil(t, err)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stub.addZone("example.org")
+		_, err := prov.GetDNSRecords(ctx, "example.org", "")
+		require.Nil(t, err)
+	}()
+	wg.Wait()
+}
+
+func TestGoogleCloudDNSListZones(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	// zones added after the provider was created are listed
+	stub.addZone("example.org")
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{
+		{Name: "example.com", ID: "example-com"},
+		{Name: "example.org", ID: "example-org"},
+	}, zones)
+}
+
+func TestGoogleCloudDNSZones(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	zone, err := prov.CreateZone(ctx, "1.example.org.")
+	require.Nil(t, err)
+	require.Equal(t, api.Zone{Name: "1.example.org", ID: "zone-1-example-org"}, zone)
+	require.Equal(t, "zone-1-example-org", prov.zoneToName["1.example.org"])
+
+	// records can be managed in the new zone straight away
+	err = prov.CreateOrUpdateDNSRecord(ctx, "1.example.org", "www.1.example.org", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+
+	err = prov.DeleteZone(ctx, "1.example.org")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(stub.zones))
+	err = prov.DeleteZone(ctx, "1.example.org")
+	require.NotNil(t, err)
+}
+
+func TestGoogleCloudDNSClose(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+	_, err := prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+
+	// a caller supplied client is left alone
+	require.Nil(t, prov.transport)
+	require.Nil(t, prov.Close())
+	require.Nil(t, prov.Close())
+
+	prov.transport = http.DefaultTransport.(*http.Transport).Clone()
+	require.Nil(t, prov.Close())
+	require.Nil(t, prov.Close())
+}
+
+func TestGoogleCloudDNSWildcard(t *testing.T) {
+	ctx := context.Background()
+	prov := newGCDNSTestProvider(t, newGCDNSStub("example.com"))
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com")
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com.")
+}
@@ -0,0 +1,350 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+const hetznerBaseURL = "https://dns.hetzner.com/api/v1"
+
+// Hetzner manages DNS records via the Hetzner DNS JSON API.
+// Hetzner stores record names relative to the zone with "@" for the
+// apex, so names are converted to and from the fully qualified form
+// used by this package.
+type Hetzner struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	logger  api.Logger
+	zone    string // zone the provider was configured for, if any
+	// shared zone ID cache, nil if disabled
+	zoneCache *zoneIDCache
+}
+
+type hetznerZone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type hetznerRecord struct {
+	ID     string `json:"id,omitempty"`
+	ZoneID string `json:"zone_id"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	TTL    int    `json:"ttl,omitempty"`
+}
+
+// NewHetznerProvider creates a new Hetzner DNS provider.
+func NewHetznerProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*Hetzner, error) {
+	return NewHetznerProviderWithCredentials(ctx, zone, hetznerCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewHetznerProviderWithCredentials creates a new Hetzner DNS provider
+// from typed credentials.
+func NewHetznerProviderWithCredentials(ctx context.Context, zone string, creds HetznerCredentials, logger api.Logger, ops ...Option) (*Hetzner, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	token := creds.Token
+	opts := getOptions(ops)
+	client := opts.httpClient()
+	return &Hetzner{
+		client:    client,
+		baseURL:   hetznerBaseURL,
+		token:     token,
+		logger:    logger,
+		zone:      zone,
+		zoneCache: opts.zoneCache,
+	}, nil
+}
+
+func (s *Hetzner) do(ctx context.Context, method, path string, in, out interface{}) error {
+	header := http.Header{}
+	header.Set("Auth-API-Token", s.token)
+	return doJSON(ctx, s.client, method, s.baseURL+path, header, in, out)
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *Hetzner) Close() error {
+	return nil
+}
+
+// ValidateCredentials lists at most one zone.
+func (s *Hetzner) ValidateCredentials(ctx context.Context) error {
+	return credentialsError(s.do(ctx, http.MethodGet, "/zones?per_page=1", nil, nil))
+}
+
+// ListZones returns the zones the token can access.
+func (s *Hetzner) ListZones(ctx context.Context) ([]api.Zone, error) {
+	resp := struct {
+		Zones []hetznerZone `json:"zones"`
+	}{}
+	if err := s.do(ctx, http.MethodGet, "/zones", nil, &resp); err != nil {
+		return nil, err
+	}
+	zones := []api.Zone{}
+	for _, z := range resp.Zones {
+		zones = append(zones, newZone(z.Name, z.ID))
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone creates the zone.
+func (s *Hetzner) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	resp := struct {
+		Zone hetznerZone `json:"zone"`
+	}{}
+	err := s.do(ctx, http.MethodPost, "/zones", &hetznerZone{Name: zone}, &resp)
+	if err != nil {
+		return api.Zone{}, fmt.Errorf("cannot create zone %s, %v", zone, err)
+	}
+	return newZone(resp.Zone.Name, resp.Zone.ID), nil
+}
+
+// DeleteZone deletes the zone and all its records.
+func (s *Hetzner) DeleteZone(ctx context.Context, zone string) error {
+	err := s.withZoneID(ctx, zone, func(zoneID string) error {
+		if err := s.do(ctx, http.MethodDelete, "/zones/"+url.PathEscape(zoneID), nil, nil); err != nil {
+			return fmt.Errorf("cannot delete zone %s, %v", zone, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.zoneCache.remove(zoneCacheKey(string(api.HetznerProvider), zone))
+	return nil
+}
+
+// withZoneID calls fn with the ID of the zone, using the zone cache if
+// enabled. A cached ID is stale once the zone has been recreated, and
+// Hetzner then answers 404, so the zone is looked up again and fn
+// retried once with the new ID. Hetzner zone IDs are globally unique.
+func (s *Hetzner) withZoneID(ctx context.Context, zone string, fn func(zoneID string) error) error {
+	key := zoneCacheKey(string(api.HetznerProvider), strings.TrimSuffix(zone, "."))
+	return s.zoneCache.withZoneID(ctx, key, func() (string, error) {
+		return s.lookupZoneID(ctx, zone)
+	}, fn)
+}
+
+// lookupZoneID finds the ID of the zone by its name.
+func (s *Hetzner) lookupZoneID(ctx context.Context, zone string) (string, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	resp := struct {
+		Zones []hetznerZone `json:"zones"`
+	}{}
+	err := s.do(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(zone), nil, &resp)
+	if err != nil {
+		return "", err
+	}
+	for _, z := range resp.Zones {
+		if strings.EqualFold(z.Name, zone) {
+			return z.ID, nil
+		}
+	}
+	return "", fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+}
+
+func (s *Hetzner) listRecords(ctx context.Context, zoneID string) ([]hetznerRecord, error) {
+	resp := struct {
+		Records []hetznerRecord `json:"records"`
+	}{}
+	err := s.do(ctx, http.MethodGet, "/records?zone_id="+url.QueryEscape(zoneID), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Records, nil
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *Hetzner) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	var hrecords []hetznerRecord
+	err := s.withZoneID(ctx, zone, func(zoneID string) (err error) {
+		hrecords, err = s.listRecords(ctx, zoneID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, hrec := range hrecords {
+		recName := absoluteName(hrec.Name, zone)
+		if name != "" && !strings.EqualFold(name, recName) {
+			continue
+		}
+		record := api.Record{
+			Type:    hrec.Type,
+			Name:    recName,
+			Content: []string{hrec.Value},
+			TTL:     hrec.TTL,
+			System:  isSystemRecord(zone, recName, hrec.Type),
+		}
+		records = append(records, fromPresentation(record)...)
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *Hetzner) SupportedRecordTypes() []string {
+	return slices.Clone(hetznerRecordTypes)
+}
+
+// Capabilities reports that Hetzner can manage zones.
+func (s *Hetzner) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// CreateOrUpdateDNSRecord changes the existing record if found, or adds a new one
+func (s *Hetzner) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.HetznerProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, hetznerRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	content, err := toPresentation(rtype, content)
+	if err != nil {
+		return err
+	}
+	relName := relativeName(name, zone)
+	rtype = strings.ToUpper(rtype)
+	return s.withZoneID(ctx, zone, func(zoneID string) error {
+		hrecords, err := s.listRecords(ctx, zoneID)
+		if err != nil {
+			return err
+		}
+		newRecord := hetznerRecord{
+			ZoneID: zoneID,
+			Type:   rtype,
+			Name:   relName,
+			Value:  content,
+			TTL:    ttl,
+		}
+
+		existing := []hetznerRecord{}
+		keep := -1
+		for _, r := range hrecords {
+			if r.Type != rtype || !strings.EqualFold(r.Name, relName) {
+				continue
+			}
+			if keep < 0 && r.Value == content {
+				keep = len(existing)
+			}
+			existing = append(existing, r)
+		}
+		if len(existing) == 0 {
+			err := s.do(ctx, http.MethodPost, "/records", &newRecord, nil)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+				return fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+			}
+			return nil
+		}
+		if keep < 0 {
+			keep = 0
+		}
+		r := existing[keep]
+		if r.Value == content && r.TTL == ttl {
+			s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", content)
+		} else {
+			s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+			err := s.do(ctx, http.MethodPut, "/records/"+url.PathEscape(r.ID), &newRecord, nil)
+			if err != nil {
+				return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+			}
+		}
+		for ii, other := range existing {
+			if ii == keep {
+				continue
+			}
+			err := s.do(ctx, http.MethodDelete, "/records/"+url.PathEscape(other.ID), nil, nil)
+			if err != nil {
+				return fmt.Errorf("delete DNS record %v failed, %v", other, err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *Hetzner) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *Hetzner) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *Hetzner) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+func (s *Hetzner) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	deleted := 0
+	err := s.withZoneID(ctx, zone, func(zoneID string) error {
+		hrecords, err := s.listRecords(ctx, zoneID)
+		if err != nil {
+			return err
+		}
+		relName := relativeName(name, zone)
+		deleted = 0
+		for _, rec := range hrecords {
+			if !strings.EqualFold(rec.Name, relName) {
+				continue
+			}
+			if rtype != "" && !strings.EqualFold(rec.Type, rtype) {
+				continue
+			}
+			err := s.do(ctx, http.MethodDelete, "/records/"+url.PathEscape(rec.ID), nil, nil)
+			if err != nil {
+				return fmt.Errorf("delete DNS record %v failed, %v", rec, err)
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
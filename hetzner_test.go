@@ -0,0 +1,193 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// hetznerStub is a minimal in-memory implementation of the Hetzner
+// DNS API.
+type hetznerStub struct {
+	mu      sync.Mutex
+	zones   []hetznerZone
+	records []hetznerRecord
+	nextID  int
+}
+
+// hasZoneID reports whether a zone has the ID.
+func (s *hetznerStub) hasZoneID(id string) bool {
+	for _, z := range s.zones {
+		if z.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *hetznerStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/zones", func(w http.ResponseWriter, r *http.Request) {
+		zones := []hetznerZone{}
+		for _, z := range s.zones {
+			if name := r.URL.Query().Get("name"); name == "" || z.Name == name {
+				zones = append(zones, z)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"zones": zones})
+	})
+	mux.HandleFunc("GET /api/v1/records", func(w http.ResponseWriter, r *http.Request) {
+		if !s.hasZoneID(r.URL.Query().Get("zone_id")) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"zone not found","code":404}}`))
+			return
+		}
+		records := []hetznerRecord{}
+		for _, rec := range s.records {
+			if rec.ZoneID == r.URL.Query().Get("zone_id") {
+				records = append(records, rec)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"records": records})
+	})
+	mux.HandleFunc("POST /api/v1/records", func(w http.ResponseWriter, r *http.Request) {
+		rec := hetznerRecord{}
+		json.NewDecoder(r.Body).Decode(&rec)
+		s.nextID++
+		rec.ID = strconv.Itoa(s.nextID)
+		s.records = append(s.records, rec)
+		json.NewEncoder(w).Encode(map[string]interface{}{"record": rec})
+	})
+	mux.HandleFunc("PUT /api/v1/records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for ii, rec := range s.records {
+			if rec.ID == r.PathValue("id") {
+				update := hetznerRecord{}
+				json.NewDecoder(r.Body).Decode(&update)
+				update.ID = rec.ID
+				s.records[ii] = update
+				json.NewEncoder(w).Encode(map[string]interface{}{"record": update})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("DELETE /api/v1/records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for ii, rec := range s.records {
+			if rec.ID == r.PathValue("id") {
+				s.records = append(s.records[:ii], s.records[ii+1:]...)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get("Auth-API-Token") != "test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func TestHetznerStub(t *testing.T) {
+	ctx := context.Background()
+	stub := &hetznerStub{
+		zones: []hetznerZone{{ID: "z1", Name: "example.com"}},
+	}
+	client := newStubClient(t, stub.handler())
+
+	prov, err := GetProvider(ctx, api.HetznerProvider, "", map[string]string{"token": "test"}, nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	duplicateRecordsTest(t, ctx, prov, "example.com", "dup.example.com", func(content string) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.nextID++
+		stub.records = append(stub.records, hetznerRecord{ID: strconv.Itoa(stub.nextID), ZoneID: "z1", Type: "A", Name: "dup", Value: content, TTL: 300})
+	})
+
+	// apex is stored as "@", other names relative to the zone
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "CNAME", "example.com.", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, "@", stub.records[0].Name)
+	require.Equal(t, "www", stub.records[1].Name)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	require.Equal(t, "example.com", records[0].Name)
+	require.Equal(t, "www.example.com", records[1].Name)
+
+	_, err = prov.GetDNSRecords(ctx, "missing.com", "")
+	require.NotNil(t, err)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "z1"}}, zones)
+}
+
+func TestHetznerZoneRecreated(t *testing.T) {
+	ctx := context.Background()
+	stub := &hetznerStub{
+		zones: []hetznerZone{{ID: "z1", Name: "example.com"}},
+	}
+	lookups := 0
+	client := newStubClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/zones" {
+			lookups++
+		}
+		stub.handler().ServeHTTP(w, r)
+	}))
+	prov, err := GetProvider(ctx, api.HetznerProvider, "", map[string]string{"token": "test"}, nil, WithHTTPClient(client), WithZoneCache(time.Minute))
+	require.Nil(t, err)
+
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www", "A", "192.0.2.1", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, lookups)
+
+	// the recreated zone has a new ID, so the cached one gets a 404
+	stub.mu.Lock()
+	stub.zones[0].ID = "z2"
+	stub.mu.Unlock()
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www", "A", "192.0.2.2", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 2, lookups)
+	require.Equal(t, "z2", stub.records[1].ZoneID)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []string{"192.0.2.2"}, records[0].Content)
+	require.Equal(t, 2, lookups)
+
+	// a deleted zone is not found
+	stub.mu.Lock()
+	stub.zones = nil
+	stub.mu.Unlock()
+	_, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// httpError is returned by doJSON for non-2xx responses.
+type httpError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (s *httpError) Error() string {
+	return fmt.Sprintf("%s %s failed, %s: %s", s.Method, s.Path, s.Status, s.Body)
+}
+
+// isHTTPStatus checks if the error is an httpError with the given
+// status code.
+func isHTTPStatus(err error, code int) bool {
+	herr, ok := err.(*httpError)
+	return ok && herr.StatusCode == code
+}
+
+// doJSON sends in as the JSON request body, if non-nil, and decodes
+// the JSON response into out, if non-nil. Backends with plain JSON
+// APIs use this rather than pulling in an SDK.
+func doJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	for key, vals := range header {
+		req.Header[key] = vals
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &httpError{
+			Method:     method,
+			Path:       req.URL.Path,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       strings.TrimSpace(string(respData)),
+		}
+	}
+	if out != nil && len(respData) > 0 {
+		return json.Unmarshal(respData, out)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// StreamImport reads records in the given format, as written by
+// StreamExport, and creates or updates each of them in the zone.
+// System records, the NS and SOA records at the zone apex, are
+// skipped since the provider manages them itself. Each value of a
+// multi-valued record is applied in turn.
+func StreamImport(ctx context.Context, prov api.Provider, zone string, r io.Reader, format api.ExportFormat) error {
+	apply := func(name, rtype string, ttl int, content string, proxy bool) error {
+		if isSystemRecord(zone, name, rtype) {
+			return nil
+		}
+		err := prov.CreateOrUpdateDNSRecord(ctx, zone, name, rtype, content, ttl, proxy)
+		if err != nil {
+			return fmt.Errorf("import of %s record %s failed, %v", rtype, name, err)
+		}
+		return nil
+	}
+
+	switch format {
+	case api.ExportFormatNDJSON:
+		dec := json.NewDecoder(r)
+		for {
+			record := api.Record{}
+			err := dec.Decode(&record)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if record.System {
+				continue
+			}
+			for _, content := range record.Content {
+				if err := apply(record.Name, record.Type, record.TTL, writeContent(record, content), record.Proxied); err != nil {
+					return err
+				}
+			}
+		}
+	case api.ExportFormatCSV:
+		cr := csv.NewReader(r)
+		header, err := cr.Read()
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(header, csvHeader) {
+			return fmt.Errorf("invalid csv header %v, expected %v", header, csvHeader)
+		}
+		for {
+			row, err := cr.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			ttl, err := strconv.Atoi(row[2])
+			if err != nil {
+				return fmt.Errorf("invalid ttl %q for %s, %v", row[2], row[0], err)
+			}
+			if err := apply(row[0], row[1], ttl, row[3], false); err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("unsupported import format %q", format)
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamImport(t *testing.T) {
+	ctx := context.Background()
+	src := NewMockProvider("example.com")
+	src.Seed("example.com", api.Record{
+		Name:    "example.com",
+		Type:    "NS",
+		Content: []string{"ns1.example.net.", "ns2.example.net."},
+		TTL:     86400,
+	}, api.Record{
+		Name:    "example.com",
+		Type:    "SOA",
+		Content: []string{"ns1.example.net. admin.example.com. 1 3600 600 86400 300"},
+		TTL:     3600,
+	}, api.Record{
+		// delegations below the apex are not system records
+		Name:    "sub.example.com",
+		Type:    "NS",
+		Content: []string{"ns.sub.example.com."},
+		TTL:     3600,
+	}, api.Record{
+		Name:    "www.example.com",
+		Type:    "A",
+		Content: []string{"10.0.0.1"},
+		TTL:     300,
+	}, api.Record{
+		Name:     "example.com",
+		Type:     "MX",
+		Content:  []string{"mail.example.com."},
+		TTL:      300,
+		Priority: 10,
+	})
+
+	records, err := src.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	system := map[string]bool{}
+	for _, record := range records {
+		system[record.Name+"/"+record.Type] = record.System
+	}
+	require.Equal(t, map[string]bool{
+		"example.com/MX":     false,
+		"example.com/NS":     true,
+		"example.com/SOA":    true,
+		"sub.example.com/NS": false,
+		"www.example.com/A":  false,
+	}, system)
+
+	for _, format := range []api.ExportFormat{api.ExportFormatNDJSON, api.ExportFormatCSV} {
+		buf := bytes.Buffer{}
+		err := StreamExport(ctx, src, "example.com", &buf, format)
+		require.Nil(t, err)
+		require.Contains(t, buf.String(), "SOA")
+
+		dst := NewMockProvider("example.com")
+		err = StreamImport(ctx, dst, "example.com", &buf, format)
+		require.Nil(t, err, format)
+		types := []string{}
+		for _, record := range dst.Dump("example.com") {
+			types = append(types, record.Name+"/"+record.Type)
+		}
+		require.Equal(t, []string{"example.com/MX", "sub.example.com/NS", "www.example.com/A"}, types, format)
+		mx, err := dst.GetDNSRecords(ctx, "example.com", "example.com")
+		require.Nil(t, err)
+		require.Equal(t, 10, mx[0].Priority)
+	}
+
+	err = StreamImport(ctx, NewMockProvider("example.com"), "example.com", strings.NewReader("a,b\n"), api.ExportFormatCSV)
+	require.NotNil(t, err)
+	err = StreamImport(ctx, NewMockProvider("example.com"), "example.com", strings.NewReader(""), "xml")
+	require.NotNil(t, err)
+}
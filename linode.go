@@ -0,0 +1,446 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/linode/linodego"
+)
+
+// linodeTTLs are the TTLs Linode stores. Any other TTL is rounded up
+// to the next one in the list, and TTLs above the last are stored as
+// the last.
+var linodeTTLs = []int{30, 120, 300, 3600, 7200, 14400, 28800, 57600, 86400, 172800, 345600, 604800, 1209600, 2419200}
+
+// linodeTTL returns the TTL Linode stores for the requested TTL, so
+// a record that was rounded is not updated again on every call. Zero
+// selects the default TTL of the domain.
+func linodeTTL(ttl int) int {
+	if ttl <= 0 {
+		return 0
+	}
+	for _, allowed := range linodeTTLs {
+		if ttl <= allowed {
+			return allowed
+		}
+	}
+	return linodeTTLs[len(linodeTTLs)-1]
+}
+
+// Linode manages DNS records via the Linode (Akamai) Domains API.
+// Linode stores record names relative to the domain, with an empty
+// name for the apex, and rounds TTLs up to one of linodeTTLs, which
+// GetDNSRecords then returns.
+type Linode struct {
+	client *linodego.Client
+	logger api.Logger
+	zone   string // zone the provider was configured for, if any
+	// shared zone ID cache, nil if disabled
+	zoneCache *zoneIDCache
+}
+
+// NewLinodeProvider creates a new Linode DNS provider.
+func NewLinodeProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*Linode, error) {
+	return NewLinodeProviderWithCredentials(ctx, zone, linodeCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewLinodeProviderWithCredentials creates a new Linode DNS provider
+// from typed credentials.
+func NewLinodeProviderWithCredentials(ctx context.Context, zone string, creds LinodeCredentials, logger api.Logger, ops ...Option) (*Linode, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	opts := getOptions(ops)
+	client := linodego.NewClient(opts.httpClient())
+	client.SetToken(creds.Token)
+	return &Linode{
+		client:    &client,
+		logger:    logger,
+		zone:      zone,
+		zoneCache: opts.zoneCache,
+	}, nil
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *Linode) Close() error {
+	return nil
+}
+
+// ValidateCredentials lists the first page of zones.
+func (s *Linode) ValidateCredentials(ctx context.Context) error {
+	_, err := s.client.ListDomains(ctx, linodego.NewListOptions(1, ""))
+	return credentialsError(err)
+}
+
+// ListZones returns the domains the token can access.
+func (s *Linode) ListZones(ctx context.Context) ([]api.Zone, error) {
+	domains, err := s.client.ListDomains(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	zones := []api.Zone{}
+	for _, d := range domains {
+		zones = append(zones, newZone(d.Domain, strconv.Itoa(d.ID)))
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone creates the domain as a master domain. Linode requires
+// an SOA email address, which is set to hostmaster at the domain.
+func (s *Linode) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	domain, err := s.client.CreateDomain(ctx, linodego.DomainCreateOptions{
+		Domain:   zone,
+		Type:     linodego.DomainTypeMaster,
+		SOAEmail: "hostmaster@" + zone,
+	})
+	if err != nil {
+		return api.Zone{}, fmt.Errorf("cannot create zone %s, %v", zone, err)
+	}
+	return newZone(domain.Domain, strconv.Itoa(domain.ID)), nil
+}
+
+// DeleteZone deletes the domain and all its records.
+func (s *Linode) DeleteZone(ctx context.Context, zone string) error {
+	domainID, err := s.getDomainID(ctx, zone)
+	if err != nil {
+		return err
+	}
+	if err := s.client.DeleteDomain(ctx, domainID); err != nil {
+		return fmt.Errorf("cannot delete zone %s, %v", zone, err)
+	}
+	s.zoneCache.remove(zoneCacheKey(string(api.LinodeProvider), zone))
+	return nil
+}
+
+// getDomainID returns the ID of the domain of the zone, using the
+// zone cache if enabled. Linode domain IDs are globally unique.
+func (s *Linode) getDomainID(ctx context.Context, zone string) (int, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	key := zoneCacheKey(string(api.LinodeProvider), zone)
+	if id, ok := s.zoneCache.get(key); ok {
+		return strconv.Atoi(id)
+	}
+	filter, err := json.Marshal(map[string]string{"domain": zone})
+	if err != nil {
+		return 0, err
+	}
+	domains, err := s.client.ListDomains(ctx, linodego.NewListOptions(0, string(filter)))
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range domains {
+		if strings.EqualFold(d.Domain, zone) {
+			s.zoneCache.put(key, strconv.Itoa(d.ID))
+			return d.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+}
+
+// linodeName returns the name relative to the zone as Linode stores
+// it, with an empty name for the apex.
+func linodeName(name, zone string) string {
+	relName := relativeName(name, zone)
+	if relName == apexName {
+		return ""
+	}
+	return relName
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *Linode) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	domainID, err := s.getDomainID(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	lrecords, err := s.client.ListDomainRecords(ctx, domainID, nil)
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, lrec := range lrecords {
+		recName := absoluteName(lrec.Name, zone)
+		if name != "" && !strings.EqualFold(name, recName) {
+			continue
+		}
+		records = append(records, linodeRecordToRecord(lrec, zone))
+	}
+	return records, nil
+}
+
+// linodeRecordToRecord converts a Linode record in the zone.
+func linodeRecordToRecord(lrec linodego.DomainRecord, zone string) api.Record {
+	recName := absoluteName(lrec.Name, zone)
+	record := api.Record{
+		Type:    string(lrec.Type),
+		Name:    recName,
+		Content: []string{lrec.Target},
+		TTL:     lrec.TTLSec,
+		System:  isSystemRecord(zone, recName, string(lrec.Type)),
+		ID:      strconv.Itoa(lrec.ID),
+	}
+	if lrec.Type == linodego.RecordTypeMX {
+		record.Priority = lrec.Priority
+	}
+	return canonicalTargets(withUnicodeName(record))
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *Linode) SupportedRecordTypes() []string {
+	return slices.Clone(linodeRecordTypes)
+}
+
+// Capabilities reports that Linode can manage zones.
+func (s *Linode) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// CreateOrUpdateDNSRecord changes the existing record of the name and
+// type if found, or adds a new one. The TTL is rounded up to one that
+// Linode allows.
+func (s *Linode) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	_, err := s.CreateOrUpdateDNSRecordResult(ctx, zone, name, rtype, content, ttl, proxy)
+	return err
+}
+
+// CreateOrUpdateDNSRecordResult changes the existing record of the
+// name and type if found, or adds a new one, and returns the record as
+// stored, with its Linode record ID and rounded TTL. If there are
+// several records, the one already holding the content is kept, or
+// else the first, and the others are deleted.
+func (s *Linode) CreateOrUpdateDNSRecordResult(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) (api.Record, error) {
+	if err := checkProxy(api.LinodeProvider, proxy); err != nil {
+		return api.Record{}, err
+	}
+	if err := checkRecord(name, rtype, linodeRecordTypes); err != nil {
+		return api.Record{}, err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return api.Record{}, err
+	}
+	rtype = strings.ToUpper(rtype)
+	content, priority, err := linodeTarget(rtype, content)
+	if err != nil {
+		return api.Record{}, err
+	}
+	ttl = linodeTTL(ttl)
+	domainID, err := s.getDomainID(ctx, zone)
+	if err != nil {
+		return api.Record{}, err
+	}
+	lrecords, err := s.client.ListDomainRecords(ctx, domainID, nil)
+	if err != nil {
+		return api.Record{}, err
+	}
+	relName := linodeName(name, zone)
+
+	existing := []linodego.DomainRecord{}
+	keep := -1
+	for _, r := range lrecords {
+		if string(r.Type) != rtype || !strings.EqualFold(r.Name, relName) {
+			continue
+		}
+		if keep < 0 && r.Target == content {
+			keep = len(existing)
+		}
+		existing = append(existing, r)
+	}
+	if len(existing) == 0 {
+		created, err := s.client.CreateDomainRecord(ctx, domainID, linodego.DomainRecordCreateOptions{
+			Type:     linodego.DomainRecordType(rtype),
+			Name:     relName,
+			Target:   content,
+			Priority: priority,
+			TTLSec:   ttl,
+		})
+		if err != nil {
+			s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+			return api.Record{}, fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+		}
+		return linodeRecordToRecord(*created, zone), nil
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	stored := &existing[keep]
+	if stored.Target == content && stored.TTLSec == ttl && (priority == nil || stored.Priority == *priority) {
+		s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", content)
+	} else {
+		s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+		stored, err = s.client.UpdateDomainRecord(ctx, domainID, stored.ID, linodego.DomainRecordUpdateOptions{
+			Type:     stored.Type,
+			Name:     relName,
+			Target:   content,
+			Priority: priority,
+			TTLSec:   ttl,
+		})
+		if err != nil {
+			return api.Record{}, fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+		}
+	}
+	for ii, other := range existing {
+		if ii == keep {
+			continue
+		}
+		if err := s.client.DeleteDomainRecord(ctx, domainID, other.ID); err != nil {
+			return api.Record{}, fmt.Errorf("delete DNS record %v failed, %v", other, err)
+		}
+	}
+	return linodeRecordToRecord(*stored, zone), nil
+}
+
+// linodeTarget splits content as passed to CreateOrUpdateDNSRecord
+// into the target and, for MX records, the priority of a Linode
+// record. Host names are stored without the trailing dot.
+func linodeTarget(rtype, content string) (string, *int, error) {
+	switch rtype {
+	case api.RecordTypeMX:
+		prio, target, err := parseMXContent(content)
+		if err != nil {
+			return "", nil, err
+		}
+		return strings.TrimSuffix(target, "."), &prio, nil
+	case api.RecordTypeTXT:
+		return txtValue(content), nil, nil
+	case api.RecordTypeCNAME, api.RecordTypeNS, api.RecordTypePTR:
+		return strings.TrimSuffix(content, "."), nil, nil
+	}
+	return content, nil, nil
+}
+
+// recordByID returns the domain ID of the zone and its record with
+// the id.
+func (s *Linode) recordByID(ctx context.Context, zone, id string) (int, linodego.DomainRecord, error) {
+	domainID, err := s.getDomainID(ctx, zone)
+	if err != nil {
+		return 0, linodego.DomainRecord{}, err
+	}
+	lrecords, err := s.client.ListDomainRecords(ctx, domainID, nil)
+	if err != nil {
+		return 0, linodego.DomainRecord{}, err
+	}
+	for _, lrec := range lrecords {
+		if strconv.Itoa(lrec.ID) == id {
+			return domainID, lrec, nil
+		}
+	}
+	return 0, linodego.DomainRecord{}, fmt.Errorf("%w: zone %s record id %s", api.ErrRecordNotFound, zone, id)
+}
+
+// UpdateDNSRecordByID sets the content and TTL of the record with the
+// id, keeping its name and type. The TTL is rounded up to one that
+// Linode allows.
+func (s *Linode) UpdateDNSRecordByID(ctx context.Context, zone, id, content string, ttl int) error {
+	domainID, lrec, err := s.recordByID(ctx, zone, id)
+	if err != nil {
+		return err
+	}
+	if err := validateContent(absoluteName(lrec.Name, zone), string(lrec.Type), ttl, content); err != nil {
+		return err
+	}
+	target, priority, err := linodeTarget(string(lrec.Type), content)
+	if err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "UpdateDNSRecordByID updating", "zone", zone, "id", id, "content", content)
+	_, err = s.client.UpdateDomainRecord(ctx, domainID, lrec.ID, linodego.DomainRecordUpdateOptions{
+		Type:     lrec.Type,
+		Name:     lrec.Name,
+		Target:   target,
+		Priority: priority,
+		TTLSec:   linodeTTL(ttl),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot update DNS record %s for zone %s, %v", id, zone, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecordByID deletes the record with the id.
+func (s *Linode) DeleteDNSRecordByID(ctx context.Context, zone, id string) error {
+	domainID, lrec, err := s.recordByID(ctx, zone, id)
+	if err != nil {
+		return err
+	}
+	if err := s.client.DeleteDomainRecord(ctx, domainID, lrec.ID); err != nil {
+		return fmt.Errorf("delete DNS record %s for zone %s failed, %v", id, zone, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *Linode) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *Linode) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *Linode) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+func (s *Linode) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	domainID, err := s.getDomainID(ctx, zone)
+	if err != nil {
+		return 0, err
+	}
+	lrecords, err := s.client.ListDomainRecords(ctx, domainID, nil)
+	if err != nil {
+		return 0, err
+	}
+	relName := linodeName(name, zone)
+	deleted := 0
+	for _, rec := range lrecords {
+		if !strings.EqualFold(rec.Name, relName) {
+			continue
+		}
+		if rtype != "" && !strings.EqualFold(string(rec.Type), rtype) {
+			continue
+		}
+		if err := s.client.DeleteDomainRecord(ctx, domainID, rec.ID); err != nil {
+			return deleted, fmt.Errorf("delete DNS record %v failed, %v", rec, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,252 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/linode/linodego"
+	"github.com/stretchr/testify/require"
+)
+
+// linodeStub is a minimal in-memory implementation of the Linode
+// domains API, which rounds TTLs up like Linode does.
+type linodeStub struct {
+	mu      sync.Mutex
+	domains []linodego.Domain
+	records map[int][]linodego.DomainRecord
+	nextID  int
+	updates int
+}
+
+func newLinodeStub(domains ...string) *linodeStub {
+	s := &linodeStub{records: map[int][]linodego.DomainRecord{}}
+	for ii, domain := range domains {
+		s.domains = append(s.domains, linodego.Domain{ID: ii + 1, Domain: domain})
+	}
+	return s
+}
+
+func (s *linodeStub) page(w http.ResponseWriter, data interface{}, results int) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    data,
+		"page":    1,
+		"pages":   1,
+		"results": results,
+	})
+}
+
+func (s *linodeStub) setRecord(rec *linodego.DomainRecord, r *http.Request) {
+	opts := linodego.DomainRecordCreateOptions{}
+	json.NewDecoder(r.Body).Decode(&opts)
+	rec.Type = opts.Type
+	rec.Name = opts.Name
+	rec.Target = opts.Target
+	rec.Priority = 0
+	if opts.Priority != nil {
+		rec.Priority = *opts.Priority
+	}
+	rec.TTLSec = linodeTTL(opts.TTLSec)
+}
+
+func (s *linodeStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v4/domains", func(w http.ResponseWriter, r *http.Request) {
+		filter := map[string]string{}
+		json.Unmarshal([]byte(r.Header.Get("X-Filter")), &filter)
+		domains := []linodego.Domain{}
+		for _, d := range s.domains {
+			if filter["domain"] == "" || filter["domain"] == d.Domain {
+				domains = append(domains, d)
+			}
+		}
+		s.page(w, domains, len(domains))
+	})
+	mux.HandleFunc("GET /v4/domains/{id}/records", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		records := append([]linodego.DomainRecord{}, s.records[id]...)
+		s.page(w, records, len(records))
+	})
+	mux.HandleFunc("POST /v4/domains/{id}/records", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		s.nextID++
+		rec := linodego.DomainRecord{ID: s.nextID}
+		s.setRecord(&rec, r)
+		s.records[id] = append(s.records[id], rec)
+		json.NewEncoder(w).Encode(rec)
+	})
+	mux.HandleFunc("PUT /v4/domains/{id}/records/{rid}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		rid, _ := strconv.Atoi(r.PathValue("rid"))
+		for ii := range s.records[id] {
+			if s.records[id][ii].ID == rid {
+				s.updates++
+				s.setRecord(&s.records[id][ii], r)
+				json.NewEncoder(w).Encode(s.records[id][ii])
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[{"reason":"Not found"}]}`))
+	})
+	mux.HandleFunc("DELETE /v4/domains/{id}/records/{rid}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		rid, _ := strconv.Atoi(r.PathValue("rid"))
+		for ii, rec := range s.records[id] {
+			if rec.ID == rid {
+				s.records[id] = append(s.records[id][:ii], s.records[id][ii+1:]...)
+				w.Write([]byte("{}"))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[{"reason":"Not found"}]}`))
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"reason":"Invalid Token"}]}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func newLinodeTestProvider(t *testing.T, stub *linodeStub) api.Provider {
+	client := newStubClient(t, stub.handler())
+	prov, err := GetProvider(context.Background(), api.LinodeProvider, "", LinodeCredentials{Token: "test"}.ToMap(), nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	return prov
+}
+
+func TestLinodeStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newLinodeStub("example.com")
+	prov := newLinodeTestProvider(t, stub)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	recordIDTest(t, ctx, prov.(api.RecordIDManager), "example.com", "id1.example.com", "id2.example.com")
+	duplicateRecordsTest(t, ctx, prov, "example.com", "dup.example.com", func(content string) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.nextID++
+		stub.records[1] = append(stub.records[1], linodego.DomainRecord{ID: stub.nextID, Type: "A", Name: "dup", Target: content, TTLSec: 300})
+	})
+
+	// the apex has an empty name, and the MX priority has its own
+	// field
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com.", 300, false)
+	require.Nil(t, err)
+	rec := stub.records[1][len(stub.records[1])-1]
+	require.Equal(t, "", rec.Name)
+	require.Equal(t, "mail.example.com", rec.Target)
+	require.Equal(t, 10, rec.Priority)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:     "example.com",
+		Type:     "MX",
+		Content:  []string{"mail.example.com."},
+		TTL:      300,
+		Priority: 10,
+		ID:       strconv.Itoa(rec.ID),
+	}}, records)
+
+	// the result is the record as stored, with the TTL rounded up
+	result, err := CreateOrUpdateDNSRecordResult(ctx, prov, "example.com", "example.com", "MX", "20 mail.example.com.", 200, false)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{result}, records)
+	require.Equal(t, strconv.Itoa(rec.ID), result.ID)
+	require.Equal(t, 300, result.TTL)
+	require.Equal(t, 20, result.Priority)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "1"}}, zones)
+}
+
+func TestLinodePTR(t *testing.T) {
+	ctx := context.Background()
+	stub := newLinodeStub("2.0.192.in-addr.arpa")
+	prov := newLinodeTestProvider(t, stub)
+
+	// the target is stored without the trailing dot, like CNAME
+	err := prov.CreateOrUpdateDNSRecord(ctx, "2.0.192.in-addr.arpa", "1.2.0.192.in-addr.arpa", "PTR", "host.example.com.", 300, false)
+	require.Nil(t, err)
+	rec := stub.records[1][len(stub.records[1])-1]
+	require.Equal(t, "1", rec.Name)
+	require.Equal(t, "host.example.com", rec.Target)
+
+	records, err := prov.GetDNSRecords(ctx, "2.0.192.in-addr.arpa", "1.2.0.192.in-addr.arpa")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:    "1.2.0.192.in-addr.arpa",
+		Type:    "PTR",
+		Content: []string{"host.example.com."},
+		TTL:     300,
+		ID:      strconv.Itoa(rec.ID),
+	}}, records)
+
+	err = prov.DeleteDNSRecordByType(ctx, "2.0.192.in-addr.arpa", "1.2.0.192.in-addr.arpa", "PTR")
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "2.0.192.in-addr.arpa", "")
+	require.Nil(t, err)
+	require.Empty(t, records)
+}
+
+func TestLinodeTTL(t *testing.T) {
+	ctx := context.Background()
+	stub := newLinodeStub("example.com")
+	prov := newLinodeTestProvider(t, stub)
+
+	require.Equal(t, 0, linodeTTL(0))
+	require.Equal(t, 30, linodeTTL(1))
+	require.Equal(t, 300, linodeTTL(300))
+	require.Equal(t, 3600, linodeTTL(301))
+	require.Equal(t, 2419200, linodeTTL(5000000))
+
+	// the TTL read back is the rounded one, and asking for the same
+	// unrounded TTL again is not an update
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 600, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 3600, records[0].TTL)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 600, false)
+	require.Nil(t, err)
+	require.Equal(t, 0, stub.updates)
+}
+
+func TestLinodeCredentials(t *testing.T) {
+	ctx := context.Background()
+	client := newStubClient(t, newLinodeStub("example.com").handler())
+
+	err := ValidateCredentials(ctx, api.LinodeProvider, LinodeCredentials{Token: "test"}.ToMap(), WithHTTPClient(client))
+	require.Nil(t, err)
+	err = ValidateCredentials(ctx, api.LinodeProvider, LinodeCredentials{Token: "wrong"}.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+}
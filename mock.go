@@ -0,0 +1,350 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// MockProvider is an in-memory provider for tests. Records are kept
+// per zone keyed by name and type, and names are stored lower case
+// without a trailing dot. The ID of each record is its name and type
+// joined by a slash. Operations on zones that have not been
+// added return an error, like a real provider would.
+type MockProvider struct {
+	mu    sync.Mutex
+	zones map[string]map[mockKey]api.Record
+}
+
+type mockKey struct {
+	name  string
+	rtype string
+}
+
+// id is the record id reported for the key. The mock keeps one record
+// per name and type, so these identify it.
+func (k mockKey) id() string {
+	return k.name + "/" + k.rtype
+}
+
+// NewMockProvider creates an in-memory provider with the given zones.
+// Empty zone names are ignored.
+func NewMockProvider(zones ...string) *MockProvider {
+	s := &MockProvider{
+		zones: map[string]map[mockKey]api.Record{},
+	}
+	for _, zone := range zones {
+		if zone != "" {
+			s.Seed(zone)
+		}
+	}
+	return s
+}
+
+func mockName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// Seed adds the zone if needed and stores the records in it,
+// replacing any existing records of the same name and type.
+func (s *MockProvider) Seed(zone string, records ...api.Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zone = mockName(zone)
+	zoneRecords, ok := s.zones[zone]
+	if !ok {
+		zoneRecords = map[mockKey]api.Record{}
+		s.zones[zone] = zoneRecords
+	}
+	for _, rec := range records {
+		rec.Name = mockName(rec.Name)
+		rec.Type = strings.ToUpper(rec.Type)
+		rec.Content = append([]string{}, rec.Content...)
+		rec.System = isSystemRecord(zone, rec.Name, rec.Type)
+		zoneRecords[mockKey{rec.Name, rec.Type}] = rec
+	}
+}
+
+// Dump returns a copy of all records in the zone sorted by name and
+// type, for test assertions. The records have no ID, since the mock
+// derives it from the name and type.
+func (s *MockProvider) Dump(zone string) []api.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list(s.zones[mockName(zone)], "")
+}
+
+func (s *MockProvider) list(zoneRecords map[mockKey]api.Record, name string) []api.Record {
+	records := []api.Record{}
+	for key, rec := range zoneRecords {
+		if name != "" && key.name != mockName(name) {
+			continue
+		}
+		rec.Content = append([]string{}, rec.Content...)
+		records = append(records, withUnicodeName(rec))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+		return records[i].Type < records[j].Type
+	})
+	return records
+}
+
+func (s *MockProvider) getZone(zone string) (map[mockKey]api.Record, error) {
+	zoneRecords, ok := s.zones[mockName(zone)]
+	if !ok {
+		return nil, fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	return zoneRecords, nil
+}
+
+// Close is a no-op.
+func (s *MockProvider) Close() error {
+	return nil
+}
+
+// ValidateCredentials always succeeds.
+func (s *MockProvider) ValidateCredentials(ctx context.Context) error {
+	return nil
+}
+
+// ListZones returns the zones that have been added. The mock
+// identifies zones by name.
+func (s *MockProvider) ListZones(ctx context.Context) ([]api.Zone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zones := []api.Zone{}
+	for zone := range s.zones {
+		zones = append(zones, newZone(zone, ""))
+	}
+	return listedZones("", zones)
+}
+
+// CreateZone adds the zone, failing if it already exists.
+func (s *MockProvider) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := mockName(zone)
+	if _, ok := s.zones[name]; ok {
+		return api.Zone{}, fmt.Errorf("zone %s already exists", zone)
+	}
+	s.zones[name] = map[mockKey]api.Record{}
+	return newZone(name, ""), nil
+}
+
+// DeleteZone removes the zone and all its records.
+func (s *MockProvider) DeleteZone(ctx context.Context, zone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.getZone(zone); err != nil {
+		return err
+	}
+	delete(s.zones, mockName(zone))
+	return nil
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *MockProvider) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zoneRecords, err := s.getZone(zone)
+	if err != nil {
+		return nil, err
+	}
+	records := s.list(zoneRecords, name)
+	for ii, rec := range records {
+		records[ii].ID = mockKey{rec.Name, rec.Type}.id()
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *MockProvider) SupportedRecordTypes() []string {
+	return slices.Clone(presentationRecordTypes)
+}
+
+// Capabilities reports that the mock can manage zones.
+func (s *MockProvider) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// CreateOrUpdateDNSRecord replaces the record of the same name and
+// type if found, or adds a new one.
+func (s *MockProvider) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.MockProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, presentationRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zoneRecords, err := s.getZone(zone)
+	if err != nil {
+		return err
+	}
+	rec, err := newMockRecord(name, rtype, content, ttl)
+	if err != nil {
+		return err
+	}
+	zoneRecords[mockKey{rec.Name, rec.Type}] = rec
+	return nil
+}
+
+// newMockRecord converts content as passed to CreateOrUpdateDNSRecord
+// into the record stored by the mock.
+func newMockRecord(name, rtype, content string, ttl int) (api.Record, error) {
+	rec := api.Record{
+		Type:    strings.ToUpper(rtype),
+		Name:    mockName(name),
+		Content: []string{content},
+		TTL:     ttl,
+	}
+	switch rec.Type {
+	case api.RecordTypeMX:
+		priority, target, err := parseMXContent(content)
+		if err != nil {
+			return api.Record{}, err
+		}
+		rec.Priority = priority
+		rec.Content = []string{target}
+	case api.RecordTypeSRV:
+		priority, weight, port, target, err := parseSRVContent(content)
+		if err != nil {
+			return api.Record{}, err
+		}
+		rec.Priority = priority
+		rec.Weight = weight
+		rec.Port = port
+		rec.Content = []string{target}
+	case api.RecordTypeTXT:
+		rec.Content = []string{txtValue(content)}
+	}
+	return canonicalTargets(rec), nil
+}
+
+// keyByID returns the key of the record with the id, which the mock
+// makes up from the name and type.
+func (s *MockProvider) keyByID(zoneRecords map[mockKey]api.Record, zone, id string) (mockKey, error) {
+	for key := range zoneRecords {
+		if key.id() == id {
+			return key, nil
+		}
+	}
+	return mockKey{}, fmt.Errorf("%w: zone %s record id %s", api.ErrRecordNotFound, zone, id)
+}
+
+// UpdateDNSRecordByID sets the content and TTL of the record with the
+// id, keeping its name and type.
+func (s *MockProvider) UpdateDNSRecordByID(ctx context.Context, zone, id, content string, ttl int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zoneRecords, err := s.getZone(zone)
+	if err != nil {
+		return err
+	}
+	key, err := s.keyByID(zoneRecords, zone, id)
+	if err != nil {
+		return err
+	}
+	if err := validateContent(key.name, key.rtype, ttl, content); err != nil {
+		return err
+	}
+	rec, err := newMockRecord(key.name, key.rtype, content, ttl)
+	if err != nil {
+		return err
+	}
+	zoneRecords[key] = rec
+	return nil
+}
+
+// DeleteDNSRecordByID deletes the record with the id.
+func (s *MockProvider) DeleteDNSRecordByID(ctx context.Context, zone, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zoneRecords, err := s.getZone(zone)
+	if err != nil {
+		return err
+	}
+	key, err := s.keyByID(zoneRecords, zone, id)
+	if err != nil {
+		return err
+	}
+	delete(zoneRecords, key)
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *MockProvider) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.DeleteDNSRecordCount(ctx, zone, name)
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number of values deleted.
+func (s *MockProvider) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zoneRecords, err := s.getZone(zone)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for key, rec := range zoneRecords {
+		if key.name == mockName(name) {
+			deleted += len(rec.Content)
+			delete(zoneRecords, key)
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteDNSRecordByType deletes the record of the given type for the
+// name.
+func (s *MockProvider) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if name == "" {
+		return fmt.Errorf("no name specified to delete")
+	}
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zoneRecords, err := s.getZone(zone)
+	if err != nil {
+		return err
+	}
+	delete(zoneRecords, mockKey{mockName(name), strings.ToUpper(rtype)})
+	return nil
+}
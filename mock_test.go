@@ -0,0 +1,162 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockProvider(t *testing.T) {
+	ctx := context.Background()
+
+	prov, err := GetProvider(ctx, api.MockProvider, "example.com", nil, nil)
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+
+	_, err = GetProvider(ctx, "unknown", "example.com", nil, nil)
+	require.NotNil(t, err)
+
+	mock := NewMockProvider()
+	_, err = mock.GetDNSRecords(ctx, "example.com", "")
+	require.NotNil(t, err)
+	zones, err := mock.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{}, zones)
+	zone, err := mock.CreateZone(ctx, "Created.example.com.")
+	require.Nil(t, err)
+	require.Equal(t, api.Zone{Name: "created.example.com", ID: "created.example.com"}, zone)
+	_, err = mock.CreateZone(ctx, "created.example.com")
+	require.NotNil(t, err)
+	require.Nil(t, mock.DeleteZone(ctx, "created.example.com"))
+	require.NotNil(t, mock.DeleteZone(ctx, "created.example.com"))
+	err = mock.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.NotNil(t, err)
+
+	mock.Seed("example.com.",
+		api.Record{Type: "a", Name: "WWW.example.com.", Content: []string{"10.0.0.1", "10.0.0.2"}, TTL: 300},
+		api.Record{Type: "TXT", Name: "www.example.com", Content: []string{"hello"}, TTL: 300},
+	)
+	records, err := mock.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	require.Equal(t, api.Record{Type: "A", Name: "www.example.com", Content: []string{"10.0.0.1", "10.0.0.2"}, TTL: 300, ID: "www.example.com/A"}, records[0])
+
+	// replace on name and type match, insert otherwise
+	err = mock.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.3", 600, false)
+	require.Nil(t, err)
+	err = mock.CreateOrUpdateDNSRecord(ctx, "example.com", "api.example.com", "A", "10.0.0.4", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{
+		{Type: "A", Name: "api.example.com", Content: []string{"10.0.0.4"}, TTL: 300},
+		{Type: "A", Name: "www.example.com", Content: []string{"10.0.0.3"}, TTL: 600},
+		{Type: "TXT", Name: "www.example.com", Content: []string{"hello"}, TTL: 300},
+	}, mock.Dump("example.com"))
+
+	// delete removes all types for the name
+	err = mock.DeleteDNSRecord(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{
+		{Type: "A", Name: "api.example.com", Content: []string{"10.0.0.4"}, TTL: 300},
+	}, mock.Dump("example.com"))
+}
+
+func TestUpdateTTL(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockProvider("example.com")
+	err := mock.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com", 300, false)
+	require.Nil(t, err)
+
+	// providers without their own support are updated by re-applying
+	// the existing content
+	err = UpdateTTL(ctx, mock, "example.com", "example.com", "MX", 600)
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{
+		{Type: "MX", Name: "example.com", Content: []string{"mail.example.com."}, TTL: 600, Priority: 10},
+	}, mock.Dump("example.com"))
+
+	err = UpdateTTL(ctx, mock, "example.com", "www.example.com", "A", 600)
+	require.ErrorIs(t, err, api.ErrRecordNotFound)
+	err = UpdateTTL(ctx, mock, "example.com", "example.com", "", 600)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "no record type specified")
+}
+
+func TestDNSSECUnsupported(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockProvider("example.com")
+	_, err := EnableDNSSEC(ctx, mock, "example.com")
+	require.ErrorIs(t, err, api.ErrUnsupported)
+	err = DisableDNSSEC(ctx, mock, "example.com")
+	require.ErrorIs(t, err, api.ErrUnsupported)
+}
+
+func TestMockProviderPTR(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockProvider("2.0.192.in-addr.arpa", "8.b.d.0.1.0.0.2.ip6.arpa")
+
+	name := api.ReverseName(net.ParseIP("192.0.2.1"))
+	err := mock.CreateOrUpdateDNSRecord(ctx, "2.0.192.in-addr.arpa", name, api.RecordTypePTR, "host.example.com", 300, false)
+	require.Nil(t, err)
+	records, err := mock.GetDNSRecords(ctx, "2.0.192.in-addr.arpa.", name)
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{
+		{Type: "PTR", Name: "1.2.0.192.in-addr.arpa", Content: []string{"host.example.com."}, TTL: 300, ID: "1.2.0.192.in-addr.arpa/PTR"},
+	}, records)
+
+	name = api.ReverseName(net.ParseIP("2001:db8::1"))
+	err = mock.CreateOrUpdateDNSRecord(ctx, "8.b.d.0.1.0.0.2.ip6.arpa", name, api.RecordTypePTR, "host.example.com.", 300, false)
+	require.Nil(t, err)
+	records, err = mock.GetDNSRecords(ctx, "8.b.d.0.1.0.0.2.ip6.arpa", "")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, name, records[0].Name)
+
+	err = mock.DeleteDNSRecordByType(ctx, "2.0.192.in-addr.arpa", "1.2.0.192.in-addr.arpa.", api.RecordTypePTR)
+	require.Nil(t, err)
+	require.Empty(t, mock.Dump("2.0.192.in-addr.arpa"))
+}
+
+func TestMockProviderRecordID(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockProvider("example.com")
+	recordIDTest(t, ctx, mock, "example.com", "id1.example.com", "id2.example.com")
+
+	// the id is made up from the name and type
+	err := mock.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com", 300, false)
+	require.Nil(t, err)
+	err = UpdateDNSRecordByID(ctx, mock, "example.com", "example.com/MX", "20 mail.example.com", 600)
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{
+		{Type: "MX", Name: "example.com", Content: []string{"mail.example.com."}, TTL: 600, Priority: 20},
+	}, mock.Dump("example.com"))
+	err = UpdateDNSRecordByID(ctx, mock, "example.net", "example.net/MX", "20 mail.example.com", 600)
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+	err = DeleteDNSRecordByID(ctx, mock, "example.com", "example.com/MX")
+	require.Nil(t, err)
+	require.Empty(t, mock.Dump("example.com"))
+
+	// providers keyed by name and type have no ids
+	gcdns := newGCDNSTestProvider(t, newGCDNSStub("example.com"))
+	err = UpdateDNSRecordByID(ctx, gcdns, "example.com", "1", "10.0.0.1", 300)
+	require.ErrorIs(t, err, api.ErrUnsupported)
+	err = DeleteDNSRecordByID(ctx, gcdns, "example.com", "1")
+	require.ErrorIs(t, err, api.ErrUnsupported)
+}
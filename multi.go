@@ -0,0 +1,227 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// MultiProvider keeps the same records in several providers, for
+// example the internal and external views of a split-horizon setup.
+// Writes and deletes are applied to all members in parallel, and
+// reads are served by the primary member, which is the first unless
+// set by WithPrimary.
+type MultiProvider struct {
+	providers []api.Provider
+	primary   int
+}
+
+// multiOptions are the settings of NewMultiProvider.
+type multiOptions struct {
+	primary int
+}
+
+// MultiOption configures NewMultiProvider.
+type MultiOption func(opts *multiOptions)
+
+// WithPrimary sets the index of the member that serves reads.
+func WithPrimary(index int) MultiOption {
+	return func(opts *multiOptions) {
+		opts.primary = index
+	}
+}
+
+// MemberError is the error of one member of a MultiProvider.
+type MemberError struct {
+	// Index is the position of the member in the providers passed
+	// to NewMultiProvider.
+	Index int
+	Err   error
+}
+
+func (s MemberError) Error() string {
+	return fmt.Sprintf("provider %d failed, %v", s.Index, s.Err)
+}
+
+func (s MemberError) Unwrap() error {
+	return s.Err
+}
+
+// MultiProviderError is returned when a write fails for some of the
+// members of a MultiProvider. The write was still applied to the
+// members not listed. It unwraps to the member errors, so errors.Is
+// matches the error of any failed member.
+type MultiProviderError struct {
+	// Failed are the errors of the failed members, by index.
+	Failed []MemberError
+	// Members is the number of members written to.
+	Members int
+}
+
+func (s *MultiProviderError) Error() string {
+	msgs := []string{}
+	for _, failed := range s.Failed {
+		msgs = append(msgs, failed.Error())
+	}
+	return fmt.Sprintf("%d of %d providers failed: %s", len(s.Failed), s.Members, strings.Join(msgs, "; "))
+}
+
+func (s *MultiProviderError) Unwrap() []error {
+	errs := []error{}
+	for _, failed := range s.Failed {
+		errs = append(errs, failed)
+	}
+	return errs
+}
+
+// NewMultiProvider creates a provider that writes to all the given
+// providers. Closing it closes all the members.
+func NewMultiProvider(providers []api.Provider, ops ...MultiOption) (*MultiProvider, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("no providers specified for multi provider")
+	}
+	opts := multiOptions{}
+	for _, op := range ops {
+		op(&opts)
+	}
+	if opts.primary < 0 || opts.primary >= len(providers) {
+		return nil, fmt.Errorf("primary provider %d out of range for %d providers", opts.primary, len(providers))
+	}
+	return &MultiProvider{
+		providers: slices.Clone(providers),
+		primary:   opts.primary,
+	}, nil
+}
+
+// fanOut calls the function for every member in parallel, and returns
+// a *MultiProviderError if any failed.
+func (s *MultiProvider) fanOut(fn func(prov api.Provider) error) error {
+	errs := make([]error, len(s.providers))
+	wg := sync.WaitGroup{}
+	for ii, prov := range s.providers {
+		wg.Add(1)
+		go func(ii int, prov api.Provider) {
+			defer wg.Done()
+			errs[ii] = fn(prov)
+		}(ii, prov)
+	}
+	wg.Wait()
+	merr := &MultiProviderError{Members: len(s.providers)}
+	for ii, err := range errs {
+		if err != nil {
+			merr.Failed = append(merr.Failed, MemberError{Index: ii, Err: err})
+		}
+	}
+	if len(merr.Failed) > 0 {
+		return merr
+	}
+	return nil
+}
+
+// GetDNSRecords reads the records from the primary member.
+func (s *MultiProvider) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	return s.providers[s.primary].GetDNSRecords(ctx, zone, name)
+}
+
+// ListZones lists the zones of the primary member.
+func (s *MultiProvider) ListZones(ctx context.Context) ([]api.Zone, error) {
+	return s.providers[s.primary].ListZones(ctx)
+}
+
+// CreateOrUpdateDNSRecord creates or updates the record in every
+// member.
+func (s *MultiProvider) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	return s.fanOut(func(prov api.Provider) error {
+		return prov.CreateOrUpdateDNSRecord(ctx, zone, name, rtype, content, ttl, proxy)
+	})
+}
+
+// CreateOrUpdateDNSRecordSet sets the values of the name and type in
+// every member. Members that cannot set several values fail unless
+// there is only one value.
+func (s *MultiProvider) CreateOrUpdateDNSRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) error {
+	if len(contents) == 0 {
+		return fmt.Errorf("no content specified for %s record %s", rtype, name)
+	}
+	return s.fanOut(func(prov api.Provider) error {
+		if setter, ok := prov.(api.RecordSetUpdater); ok {
+			return setter.CreateOrUpdateDNSRecordSet(ctx, zone, name, rtype, contents, ttl, proxy)
+		}
+		if len(contents) > 1 {
+			return fmt.Errorf("provider cannot set multiple values %v", contents)
+		}
+		return prov.CreateOrUpdateDNSRecord(ctx, zone, name, rtype, contents[0], ttl, proxy)
+	})
+}
+
+// DeleteDNSRecord deletes all DNS records for the name from every
+// member.
+func (s *MultiProvider) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	return s.fanOut(func(prov api.Provider) error {
+		return prov.DeleteDNSRecord(ctx, zone, name)
+	})
+}
+
+// DeleteDNSRecordByType deletes the DNS records of the given type for
+// the name from every member.
+func (s *MultiProvider) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	return s.fanOut(func(prov api.Provider) error {
+		return prov.DeleteDNSRecordByType(ctx, zone, name, rtype)
+	})
+}
+
+// SupportedRecordTypes returns the record types every member can
+// create.
+func (s *MultiProvider) SupportedRecordTypes() []string {
+	return s.Capabilities().SupportedRecordTypes
+}
+
+// Capabilities reports the features all members support. Zone
+// management, batches and DNSSEC are not fanned out, so are never
+// supported.
+func (s *MultiProvider) Capabilities() api.Capabilities {
+	caps := api.Capabilities{
+		SupportedRecordTypes: []string{},
+		SupportsProxy:        true,
+	}
+	for ii, prov := range s.providers {
+		member := prov.Capabilities()
+		if ii == 0 {
+			caps.SupportedRecordTypes = slices.Clone(member.SupportedRecordTypes)
+		} else {
+			caps.SupportedRecordTypes = slices.DeleteFunc(caps.SupportedRecordTypes, func(rtype string) bool {
+				return !slices.Contains(member.SupportedRecordTypes, rtype)
+			})
+		}
+		caps.SupportsProxy = caps.SupportsProxy && member.SupportsProxy
+	}
+	return caps
+}
+
+// Close closes every member.
+func (s *MultiProvider) Close() error {
+	errs := []error{}
+	for _, prov := range s.providers {
+		errs = append(errs, prov.Close())
+	}
+	return errors.Join(errs...)
+}
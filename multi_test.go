@@ -0,0 +1,121 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiProvider(t *testing.T) {
+	ctx := context.Background()
+	first := NewMockProvider("example.com")
+	second := NewMockProvider("example.com")
+	multi, err := NewMultiProvider([]api.Provider{first, second})
+	require.Nil(t, err)
+	ProviderTest(t, ctx, multi, "example.com")
+
+	// writes land in both members
+	err = multi.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	expected := []api.Record{{
+		Name:    "www.example.com",
+		Type:    "A",
+		Content: []string{"10.0.0.1"},
+		TTL:     300,
+	}}
+	require.Equal(t, expected, first.Dump("example.com"))
+	require.Equal(t, expected, second.Dump("example.com"))
+
+	// a single value can be set on members without set support
+	err = multi.CreateOrUpdateDNSRecordSet(ctx, "example.com", "www.example.com", "A", []string{"10.0.0.2"}, 300, false)
+	require.Nil(t, err)
+	require.Equal(t, []string{"10.0.0.2"}, second.Dump("example.com")[0].Content)
+	err = multi.CreateOrUpdateDNSRecordSet(ctx, "example.com", "www.example.com", "A", []string{"10.0.0.3", "10.0.0.4"}, 300, false)
+	merr := &MultiProviderError{}
+	require.True(t, errors.As(err, &merr))
+	require.Equal(t, 2, len(merr.Failed))
+
+	err = multi.DeleteDNSRecord(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Empty(t, first.Dump("example.com"))
+	require.Empty(t, second.Dump("example.com"))
+}
+
+func TestMultiProviderPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	first := NewMockProvider("example.com")
+	second := NewMockProvider("example.org")
+	multi, err := NewMultiProvider([]api.Provider{first, second})
+	require.Nil(t, err)
+
+	// the write is applied to the members that can take it, and the
+	// error reports the member that failed
+	err = multi.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.NotNil(t, err)
+	merr := &MultiProviderError{}
+	require.True(t, errors.As(err, &merr))
+	require.Equal(t, 2, merr.Members)
+	require.Equal(t, 1, len(merr.Failed))
+	require.Equal(t, 1, merr.Failed[0].Index)
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+	require.Contains(t, err.Error(), "1 of 2 providers failed: provider 1 failed")
+	require.Equal(t, 1, len(first.Dump("example.com")))
+
+	err = multi.DeleteDNSRecordByType(ctx, "example.com", "www.example.com", "A")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+	require.Empty(t, first.Dump("example.com"))
+}
+
+func TestMultiProviderPrimary(t *testing.T) {
+	ctx := context.Background()
+	first := NewMockProvider("example.com")
+	second := NewMockProvider("example.com", "example.org")
+	second.Seed("example.com", api.Record{Name: "www.example.com", Type: "A", Content: []string{"10.0.0.1"}, TTL: 300})
+
+	// reads are served by the first member unless a primary is set
+	multi, err := NewMultiProvider([]api.Provider{first, second})
+	require.Nil(t, err)
+	records, err := multi.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Empty(t, records)
+
+	multi, err = NewMultiProvider([]api.Provider{first, second}, WithPrimary(1))
+	require.Nil(t, err)
+	records, err = multi.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	zones, err := multi.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(zones))
+
+	_, err = NewMultiProvider([]api.Provider{first, second}, WithPrimary(2))
+	require.NotNil(t, err)
+	_, err = NewMultiProvider(nil)
+	require.NotNil(t, err)
+}
+
+func TestMultiProviderCapabilities(t *testing.T) {
+	multi, err := NewMultiProvider([]api.Provider{NewMockProvider(), &Linode{}})
+	require.Nil(t, err)
+	caps := multi.Capabilities()
+	require.ElementsMatch(t, linodeRecordTypes, caps.SupportedRecordTypes)
+	require.False(t, caps.SupportsProxy)
+	require.False(t, caps.SupportsZoneManagement)
+}
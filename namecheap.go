@@ -0,0 +1,561 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+const (
+	CredentialKeyAPIUser  = "apiUser"
+	CredentialKeyClientIP = "clientIP"
+)
+
+const (
+	namecheapAPIURL = "https://api.namecheap.com/xml.response"
+	// namecheapPageSize is the largest page size of the domains list
+	namecheapPageSize = 100
+)
+
+// namecheapAuthErrors are the numbers of the Namecheap errors for an
+// unknown or disabled API user, API key, user name or client IP.
+var namecheapAuthErrors = []string{
+	"1010101", "1011102", "1011150", "1016103", "1017101",
+	"1017103", "1017105", "1017150", "1019103",
+}
+
+// namecheapZoneErrors are the numbers of the Namecheap errors for a
+// domain that does not exist or is not in the account.
+var namecheapZoneErrors = []string{"2019166", "2016166"}
+
+// namecheapTTLs are the TTLs setHosts accepts.
+var namecheapTTLs = ttlRange{min: 60, max: 60000}
+
+// Namecheap manages DNS records via the Namecheap XML API. Namecheap
+// zones are the registered domains of the account, using Namecheap's
+// own name servers.
+//
+// Namecheap cannot change single records. The setHosts command
+// replaces the whole host list of a domain, so every change reads the
+// list with getHosts, merges the change and writes the full list
+// back. Changes made by others between the read and the write are
+// lost, including those of other provider instances and the Namecheap
+// web interface. Changes from this provider instance are serialized.
+type Namecheap struct {
+	client   *http.Client
+	apiURL   string
+	apiUser  string
+	apiKey   string
+	username string
+	clientIP string
+	logger   api.Logger
+	zone     string // zone the provider was configured for, if any
+	// applied to TTLs outside namecheapTTLs
+	ttlPolicy TTLPolicy
+	// mu serializes read-merge-write cycles of the host lists
+	mu sync.Mutex
+}
+
+// namecheapHost is a record of a Namecheap host list. Name is relative
+// to the domain, with "@" for the apex. The MX priority is kept in
+// MXPref.
+type namecheapHost struct {
+	HostID  string `xml:"HostId,attr"`
+	Name    string `xml:"Name,attr"`
+	Type    string `xml:"Type,attr"`
+	Address string `xml:"Address,attr"`
+	MXPref  int    `xml:"MXPref,attr"`
+	TTL     int    `xml:"TTL,attr"`
+}
+
+// namecheapHosts is the host list of a domain, and the email type
+// that must be sent back with it for MX records to be served.
+type namecheapHosts struct {
+	EmailType string          `xml:"EmailType,attr"`
+	Hosts     []namecheapHost `xml:"host"`
+}
+
+type namecheapResponse struct {
+	Status string `xml:"Status,attr"`
+	Errors []struct {
+		Number  string `xml:"Number,attr"`
+		Message string `xml:",chardata"`
+	} `xml:"Errors>Error"`
+	CommandResponse struct {
+		Hosts   namecheapHosts `xml:"DomainDNSGetHostsResult"`
+		Domains []struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"DomainGetListResult>Domain"`
+		TotalItems int `xml:"Paging>TotalItems"`
+		SetHosts   struct {
+			IsSuccess bool `xml:"IsSuccess,attr"`
+		} `xml:"DomainDNSSetHostsResult"`
+	} `xml:"CommandResponse"`
+}
+
+// namecheapError is an error returned by the Namecheap API. Errors
+// for invalid credentials and unknown domains unwrap to
+// api.ErrInvalidCredentials and api.ErrZoneNotFound.
+type namecheapError struct {
+	Number  string
+	Message string
+}
+
+func (s *namecheapError) Error() string {
+	return fmt.Sprintf("namecheap error %s: %s", s.Number, s.Message)
+}
+
+func (s *namecheapError) Unwrap() error {
+	switch {
+	case slices.Contains(namecheapAuthErrors, s.Number):
+		return api.ErrInvalidCredentials
+	case slices.Contains(namecheapZoneErrors, s.Number):
+		return api.ErrZoneNotFound
+	}
+	return nil
+}
+
+// NewNamecheapProvider creates a new Namecheap DNS provider.
+func NewNamecheapProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*Namecheap, error) {
+	return NewNamecheapProviderWithCredentials(ctx, zone, namecheapCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewNamecheapProviderWithCredentials creates a new Namecheap DNS
+// provider from typed credentials.
+func NewNamecheapProviderWithCredentials(ctx context.Context, zone string, creds NamecheapCredentials, logger api.Logger, ops ...Option) (*Namecheap, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	apiURL := creds.APIURL
+	if apiURL == "" {
+		apiURL = namecheapAPIURL
+	}
+	opts := getOptions(ops)
+	client := opts.httpClient()
+	return &Namecheap{
+		client:    client,
+		apiURL:    apiURL,
+		apiUser:   creds.APIUser,
+		apiKey:    creds.APIKey,
+		username:  creds.Username,
+		clientIP:  creds.ClientIP,
+		logger:    logger,
+		zone:      zone,
+		ttlPolicy: opts.ttlPolicy,
+	}, nil
+}
+
+// do runs the API command. Parameters are sent as a form, since the
+// host list of setHosts may be too long for a URL.
+func (s *Namecheap) do(ctx context.Context, command string, params url.Values) (*namecheapResponse, error) {
+	form := url.Values{}
+	for key, vals := range params {
+		form[key] = vals
+	}
+	form.Set("ApiUser", s.apiUser)
+	form.Set("ApiKey", s.apiKey)
+	form.Set("UserName", s.username)
+	form.Set("ClientIp", s.clientIP)
+	form.Set("Command", command)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &httpError{
+			Method:     req.Method,
+			Path:       command,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       strings.TrimSpace(string(data)),
+		}
+	}
+	out := namecheapResponse{}
+	if err := xml.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("cannot parse namecheap %s response, %v", command, err)
+	}
+	if out.Status != "OK" {
+		if len(out.Errors) > 0 {
+			return nil, &namecheapError{
+				Number:  out.Errors[0].Number,
+				Message: strings.TrimSpace(out.Errors[0].Message),
+			}
+		}
+		return nil, fmt.Errorf("namecheap %s failed with status %q", command, out.Status)
+	}
+	return &out, nil
+}
+
+// namecheapDomainParams returns the SLD and TLD parameters of the
+// domain. Namecheap zones are registered domains, so everything after
+// the first label is the public suffix.
+func namecheapDomainParams(zone string) (url.Values, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	sld, tld, ok := strings.Cut(zone, ".")
+	if !ok || sld == "" || tld == "" {
+		return nil, fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	params := url.Values{}
+	params.Set("SLD", sld)
+	params.Set("TLD", tld)
+	return params, nil
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *Namecheap) Close() error {
+	return nil
+}
+
+// ValidateCredentials lists the first page of domains.
+func (s *Namecheap) ValidateCredentials(ctx context.Context) error {
+	params := url.Values{}
+	params.Set("PageSize", "10")
+	_, err := s.do(ctx, "namecheap.domains.getList", params)
+	return credentialsError(err)
+}
+
+// ListZones returns the domains of the account.
+func (s *Namecheap) ListZones(ctx context.Context) ([]api.Zone, error) {
+	zones := []api.Zone{}
+	for page := 1; ; page++ {
+		params := url.Values{}
+		params.Set("Page", strconv.Itoa(page))
+		params.Set("PageSize", strconv.Itoa(namecheapPageSize))
+		resp, err := s.do(ctx, "namecheap.domains.getList", params)
+		if err != nil {
+			return nil, err
+		}
+		for _, domain := range resp.CommandResponse.Domains {
+			zones = append(zones, newZone(domain.Name, ""))
+		}
+		if len(resp.CommandResponse.Domains) == 0 || len(zones) >= resp.CommandResponse.TotalItems {
+			break
+		}
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone is not supported, since Namecheap zones are registered
+// domains.
+func (s *Namecheap) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	return api.Zone{}, fmt.Errorf("%w, namecheap zones are created by registering the domain", api.ErrUnsupported)
+}
+
+// DeleteZone is not supported, since Namecheap zones are registered
+// domains.
+func (s *Namecheap) DeleteZone(ctx context.Context, zone string) error {
+	return fmt.Errorf("%w, namecheap zones are deleted with the domain", api.ErrUnsupported)
+}
+
+// getHosts returns the host list of the domain.
+func (s *Namecheap) getHosts(ctx context.Context, zone string) (*namecheapHosts, error) {
+	params, err := namecheapDomainParams(zone)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, "namecheap.domains.dns.getHosts", params)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.CommandResponse.Hosts, nil
+}
+
+// setHosts replaces the host list of the domain with hosts. Any host
+// left out is deleted by Namecheap.
+func (s *Namecheap) setHosts(ctx context.Context, zone string, hosts *namecheapHosts) error {
+	params, err := namecheapDomainParams(zone)
+	if err != nil {
+		return err
+	}
+	emailType := hosts.EmailType
+	for ii, host := range hosts.Hosts {
+		n := strconv.Itoa(ii + 1)
+		params.Set("HostName"+n, host.Name)
+		params.Set("RecordType"+n, host.Type)
+		params.Set("Address"+n, host.Address)
+		if host.TTL > 0 {
+			params.Set("TTL"+n, strconv.Itoa(host.TTL))
+		}
+		if host.Type == api.RecordTypeMX {
+			params.Set("MXPref"+n, strconv.Itoa(host.MXPref))
+			// MX hosts are ignored unless the email type says so
+			emailType = api.RecordTypeMX
+		}
+	}
+	if emailType != "" {
+		params.Set("EmailType", emailType)
+	}
+	resp, err := s.do(ctx, "namecheap.domains.dns.setHosts", params)
+	if err != nil {
+		return err
+	}
+	if !resp.CommandResponse.SetHosts.IsSuccess {
+		return fmt.Errorf("namecheap did not set the hosts of %s", zone)
+	}
+	return nil
+}
+
+// namecheapHostToRecord converts a host of a DNS record type. Hosts of
+// Namecheap specific types, such as URL redirects, are skipped.
+func namecheapHostToRecord(host namecheapHost, zone string) (api.Record, bool) {
+	rtype := strings.ToUpper(host.Type)
+	if !slices.Contains(namecheapRecordTypes, rtype) {
+		return api.Record{}, false
+	}
+	recName := absoluteName(host.Name, zone)
+	record := api.Record{
+		Type:    rtype,
+		Name:    recName,
+		Content: []string{host.Address},
+		TTL:     host.TTL,
+		System:  isSystemRecord(zone, recName, rtype),
+	}
+	switch rtype {
+	case api.RecordTypeMX:
+		record.Priority = host.MXPref
+	case api.RecordTypeTXT:
+		record.Content = []string{parseTXTRRData(host.Address)}
+	}
+	return canonicalTargets(withUnicodeName(record)), true
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *Namecheap) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	hosts, err := s.getHosts(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, host := range hosts.Hosts {
+		record, ok := namecheapHostToRecord(host, zone)
+		if !ok {
+			continue
+		}
+		if name != "" && !strings.EqualFold(name, record.Name) {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *Namecheap) SupportedRecordTypes() []string {
+	return slices.Clone(namecheapRecordTypes)
+}
+
+// Capabilities reports only the record types, since Namecheap zones
+// are registered domains.
+func (s *Namecheap) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes: s.SupportedRecordTypes(),
+	}
+}
+
+// newNamecheapHost builds the host for content as passed to
+// CreateOrUpdateDNSRecord, splitting the MX priority into MXPref.
+func newNamecheapHost(name, rtype, content string, ttl int) (namecheapHost, error) {
+	host := namecheapHost{
+		Name:    name,
+		Type:    rtype,
+		Address: content,
+		TTL:     ttl,
+	}
+	switch rtype {
+	case api.RecordTypeMX:
+		priority, target, err := parseMXContent(content)
+		if err != nil {
+			return namecheapHost{}, err
+		}
+		host.MXPref = priority
+		host.Address = fqdnTarget(target)
+	case api.RecordTypeTXT:
+		host.Address = txtValue(content)
+	case api.RecordTypeCNAME, api.RecordTypeNS:
+		host.Address = fqdnTarget(content)
+	}
+	return host, nil
+}
+
+// CreateOrUpdateDNSRecord changes the existing host of the name and
+// type if found, or adds a new one, then writes back the whole host
+// list of the domain. If there are several hosts, the one already
+// holding the content is kept, or else the first, and the others are
+// dropped. See Namecheap for the risk of lost updates.
+func (s *Namecheap) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.NamecheapProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, namecheapRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	ttl, err := s.ttlPolicy.normalize(api.NamecheapProvider, ttl, namecheapTTLs)
+	if err != nil {
+		return err
+	}
+	rtype = strings.ToUpper(rtype)
+	newHost, err := newNamecheapHost(relativeName(name, zone), rtype, content, ttl)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hosts, err := s.getHosts(ctx, zone)
+	if err != nil {
+		return err
+	}
+	sameAddress := func(h namecheapHost) bool {
+		if hasHostTarget(rtype) {
+			return strings.EqualFold(fqdnTarget(h.Address), fqdnTarget(newHost.Address))
+		}
+		return h.Address == newHost.Address
+	}
+	existing := []int{}
+	keep := -1
+	for ii, h := range hosts.Hosts {
+		if !strings.EqualFold(h.Type, rtype) || !strings.EqualFold(h.Name, newHost.Name) {
+			continue
+		}
+		if keep < 0 && sameAddress(h) {
+			keep = len(existing)
+		}
+		existing = append(existing, ii)
+	}
+	found := len(existing) > 0
+	changed := false
+	if !found {
+		hosts.Hosts = append(hosts.Hosts, newHost)
+		changed = true
+	} else {
+		if keep < 0 {
+			keep = 0
+		}
+		h := hosts.Hosts[existing[keep]]
+		// without a TTL Namecheap uses its default, so any TTL matches
+		if sameAddress(h) && h.MXPref == newHost.MXPref && (ttl == 0 || h.TTL == ttl) {
+			s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", content)
+		} else {
+			s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+			hosts.Hosts[existing[keep]] = newHost
+			changed = true
+		}
+		if len(existing) > 1 {
+			kept := []namecheapHost{}
+			for ii, h := range hosts.Hosts {
+				if ii != existing[keep] && slices.Contains(existing, ii) {
+					continue
+				}
+				kept = append(kept, h)
+			}
+			hosts.Hosts = kept
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := s.setHosts(ctx, zone, hosts); err != nil {
+		s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+		if found {
+			return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+		}
+		return fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *Namecheap) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *Namecheap) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *Namecheap) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+// deleteRecords writes back the host list without the DNS records of
+// the name, and of the type if given. Namecheap specific hosts of the
+// name are kept.
+func (s *Namecheap) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	relName := relativeName(name, zone)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hosts, err := s.getHosts(ctx, zone)
+	if err != nil {
+		return 0, err
+	}
+	kept := []namecheapHost{}
+	for _, h := range hosts.Hosts {
+		if strings.EqualFold(h.Name, relName) && slices.Contains(namecheapRecordTypes, strings.ToUpper(h.Type)) &&
+			(rtype == "" || strings.EqualFold(h.Type, rtype)) {
+			continue
+		}
+		kept = append(kept, h)
+	}
+	deleted := len(hosts.Hosts) - len(kept)
+	if deleted == 0 {
+		return 0, nil
+	}
+	hosts.Hosts = kept
+	if err := s.setHosts(ctx, zone, hosts); err != nil {
+		return 0, fmt.Errorf("cannot delete DNS records for zone %s name %s, %v", zone, name, err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,301 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// namecheapStub is a minimal in-memory implementation of the Namecheap
+// XML API. Like Namecheap, setHosts replaces the whole host list.
+type namecheapStub struct {
+	mu        sync.Mutex
+	domains   map[string]*namecheapHosts
+	nextID    int
+	getHosts  int
+	setHosts  int
+	lastForm  map[string]string // form of the last setHosts call
+	beforeSet func()            // called between reading and writing, if set
+}
+
+func newNamecheapStub(domains ...string) *namecheapStub {
+	s := &namecheapStub{domains: map[string]*namecheapHosts{}}
+	for _, domain := range domains {
+		s.domains[domain] = &namecheapHosts{EmailType: "FWD"}
+	}
+	return s
+}
+
+func (s *namecheapStub) write(w http.ResponseWriter, command, result string) {
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>
+<ApiResponse Status="OK" xmlns="http://api.namecheap.com/xml.response">
+  <Errors />
+  <RequestedCommand>%s</RequestedCommand>
+  <CommandResponse Type="%s">%s</CommandResponse>
+</ApiResponse>`, command, command, result)
+}
+
+func (s *namecheapStub) error(w http.ResponseWriter, number, message string) {
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>
+<ApiResponse Status="ERROR" xmlns="http://api.namecheap.com/xml.response">
+  <Errors><Error Number="%s">%s</Error></Errors>
+  <CommandResponse />
+</ApiResponse>`, number, message)
+}
+
+func (s *namecheapStub) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "text/xml")
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("ApiUser") != "user" || r.Form.Get("UserName") != "user" {
+			s.error(w, "1011102", "API Key is invalid or API access has not been enabled")
+			return
+		}
+		if r.Form.Get("ApiKey") != "key" {
+			s.error(w, "1011102", "API Key is invalid or API access has not been enabled")
+			return
+		}
+		if r.Form.Get("ClientIp") != "192.0.2.1" {
+			s.error(w, "1011150", "Invalid request IP: 192.0.2.2")
+			return
+		}
+		command := r.Form.Get("Command")
+		domain := r.Form.Get("SLD") + "." + r.Form.Get("TLD")
+		switch command {
+		case "namecheap.domains.getList":
+			names := []string{}
+			for name := range s.domains {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			result := "<DomainGetListResult>"
+			for ii, name := range names {
+				result += fmt.Sprintf(`<Domain ID="%d" Name="%s" IsOurDNS="true" />`, ii+1, name)
+			}
+			result += fmt.Sprintf("</DomainGetListResult><Paging><TotalItems>%d</TotalItems></Paging>", len(names))
+			s.write(w, command, result)
+		case "namecheap.domains.dns.getHosts":
+			hosts, ok := s.domains[domain]
+			if !ok {
+				s.error(w, "2019166", "Domain not found")
+				return
+			}
+			s.getHosts++
+			result := struct {
+				XMLName xml.Name `xml:"DomainDNSGetHostsResult"`
+				Domain  string   `xml:"Domain,attr"`
+				*namecheapHosts
+			}{Domain: domain, namecheapHosts: hosts}
+			data, _ := xml.Marshal(result)
+			s.write(w, command, string(data))
+		case "namecheap.domains.dns.setHosts":
+			if _, ok := s.domains[domain]; !ok {
+				s.error(w, "2019166", "Domain not found")
+				return
+			}
+			if s.beforeSet != nil {
+				s.beforeSet()
+			}
+			s.setHosts++
+			s.lastForm = map[string]string{}
+			for key := range r.Form {
+				s.lastForm[key] = r.Form.Get(key)
+			}
+			hosts := &namecheapHosts{EmailType: r.Form.Get("EmailType")}
+			for ii := 1; r.Form.Has("HostName" + strconv.Itoa(ii)); ii++ {
+				n := strconv.Itoa(ii)
+				s.nextID++
+				host := namecheapHost{
+					HostID:  strconv.Itoa(s.nextID),
+					Name:    r.Form.Get("HostName" + n),
+					Type:    r.Form.Get("RecordType" + n),
+					Address: r.Form.Get("Address" + n),
+					TTL:     1800,
+				}
+				if ttl := r.Form.Get("TTL" + n); ttl != "" {
+					host.TTL, _ = strconv.Atoi(ttl)
+				}
+				if mxpref := r.Form.Get("MXPref" + n); mxpref != "" {
+					host.MXPref, _ = strconv.Atoi(mxpref)
+				}
+				hosts.Hosts = append(hosts.Hosts, host)
+			}
+			s.domains[domain] = hosts
+			s.write(w, command, fmt.Sprintf(`<DomainDNSSetHostsResult Domain="%s" IsSuccess="true" />`, domain))
+		default:
+			s.error(w, "1010104", "Command is invalid")
+		}
+	})
+}
+
+func namecheapTestCredentials() NamecheapCredentials {
+	return NamecheapCredentials{
+		APIUser:  "user",
+		APIKey:   "key",
+		Username: "user",
+		ClientIP: "192.0.2.1",
+	}
+}
+
+func newNamecheapTestProvider(t *testing.T, stub *namecheapStub) api.Provider {
+	client := newStubClient(t, stub.handler())
+	prov, err := GetProvider(context.Background(), api.NamecheapProvider, "", namecheapTestCredentials().ToMap(), nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	return prov
+}
+
+func TestNamecheapStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newNamecheapStub("example.com")
+	prov := newNamecheapTestProvider(t, stub)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com")
+	duplicateRecordsTest(t, ctx, prov, "example.com", "dup.example.com", func(content string) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.nextID++
+		hosts := stub.domains["example.com"]
+		hosts.Hosts = append(hosts.Hosts, namecheapHost{HostID: strconv.Itoa(stub.nextID), Name: "dup", Type: "A", Address: content, TTL: 1800})
+	})
+
+	// the MX priority has its own field, and MX hosts are only served
+	// with the MX email type
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, "MX", stub.domains["example.com"].EmailType)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:     "example.com",
+		Type:     "MX",
+		Content:  []string{"mail.example.com."},
+		TTL:      300,
+		Priority: 10,
+	}}, records)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "example.com"}}, zones)
+
+	_, err = prov.(api.ZoneManager).CreateZone(ctx, "example.org")
+	require.ErrorIs(t, err, api.ErrUnsupported)
+}
+
+func TestNamecheapReadMergeWrite(t *testing.T) {
+	ctx := context.Background()
+	stub := newNamecheapStub("example.co.uk")
+	stub.domains["example.co.uk"].Hosts = []namecheapHost{
+		{HostID: "1", Name: "@", Type: "A", Address: "10.0.0.1", TTL: 1800},
+		{HostID: "2", Name: "www", Type: "URL301", Address: "https://example.org", TTL: 1800},
+	}
+	prov := newNamecheapTestProvider(t, stub)
+
+	// a new record is merged into the host list read before, and the
+	// whole list is written back
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.co.uk", "txt.example.co.uk", "TXT", "hello world", 600, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.getHosts)
+	require.Equal(t, 1, stub.setHosts)
+	require.Equal(t, "example", stub.lastForm["SLD"])
+	require.Equal(t, "co.uk", stub.lastForm["TLD"])
+	require.Equal(t, "@", stub.lastForm["HostName1"])
+	require.Equal(t, "10.0.0.1", stub.lastForm["Address1"])
+	require.Equal(t, "URL301", stub.lastForm["RecordType2"])
+	require.Equal(t, "txt", stub.lastForm["HostName3"])
+	require.Equal(t, "hello world", stub.lastForm["Address3"])
+	require.Equal(t, "600", stub.lastForm["TTL3"])
+	require.Equal(t, 3, len(stub.domains["example.co.uk"].Hosts))
+
+	// Namecheap specific hosts are not returned
+	records, err := prov.GetDNSRecords(ctx, "example.co.uk", "")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+
+	// nothing is written if nothing changed
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.co.uk", "txt.example.co.uk", "TXT", "hello world", 600, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.setHosts)
+
+	// an update replaces the host in place
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.co.uk", "example.co.uk", "A", "10.0.0.2", 1800, false)
+	require.Nil(t, err)
+	require.Equal(t, 2, stub.setHosts)
+	require.Equal(t, "10.0.0.2", stub.lastForm["Address1"])
+	require.Equal(t, 3, len(stub.domains["example.co.uk"].Hosts))
+
+	// deletes write back the list without the records of the name,
+	// keeping Namecheap specific hosts of the same name
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.co.uk", "www.example.co.uk", "TXT", "www", 1800, false)
+	require.Nil(t, err)
+	count, err := prov.(api.DeleteCounter).DeleteDNSRecordCount(ctx, "example.co.uk", "www.example.co.uk")
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+	hosts := stub.domains["example.co.uk"].Hosts
+	require.Equal(t, 3, len(hosts))
+	require.Equal(t, "URL301", hosts[1].Type)
+	setHosts := stub.setHosts
+	count, err = prov.(api.DeleteCounter).DeleteDNSRecordCount(ctx, "example.co.uk", "www.example.co.uk")
+	require.Nil(t, err)
+	require.Equal(t, 0, count)
+	require.Equal(t, setHosts, stub.setHosts)
+
+	// a host added by someone else between the read and the write is
+	// lost, which is the risk of replacing the whole list
+	stub.beforeSet = func() {
+		stub.domains["example.co.uk"].Hosts = append(stub.domains["example.co.uk"].Hosts,
+			namecheapHost{HostID: "100", Name: "other", Type: "A", Address: "10.0.0.3", TTL: 1800})
+		stub.beforeSet = nil
+	}
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.co.uk", "new.example.co.uk", "A", "10.0.0.4", 1800, false)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.co.uk", "other.example.co.uk")
+	require.Nil(t, err)
+	require.Equal(t, 0, len(records))
+}
+
+func TestNamecheapCredentials(t *testing.T) {
+	ctx := context.Background()
+	client := newStubClient(t, newNamecheapStub("example.com").handler())
+
+	creds := namecheapTestCredentials()
+	err := ValidateCredentials(ctx, api.NamecheapProvider, creds.ToMap(), WithHTTPClient(client))
+	require.Nil(t, err)
+	creds.APIKey = "wrong"
+	err = ValidateCredentials(ctx, api.NamecheapProvider, creds.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+	creds = namecheapTestCredentials()
+	creds.ClientIP = "192.0.2.2"
+	err = ValidateCredentials(ctx, api.NamecheapProvider, creds.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+
+	err = NamecheapCredentials{APIUser: "user", APIKey: "key", Username: "user"}.Validate()
+	require.EqualError(t, err, "missing clientIP key from namecheap dns provider credentials data")
+}
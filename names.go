@@ -0,0 +1,85 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+	"golang.org/x/net/idna"
+)
+
+// apexName is the relative name many backends use for the zone apex.
+const apexName = "@"
+
+// relativeName strips the zone suffix from a fully qualified name,
+// returning "@" for the zone apex. Names outside the zone are
+// returned unchanged (without a trailing dot).
+func relativeName(name, zone string) string {
+	name = strings.TrimSuffix(name, ".")
+	zone = strings.TrimSuffix(zone, ".")
+	if strings.EqualFold(name, zone) {
+		return apexName
+	}
+	suffix := "." + zone
+	if len(name) > len(suffix) && strings.EqualFold(name[len(name)-len(suffix):], suffix) {
+		return name[:len(name)-len(suffix)]
+	}
+	return name
+}
+
+// unicodeName returns the Unicode form of a name with punycode
+// labels, or "" if the name has none or cannot be decoded.
+func unicodeName(name string) string {
+	if !strings.Contains(strings.ToLower(name), "xn--") {
+		return ""
+	}
+	uname, err := idna.Lookup.ToUnicode(name)
+	if err != nil {
+		return ""
+	}
+	return uname
+}
+
+// withUnicodeName sets the UnicodeName of a record read back from a
+// backend.
+func withUnicodeName(record api.Record) api.Record {
+	record.UnicodeName = unicodeName(record.Name)
+	return record
+}
+
+// isSystemRecord reports whether the record is one the backend
+// manages itself, namely the NS and SOA records at the zone apex.
+func isSystemRecord(zone, name, rtype string) bool {
+	if rtype != api.RecordTypeNS && rtype != "SOA" {
+		return false
+	}
+	return strings.EqualFold(strings.TrimSuffix(name, "."), strings.TrimSuffix(zone, "."))
+}
+
+// absoluteName converts a name relative to the zone back into the
+// fully qualified form (without a trailing dot) used by this package.
+// An empty name or "@" refers to the zone apex.
+func absoluteName(name, zone string) string {
+	zone = strings.TrimSuffix(zone, ".")
+	if name == "" || name == apexName {
+		return zone
+	}
+	name = strings.TrimSuffix(name, ".")
+	if strings.EqualFold(name, zone) || strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(zone)) {
+		return name
+	}
+	return name + "." + zone
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNames(t *testing.T) {
+	var tests = []struct {
+		fqdn string
+		zone string
+		rel  string
+	}{
+		{"example.com", "example.com", "@"},
+		{"example.com.", "example.com.", "@"},
+		{"www.example.com", "example.com", "www"},
+		{"a.b.example.com.", "example.com", "a.b"},
+		{"*.example.com", "example.com", "*"},
+		{"WWW.Example.com", "example.com", "WWW"},
+		{"1.2.0.192.in-addr.arpa", "2.0.192.in-addr.arpa", "1"},
+		{"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", "8.b.d.0.1.0.0.2.ip6.arpa", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0"},
+	}
+	for _, test := range tests {
+		rel := relativeName(test.fqdn, test.zone)
+		require.Equal(t, test.rel, rel, test.fqdn)
+		require.Equal(t, relativeName(absoluteName(rel, test.zone), test.zone), rel, test.fqdn)
+	}
+	require.Equal(t, "example.com", absoluteName("", "example.com."))
+	require.Equal(t, "www.example.com", absoluteName("www.example.com.", "example.com"))
+	// names outside the zone are left alone
+	require.Equal(t, "other.org", relativeName("other.org", "example.com"))
+}
+
+func TestReverseName(t *testing.T) {
+	require.Equal(t, "1.2.0.192.in-addr.arpa", api.ReverseName(net.ParseIP("192.0.2.1")))
+	require.Equal(t, "1.2.0.192.in-addr.arpa", api.ReverseName(net.IPv4(192, 0, 2, 1).To4()))
+	require.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", api.ReverseName(net.ParseIP("2001:db8::1")))
+	require.Equal(t, "", api.ReverseName(nil))
+	require.True(t, api.IsHostname(api.ReverseName(net.ParseIP("2001:db8::1"))))
+}
+
+func TestUnicodeName(t *testing.T) {
+	require.Equal(t, "bücher.example", unicodeName("xn--bcher-kva.example"))
+	require.Equal(t, "www.bücher.example.", unicodeName("www.XN--BCHER-KVA.example."))
+	require.Equal(t, "", unicodeName("www.example.com"))
+	// names that are not valid punycode are left without one
+	require.Equal(t, "", unicodeName("xn--a.example"))
+
+	ctx := context.Background()
+	zone := "xn--bcher-kva.example"
+	prov := NewMockProvider(zone)
+	err := prov.CreateOrUpdateDNSRecord(ctx, zone, "www."+zone, "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, zone, "www."+zone)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "www.xn--bcher-kva.example", records[0].Name)
+	require.Equal(t, "www.bücher.example", records[0].UnicodeName)
+}
@@ -0,0 +1,345 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+	ns1 "gopkg.in/ns1/ns1-go.v2/rest"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+)
+
+// NS1 manages DNS records via the NS1 API. NS1 keeps all values of a
+// name and type in one record as its answers, and requires record
+// domains to be fully qualified, without the trailing dot.
+type NS1 struct {
+	client *http.Client
+	apiKey string
+	logger api.Logger
+	zone   string // zone the provider was configured for, if any
+}
+
+// ns1Doer passes the context of the provider call on to the requests
+// made by the SDK, which does not take one itself.
+type ns1Doer struct {
+	ctx    context.Context
+	client *http.Client
+}
+
+func (s ns1Doer) Do(req *http.Request) (*http.Response, error) {
+	return s.client.Do(req.WithContext(s.ctx))
+}
+
+// NewNS1Provider creates a new NS1 provider.
+func NewNS1Provider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*NS1, error) {
+	return NewNS1ProviderWithCredentials(ctx, zone, ns1CredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewNS1ProviderWithCredentials creates a new NS1 provider from typed
+// credentials.
+func NewNS1ProviderWithCredentials(ctx context.Context, zone string, creds NS1Credentials, logger api.Logger, ops ...Option) (*NS1, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	opts := getOptions(ops)
+	client := opts.httpClient()
+	return &NS1{
+		client: client,
+		apiKey: creds.APIKey,
+		logger: logger,
+		zone:   zone,
+	}, nil
+}
+
+// api returns an SDK client whose requests use the context.
+func (s *NS1) api(ctx context.Context) *ns1.Client {
+	return ns1.NewClient(ns1Doer{ctx: ctx, client: s.client}, ns1.SetAPIKey(s.apiKey))
+}
+
+// ns1ZoneError converts the errors NS1 returns for a missing zone into
+// api.ErrZoneNotFound. Other errors are returned unchanged.
+func ns1ZoneError(err error, zone string) error {
+	var restErr *ns1.Error
+	if errors.Is(err, ns1.ErrZoneMissing) || errors.As(err, &restErr) && restErr.Message == "zone not found" {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	return err
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *NS1) Close() error {
+	return nil
+}
+
+// ValidateCredentials lists the zones.
+func (s *NS1) ValidateCredentials(ctx context.Context) error {
+	_, _, err := s.api(ctx).Zones.List()
+	return credentialsError(err)
+}
+
+// ListZones returns the zones the API key can access.
+func (s *NS1) ListZones(ctx context.Context) ([]api.Zone, error) {
+	nzones, _, err := s.api(ctx).Zones.List()
+	if err != nil {
+		return nil, err
+	}
+	zones := []api.Zone{}
+	for _, z := range nzones {
+		zones = append(zones, newZone(z.Zone, z.ID))
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone creates the zone.
+func (s *NS1) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	nzone := dns.NewZone(zone)
+	if _, err := s.api(ctx).Zones.Create(nzone); err != nil {
+		return api.Zone{}, fmt.Errorf("cannot create zone %s, %v", zone, err)
+	}
+	return newZone(nzone.Zone, nzone.ID), nil
+}
+
+// DeleteZone deletes the zone and all its records.
+func (s *NS1) DeleteZone(ctx context.Context, zone string) error {
+	zone = strings.TrimSuffix(zone, ".")
+	_, err := s.api(ctx).Zones.Delete(zone)
+	if err != nil {
+		if err = ns1ZoneError(err, zone); errors.Is(err, api.ErrZoneNotFound) {
+			return err
+		}
+		return fmt.Errorf("cannot delete zone %s, %v", zone, err)
+	}
+	return nil
+}
+
+// zoneRecords returns the answer-grouped records of the zone, or only
+// those of the name if set. Linked records, which have no answers of
+// their own, are left out.
+func (s *NS1) zoneRecords(ctx context.Context, zone, name string) ([]*dns.ZoneRecord, error) {
+	zone = strings.TrimSuffix(zone, ".")
+	nzone, _, err := s.api(ctx).Zones.Get(zone)
+	if err != nil {
+		return nil, ns1ZoneError(err, zone)
+	}
+	records := []*dns.ZoneRecord{}
+	for _, rec := range nzone.Records {
+		if rec.Link != "" {
+			continue
+		}
+		if name != "" && !strings.EqualFold(rec.Domain, absoluteName(name, zone)) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *NS1) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	nrecords, err := s.zoneRecords(ctx, zone, name)
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, nrec := range nrecords {
+		content := []string{}
+		for _, answer := range nrec.ShortAns {
+			if nrec.Type == api.RecordTypeTXT {
+				answer = txtRRData(answer)
+			}
+			content = append(content, answer)
+		}
+		record := api.Record{
+			Type:    nrec.Type,
+			Name:    nrec.Domain,
+			Content: content,
+			TTL:     nrec.TTL,
+			System:  isSystemRecord(zone, nrec.Domain, nrec.Type),
+			// the id is that of the whole record set
+			ID: nrec.ID,
+		}
+		records = append(records, fromPresentation(record)...)
+	}
+	return records, nil
+}
+
+// ns1Rdata converts content as passed to CreateOrUpdateDNSRecord into
+// the rdata fields of an NS1 answer, which keeps host names without
+// the trailing dot.
+func ns1Rdata(rtype, content string) ([]string, error) {
+	switch rtype {
+	case api.RecordTypeMX:
+		priority, target, err := parseMXContent(content)
+		if err != nil {
+			return nil, err
+		}
+		return []string{strconv.Itoa(priority), strings.TrimSuffix(target, ".")}, nil
+	case api.RecordTypeSRV:
+		priority, weight, port, target, err := parseSRVContent(content)
+		if err != nil {
+			return nil, err
+		}
+		return []string{strconv.Itoa(priority), strconv.Itoa(weight), strconv.Itoa(port), strings.TrimSuffix(target, ".")}, nil
+	case api.RecordTypeTXT:
+		return []string{txtValue(content)}, nil
+	case api.RecordTypeCNAME, api.RecordTypeNS, api.RecordTypePTR:
+		return []string{strings.TrimSuffix(content, ".")}, nil
+	}
+	return []string{content}, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *NS1) SupportedRecordTypes() []string {
+	return slices.Clone(ns1RecordTypes)
+}
+
+// Capabilities reports that NS1 can manage zones.
+func (s *NS1) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// CreateOrUpdateDNSRecord sets the answers of the record of the name
+// and type to the content, creating the record if needed.
+func (s *NS1) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	return s.CreateOrUpdateDNSRecordSet(ctx, zone, name, rtype, []string{content}, ttl, proxy)
+}
+
+// CreateOrUpdateDNSRecordSet sets the answers of the record of the
+// name and type to the contents, creating the record if needed.
+func (s *NS1) CreateOrUpdateDNSRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) error {
+	if err := checkProxy(api.NS1Provider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, ns1RecordTypes); err != nil {
+		return err
+	}
+	if len(contents) == 0 {
+		return fmt.Errorf("no content specified for %s record %s", rtype, name)
+	}
+	rtype = strings.ToUpper(rtype)
+	answers := []*dns.Answer{}
+	for _, content := range contents {
+		rdata, err := ns1Rdata(rtype, content)
+		if err != nil {
+			return err
+		}
+		answers = append(answers, dns.NewAnswer(rdata))
+	}
+	// record domains must be fully qualified, even though the zone
+	// is part of the path
+	zone = strings.TrimSuffix(zone, ".")
+	domain := absoluteName(name, zone)
+	client := s.api(ctx)
+
+	existing, _, err := client.Records.Get(zone, domain, rtype)
+	if errors.Is(err, ns1.ErrRecordMissing) {
+		record := dns.NewRecord(zone, domain, rtype)
+		record.Answers = answers
+		record.TTL = ttl
+		if _, err := client.Records.Create(record); err != nil {
+			err = ns1ZoneError(err, zone)
+			s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+			return fmt.Errorf("cannot create DNS record for zone %s, %w", zone, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return ns1ZoneError(err, zone)
+	}
+	if existing.TTL == ttl && ns1SameAnswers(existing.Answers, answers) {
+		s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", contents)
+		return nil
+	}
+	s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", contents)
+	existing.Answers = answers
+	existing.TTL = ttl
+	if _, err := client.Records.Update(existing); err != nil {
+		return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+	}
+	return nil
+}
+
+// ns1SameAnswers reports whether the answers have the same rdata,
+// ignoring their order.
+func ns1SameAnswers(a, b []*dns.Answer) bool {
+	key := func(answers []*dns.Answer) []string {
+		keys := []string{}
+		for _, answer := range answers {
+			keys = append(keys, strings.Join(answer.Rdata, " "))
+		}
+		slices.Sort(keys)
+		return keys
+	}
+	return slices.Equal(key(a), key(b))
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *NS1) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number of answers deleted.
+func (s *NS1) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *NS1) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+func (s *NS1) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	nrecords, err := s.zoneRecords(ctx, zone, name)
+	if err != nil {
+		return 0, err
+	}
+	client := s.api(ctx)
+	deleted := 0
+	for _, rec := range nrecords {
+		if rtype != "" && !strings.EqualFold(rec.Type, rtype) {
+			continue
+		}
+		_, err := client.Records.Delete(strings.TrimSuffix(zone, "."), rec.Domain, rec.Type)
+		if err != nil && !errors.Is(err, ns1.ErrRecordMissing) {
+			return deleted, fmt.Errorf("delete DNS record %s %s failed, %v", rec.Type, rec.Domain, err)
+		}
+		deleted += len(rec.ShortAns)
+	}
+	return deleted, nil
+}
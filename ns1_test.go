@@ -0,0 +1,227 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+)
+
+// ns1Stub is a minimal in-memory implementation of the NS1 zones and
+// records API.
+type ns1Stub struct {
+	mu       sync.Mutex
+	zones    map[string][]*dns.Record
+	delay    time.Duration
+	requests []string // method and path of the requests, in order
+}
+
+func newNS1Stub(zones ...string) *ns1Stub {
+	s := &ns1Stub{zones: map[string][]*dns.Record{}}
+	for _, zone := range zones {
+		s.zones[zone] = []*dns.Record{}
+	}
+	return s
+}
+
+func (s *ns1Stub) notFound(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+func (s *ns1Stub) find(zone, domain, rtype string) int {
+	for ii, rec := range s.zones[zone] {
+		if rec.Domain == domain && rec.Type == rtype {
+			return ii
+		}
+	}
+	return -1
+}
+
+func (s *ns1Stub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/zones", func(w http.ResponseWriter, r *http.Request) {
+		zones := []dns.Zone{}
+		for name := range s.zones {
+			zones = append(zones, dns.Zone{ID: "id-" + name, Zone: name})
+		}
+		json.NewEncoder(w).Encode(zones)
+	})
+	mux.HandleFunc("GET /v1/zones/{zone}", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		recs, ok := s.zones[zone]
+		if !ok {
+			s.notFound(w, "zone not found")
+			return
+		}
+		resp := map[string]interface{}{"zone": zone, "id": "id-" + zone}
+		records := []map[string]interface{}{}
+		for _, rec := range recs {
+			answers := []string{}
+			for _, answer := range rec.Answers {
+				answers = append(answers, strings.Join(answer.Rdata, " "))
+			}
+			records = append(records, map[string]interface{}{
+				"domain":        rec.Domain,
+				"type":          rec.Type,
+				"ttl":           rec.TTL,
+				"short_answers": answers,
+			})
+		}
+		resp["records"] = records
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("GET /v1/zones/{zone}/{domain}/{type}", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		if _, ok := s.zones[zone]; !ok {
+			s.notFound(w, "zone not found")
+			return
+		}
+		ii := s.find(zone, r.PathValue("domain"), r.PathValue("type"))
+		if ii < 0 {
+			s.notFound(w, "record not found")
+			return
+		}
+		json.NewEncoder(w).Encode(s.zones[zone][ii])
+	})
+	mux.HandleFunc("PUT /v1/zones/{zone}/{domain}/{type}", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		if _, ok := s.zones[zone]; !ok {
+			s.notFound(w, "zone not found")
+			return
+		}
+		rec := &dns.Record{}
+		json.NewDecoder(r.Body).Decode(rec)
+		if !strings.HasSuffix(rec.Domain, "."+zone) && rec.Domain != zone {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": "invalid: FQDN must end with zone"})
+			return
+		}
+		s.zones[zone] = append(s.zones[zone], rec)
+		json.NewEncoder(w).Encode(rec)
+	})
+	mux.HandleFunc("POST /v1/zones/{zone}/{domain}/{type}", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		ii := s.find(zone, r.PathValue("domain"), r.PathValue("type"))
+		if ii < 0 {
+			s.notFound(w, "record not found")
+			return
+		}
+		rec := &dns.Record{}
+		json.NewDecoder(r.Body).Decode(rec)
+		s.zones[zone][ii] = rec
+		json.NewEncoder(w).Encode(rec)
+	})
+	mux.HandleFunc("DELETE /v1/zones/{zone}/{domain}/{type}", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		ii := s.find(zone, r.PathValue("domain"), r.PathValue("type"))
+		if ii < 0 {
+			s.notFound(w, "record not found")
+			return
+		}
+		s.zones[zone] = append(s.zones[zone][:ii], s.zones[zone][ii+1:]...)
+		w.Write([]byte("{}"))
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(s.delay)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get("X-NSONE-Key") != "test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Unauthorized"})
+			return
+		}
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func newNS1TestProvider(t *testing.T, stub *ns1Stub) api.Provider {
+	client := newStubClient(t, stub.handler())
+	creds := NS1Credentials{APIKey: "test"}
+	prov, err := GetProvider(context.Background(), api.NS1Provider, "", creds.ToMap(), nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	return prov
+}
+
+func TestNS1Stub(t *testing.T) {
+	ctx := context.Background()
+	stub := newNS1Stub("example.com")
+	prov := newNS1TestProvider(t, stub)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	recordSetTest(t, ctx, prov.(api.RecordSetUpdater), "example.com", "set.example.com")
+
+	// relative names are made fully qualified, and multiple values
+	// are answers of one record
+	err := prov.(api.RecordSetUpdater).CreateOrUpdateDNSRecordSet(ctx, "example.com", "mail", "MX", []string{"10 mx1.example.com.", "20 mx2.example.com."}, 300, false)
+	require.Nil(t, err)
+	rec := stub.zones["example.com"][stub.find("example.com", "mail.example.com", "MX")]
+	require.Equal(t, 2, len(rec.Answers))
+	require.Equal(t, []string{"10", "mx1.example.com"}, rec.Answers[0].Rdata)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "mail.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	require.Equal(t, []string{"mx1.example.com."}, records[0].Content)
+	require.Equal(t, 10, records[0].Priority)
+	require.Equal(t, 20, records[1].Priority)
+
+	// an unchanged record is not updated
+	stub.requests = nil
+	err = prov.(api.RecordSetUpdater).CreateOrUpdateDNSRecordSet(ctx, "example.com", "mail.example.com", "MX", []string{"20 mx2.example.com", "10 mx1.example.com"}, 300, false)
+	require.Nil(t, err)
+	require.Equal(t, []string{"GET /v1/zones/example.com/mail.example.com/MX"}, stub.requests)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "id-example.com"}}, zones)
+}
+
+func TestNS1Context(t *testing.T) {
+	stub := newNS1Stub("example.com")
+	stub.delay = 200 * time.Millisecond
+	prov := newNS1TestProvider(t, stub)
+
+	// the SDK takes no context, so it is added to its requests
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := prov.GetDNSRecords(ctx, "example.com", "")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestNS1Credentials(t *testing.T) {
+	ctx := context.Background()
+	client := newStubClient(t, newNS1Stub("example.com").handler())
+
+	err := ValidateCredentials(ctx, api.NS1Provider, NS1Credentials{APIKey: "test"}.ToMap(), WithHTTPClient(client))
+	require.Nil(t, err)
+	err = ValidateCredentials(ctx, api.NS1Provider, NS1Credentials{APIKey: "wrong"}.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+
+	err = NS1Credentials{}.Validate()
+	require.EqualError(t, err, "missing apiKey key from ns1 dns provider credentials data")
+}
@@ -2,23 +2,45 @@ package dnsproviders
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	golangsdk "github.com/opentelekomcloud/gophertelekomcloud"
 	"github.com/opentelekomcloud/gophertelekomcloud/openstack"
+	"github.com/opentelekomcloud/gophertelekomcloud/openstack/dns/v2/nameservers"
 	"github.com/opentelekomcloud/gophertelekomcloud/openstack/dns/v2/recordsets"
 	"github.com/opentelekomcloud/gophertelekomcloud/openstack/dns/v2/zones"
+	"github.com/opentelekomcloud/gophertelekomcloud/openstack/identity/v3/tokens"
+	"github.com/opentelekomcloud/gophertelekomcloud/pagination"
 
 	"github.com/edgexr/dnsproviders/api"
 )
 
+// OTC manages records in Open Telekom Cloud DNS zones. Each name and
+// type is one record set holding all of its values. The NS and SOA
+// record sets at the zone apex are created with the zone and cannot
+// be changed, so NS records can only be set on subnames, to delegate
+// them. When the IAM token expires, the first request to be rejected
+// with 401 Unauthorized issues a new token with the credentials and
+// is retried once. Concurrent requests rejected with the same token
+// wait for it rather than each issuing their own.
 type OTC struct {
 	client *golangsdk.ProviderClient
-	dns    *golangsdk.ServiceClient
-	logger api.Logger
-	region string
+	// kept to issue new tokens when validating the credentials
+	authOptions golangsdk.AuthOptions
+	dns         *golangsdk.ServiceClient
+	logger      api.Logger
+	region      string
+	zone        string // zone the provider was configured for, if any
+	tenant      string
+	// shared zone ID cache, nil if disabled
+	zoneCache *zoneIDCache
+	// limits on listings, unlimited if zero
+	maxListPages    int
+	maxListDuration time.Duration
 }
 
 var _ api.Provider = OTC{}
@@ -32,36 +54,59 @@ const (
 	identityEndpointFormat  = "https://iam.%s.otc.t-systems.com/v3"
 )
 
+// ErrZoneNotFound and ErrRecordNotFound are the shared sentinel errors,
+// kept here for compatibility.
 var (
-	ErrZoneNotFound   = errors.New("could not find zone by the given name")
-	ErrRecordNotFound = errors.New("could not find record by the given name")
+	ErrZoneNotFound   = api.ErrZoneNotFound
+	ErrRecordNotFound = api.ErrRecordNotFound
 )
 
-func NewOtcProvider(_ context.Context, _ string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*OTC, error) {
-	for _, key := range []string{CredentialKeyRegion, CredentialKeyDomainName, CredentialKeyTenantName, CredentialKeyUsername, CredentialKeyPassword} {
-		if _, isSet := credentialsData[key]; !isSet {
-			return nil, fmt.Errorf("missing key %s is credentialData", key)
-		}
-	}
+// NewOtcProvider creates a new Open Telekom Cloud DNS provider from
+// credentials data. An HTTP client set with WithHTTPClient is used for
+// the identity service as well as for DNS.
+func NewOtcProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*OTC, error) {
+	return NewOtcProviderWithCredentials(ctx, zone, otcCredentialsFromMap(credentialsData), logger, ops...)
+}
 
-	client, err := openstack.AuthenticatedClient(golangsdk.AuthOptions{
-		IdentityEndpoint: fmt.Sprintf(identityEndpointFormat, credentialsData[CredentialKeyRegion]),
-		DomainName:       credentialsData[CredentialKeyDomainName],
-		TenantName:       credentialsData[CredentialKeyTenantName],
-		Username:         credentialsData[CredentialKeyUsername],
-		Password:         credentialsData[CredentialKeyPassword],
-	})
-	opts := getOptions(ops)
-	if opts.client != nil {
-		client.HTTPClient = *opts.client
+// NewOtcProviderWithCredentials creates a new Open Telekom Cloud DNS
+// provider from typed credentials. It authenticates with the identity
+// service, which is aborted if the context is cancelled or its
+// deadline passes.
+func NewOtcProviderWithCredentials(ctx context.Context, zone string, creds OTCCredentials, logger api.Logger, ops ...Option) (*OTC, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
 	}
 
+	authOptions := golangsdk.AuthOptions{
+		IdentityEndpoint: fmt.Sprintf(identityEndpointFormat, creds.Region),
+		DomainName:       creds.DomainName,
+		TenantName:       creds.TenantName,
+		Username:         creds.Username,
+		Password:         creds.Password,
+	}
+	client, err := openstack.NewClient(authOptions.IdentityEndpoint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize authenticated client: %v", err)
+		return nil, fmt.Errorf("failed to initialize authenticated client: %w", err)
 	}
+	// serializes reauthentication, which is skipped by requests whose
+	// token was already replaced
+	client.UseTokenLock()
+	opts := getOptions(ops)
+	httpClient := opts.httpClient()
+	// the client has no context of its own, so the requests made to
+	// authenticate are bound to the context, and later ones are not
+	authClient := *httpClient
+	authClient.Transport = &contextTransport{ctx: ctx, base: httpClient.Transport}
+	client.HTTPClient = authClient
+	if err := openstack.Authenticate(client, authOptions); err != nil {
+		return nil, fmt.Errorf("failed to initialize authenticated client: %w", err)
+	}
+	client.HTTPClient = *httpClient
+	client.ReauthFunc = otcReauthFunc(client, authOptions)
 
 	dns, err := openstack.NewDNSV2(client, golangsdk.EndpointOpts{
-		Region: credentialsData[CredentialKeyRegion],
+		Region: creds.Region,
 	})
 
 	if err != nil {
@@ -69,122 +114,445 @@ func NewOtcProvider(_ context.Context, _ string, credentialsData map[string]stri
 	}
 
 	return &OTC{
-		client: client,
-		dns:    dns,
-		region: credentialsData[CredentialKeyRegion],
-		logger: logger,
+		client:          client,
+		authOptions:     authOptions,
+		dns:             dns,
+		region:          creds.Region,
+		zone:            zone,
+		tenant:          creds.TenantName,
+		zoneCache:       opts.zoneCache,
+		logger:          logger,
+		maxListPages:    opts.maxListPages,
+		maxListDuration: opts.maxListDuration,
 	}, nil
 }
 
-func (o OTC) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
-	z, err := o.findZoneByName(ctx, zone)
-	if err != nil {
-		return nil, err
+// otcReauthFunc returns the function called by the client to issue a
+// new token when a request is rejected with 401 Unauthorized, after
+// which the request is retried once. The token is issued with a copy
+// of the client that does not reauthenticate, since a rejected token
+// request would otherwise reauthenticate again while the client's
+// token lock is held, and deadlock.
+func otcReauthFunc(client *golangsdk.ProviderClient, authOptions golangsdk.AuthOptions) func() error {
+	return func() error {
+		authClient := *client
+		authClient.ReauthFunc = nil
+		authClient.TokenID = ""
+		if err := openstack.Authenticate(&authClient, authOptions); err != nil {
+			return err
+		}
+		// the caller holds the token lock, so SetToken cannot be used
+		client.TokenID = authClient.TokenID
+		return nil
 	}
+}
 
-	zoneID := z.ID
+// contextTransport sends each request with the context, for clients
+// that do not take one.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (s *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.base.RoundTrip(req.WithContext(s.ctx))
+}
 
-	recordSets, err := o.listRecordSets(ctx, zoneID, name, "")
+func (o OTC) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	var recordSets []recordsets.RecordSet
+	err := o.withZoneID(ctx, zone, func(zoneID string) (err error) {
+		recordSets, err = o.listRecordSets(ctx, zoneID, name, "")
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	// the name filter of the listing is a fuzzy match, and names
+	// are returned relative to the zone as they are given
 	var apiRecords []api.Record
 	for _, rec := range recordSets {
-		fqdn := fmt.Sprintf("%s.%s", name, zone)
-		if name == "" || rec.Name == fqdn {
-			apiRecords = append(apiRecords, api.Record{
-				Type:    rec.Type,
-				Name:    name,
-				Content: rec.Records,
-				TTL:     rec.TTL,
-			})
+		recName := relativeName(rec.Name, zone)
+		if name != "" && !otcSameName(zone, rec.Name, name) {
+			continue
 		}
+		apiRecords = append(apiRecords, fromPresentation(api.Record{
+			Type:    rec.Type,
+			Name:    recName,
+			Content: rec.Records,
+			TTL:     rec.TTL,
+			System:  isSystemRecord(zone, rec.Name, rec.Type),
+		})...)
 	}
 
 	return apiRecords, nil
 }
 
-func (o OTC) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
-	z, err := o.findZoneByName(ctx, zone)
-	if err != nil {
-		return err
+// SupportedRecordTypes returns the record types that can be created.
+func (o OTC) SupportedRecordTypes() []string {
+	return slices.Clone(otcRecordTypes)
+}
+
+// Capabilities reports that OTC can manage zones. Batches are applied
+// record by record.
+func (o OTC) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   o.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
 	}
+}
 
-	zoneID := z.ID
+func (o OTC) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	return o.setRecordSet(ctx, zone, name, rtype, []string{content}, ttl, proxy)
+}
 
-	records, err := o.listRecordSets(ctx, zoneID, name, rtype)
-	if err != nil {
+// CreateOrUpdateDNSRecordSet sets the complete list of values for the
+// name and type, replacing any existing values. No change is made if
+// the existing values, in any order, and TTL already match.
+func (o OTC) CreateOrUpdateDNSRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) error {
+	return o.setRecordSet(ctx, zone, name, rtype, contents, ttl, proxy)
+}
+
+func (o OTC) setRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) error {
+	if err := checkProxy(api.OpenTelekomCloudProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, otcRecordTypes); err != nil {
 		return err
 	}
+	if err := validateContent(name, rtype, ttl, contents...); err != nil {
+		return err
+	}
+	if err := checkDelegation(zone, name, rtype); err != nil {
+		return err
+	}
+	if len(contents) == 0 {
+		return fmt.Errorf("no content specified for %s", name)
+	}
+	rrdatas := []string{}
+	for _, content := range contents {
+		rrdata, err := toPresentation(rtype, content)
+		if err != nil {
+			return err
+		}
+		rrdatas = append(rrdatas, rrdata)
+	}
+	return o.withZoneID(ctx, zone, func(zoneID string) error {
+		listed, err := o.listRecordSets(ctx, zoneID, name, rtype)
+		if err != nil {
+			return err
+		}
+		// the name filter of the listing is a fuzzy match
+		records := []recordsets.RecordSet{}
+		for _, record := range listed {
+			if otcSameName(zone, record.Name, name) {
+				records = append(records, record)
+			}
+		}
 
-	if len(records) == 0 {
-		if err := o.createDNSRecord(ctx, zoneID, fmt.Sprintf("%s.%s", name, zone), rtype, content, ttl, proxy); err != nil {
-			return fmt.Errorf("failed to create record in zoneID '%s' (zone name '%s') with name %s: %v", zoneID, zone, name, err)
+		if len(records) == 0 {
+			fqdn := absoluteName(name, zone) + "."
+			if err := o.createDNSRecord(ctx, zoneID, fqdn, rtype, rrdatas, ttl, proxy); err != nil {
+				return err
+			}
+
+			return nil
 		}
 
-		return nil
-	}
+		record := records[0]
+
+		// no change
+		if record.TTL == ttl && sameValues(record.Records, rrdatas) {
+			return nil
+		}
 
-	record := records[0]
+		result := recordsets.Update(o.dns, zoneID, record.ID, recordsets.UpdateOpts{
+			TTL:     ttl,
+			Records: rrdatas,
+		})
+
+		if result.Err != nil {
+			return fmt.Errorf("failed to update record for zone %s (name='%s'): %v", zone, name, result.Err)
+		}
 
-	// no change
-	if record.TTL == ttl && record.Records[0] == strings.Trim(content, "\"") {
 		return nil
+	})
+}
+
+// UpdateTTL updates only the TTL of the record sets of the name and
+// type, resending their existing Records.
+func (o OTC) UpdateTTL(ctx context.Context, zone, name, rtype string, ttl int) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to update")
 	}
+	rtype = strings.ToUpper(rtype)
+	return o.withZoneID(ctx, zone, func(zoneID string) error {
+		records, err := o.listRecordSets(ctx, zoneID, name, rtype)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, record := range records {
+			if !otcSameName(zone, record.Name, name) {
+				continue
+			}
+			found = true
+			if record.TTL == ttl {
+				continue
+			}
+			result := recordsets.Update(o.dns, zoneID, record.ID, recordsets.UpdateOpts{
+				TTL:     ttl,
+				Records: record.Records,
+			})
+			if result.Err != nil {
+				return fmt.Errorf("failed to update record ttl for zone %s (name='%s'): %v", zone, name, result.Err)
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: zone %s name %s type %s", api.ErrRecordNotFound, zone, name, rtype)
+		}
+		return nil
+	})
+}
+
+// BatchCreateOrUpdateDNSRecords applies each record in turn. Failed
+// records do not stop the batch, and their errors are joined in the
+// result.
+func (o OTC) BatchCreateOrUpdateDNSRecords(ctx context.Context, zone string, records []api.Record) error {
+	return applyRecords(ctx, o, zone, records)
+}
+
+func (o OTC) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := o.deleteRecordSets(ctx, zone, name, "")
+	return err
+}
 
-	// wrap content in quotation marks, if not already quoted
-	if rtype == api.RecordTypeTXT && !strings.HasPrefix(content, "\"") && !strings.HasSuffix(content, "\"") {
-		content = fmt.Sprintf("\"%s\"", content)
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number of values in the deleted record sets.
+func (o OTC) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return o.deleteRecordSets(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the record set of the given type
+// for the name.
+func (o OTC) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
 	}
+	_, err := o.deleteRecordSets(ctx, zone, name, strings.ToUpper(rtype))
+	return err
+}
 
-	result := recordsets.Update(o.dns, zoneID, record.ID, recordsets.UpdateOpts{
-		TTL:     ttl,
-		Records: []string{content},
+// deleteRecordSets deletes the matching record sets and returns the
+// number of values they held.
+func (o OTC) deleteRecordSets(ctx context.Context, zone, name, rtype string) (int, error) {
+	deleted := 0
+	err := o.withZoneID(ctx, zone, func(zoneID string) error {
+		records, err := o.listRecordSets(ctx, zoneID, name, rtype)
+		if err != nil {
+			return fmt.Errorf("failed to list record sets by zoneID '%s' (zone name '%s'): %v", zoneID, zone, err)
+		}
+
+		// the name filter of the listing is a fuzzy match
+		deletedSets := 0
+		deleted = 0
+		for _, record := range records {
+			if !otcSameName(zone, record.Name, name) {
+				continue
+			}
+			if err := recordsets.Delete(o.dns, zoneID, record.ID).Err; err != nil {
+				return fmt.Errorf("failed to delete record with ID %s: %v", record.ID, err)
+			}
+			deletedSets++
+			deleted += len(record.Records)
+		}
+
+		if deletedSets == 0 {
+			return ErrRecordNotFound
+		}
+		return nil
 	})
+	return deleted, err
+}
 
-	if result.Err != nil {
-		return fmt.Errorf("failed to update record for zone %s (name='%s'): %v", zone, name, result.Err)
+// eachPage calls fn with each page of the listing. Unlike AllPages,
+// the context is checked before each page is fetched, and the
+// configured page and duration limits are enforced.
+func (o OTC) eachPage(ctx context.Context, pager pagination.Pager, fn func(pagination.Page) error) error {
+	if o.maxListDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.maxListDuration)
+		defer cancel()
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	count := 0
+	return pager.EachPage(func(page pagination.Page) (bool, error) {
+		if err := fn(page); err != nil {
+			return false, err
+		}
+		count++
+		if o.maxListPages > 0 && count >= o.maxListPages {
+			if next, _ := page.NextPageURL(); next != "" {
+				return false, fmt.Errorf("listing exceeded the maximum of %d pages", o.maxListPages)
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+}
 
+// Close revokes the provider's IAM token and closes idle connections.
+// The client is no longer re-authenticated, so later calls fail.
+func (o OTC) Close() error {
+	o.client.HTTPClient.CloseIdleConnections()
+	token := o.client.Token()
+	if token == "" {
+		return nil
+	}
+	o.client.ReauthFunc = nil
+	identity, err := openstack.NewIdentityV3(o.client, golangsdk.EndpointOpts{})
+	if err == nil {
+		err = tokens.Revoke(identity, token).Err
+	}
+	o.client.SetToken("")
+	if err != nil {
+		return fmt.Errorf("cannot revoke OTC token, %v", err)
+	}
 	return nil
 }
 
-func (o OTC) DeleteDNSRecord(ctx context.Context, zone, name string) error {
-	z, err := o.findZoneByName(ctx, zone)
+// ValidateCredentials issues a new token with the credentials, which
+// is revoked again straight away.
+func (o OTC) ValidateCredentials(ctx context.Context) error {
+	// a client of its own keeps a rejected request from triggering
+	// reauthentication of the provider's token
+	client := *o.client
+	client.ReauthFunc = nil
+	client.TokenID = ""
+	identity, err := openstack.NewIdentityV3(&client, golangsdk.EndpointOpts{})
 	if err != nil {
 		return err
 	}
-
-	zoneID := z.ID
-
-	records, err := o.listRecordSets(ctx, zoneID, name, "")
+	token, err := tokens.Create(identity, &o.authOptions).ExtractToken()
 	if err != nil {
-		return fmt.Errorf("failed to list record sets by zoneID '%s' (zone name '%s'): %v", zoneID, zone, err)
+		return credentialsError(fmt.Errorf("cannot issue OTC token, %w", err))
 	}
-
-	if len(records) == 0 {
-		return ErrRecordNotFound
+	if err := tokens.Revoke(identity, token.ID).Err; err != nil {
+		o.logger.WarnContext(ctx, "cannot revoke OTC validation token", "err", err)
 	}
+	return nil
+}
 
-	for _, record := range records {
-		if err := recordsets.Delete(o.dns, zoneID, record.ID).Err; err != nil {
-			return fmt.Errorf("failed to delete record with ID %s: %v", record.ID, err)
+// ListZones returns the zones visible to the tenant.
+func (o OTC) ListZones(ctx context.Context) ([]api.Zone, error) {
+	zoneList := []api.Zone{}
+	err := o.eachPage(ctx, zones.List(o.dns, zones.ListOpts{}), func(page pagination.Page) error {
+		pageZones, err := zones.ExtractZones(page)
+		if err != nil {
+			return err
+		}
+		for _, zone := range pageZones {
+			zoneList = append(zoneList, newZone(zone.Name, zone.ID))
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return listedZones(o.zone, zoneList)
+}
 
-	return nil
+// withZoneID calls fn with the ID of the zone, using the zone cache if
+// enabled, and retries once with the zone looked up again if a cached
+// ID turns out to be stale. Zone IDs are scoped to the region and
+// tenant.
+func (o OTC) withZoneID(ctx context.Context, zone string, fn func(zoneID string) error) error {
+	key := zoneCacheKey(string(api.OpenTelekomCloudProvider), o.region, o.tenant, zone)
+	return o.zoneCache.withZoneID(ctx, key, func() (string, error) {
+		z, err := o.findZoneByName(ctx, zone)
+		if err != nil {
+			return "", err
+		}
+		return z.ID, nil
+	}, fn)
+}
+
+// GetZone returns the zone's status and name servers. OTC does not
+// report DNSSEC.
+func (o OTC) GetZone(ctx context.Context, zone string) (api.ZoneInfo, error) {
+	var z *zones.Zone
+	var servers []nameservers.Nameserver
+	err := o.withZoneID(ctx, zone, func(zoneID string) (err error) {
+		z, err = zones.Get(o.dns, zoneID).Extract()
+		if err != nil {
+			return fmt.Errorf("failed to get zone %s (zoneID '%s'): %v", zone, zoneID, err)
+		}
+		servers, err = nameservers.List(o.dns, zoneID).Extract()
+		if err != nil {
+			return fmt.Errorf("failed to list name servers of zone %s (zoneID '%s'): %v", zone, zoneID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return api.ZoneInfo{}, err
+	}
+	hosts := []string{}
+	for _, server := range servers {
+		hosts = append(hosts, server.Hostname)
+	}
+	return api.ZoneInfo{
+		Name:        strings.TrimSuffix(z.Name, "."),
+		ID:          z.ID,
+		NameServers: nameServers(hosts),
+		Status:      z.Status,
+	}, nil
 }
 
-func (o OTC) findZoneByName(_ context.Context, name string) (*zones.Zone, error) {
-	pages := zones.List(o.dns, zones.ListOpts{Name: name})
+// CreateZone creates a public zone.
+func (o OTC) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	name := strings.TrimSuffix(zone, ".") + "."
+	created, err := zones.Create(o.dns, zones.CreateOpts{
+		Name:     name,
+		ZoneType: "public",
+	}).Extract()
+	if err != nil {
+		return api.Zone{}, fmt.Errorf("failed to create zone %s: %v", name, err)
+	}
+	return newZone(created.Name, created.ID), nil
+}
 
-	allPages, err := pages.AllPages()
+// DeleteZone deletes the zone and all its record sets.
+func (o OTC) DeleteZone(ctx context.Context, zone string) error {
+	err := o.withZoneID(ctx, zone, func(zoneID string) error {
+		if err := zones.Delete(o.dns, zoneID).Err; err != nil {
+			return fmt.Errorf("failed to delete zone %s (zoneID '%s'): %v", zone, zoneID, err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
+	o.zoneCache.remove(zoneCacheKey(string(api.OpenTelekomCloudProvider), o.region, o.tenant, zone))
+	return nil
+}
 
-	allZones, err := zones.ExtractZones(allPages)
+func (o OTC) findZoneByName(ctx context.Context, name string) (*zones.Zone, error) {
+	allZones := []zones.Zone{}
+	err := o.eachPage(ctx, zones.List(o.dns, zones.ListOpts{Name: name}), func(page pagination.Page) error {
+		pageZones, err := zones.ExtractZones(page)
+		if err != nil {
+			return err
+		}
+		allZones = append(allZones, pageZones...)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -195,50 +563,56 @@ func (o OTC) findZoneByName(_ context.Context, name string) (*zones.Zone, error)
 		}
 	}
 
-	return nil, ErrZoneNotFound
+	return nil, fmt.Errorf("%w for %s", api.ErrZoneNotFound, name)
+}
+
+// otcSameName reports whether the record set name, which is fully
+// qualified, is the name, which may be relative to the zone.
+func otcSameName(zone, recordSetName, name string) bool {
+	return strings.EqualFold(relativeName(recordSetName, zone), relativeName(name, zone))
 }
 
-func (o OTC) listRecordSets(_ context.Context, zoneID, name, rtype string) ([]recordsets.RecordSet, error) {
+// listRecordSets lists the record sets in the zone whose name contains
+// the name, as the name filter is a fuzzy match. Callers need to match
+// the names exactly.
+func (o OTC) listRecordSets(ctx context.Context, zoneID, name, rtype string) ([]recordsets.RecordSet, error) {
+	// a literal * is not reliable in the fuzzy filter, so wildcard
+	// names are listed by the rest of the name
+	if name == "*" || strings.HasPrefix(name, "*.") {
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "*"), ".")
+	}
 	pages := recordsets.ListByZone(o.dns, zoneID, recordsets.ListOpts{
 		Name: name,
 		Type: rtype,
 	})
 
-	page, err := pages.AllPages()
-	if err != nil {
-		return nil, err
-	}
-
-	recordSets, err := recordsets.ExtractRecordSets(page)
+	recordSets := []recordsets.RecordSet{}
+	err := o.eachPage(ctx, pages, func(page pagination.Page) error {
+		pageRecordSets, err := recordsets.ExtractRecordSets(page)
+		if err != nil {
+			return err
+		}
+		recordSets = append(recordSets, pageRecordSets...)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	output := make([]recordsets.RecordSet, 0)
-	for _, recordSet := range recordSets {
-		values := make([]string, len(recordSet.Records))
-		for idx, value := range recordSet.Records {
-			values[idx] = strings.Trim(value, "\"")
-		}
-
-		recordSet.Records = values
-		output = append(output, recordSet)
-	}
-
-	return output, nil
+	return recordSets, nil
 }
 
-func (o OTC) createDNSRecord(_ context.Context, zoneID, fqdn, rtype, content string, ttl int, _ bool) error {
-	if rtype == "TXT" && !strings.HasPrefix(content, "\"") && !strings.HasSuffix(content, "\"") {
-		content = fmt.Sprintf("\"%s\"", content)
-	}
-
+func (o OTC) createDNSRecord(_ context.Context, zoneID, fqdn, rtype string, rrdatas []string, ttl int, _ bool) error {
 	result := recordsets.Create(o.dns, zoneID, recordsets.CreateOpts{
 		Name:    fqdn,
-		Records: []string{content},
+		Records: rrdatas,
 		TTL:     ttl,
 		Type:    rtype,
 	})
 
-	return result.Err
+	if result.Err != nil {
+		return fmt.Errorf("failed to create record in zoneID '%s' with name %s: %v", zoneID, fqdn, result.Err)
+	}
+
+	return nil
 }
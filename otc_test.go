@@ -0,0 +1,810 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	golangsdk "github.com/opentelekomcloud/gophertelekomcloud"
+	"github.com/opentelekomcloud/gophertelekomcloud/openstack/dns/v2/recordsets"
+	"github.com/opentelekomcloud/gophertelekomcloud/openstack/dns/v2/zones"
+	"github.com/stretchr/testify/require"
+)
+
+// otcStub is a minimal in-memory implementation of the OTC DNS v2
+// zones and record sets API.
+type otcStub struct {
+	mu         sync.Mutex
+	zones      []zones.Zone
+	recordSets []recordsets.RecordSet
+	nextID     int
+	requests   []string
+	pageSize   int           // 0 returns everything in one page
+	delay      time.Duration // added to each record set listing
+	failStatus int           // if set, record set changes fail with this status
+	authStatus int           // if set, token requests fail with this status
+	tokens     int           // number of tokens issued
+	checkToken bool          // if set, DNS requests must carry the latest token
+}
+
+const otcTestEndpoint = "https://dns.test.otc.t-systems.com/v2/"
+
+func newOTCStub(zoneNames ...string) *otcStub {
+	s := &otcStub{}
+	for ii, name := range zoneNames {
+		s.zones = append(s.zones, zones.Zone{
+			ID:     "zone" + strconv.Itoa(ii+1),
+			Name:   name,
+			Status: "ACTIVE",
+		})
+	}
+	return s
+}
+
+// count returns the number of requests received with the given
+// method whose path contains substr.
+func (s *otcStub) count(method, substr string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, req := range s.requests {
+		if strings.HasPrefix(req, method+" ") && strings.Contains(req, substr) {
+			count++
+		}
+	}
+	return count
+}
+
+// expireToken makes the latest token invalid, so it has to be issued
+// again.
+func (s *otcStub) expireToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens++
+}
+
+func (s *otcStub) getRecordSet(zoneID, id string) (int, bool) {
+	for ii, rs := range s.recordSets {
+		if rs.ZoneID == zoneID && rs.ID == id {
+			return ii, true
+		}
+	}
+	return 0, false
+}
+
+// hasZoneID reports whether a zone has the ID.
+func (s *otcStub) hasZoneID(id string) bool {
+	for _, zone := range s.zones {
+		if zone.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *otcStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/zones", func(w http.ResponseWriter, r *http.Request) {
+		list := []zones.Zone{}
+		for _, zone := range s.zones {
+			if name := r.URL.Query().Get("name"); name == "" || strings.Contains(zone.Name, name) {
+				list = append(list, zone)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"zones": list})
+	})
+	mux.HandleFunc("POST /v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		if s.authStatus != 0 {
+			w.WriteHeader(s.authStatus)
+			w.Write([]byte(`{"error":{"code":401,"message":"The request you have made requires authentication."}}`))
+			return
+		}
+		s.tokens++
+		w.Header().Set("X-Subject-Token", "token"+strconv.Itoa(s.tokens))
+		w.WriteHeader(http.StatusCreated)
+		// the catalog points the DNS client back at the stub
+		w.Write([]byte(`{"token":{"expires_at":"2030-01-01T00:00:00.000000Z",` +
+			`"project":{"id":"project1","name":"tenant","domain":{"id":"domain1"}},` +
+			`"user":{"id":"user1","domain":{"id":"domain1"}},` +
+			`"catalog":[{"type":"dns","name":"dns","endpoints":[{"interface":"public","region":"eu-de","region_id":"eu-de","url":"` +
+			strings.TrimSuffix(otcTestEndpoint, "v2/") + `"}]}]}}`))
+	})
+	mux.HandleFunc("DELETE /v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Subject-Token") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /v2/zones", func(w http.ResponseWriter, r *http.Request) {
+		opts := zones.CreateOpts{}
+		json.NewDecoder(r.Body).Decode(&opts)
+		zone := zones.Zone{
+			ID:       "zone" + strconv.Itoa(len(s.zones)+1),
+			Name:     opts.Name,
+			ZoneType: opts.ZoneType,
+		}
+		s.zones = append(s.zones, zone)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(zone)
+	})
+	mux.HandleFunc("GET /v2/zones/{zone}", func(w http.ResponseWriter, r *http.Request) {
+		for _, zone := range s.zones {
+			if zone.ID == r.PathValue("zone") {
+				json.NewEncoder(w).Encode(zone)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /v2/zones/{zone}/nameservers", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"nameservers": []map[string]interface{}{
+				{"hostname": "ns1.switch.otc.t-systems.com.", "priority": 1},
+				{"hostname": "ns2.switch.otc.t-systems.com.", "priority": 2},
+			},
+		})
+	})
+	mux.HandleFunc("DELETE /v2/zones/{zone}", func(w http.ResponseWriter, r *http.Request) {
+		for ii, zone := range s.zones {
+			if zone.ID == r.PathValue("zone") {
+				s.zones = append(s.zones[:ii], s.zones[ii+1:]...)
+				w.WriteHeader(http.StatusAccepted)
+				json.NewEncoder(w).Encode(zone)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /v2/zones/{zone}/recordsets", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		list := []recordsets.RecordSet{}
+		for _, rs := range s.recordSets {
+			if rs.ZoneID != r.PathValue("zone") {
+				continue
+			}
+			// the name filter is a fuzzy match
+			if name := query.Get("name"); name != "" && !strings.Contains(rs.Name, name) {
+				continue
+			}
+			if rtype := query.Get("type"); rtype != "" && rtype != rs.Type {
+				continue
+			}
+			list = append(list, rs)
+		}
+		links := map[string]string{}
+		start, _ := strconv.Atoi(query.Get("marker"))
+		end := len(list)
+		if s.pageSize > 0 && start+s.pageSize < end {
+			end = start + s.pageSize
+			query.Set("marker", strconv.Itoa(end))
+			links["next"] = strings.TrimSuffix(otcTestEndpoint, "/v2/") + r.URL.Path + "?" + query.Encode()
+		}
+		time.Sleep(s.delay)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"recordsets": list[start:end],
+			"links":      links,
+		})
+	})
+	mux.HandleFunc("POST /v2/zones/{zone}/recordsets", func(w http.ResponseWriter, r *http.Request) {
+		rs := recordsets.RecordSet{}
+		json.NewDecoder(r.Body).Decode(&rs)
+		s.nextID++
+		rs.ID = "rs" + strconv.Itoa(s.nextID)
+		rs.ZoneID = r.PathValue("zone")
+		s.recordSets = append(s.recordSets, rs)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(rs)
+	})
+	mux.HandleFunc("PUT /v2/zones/{zone}/recordsets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ii, ok := s.getRecordSet(r.PathValue("zone"), r.PathValue("id"))
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		update := recordsets.RecordSet{}
+		json.NewDecoder(r.Body).Decode(&update)
+		s.recordSets[ii].TTL = update.TTL
+		s.recordSets[ii].Records = update.Records
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(s.recordSets[ii])
+	})
+	mux.HandleFunc("DELETE /v2/zones/{zone}/recordsets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ii, ok := s.getRecordSet(r.PathValue("zone"), r.PathValue("id"))
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		s.recordSets = append(s.recordSets[:ii], s.recordSets[ii+1:]...)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		if s.checkToken && strings.HasPrefix(r.URL.Path, "/v2/") && r.Header.Get("X-Auth-Token") != "token"+strconv.Itoa(s.tokens) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":401,"message":"The request you have made requires authentication."}}`))
+			return
+		}
+		if s.failStatus != 0 && r.Method != http.MethodGet {
+			w.WriteHeader(s.failStatus)
+			w.Write([]byte(`{"code":"DNS.0403","message":"quota exceeded"}`))
+			return
+		}
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/v2/zones/"); ok && !s.hasZoneID(strings.Split(rest, "/")[0]) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"DNS.0101","message":"The zone does not exist."}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// newOTCTestProvider creates an OTC provider backed by the stub,
+// skipping the IAM authentication done by NewOtcProvider.
+func newOTCTestProvider(t *testing.T, stub *otcStub) OTC {
+	client := newStubClient(t, stub.handler())
+	provider := &golangsdk.ProviderClient{
+		HTTPClient: *client,
+	}
+	return OTC{
+		client: provider,
+		authOptions: golangsdk.AuthOptions{
+			DomainName: "domain",
+			TenantName: "tenant",
+			Username:   "user",
+			Password:   "secret",
+		},
+		dns: &golangsdk.ServiceClient{
+			ProviderClient: provider,
+			Endpoint:       otcTestEndpoint,
+		},
+		logger: slog.Default(),
+	}
+}
+
+func TestOTCProxy(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "www", "A", "10.0.0.1", 300, true)
+	require.ErrorIs(t, err, api.ErrProxyNotSupported)
+	require.Empty(t, stub.requests)
+}
+
+func TestOTCTTL(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+
+	for _, ttl := range []int{300, 3600} {
+		name := "ttl" + strconv.Itoa(ttl)
+		err := prov.CreateOrUpdateDNSRecord(ctx, "example.com.", name, "A", "10.0.0.1", ttl, false)
+		require.Nil(t, err)
+		records, err := prov.GetDNSRecords(ctx, "example.com.", name)
+		require.Nil(t, err)
+		require.Equal(t, 1, len(records))
+		require.Equal(t, ttl, records[0].TTL)
+	}
+
+	// a TTL-only change is applied exactly
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "ttl300", "A", "10.0.0.1", 3600, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com.", "ttl300")
+	require.Nil(t, err)
+	require.Equal(t, 3600, records[0].TTL)
+}
+
+func TestOTCUpdateTTL(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "ttl", "TXT", "hello world", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "ttl2", "TXT", "other", 300, false)
+	require.Nil(t, err)
+
+	err = prov.UpdateTTL(ctx, "example.com.", "ttl", "TXT", 600)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com.", "ttl")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, []string{"hello world"}, records[0].Content)
+	require.Equal(t, 600, records[0].TTL)
+	// the fuzzy name match does not touch other records
+	records, err = prov.GetDNSRecords(ctx, "example.com.", "ttl2")
+	require.Nil(t, err)
+	require.Equal(t, 300, records[0].TTL)
+
+	err = prov.UpdateTTL(ctx, "example.com.", "ttl", "A", 600)
+	require.ErrorIs(t, err, api.ErrRecordNotFound)
+}
+
+func TestOTCRecordNames(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+
+	for _, name := range []string{"www", "api", "www2"} {
+		err := prov.CreateOrUpdateDNSRecord(ctx, "example.com.", name, "A", "10.0.0.1", 300, false)
+		require.Nil(t, err)
+	}
+	stub.recordSets = append(stub.recordSets, recordsets.RecordSet{
+		ID:      "ns",
+		ZoneID:  "zone1",
+		Name:    "example.com.",
+		Type:    "NS",
+		TTL:     86400,
+		Records: []string{"ns1.open-telekom-cloud.com."},
+	})
+
+	records, err := prov.GetDNSRecords(ctx, "example.com.", "")
+	require.Nil(t, err)
+	names := []string{}
+	for _, record := range records {
+		names = append(names, record.Name)
+	}
+	require.Equal(t, []string{"www", "api", "www2", "@"}, names)
+	require.True(t, records[3].System)
+
+	// the fuzzy backend match on "www" does not leak "www2"
+	records, err = prov.GetDNSRecords(ctx, "example.com.", "www")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "www", records[0].Name)
+}
+
+func TestOTCDelete(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "www", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "www", "AAAA", "fd00::1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "www2", "A", "10.0.0.2", 300, false)
+	require.Nil(t, err)
+
+	err = prov.DeleteDNSRecordByType(ctx, "example.com.", "www", "A")
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com.", "www")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "AAAA", records[0].Type)
+
+	err = prov.DeleteDNSRecordByType(ctx, "example.com.", "www", "A")
+	require.Equal(t, ErrRecordNotFound, err)
+
+	// the fuzzy backend match on "www" does not delete "www2"
+	count, err := prov.DeleteDNSRecordCount(ctx, "example.com.", "www")
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+	records, err = prov.GetDNSRecords(ctx, "example.com.", "")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "www2", records[0].Name)
+}
+
+func TestOTCQuoting(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "quote", "A", "80.0.0.0", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "quote", "AAAA", "2001:db8::1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "quote", "TXT", `say "hi"`, 300, false)
+	require.Nil(t, err)
+
+	// only TXT values are stored quoted
+	stored := map[string][]string{}
+	for _, rs := range stub.recordSets {
+		stored[rs.Type] = rs.Records
+	}
+	require.Equal(t, map[string][]string{
+		"A":    {"80.0.0.0"},
+		"AAAA": {"2001:db8::1"},
+		"TXT":  {`"say \"hi\""`},
+	}, stored)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com.", "quote")
+	require.Nil(t, err)
+	content := map[string][]string{}
+	for _, record := range records {
+		content[record.Type] = record.Content
+	}
+	require.Equal(t, map[string][]string{
+		"A":    {"80.0.0.0"},
+		"AAAA": {"2001:db8::1"},
+		"TXT":  {`say "hi"`},
+	}, content)
+
+	// re-applying the TXT value is a no-op
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "quote", "TXT", `say "hi"`, 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 0, stub.count("PUT", "/recordsets/"))
+}
+
+func TestOTCErrors(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "www", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+
+	stub.failStatus = http.StatusForbidden
+	// update
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "www", "A", "10.0.0.2", 300, false)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "failed to update record for zone example.com. (name='www')")
+	require.Contains(t, err.Error(), "quota exceeded")
+	// create
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "api", "A", "10.0.0.1", 300, false)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "failed to create record in zoneID 'zone1' with name api.example.com.")
+	require.Contains(t, err.Error(), "quota exceeded")
+}
+
+func TestOTCPaging(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	for ii := 0; ii < 20; ii++ {
+		stub.recordSets = append(stub.recordSets, recordsets.RecordSet{
+			ID:      "rs" + strconv.Itoa(ii),
+			ZoneID:  "zone1",
+			Name:    "host" + strconv.Itoa(ii) + ".example.com.",
+			Type:    "A",
+			TTL:     300,
+			Records: []string{"10.0.0." + strconv.Itoa(ii)},
+		})
+	}
+	stub.pageSize = 3
+	prov := newOTCTestProvider(t, stub)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com.", "")
+	require.Nil(t, err)
+	require.Equal(t, 20, len(records))
+
+	// page limit
+	prov.maxListPages = 2
+	_, err = prov.GetDNSRecords(ctx, "example.com.", "")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "maximum of 2 pages")
+	prov.maxListPages = 0
+
+	// a slow listing is abandoned once the context expires
+	stub.delay = 50 * time.Millisecond
+	shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = prov.GetDNSRecords(shortCtx, "example.com.", "")
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	require.Less(t, time.Since(start), 250*time.Millisecond)
+
+	// as is one exceeding the duration limit
+	prov.maxListDuration = 50 * time.Millisecond
+	start = time.Now()
+	_, err = prov.GetDNSRecords(ctx, "example.com.", "")
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	require.Less(t, time.Since(start), 250*time.Millisecond)
+}
+
+func TestOTCRecordSet(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+	recordSetTest(t, ctx, prov, "example.com.", "rr.example.com")
+	delegationTest(t, ctx, prov, "example.com.", "sub.example.com")
+}
+
+func TestOTCBatch(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+
+	// the unsupported record fails without stopping the others
+	err := prov.BatchCreateOrUpdateDNSRecords(ctx, "example.com.", []api.Record{{
+		Name:    "www",
+		Type:    "A",
+		Content: []string{"10.0.0.1"},
+		TTL:     300,
+	}, {
+		Name:    "ssh",
+		Type:    "SSHFP",
+		Content: []string{"1 1 123456789abcdef67890123456789abcdef67890"},
+		TTL:     300,
+	}, {
+		Name:    "www",
+		Type:    "AAAA",
+		Content: []string{"fd00::1"},
+		TTL:     300,
+	}, {
+		Name:    "alias",
+		Type:    "CNAME",
+		Content: []string{"www.example.com."},
+		TTL:     300,
+	}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "SSHFP record ssh failed")
+
+	records, err := prov.GetDNSRecords(ctx, "example.com.", "")
+	require.Nil(t, err)
+	contents := map[string]string{}
+	for _, record := range records {
+		contents[record.Name+"/"+record.Type] = record.Content[0]
+	}
+	require.Equal(t, map[string]string{
+		"www/A":       "10.0.0.1",
+		"www/AAAA":    "fd00::1",
+		"alias/CNAME": "www.example.com.",
+	}, contents)
+}
+
+func TestOTCListZones(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.", "example.org.")
+	prov := newOTCTestProvider(t, stub)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{
+		{Name: "example.com", ID: "zone1"},
+		{Name: "example.org", ID: "zone2"},
+	}, zones)
+
+	stub = newOTCStub()
+	prov = newOTCTestProvider(t, stub)
+	prov.zone = "example.com."
+	_, err = prov.ListZones(ctx)
+	require.True(t, errors.Is(err, api.ErrNoZonesAccessible))
+}
+
+func TestOTCZones(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub()
+	prov := newOTCTestProvider(t, stub)
+
+	zone, err := prov.CreateZone(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, api.Zone{Name: "example.com", ID: "zone1"}, zone)
+	require.Equal(t, "public", stub.zones[0].ZoneType)
+
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "www", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+
+	err = prov.DeleteZone(ctx, "example.com.")
+	require.Nil(t, err)
+	require.Equal(t, 0, len(stub.zones))
+	err = prov.DeleteZone(ctx, "example.com.")
+	require.ErrorIs(t, err, ErrZoneNotFound)
+	require.Contains(t, err.Error(), "example.com.")
+	zoneNotFoundTest(t, ctx, prov)
+}
+
+func TestOTCClose(t *testing.T) {
+	stub := newOTCStub("example.com")
+	prov := newOTCTestProvider(t, stub)
+	prov.client.IdentityBase = "https://iam.test.otc.t-systems.com/"
+	prov.client.SetToken("test-token")
+
+	// the token is revoked once, and closing again does nothing
+	require.Nil(t, prov.Close())
+	require.Nil(t, prov.Close())
+	require.Equal(t, 1, stub.count("DELETE", "/v3/auth/tokens"))
+	// the closed provider does not re-authenticate
+	require.Equal(t, "", prov.client.Token())
+	require.Nil(t, prov.client.ReauthFunc)
+}
+
+func TestOTCValidateCredentials(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+	prov.client.IdentityBase = "https://iam.test.otc.t-systems.com/"
+
+	require.NoError(t, prov.ValidateCredentials(ctx))
+	require.Equal(t, 1, stub.count("POST", "/v3/auth/tokens"))
+	require.Equal(t, 1, stub.count("DELETE", "/v3/auth/tokens"))
+
+	stub.authStatus = http.StatusUnauthorized
+	err := prov.ValidateCredentials(ctx)
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+
+	stub.authStatus = http.StatusForbidden
+	err = prov.ValidateCredentials(ctx)
+	require.ErrorIs(t, err, api.ErrPermissionDenied)
+}
+
+func TestOTCWildcard(t *testing.T) {
+	ctx := context.Background()
+	prov := newOTCTestProvider(t, newOTCStub("example.com."))
+	wildcardTest(t, ctx, prov, "example.com.", "example.com", "*")
+	wildcardTest(t, ctx, prov, "example.com.", "example.com", "*.example.com")
+}
+
+func TestOTCGetZone(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	prov := newOTCTestProvider(t, stub)
+
+	info, err := prov.GetZone(ctx, "example.com.")
+	require.Nil(t, err)
+	require.Equal(t, api.ZoneInfo{
+		Name:        "example.com",
+		ID:          "zone1",
+		NameServers: []string{"ns1.switch.otc.t-systems.com", "ns2.switch.otc.t-systems.com"},
+		Status:      "ACTIVE",
+	}, info)
+
+	_, err = prov.GetZone(ctx, "example.org.")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+}
+
+func TestOTCConstructorContext(t *testing.T) {
+	creds := OTCCredentials{
+		Region:     "eu-de",
+		DomainName: "domain",
+		TenantName: "tenant",
+		Username:   "user",
+		Password:   "secret",
+	}
+	// the identity service does not answer until the test ends
+	var requests atomic.Int32
+	done := make(chan struct{})
+	client := newStubClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	t.Cleanup(func() { close(done) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	_, err := NewOtcProviderWithCredentials(ctx, "", creds, nil, WithHTTPClient(client))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, int32(0), requests.Load())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	_, err = NewOtcProviderWithCredentials(ctx, "", creds, nil, WithHTTPClient(client))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, int32(1), requests.Load())
+}
+
+func TestOTCConstructorHTTPClient(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	creds := OTCCredentials{
+		Region:     "eu-de",
+		DomainName: "domain",
+		TenantName: "tenant",
+		Username:   "user",
+		Password:   "secret",
+	}
+	// both the identity and DNS requests go through the client
+	prov, err := NewOtcProviderWithCredentials(ctx, "example.com.", creds, nil, WithHTTPClient(newStubClient(t, stub.handler())))
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.count(http.MethodPost, "/v3/auth/tokens"))
+
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "www", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.count(http.MethodPost, "/v2/zones/zone1/recordsets"))
+	records, err := prov.GetDNSRecords(ctx, "example.com.", "www")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{
+		{Type: "A", Name: "www", Content: []string{"10.0.0.1"}, TTL: 300},
+	}, records)
+}
+
+func TestOTCTokenExpiry(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	stub.checkToken = true
+	creds := OTCCredentials{
+		Region:     "eu-de",
+		DomainName: "domain",
+		TenantName: "tenant",
+		Username:   "user",
+		Password:   "secret",
+	}
+	prov, err := NewOtcProviderWithCredentials(ctx, "example.com.", creds, nil, WithHTTPClient(newStubClient(t, stub.handler())))
+	require.Nil(t, err)
+
+	// each operation is retried once with a new token
+	stub.expireToken()
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "www", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 2, stub.count(http.MethodPost, "/v3/auth/tokens"))
+	stub.expireToken()
+	records, err := prov.GetDNSRecords(ctx, "example.com.", "www")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, 3, stub.count(http.MethodPost, "/v3/auth/tokens"))
+	stub.expireToken()
+	err = prov.DeleteDNSRecord(ctx, "example.com.", "www")
+	require.Nil(t, err)
+	require.Equal(t, 4, stub.count(http.MethodPost, "/v3/auth/tokens"))
+	require.Empty(t, stub.recordSets)
+
+	// concurrent requests rejected with the same token share the
+	// new one
+	stub.expireToken()
+	wg := sync.WaitGroup{}
+	errs := make([]error, 10)
+	for ii := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[ii] = prov.GetDNSRecords(ctx, "example.com.", "")
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.Nil(t, err)
+	}
+	require.Equal(t, 5, stub.count(http.MethodPost, "/v3/auth/tokens"))
+
+	// a request is only retried once
+	stub.expireToken()
+	stub.authStatus = http.StatusUnauthorized
+	_, err = prov.GetDNSRecords(ctx, "example.com.", "")
+	require.NotNil(t, err)
+	require.Equal(t, 6, stub.count(http.MethodPost, "/v3/auth/tokens"))
+}
+
+func TestOTCValidateAll(t *testing.T) {
+	ctx := context.Background()
+	stub := newOTCStub("example.com.")
+	creds := OTCCredentials{
+		Region:     "eu-de",
+		DomainName: "domain",
+		TenantName: "tenant",
+		Username:   "user",
+		Password:   "secret",
+	}
+	configs := []api.ProviderConfig{{
+		Type:            api.OpenTelekomCloudProvider,
+		Zone:            "example.com.",
+		CredentialsData: creds.ToMap(),
+	}}
+	// the token of the provider built for the check is revoked
+	results := ValidateAll(ctx, configs, WithHTTPClient(newStubClient(t, stub.handler())))
+	require.Nil(t, results[0].Err)
+	require.Equal(t, 1, stub.count(http.MethodPost, "/v3/auth/tokens"))
+	require.Equal(t, 1, stub.count(http.MethodDelete, "/v3/auth/tokens"))
+}
@@ -0,0 +1,392 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/ovh/go-ovh/ovh"
+)
+
+const (
+	CredentialKeyEndpoint          = "endpoint"
+	CredentialKeyApplicationKey    = "applicationKey"
+	CredentialKeyApplicationSecret = "applicationSecret"
+	CredentialKeyConsumerKey       = "consumerKey"
+)
+
+// OVH manages DNS records via the OVHcloud API. OVH stores record
+// names relative to the zone, with an empty name for the apex, and
+// takes record content in presentation format.
+//
+// Changes to records are staged by OVH and only served once the zone
+// is refreshed, so every call that changes records ends by refreshing
+// the zone.
+type OVH struct {
+	client *ovh.Client
+	logger api.Logger
+	zone   string // zone the provider was configured for, if any
+}
+
+// ovhRecord is a record of the OVH zone API. The ID and type cannot
+// be changed, so they are left out of updates.
+type ovhRecord struct {
+	ID        int64  `json:"id,omitempty"`
+	FieldType string `json:"fieldType,omitempty"`
+	SubDomain string `json:"subDomain"`
+	Target    string `json:"target"`
+	TTL       int    `json:"ttl"`
+}
+
+// NewOVHProvider creates a new OVH DNS provider.
+func NewOVHProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*OVH, error) {
+	return NewOVHProviderWithCredentials(ctx, zone, ovhCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewOVHProviderWithCredentials creates a new OVH DNS provider from
+// typed credentials.
+func NewOVHProviderWithCredentials(ctx context.Context, zone string, creds OVHCredentials, logger api.Logger, ops ...Option) (*OVH, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	client, err := ovh.NewClient(creds.Endpoint, creds.ApplicationKey, creds.ApplicationSecret, creds.ConsumerKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create ovh client, %v", err)
+	}
+	opts := getOptions(ops)
+	// go-ovh sets the timeout of its client on every request, so it
+	// gets a copy rather than the caller's client
+	client.Client = opts.httpClient()
+	return &OVH{
+		client: client,
+		logger: logger,
+		zone:   zone,
+	}, nil
+}
+
+func isOVHStatus(err error, code int) bool {
+	var oerr *ovh.APIError
+	return errors.As(err, &oerr) && oerr.Code == code
+}
+
+func ovhZonePath(zone string, elems ...string) string {
+	path := "/domain/zone/" + url.PathEscape(strings.TrimSuffix(zone, "."))
+	for _, elem := range elems {
+		path += "/" + url.PathEscape(elem)
+	}
+	return path
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *OVH) Close() error {
+	return nil
+}
+
+// ValidateCredentials reads the consumer key the provider signs with.
+func (s *OVH) ValidateCredentials(ctx context.Context) error {
+	err := s.client.GetWithContext(ctx, "/auth/currentCredential", nil)
+	if ovhInvalidKey(err) {
+		return fmt.Errorf("%w, %v", api.ErrInvalidCredentials, err)
+	}
+	return credentialsError(err)
+}
+
+// ovhInvalidKey reports whether OVH rejected the application or
+// consumer key itself. OVH answers those with a 403 like it does for
+// missing permissions, so they are told apart by the message.
+func ovhInvalidKey(err error) bool {
+	var oerr *ovh.APIError
+	if !errors.As(err, &oerr) || oerr.Code != http.StatusForbidden {
+		return false
+	}
+	msg := strings.ToLower(oerr.Message)
+	return strings.Contains(msg, "invalid application key") ||
+		strings.Contains(msg, "credential does not exist") ||
+		strings.Contains(msg, "credential is not valid")
+}
+
+// ListZones returns the zones of the account. OVH identifies zones by
+// name.
+func (s *OVH) ListZones(ctx context.Context) ([]api.Zone, error) {
+	names := []string{}
+	if err := s.client.GetWithContext(ctx, "/domain/zone", &names); err != nil {
+		return nil, err
+	}
+	zones := []api.Zone{}
+	for _, name := range names {
+		zones = append(zones, newZone(name, ""))
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone is not supported, since OVH zones are ordered like other
+// OVH services.
+func (s *OVH) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	return api.Zone{}, fmt.Errorf("%w, ovh zones are created by ordering them", api.ErrUnsupported)
+}
+
+// DeleteZone is not supported, since OVH zones are ordered like other
+// OVH services.
+func (s *OVH) DeleteZone(ctx context.Context, zone string) error {
+	return fmt.Errorf("%w, ovh zones are deleted by terminating them", api.ErrUnsupported)
+}
+
+// refreshZone applies the staged record changes of the zone, which
+// OVH requires before changed records are served.
+func (s *OVH) refreshZone(ctx context.Context, zone string) error {
+	if err := s.client.PostWithContext(ctx, ovhZonePath(zone, "refresh"), nil, nil); err != nil {
+		return fmt.Errorf("cannot refresh zone %s, %v", zone, err)
+	}
+	return nil
+}
+
+// ovhSubDomain returns the name relative to the zone as OVH stores
+// it, with an empty name for the apex.
+func ovhSubDomain(name, zone string) string {
+	relName := relativeName(name, zone)
+	if relName == apexName {
+		return ""
+	}
+	return relName
+}
+
+// listRecords returns the records of the zone, or only those of the
+// name and type if given. OVH only lists record IDs, so each record
+// is then read by its ID.
+func (s *OVH) listRecords(ctx context.Context, zone, name, rtype string) ([]ovhRecord, error) {
+	query := url.Values{}
+	subDomain := ovhSubDomain(name, zone)
+	if subDomain != "" {
+		query.Set("subDomain", subDomain)
+	}
+	if rtype != "" {
+		query.Set("fieldType", strings.ToUpper(rtype))
+	}
+	path := ovhZonePath(zone, "record")
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	ids := []int64{}
+	err := s.client.GetWithContext(ctx, path, &ids)
+	if isOVHStatus(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return nil, err
+	}
+	records := []ovhRecord{}
+	for _, id := range ids {
+		record := ovhRecord{}
+		err := s.client.GetWithContext(ctx, ovhZonePath(zone, "record", strconv.FormatInt(id, 10)), &record)
+		if isOVHStatus(err, http.StatusNotFound) {
+			// deleted since it was listed
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// the apex cannot be filtered for, and the subDomain filter
+		// may match more than the exact name
+		if name != "" && !strings.EqualFold(record.SubDomain, subDomain) {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *OVH) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	orecords, err := s.listRecords(ctx, zone, name, "")
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, orec := range orecords {
+		recName := absoluteName(orec.SubDomain, zone)
+		records = append(records, fromPresentation(api.Record{
+			Type:    orec.FieldType,
+			Name:    recName,
+			Content: []string{orec.Target},
+			TTL:     orec.TTL,
+			System:  isSystemRecord(zone, recName, orec.FieldType),
+		})...)
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *OVH) SupportedRecordTypes() []string {
+	return slices.Clone(ovhRecordTypes)
+}
+
+// Capabilities reports only the record types, since OVH zones are
+// ordered rather than created.
+func (s *OVH) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes: s.SupportedRecordTypes(),
+	}
+}
+
+// ovhSameTarget reports whether a target read back from OVH is the
+// same as the target in presentation format about to be written.
+func ovhSameTarget(rtype, current, target string) bool {
+	if rtype == api.RecordTypeTXT {
+		return parseTXTRRData(current) == parseTXTRRData(target)
+	}
+	return strings.EqualFold(current, target)
+}
+
+// CreateOrUpdateDNSRecord changes the existing record of the name and
+// type if found, or adds a new one, then refreshes the zone. If there
+// are several records, the one already holding the content is kept,
+// or else the first, and the others are deleted.
+func (s *OVH) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.OVHProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, ovhRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	content, err := toPresentation(rtype, content)
+	if err != nil {
+		return err
+	}
+	rtype = strings.ToUpper(rtype)
+	orecords, err := s.listRecords(ctx, zone, name, rtype)
+	if err != nil {
+		return err
+	}
+	update := ovhRecord{
+		SubDomain: ovhSubDomain(name, zone),
+		Target:    content,
+		TTL:       ttl,
+	}
+
+	if len(orecords) == 0 {
+		create := update
+		create.FieldType = rtype
+		if err := s.client.PostWithContext(ctx, ovhZonePath(zone, "record"), &create, nil); err != nil {
+			s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+			return fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+		}
+		return s.refreshZone(ctx, zone)
+	}
+	keep := -1
+	for ii, r := range orecords {
+		if keep < 0 && ovhSameTarget(rtype, r.Target, content) {
+			keep = ii
+		}
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	changed := false
+	r := orecords[keep]
+	if ovhSameTarget(rtype, r.Target, content) && r.TTL == ttl {
+		s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", content)
+	} else {
+		s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+		err := s.client.PutWithContext(ctx, ovhZonePath(zone, "record", strconv.FormatInt(r.ID, 10)), &update, nil)
+		if err != nil {
+			return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+		}
+		changed = true
+	}
+	for ii, other := range orecords {
+		if ii == keep {
+			continue
+		}
+		err := s.client.DeleteWithContext(ctx, ovhZonePath(zone, "record", strconv.FormatInt(other.ID, 10)), nil)
+		if err != nil && !isOVHStatus(err, http.StatusNotFound) {
+			return fmt.Errorf("delete DNS record %v failed, %v", other, err)
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return s.refreshZone(ctx, zone)
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *OVH) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *OVH) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *OVH) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+// deleteRecords deletes the matching records, then refreshes the
+// zone if any were deleted. The zone is also refreshed after a
+// failure part way, so the records already deleted are not left
+// staged.
+func (s *OVH) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	orecords, err := s.listRecords(ctx, zone, name, rtype)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, rec := range orecords {
+		err = s.client.DeleteWithContext(ctx, ovhZonePath(zone, "record", strconv.FormatInt(rec.ID, 10)), nil)
+		if isOVHStatus(err, http.StatusNotFound) {
+			// already deleted by someone else
+			err = nil
+			continue
+		}
+		if err != nil {
+			err = fmt.Errorf("delete DNS record %v failed, %v", rec, err)
+			break
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		if refreshErr := s.refreshZone(ctx, zone); refreshErr != nil && err == nil {
+			err = refreshErr
+		}
+	}
+	return deleted, err
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// ovhStub is a minimal in-memory implementation of the OVH domain
+// zone API. Record changes are staged until the zone is refreshed,
+// as OVH does.
+type ovhStub struct {
+	mu        sync.Mutex
+	zones     map[string][]ovhRecord
+	nextID    int64
+	refreshes int
+	live      map[string][]ovhRecord // records as of the last refresh
+}
+
+func newOVHStub(zones ...string) *ovhStub {
+	s := &ovhStub{
+		zones: map[string][]ovhRecord{},
+		live:  map[string][]ovhRecord{},
+	}
+	for _, zone := range zones {
+		s.zones[zone] = []ovhRecord{}
+	}
+	return s
+}
+
+func (s *ovhStub) error(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"class": "Client::NotFound", "message": message})
+}
+
+func (s *ovhStub) find(zone, id string) int {
+	for ii, rec := range s.zones[zone] {
+		if strconv.FormatInt(rec.ID, 10) == id {
+			return ii
+		}
+	}
+	return -1
+}
+
+func (s *ovhStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1.0/auth/currentCredential", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "validated"}`))
+	})
+	mux.HandleFunc("GET /1.0/domain/zone", func(w http.ResponseWriter, r *http.Request) {
+		names := []string{}
+		for name := range s.zones {
+			names = append(names, name)
+		}
+		json.NewEncoder(w).Encode(names)
+	})
+	mux.HandleFunc("GET /1.0/domain/zone/{zone}/record", func(w http.ResponseWriter, r *http.Request) {
+		records, ok := s.zones[r.PathValue("zone")]
+		if !ok {
+			s.error(w, http.StatusNotFound, "This service does not exist")
+			return
+		}
+		query := r.URL.Query()
+		ids := []int64{}
+		for _, rec := range records {
+			if sub := query.Get("subDomain"); sub != "" && rec.SubDomain != sub {
+				continue
+			}
+			if typ := query.Get("fieldType"); typ != "" && rec.FieldType != typ {
+				continue
+			}
+			ids = append(ids, rec.ID)
+		}
+		json.NewEncoder(w).Encode(ids)
+	})
+	mux.HandleFunc("GET /1.0/domain/zone/{zone}/record/{id}", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		ii := s.find(zone, r.PathValue("id"))
+		if ii < 0 {
+			s.error(w, http.StatusNotFound, "The requested object does not exist")
+			return
+		}
+		json.NewEncoder(w).Encode(s.zones[zone][ii])
+	})
+	mux.HandleFunc("POST /1.0/domain/zone/{zone}/record", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		if _, ok := s.zones[zone]; !ok {
+			s.error(w, http.StatusNotFound, "This service does not exist")
+			return
+		}
+		rec := ovhRecord{}
+		json.NewDecoder(r.Body).Decode(&rec)
+		s.nextID++
+		rec.ID = s.nextID
+		s.zones[zone] = append(s.zones[zone], rec)
+		json.NewEncoder(w).Encode(rec)
+	})
+	mux.HandleFunc("PUT /1.0/domain/zone/{zone}/record/{id}", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		ii := s.find(zone, r.PathValue("id"))
+		if ii < 0 {
+			s.error(w, http.StatusNotFound, "The requested object does not exist")
+			return
+		}
+		update := ovhRecord{}
+		json.NewDecoder(r.Body).Decode(&update)
+		rec := &s.zones[zone][ii]
+		rec.SubDomain = update.SubDomain
+		rec.Target = update.Target
+		rec.TTL = update.TTL
+		w.Write([]byte("null"))
+	})
+	mux.HandleFunc("DELETE /1.0/domain/zone/{zone}/record/{id}", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		ii := s.find(zone, r.PathValue("id"))
+		if ii < 0 {
+			s.error(w, http.StatusNotFound, "The requested object does not exist")
+			return
+		}
+		s.zones[zone] = append(s.zones[zone][:ii], s.zones[zone][ii+1:]...)
+		w.Write([]byte("null"))
+	})
+	mux.HandleFunc("POST /1.0/domain/zone/{zone}/refresh", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		if _, ok := s.zones[zone]; !ok {
+			s.error(w, http.StatusNotFound, "This service does not exist")
+			return
+		}
+		s.refreshes++
+		s.live[zone] = append([]ovhRecord{}, s.zones[zone]...)
+		w.Write([]byte("null"))
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/1.0/auth/time" {
+			w.Write([]byte(strconv.FormatInt(time.Now().Unix(), 10)))
+			return
+		}
+		if r.Header.Get("X-Ovh-Application") != "appkey" || r.Header.Get("X-Ovh-Signature") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"class": "Client::Forbidden", "message": "Invalid application key"}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func ovhTestCredentials() OVHCredentials {
+	return OVHCredentials{
+		Endpoint:          "ovh-eu",
+		ApplicationKey:    "appkey",
+		ApplicationSecret: "appsecret",
+		ConsumerKey:       "consumerkey",
+	}
+}
+
+func TestOVHStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newOVHStub("example.com")
+	client := newStubClient(t, stub.handler())
+	prov, err := GetProvider(ctx, api.OVHProvider, "", ovhTestCredentials().ToMap(), nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com")
+	duplicateRecordsTest(t, ctx, prov, "example.com", "dup.example.com", func(content string) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.nextID++
+		stub.zones["example.com"] = append(stub.zones["example.com"], ovhRecord{ID: stub.nextID, FieldType: "A", SubDomain: "dup", Target: content, TTL: 300})
+	})
+
+	// every change is followed by a refresh, which makes the change
+	// live, and nothing is refreshed if nothing changed
+	stub.refreshes = 0
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.refreshes)
+	live := stub.live["example.com"]
+	require.Equal(t, "", live[len(live)-1].SubDomain)
+	require.Equal(t, "10 mail.example.com.", live[len(live)-1].Target)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.refreshes)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "txt.example.com", "TXT", "hello world", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 2, stub.refreshes)
+
+	records, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:     "example.com",
+		Type:     "MX",
+		Content:  []string{"mail.example.com."},
+		TTL:      300,
+		Priority: 10,
+	}}, records)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "txt.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, []string{"hello world"}, records[0].Content)
+
+	// deleting all records of a name refreshes once
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "txt.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	stub.refreshes = 0
+	count, err := prov.(api.DeleteCounter).DeleteDNSRecordCount(ctx, "example.com", "txt.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, 1, stub.refreshes)
+	for _, rec := range stub.live["example.com"] {
+		require.NotEqual(t, "txt", rec.SubDomain)
+	}
+	count, err = prov.(api.DeleteCounter).DeleteDNSRecordCount(ctx, "example.com", "txt.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 0, count)
+	require.Equal(t, 1, stub.refreshes)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "example.com"}}, zones)
+
+	_, err = prov.(api.ZoneManager).CreateZone(ctx, "example.org")
+	require.ErrorIs(t, err, api.ErrUnsupported)
+}
+
+func TestOVHCredentials(t *testing.T) {
+	ctx := context.Background()
+	client := newStubClient(t, newOVHStub("example.com").handler())
+
+	creds := ovhTestCredentials()
+	err := ValidateCredentials(ctx, api.OVHProvider, creds.ToMap(), WithHTTPClient(client))
+	require.Nil(t, err)
+	creds.ApplicationKey = "wrong"
+	err = ValidateCredentials(ctx, api.OVHProvider, creds.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+
+	creds = ovhTestCredentials()
+	creds.Endpoint = ""
+	err = creds.Validate()
+	require.True(t, strings.Contains(err.Error(), "missing endpoint key"))
+}
@@ -0,0 +1,481 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+const CredentialKeySecretAPIKey = "secretAPIKey"
+
+const porkbunBaseURL = "https://api.porkbun.com/api/json/v3"
+
+// Porkbun manages DNS records via the Porkbun JSON API. Every request
+// is a POST carrying the API keys in its JSON body. Porkbun takes the
+// record name as the subdomain, empty for the apex, and returns the
+// fully qualified name when reading records. Porkbun raises TTLs
+// below its minimum of 600 seconds itself, so they are not normalized.
+type Porkbun struct {
+	client       *http.Client
+	baseURL      string
+	apiKey       string
+	secretAPIKey string
+	logger       api.Logger
+	zone         string // zone the provider was configured for, if any
+}
+
+// porkbunRecord is a record as read from Porkbun, which sends numbers
+// as strings.
+type porkbunRecord struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+	TTL     string `json:"ttl"`
+	Prio    string `json:"prio"`
+}
+
+// porkbunRequest is the body of a request, with the API keys and, for
+// creates and edits, the record.
+type porkbunRequest struct {
+	APIKey       string `json:"apikey"`
+	SecretAPIKey string `json:"secretapikey"`
+	Name         string `json:"name,omitempty"`
+	Type         string `json:"type,omitempty"`
+	Content      string `json:"content,omitempty"`
+	TTL          string `json:"ttl,omitempty"`
+	Prio         string `json:"prio,omitempty"`
+	Start        *int   `json:"start,omitempty"`
+}
+
+// porkbunError is an error returned by the Porkbun API. Errors for
+// invalid API keys and unknown domains unwrap to
+// api.ErrInvalidCredentials and api.ErrZoneNotFound.
+type porkbunError struct {
+	StatusCode int
+	Message    string
+}
+
+func (s *porkbunError) Error() string {
+	return fmt.Sprintf("porkbun error, %s", s.Message)
+}
+
+func (s *porkbunError) Unwrap() error {
+	msg := strings.ToLower(s.Message)
+	switch {
+	case strings.Contains(msg, "invalid api key"):
+		return api.ErrInvalidCredentials
+	case strings.Contains(msg, "invalid domain"):
+		return api.ErrZoneNotFound
+	}
+	return nil
+}
+
+// NewPorkbunProvider creates a new Porkbun DNS provider.
+func NewPorkbunProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*Porkbun, error) {
+	return NewPorkbunProviderWithCredentials(ctx, zone, porkbunCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewPorkbunProviderWithCredentials creates a new Porkbun DNS provider
+// from typed credentials.
+func NewPorkbunProviderWithCredentials(ctx context.Context, zone string, creds PorkbunCredentials, logger api.Logger, ops ...Option) (*Porkbun, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	opts := getOptions(ops)
+	client := opts.httpClient()
+	return &Porkbun{
+		client:       client,
+		baseURL:      porkbunBaseURL,
+		apiKey:       creds.APIKey,
+		secretAPIKey: creds.SecretAPIKey,
+		logger:       logger,
+		zone:         zone,
+	}, nil
+}
+
+// do posts the request with the API keys set. Porkbun reports errors
+// with a status and message in the body.
+func (s *Porkbun) do(ctx context.Context, path string, in porkbunRequest, out interface{}) error {
+	in.APIKey = s.apiKey
+	in.SecretAPIKey = s.secretAPIKey
+	err := doJSON(ctx, s.client, http.MethodPost, s.baseURL+path, nil, &in, out)
+	if herr, ok := err.(*httpError); ok {
+		resp := struct {
+			Message string `json:"message"`
+		}{}
+		if json.Unmarshal([]byte(herr.Body), &resp) == nil && resp.Message != "" {
+			return &porkbunError{StatusCode: herr.StatusCode, Message: resp.Message}
+		}
+	}
+	return err
+}
+
+func porkbunPath(elems ...string) string {
+	path := ""
+	for _, elem := range elems {
+		path += "/" + url.PathEscape(elem)
+	}
+	return path
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *Porkbun) Close() error {
+	return nil
+}
+
+// ValidateCredentials pings the API, which checks the API keys.
+func (s *Porkbun) ValidateCredentials(ctx context.Context) error {
+	return credentialsError(s.do(ctx, "/ping", porkbunRequest{}, nil))
+}
+
+// ListZones returns the domains of the account. Porkbun identifies
+// domains by name, and lists them in chunks of 1000.
+func (s *Porkbun) ListZones(ctx context.Context) ([]api.Zone, error) {
+	zones := []api.Zone{}
+	for start := 0; ; {
+		resp := struct {
+			Domains []struct {
+				Domain string `json:"domain"`
+			} `json:"domains"`
+		}{}
+		if err := s.do(ctx, "/domain/listAll", porkbunRequest{Start: &start}, &resp); err != nil {
+			return nil, err
+		}
+		for _, d := range resp.Domains {
+			zones = append(zones, newZone(d.Domain, ""))
+		}
+		if len(resp.Domains) == 0 {
+			break
+		}
+		start += len(resp.Domains)
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone is not supported, since Porkbun zones are registered
+// domains.
+func (s *Porkbun) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	return api.Zone{}, fmt.Errorf("%w, porkbun zones are created by registering the domain", api.ErrUnsupported)
+}
+
+// DeleteZone is not supported, since Porkbun zones are registered
+// domains.
+func (s *Porkbun) DeleteZone(ctx context.Context, zone string) error {
+	return fmt.Errorf("%w, porkbun zones are deleted with the domain", api.ErrUnsupported)
+}
+
+// porkbunName returns the name relative to the zone as Porkbun takes
+// it, with an empty subdomain for the apex.
+func porkbunName(name, zone string) string {
+	relName := relativeName(name, zone)
+	if relName == apexName {
+		return ""
+	}
+	return relName
+}
+
+// listRecords returns the records of the domain, or only those of the
+// name if given. Records of the name and type are looked up with
+// retrieveByNameType, which needs no subdomain for the apex.
+func (s *Porkbun) listRecords(ctx context.Context, zone, name, rtype string) ([]porkbunRecord, error) {
+	domain := strings.TrimSuffix(zone, ".")
+	path := porkbunPath("dns", "retrieve", domain)
+	if rtype != "" {
+		path = porkbunPath("dns", "retrieveByNameType", domain, strings.ToUpper(rtype))
+		if sub := porkbunName(name, zone); sub != "" {
+			path += porkbunPath(sub)
+		}
+	}
+	resp := struct {
+		Records []porkbunRecord `json:"records"`
+	}{}
+	if err := s.do(ctx, path, porkbunRequest{}, &resp); err != nil {
+		return nil, err
+	}
+	records := []porkbunRecord{}
+	for _, rec := range resp.Records {
+		// names are read back fully qualified, and the apex is
+		// normalized to the zone name
+		rec.Name = absoluteName(rec.Name, domain)
+		if name != "" && !strings.EqualFold(rec.Name, absoluteName(name, domain)) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func porkbunRecordToRecord(prec porkbunRecord, zone string) api.Record {
+	ttl, _ := strconv.Atoi(prec.TTL)
+	prio, _ := strconv.Atoi(prec.Prio)
+	record := api.Record{
+		Type:    prec.Type,
+		Name:    prec.Name,
+		Content: []string{prec.Content},
+		TTL:     ttl,
+		System:  isSystemRecord(zone, prec.Name, prec.Type),
+		ID:      prec.ID,
+	}
+	switch prec.Type {
+	case api.RecordTypeMX:
+		record.Priority = prio
+	case api.RecordTypeSRV:
+		// the content of SRV records is "weight port target"
+		record.Priority = prio
+		if values, target, err := parseUint16Fields(api.RecordTypeSRV, prec.Content, "weight port target", 2); err == nil {
+			record.Weight = values[0]
+			record.Port = values[1]
+			record.Content = []string{target}
+		}
+	case api.RecordTypeTXT:
+		record.Content = []string{parseTXTRRData(prec.Content)}
+	}
+	return canonicalTargets(withUnicodeName(record))
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *Porkbun) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	precords, err := s.listRecords(ctx, zone, name, "")
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, prec := range precords {
+		records = append(records, porkbunRecordToRecord(prec, zone))
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *Porkbun) SupportedRecordTypes() []string {
+	return slices.Clone(porkbunRecordTypes)
+}
+
+// Capabilities reports only the record types, since Porkbun zones are
+// registered domains.
+func (s *Porkbun) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes: s.SupportedRecordTypes(),
+	}
+}
+
+// porkbunContent splits content as passed to CreateOrUpdateDNSRecord
+// into the content and priority of a Porkbun record. Host name
+// targets are stored without the trailing dot.
+func porkbunContent(rtype, content string) (string, int, error) {
+	switch rtype {
+	case api.RecordTypeMX:
+		priority, target, err := parseMXContent(content)
+		if err != nil {
+			return "", 0, err
+		}
+		return strings.TrimSuffix(target, "."), priority, nil
+	case api.RecordTypeSRV:
+		priority, weight, port, target, err := parseSRVContent(content)
+		if err != nil {
+			return "", 0, err
+		}
+		return fmt.Sprintf("%d %d %s", weight, port, strings.TrimSuffix(target, ".")), priority, nil
+	case api.RecordTypeTXT:
+		return txtValue(content), 0, nil
+	}
+	if hasHostTarget(rtype) {
+		return strings.TrimSuffix(content, "."), 0, nil
+	}
+	return content, 0, nil
+}
+
+// CreateOrUpdateDNSRecord edits the existing record of the name and
+// type by its ID if found, or creates a new one. If there are several
+// records, the one already holding the content is kept, or else the
+// first, and the others are deleted.
+func (s *Porkbun) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.PorkbunProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, porkbunRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	rtype = strings.ToUpper(rtype)
+	data, priority, err := porkbunContent(rtype, content)
+	if err != nil {
+		return err
+	}
+	precords, err := s.listRecords(ctx, zone, name, rtype)
+	if err != nil {
+		return err
+	}
+	domain := strings.TrimSuffix(zone, ".")
+	req := porkbunRequest{
+		Name:    porkbunName(name, zone),
+		Type:    rtype,
+		Content: data,
+	}
+	if ttl > 0 {
+		req.TTL = strconv.Itoa(ttl)
+	}
+	if rtype == api.RecordTypeMX || rtype == api.RecordTypeSRV {
+		req.Prio = strconv.Itoa(priority)
+	}
+
+	if len(precords) == 0 {
+		if err := s.do(ctx, porkbunPath("dns", "create", domain), req, nil); err != nil {
+			s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+			return fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+		}
+		return nil
+	}
+	keep := -1
+	for ii, r := range precords {
+		if keep < 0 && r.Content == data {
+			keep = ii
+		}
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	r := precords[keep]
+	if r.Content == data && (ttl == 0 || r.TTL == req.TTL) && (req.Prio == "" || r.Prio == req.Prio) {
+		s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", content)
+	} else {
+		s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+		if err := s.do(ctx, porkbunPath("dns", "edit", domain, r.ID), req, nil); err != nil {
+			return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+		}
+	}
+	for ii, other := range precords {
+		if ii == keep {
+			continue
+		}
+		if err := s.do(ctx, porkbunPath("dns", "delete", domain, other.ID), porkbunRequest{}, nil); err != nil {
+			return fmt.Errorf("delete DNS record %v failed, %v", other, err)
+		}
+	}
+	return nil
+}
+
+// recordByID returns the record of the zone with the id.
+func (s *Porkbun) recordByID(ctx context.Context, zone, id string) (porkbunRecord, error) {
+	precords, err := s.listRecords(ctx, zone, "", "")
+	if err != nil {
+		return porkbunRecord{}, err
+	}
+	for _, rec := range precords {
+		if rec.ID == id {
+			return rec, nil
+		}
+	}
+	return porkbunRecord{}, fmt.Errorf("%w: zone %s record id %s", api.ErrRecordNotFound, zone, id)
+}
+
+// UpdateDNSRecordByID sets the content and TTL of the record with the
+// id, keeping its name and type.
+func (s *Porkbun) UpdateDNSRecordByID(ctx context.Context, zone, id, content string, ttl int) error {
+	prec, err := s.recordByID(ctx, zone, id)
+	if err != nil {
+		return err
+	}
+	if err := validateContent(prec.Name, prec.Type, ttl, content); err != nil {
+		return err
+	}
+	data, priority, err := porkbunContent(prec.Type, content)
+	if err != nil {
+		return err
+	}
+	req := porkbunRequest{
+		Name:    porkbunName(prec.Name, zone),
+		Type:    prec.Type,
+		Content: data,
+	}
+	if ttl > 0 {
+		req.TTL = strconv.Itoa(ttl)
+	}
+	if prec.Type == api.RecordTypeMX || prec.Type == api.RecordTypeSRV {
+		req.Prio = strconv.Itoa(priority)
+	}
+	s.logger.InfoContext(ctx, "UpdateDNSRecordByID updating", "zone", zone, "id", id, "content", content)
+	if err := s.do(ctx, porkbunPath("dns", "edit", strings.TrimSuffix(zone, "."), id), req, nil); err != nil {
+		return fmt.Errorf("cannot update DNS record %s for zone %s, %v", id, zone, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecordByID deletes the record with the id.
+func (s *Porkbun) DeleteDNSRecordByID(ctx context.Context, zone, id string) error {
+	if _, err := s.recordByID(ctx, zone, id); err != nil {
+		return err
+	}
+	if err := s.do(ctx, porkbunPath("dns", "delete", strings.TrimSuffix(zone, "."), id), porkbunRequest{}, nil); err != nil {
+		return fmt.Errorf("delete DNS record %s for zone %s failed, %v", id, zone, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *Porkbun) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *Porkbun) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *Porkbun) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+func (s *Porkbun) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	precords, err := s.listRecords(ctx, zone, name, rtype)
+	if err != nil {
+		return 0, err
+	}
+	domain := strings.TrimSuffix(zone, ".")
+	deleted := 0
+	for _, rec := range precords {
+		if err := s.do(ctx, porkbunPath("dns", "delete", domain, rec.ID), porkbunRequest{}, nil); err != nil {
+			return deleted, fmt.Errorf("delete DNS record %v failed, %v", rec, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
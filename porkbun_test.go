@@ -0,0 +1,259 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// porkbunStub is a minimal in-memory implementation of the Porkbun DNS
+// API. Records are stored by subdomain, and read back with fully
+// qualified names, except for the apex which is read back with an
+// empty name.
+type porkbunStub struct {
+	mu      sync.Mutex
+	domains map[string][]porkbunRecord
+	nextID  int
+	edits   int
+}
+
+func newPorkbunStub(domains ...string) *porkbunStub {
+	s := &porkbunStub{domains: map[string][]porkbunRecord{}}
+	for _, domain := range domains {
+		s.domains[domain] = []porkbunRecord{}
+	}
+	return s
+}
+
+func (s *porkbunStub) error(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ERROR", "message": message})
+}
+
+func (s *porkbunStub) success(w http.ResponseWriter, fields map[string]interface{}) {
+	resp := map[string]interface{}{"status": "SUCCESS"}
+	for key, val := range fields {
+		resp[key] = val
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *porkbunStub) readName(sub, domain string) string {
+	if sub == "" {
+		return ""
+	}
+	return sub + "." + domain
+}
+
+func (s *porkbunStub) find(domain, id string) int {
+	for ii, rec := range s.domains[domain] {
+		if rec.ID == id {
+			return ii
+		}
+	}
+	return -1
+}
+
+func (s *porkbunStub) setRecord(rec *porkbunRecord, req *porkbunRequest) {
+	rec.Name = req.Name
+	rec.Type = req.Type
+	rec.Content = req.Content
+	rec.TTL = req.TTL
+	if rec.TTL == "" {
+		rec.TTL = "600"
+	}
+	rec.Prio = req.Prio
+	if rec.Prio == "" {
+		rec.Prio = "0"
+	}
+}
+
+func (s *porkbunStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/json/v3/ping", func(w http.ResponseWriter, r *http.Request) {
+		s.success(w, map[string]interface{}{"yourIp": "192.0.2.1"})
+	})
+	mux.HandleFunc("POST /api/json/v3/domain/listAll", func(w http.ResponseWriter, r *http.Request) {
+		req := r.Context().Value(porkbunRequest{}).(*porkbunRequest)
+		domains := []map[string]string{}
+		if req.Start == nil || *req.Start == 0 {
+			for name := range s.domains {
+				domains = append(domains, map[string]string{"domain": name})
+			}
+		}
+		s.success(w, map[string]interface{}{"domains": domains})
+	})
+	retrieve := func(w http.ResponseWriter, r *http.Request) {
+		domain := r.PathValue("domain")
+		all, ok := s.domains[domain]
+		if !ok {
+			s.error(w, "Invalid domain.")
+			return
+		}
+		records := []porkbunRecord{}
+		for _, rec := range all {
+			if typ := r.PathValue("type"); typ != "" && (rec.Type != typ || rec.Name != r.PathValue("sub")) {
+				continue
+			}
+			rec.Name = s.readName(rec.Name, domain)
+			records = append(records, rec)
+		}
+		s.success(w, map[string]interface{}{"records": records})
+	}
+	mux.HandleFunc("POST /api/json/v3/dns/retrieve/{domain}", retrieve)
+	mux.HandleFunc("POST /api/json/v3/dns/retrieveByNameType/{domain}/{type}", retrieve)
+	mux.HandleFunc("POST /api/json/v3/dns/retrieveByNameType/{domain}/{type}/{sub}", retrieve)
+	mux.HandleFunc("POST /api/json/v3/dns/create/{domain}", func(w http.ResponseWriter, r *http.Request) {
+		domain := r.PathValue("domain")
+		if _, ok := s.domains[domain]; !ok {
+			s.error(w, "Invalid domain.")
+			return
+		}
+		s.nextID++
+		rec := porkbunRecord{ID: strconv.Itoa(s.nextID)}
+		s.setRecord(&rec, r.Context().Value(porkbunRequest{}).(*porkbunRequest))
+		s.domains[domain] = append(s.domains[domain], rec)
+		s.success(w, map[string]interface{}{"id": s.nextID})
+	})
+	mux.HandleFunc("POST /api/json/v3/dns/edit/{domain}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		domain := r.PathValue("domain")
+		ii := s.find(domain, r.PathValue("id"))
+		if ii < 0 {
+			s.error(w, "Invalid record ID.")
+			return
+		}
+		s.edits++
+		s.setRecord(&s.domains[domain][ii], r.Context().Value(porkbunRequest{}).(*porkbunRequest))
+		s.success(w, nil)
+	})
+	mux.HandleFunc("POST /api/json/v3/dns/delete/{domain}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		domain := r.PathValue("domain")
+		ii := s.find(domain, r.PathValue("id"))
+		if ii < 0 {
+			s.error(w, "Invalid record ID.")
+			return
+		}
+		s.domains[domain] = append(s.domains[domain][:ii], s.domains[domain][ii+1:]...)
+		s.success(w, nil)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		req := porkbunRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.error(w, "Invalid request body.")
+			return
+		}
+		if req.APIKey != "pk1_test" || req.SecretAPIKey != "sk1_test" {
+			s.error(w, "Invalid API key. (002)")
+			return
+		}
+		mux.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), porkbunRequest{}, &req)))
+	})
+}
+
+func newPorkbunTestProvider(t *testing.T, stub *porkbunStub) api.Provider {
+	client := newStubClient(t, stub.handler())
+	prov, err := GetProvider(context.Background(), api.PorkbunProvider, "", PorkbunCredentials{APIKey: "pk1_test", SecretAPIKey: "sk1_test"}.ToMap(), nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	return prov
+}
+
+func TestPorkbunStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newPorkbunStub("example.com")
+	prov := newPorkbunTestProvider(t, stub)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	recordIDTest(t, ctx, prov.(api.RecordIDManager), "example.com", "id1.example.com", "id2.example.com")
+	duplicateRecordsTest(t, ctx, prov, "example.com", "dup.example.com", func(content string) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.nextID++
+		stub.domains["example.com"] = append(stub.domains["example.com"], porkbunRecord{ID: strconv.Itoa(stub.nextID), Type: "A", Name: "dup", Content: content, TTL: "600", Prio: "0"})
+	})
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com")
+
+	// the apex is written as an empty subdomain, and its empty name is
+	// read back as the zone name
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com.", 600, false)
+	require.Nil(t, err)
+	records := stub.domains["example.com"]
+	require.Equal(t, "", records[len(records)-1].Name)
+	require.Equal(t, "mail.example.com", records[len(records)-1].Content)
+	require.Equal(t, "10", records[len(records)-1].Prio)
+	got, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:     "example.com",
+		Type:     "MX",
+		Content:  []string{"mail.example.com."},
+		TTL:      600,
+		Priority: 10,
+		ID:       records[len(records)-1].ID,
+	}}, got)
+
+	// updates edit the record found by name and type by its ID
+	stub.edits = 0
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "20 mail.example.com.", 600, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.edits)
+	require.Equal(t, len(records), len(stub.domains["example.com"]))
+	require.Equal(t, "20", stub.domains["example.com"][len(records)-1].Prio)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "20 mail.example.com.", 600, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.edits)
+
+	// SRV content is "weight port target" with a separate priority
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "_sip._tcp.example.com", "SRV", "10 20 5060 sip.example.com", 600, false)
+	require.Nil(t, err)
+	got, err = prov.GetDNSRecords(ctx, "example.com", "_sip._tcp.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:     "_sip._tcp.example.com",
+		Type:     "SRV",
+		Content:  []string{"sip.example.com."},
+		TTL:      600,
+		Priority: 10,
+		Weight:   20,
+		Port:     5060,
+		ID:       stub.domains["example.com"][len(stub.domains["example.com"])-1].ID,
+	}}, got)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "example.com"}}, zones)
+}
+
+func TestPorkbunCredentials(t *testing.T) {
+	ctx := context.Background()
+	client := newStubClient(t, newPorkbunStub("example.com").handler())
+
+	creds := PorkbunCredentials{APIKey: "pk1_test", SecretAPIKey: "sk1_test"}
+	err := ValidateCredentials(ctx, api.PorkbunProvider, creds.ToMap(), WithHTTPClient(client))
+	require.Nil(t, err)
+	creds.SecretAPIKey = "sk1_wrong"
+	err = ValidateCredentials(ctx, api.PorkbunProvider, creds.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+}
@@ -0,0 +1,361 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+const (
+	CredentialKeyAPIURL   = "apiURL"
+	CredentialKeyAPIKey   = "apiKey"
+	CredentialKeyServerID = "serverID"
+
+	powerDNSDefaultServerID = "localhost"
+	powerDNSChangeReplace   = "REPLACE"
+	powerDNSChangeDelete    = "DELETE"
+)
+
+// PowerDNS manages DNS records via the PowerDNS authoritative server
+// HTTP API. PowerDNS requires canonical names with a trailing dot,
+// which are converted to and from the form used by this package.
+type PowerDNS struct {
+	client   *http.Client
+	baseURL  string
+	apiKey   string
+	serverID string
+	logger   api.Logger
+	author   string
+	comment  string
+	zone     string // zone the provider was configured for, if any
+}
+
+type powerDNSZone struct {
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	RRsets []powerDNSRRset `json:"rrsets"`
+}
+
+type powerDNSRRset struct {
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	TTL        int               `json:"ttl,omitempty"`
+	ChangeType string            `json:"changetype,omitempty"`
+	Records    []powerDNSRecord  `json:"records"`
+	Comments   []powerDNSComment `json:"comments,omitempty"`
+}
+
+type powerDNSComment struct {
+	Content    string `json:"content"`
+	Account    string `json:"account"`
+	ModifiedAt int64  `json:"modified_at,omitempty"`
+}
+
+type powerDNSRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+// NewPowerDNSProvider creates a new PowerDNS provider. The credentials
+// must include the API base URL, for example http://pdns:8081, and the
+// API key. The server id defaults to localhost.
+func NewPowerDNSProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*PowerDNS, error) {
+	return NewPowerDNSProviderWithCredentials(ctx, zone, powerDNSCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewPowerDNSProviderWithCredentials creates a new PowerDNS provider
+// from typed credentials.
+func NewPowerDNSProviderWithCredentials(ctx context.Context, zone string, creds PowerDNSCredentials, logger api.Logger, ops ...Option) (*PowerDNS, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	baseURL := creds.APIURL
+	apiKey := creds.APIKey
+	serverID := creds.ServerID
+	if serverID == "" {
+		serverID = powerDNSDefaultServerID
+	}
+	opts := getOptions(ops)
+	client := opts.httpClient()
+	return &PowerDNS{
+		client:   client,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		apiKey:   apiKey,
+		serverID: serverID,
+		logger:   logger,
+		author:   opts.changeAuthor,
+		comment:  opts.changeComment(),
+		zone:     zone,
+	}, nil
+}
+
+func (s *PowerDNS) zonesPath() string {
+	return s.baseURL + "/api/v1/servers/" + url.PathEscape(s.serverID) + "/zones"
+}
+
+func (s *PowerDNS) zonePath(zone string) string {
+	return s.zonesPath() + "/" + url.PathEscape(canonicalName(zone))
+}
+
+func (s *PowerDNS) do(ctx context.Context, method, path string, in, out interface{}) error {
+	header := http.Header{}
+	header.Set("X-API-Key", s.apiKey)
+	return doJSON(ctx, s.client, method, path, header, in, out)
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *PowerDNS) Close() error {
+	return nil
+}
+
+// ValidateCredentials reads the configured server, which also checks
+// that the server id exists.
+func (s *PowerDNS) ValidateCredentials(ctx context.Context) error {
+	path := s.baseURL + "/api/v1/servers/" + url.PathEscape(s.serverID)
+	return credentialsError(s.do(ctx, http.MethodGet, path, nil, nil))
+}
+
+// ListZones returns the zones of the server.
+func (s *PowerDNS) ListZones(ctx context.Context) ([]api.Zone, error) {
+	pzones := []powerDNSZone{}
+	if err := s.do(ctx, http.MethodGet, s.zonesPath(), nil, &pzones); err != nil {
+		return nil, err
+	}
+	zones := []api.Zone{}
+	for _, pzone := range pzones {
+		zones = append(zones, newZone(pzone.Name, pzone.ID))
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone creates a native zone, which PowerDNS serves from its
+// own backend without replication by zone transfers.
+func (s *PowerDNS) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	in := struct {
+		Name        string   `json:"name"`
+		Kind        string   `json:"kind"`
+		Nameservers []string `json:"nameservers"`
+	}{
+		Name:        canonicalName(zone),
+		Kind:        "Native",
+		Nameservers: []string{},
+	}
+	pzone := powerDNSZone{}
+	if err := s.do(ctx, http.MethodPost, s.zonesPath(), &in, &pzone); err != nil {
+		return api.Zone{}, fmt.Errorf("cannot create zone %s, %v", zone, err)
+	}
+	return newZone(pzone.Name, pzone.ID), nil
+}
+
+// DeleteZone deletes the zone and all its records.
+func (s *PowerDNS) DeleteZone(ctx context.Context, zone string) error {
+	err := s.do(ctx, http.MethodDelete, s.zonePath(zone), nil, nil)
+	if isHTTPStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete zone %s, %v", zone, err)
+	}
+	return nil
+}
+
+func (s *PowerDNS) getZone(ctx context.Context, zone string) (*powerDNSZone, error) {
+	pzone := powerDNSZone{}
+	err := s.do(ctx, http.MethodGet, s.zonePath(zone), nil, &pzone)
+	if isHTTPStatus(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pzone, nil
+}
+
+func (s *PowerDNS) patchZone(ctx context.Context, zone string, rrsets []powerDNSRRset) error {
+	patch := struct {
+		RRsets []powerDNSRRset `json:"rrsets"`
+	}{
+		RRsets: rrsets,
+	}
+	err := s.do(ctx, http.MethodPatch, s.zonePath(zone), &patch, nil)
+	if isHTTPStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	return err
+}
+
+// canonicalName returns the name with the trailing dot PowerDNS
+// requires.
+func canonicalName(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *PowerDNS) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	pzone, err := s.getZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, rrset := range pzone.RRsets {
+		rrName := strings.TrimSuffix(rrset.Name, ".")
+		if name != "" && !strings.EqualFold(strings.TrimSuffix(name, "."), rrName) {
+			continue
+		}
+		record := api.Record{
+			Type:    rrset.Type,
+			Name:    rrName,
+			Content: []string{},
+			TTL:     rrset.TTL,
+			System:  isSystemRecord(zone, rrName, rrset.Type),
+		}
+		for _, rec := range rrset.Records {
+			if rec.Disabled {
+				continue
+			}
+			record.Content = append(record.Content, rec.Content)
+		}
+		if len(record.Content) == 0 {
+			// comment-only or fully disabled rrset
+			continue
+		}
+		records = append(records, fromPresentation(record)...)
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *PowerDNS) SupportedRecordTypes() []string {
+	return slices.Clone(presentationRecordTypes)
+}
+
+// Capabilities reports that PowerDNS can manage zones.
+func (s *PowerDNS) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// CreateOrUpdateDNSRecord replaces any existing records of the same
+// name and type with the new record.
+func (s *PowerDNS) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.PowerDNSProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, presentationRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	content, err := toPresentation(rtype, content)
+	if err != nil {
+		return err
+	}
+	rrset := powerDNSRRset{
+		Name:       canonicalName(name),
+		Type:       strings.ToUpper(rtype),
+		TTL:        ttl,
+		ChangeType: powerDNSChangeReplace,
+		Records: []powerDNSRecord{{
+			Content: content,
+		}},
+	}
+	if s.comment != "" {
+		// replaces any existing comments on the rrset
+		rrset.Comments = []powerDNSComment{{
+			Content: s.comment,
+			Account: s.author,
+		}}
+	}
+	s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+	err = s.patchZone(ctx, zone, []powerDNSRRset{rrset})
+	if err != nil {
+		return fmt.Errorf("cannot update DNS record for zone %s name %s, %w", zone, name, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *PowerDNS) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRRsets(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number of values in the deleted rrsets.
+func (s *PowerDNS) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRRsets(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the rrset of the given type for
+// the name.
+func (s *PowerDNS) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRRsets(ctx, zone, name, rtype)
+	return err
+}
+
+func (s *PowerDNS) deleteRRsets(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	pzone, err := s.getZone(ctx, zone)
+	if err != nil {
+		return 0, err
+	}
+	rrName := canonicalName(name)
+	deletes := []powerDNSRRset{}
+	count := 0
+	for _, rrset := range pzone.RRsets {
+		if !strings.EqualFold(rrset.Name, rrName) {
+			continue
+		}
+		if rtype != "" && !strings.EqualFold(rrset.Type, rtype) {
+			continue
+		}
+		deletes = append(deletes, powerDNSRRset{
+			Name:       rrset.Name,
+			Type:       rrset.Type,
+			ChangeType: powerDNSChangeDelete,
+			Records:    []powerDNSRecord{},
+		})
+		count += len(rrset.Records)
+	}
+	if len(deletes) == 0 {
+		return 0, nil
+	}
+	err = s.patchZone(ctx, zone, deletes)
+	if err != nil {
+		return 0, fmt.Errorf("cannot delete DNS records for zone %s name %s, %w", zone, name, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// powerDNSStub is a minimal in-memory implementation of the PowerDNS
+// zones API for a single server.
+type powerDNSStub struct {
+	mu       sync.Mutex
+	serverID string
+	zones    map[string]*powerDNSZone
+	patches  []powerDNSRRset
+}
+
+func (s *powerDNSStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/servers/{server}/zones/{zone}", func(w http.ResponseWriter, r *http.Request) {
+		zone, ok := s.zones[r.PathValue("zone")]
+		if !ok || r.PathValue("server") != s.serverID {
+			http.Error(w, `{"error": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(zone)
+	})
+	mux.HandleFunc("PATCH /api/v1/servers/{server}/zones/{zone}", func(w http.ResponseWriter, r *http.Request) {
+		zone, ok := s.zones[r.PathValue("zone")]
+		if !ok || r.PathValue("server") != s.serverID {
+			http.Error(w, `{"error": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		patch := powerDNSZone{}
+		json.NewDecoder(r.Body).Decode(&patch)
+		for _, change := range patch.RRsets {
+			if !strings.HasSuffix(change.Name, ".") {
+				http.Error(w, `{"error": "name is not canonical"}`, http.StatusUnprocessableEntity)
+				return
+			}
+			s.patches = append(s.patches, change)
+			kept := []powerDNSRRset{}
+			for _, rrset := range zone.RRsets {
+				if rrset.Name != change.Name || rrset.Type != change.Type {
+					kept = append(kept, rrset)
+				}
+			}
+			if change.ChangeType == powerDNSChangeReplace {
+				change.ChangeType = ""
+				kept = append(kept, change)
+			}
+			zone.RRsets = kept
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get("X-API-Key") != "test" {
+			http.Error(w, `{"error": "Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func TestPowerDNSStub(t *testing.T) {
+	ctx := context.Background()
+	stub := &powerDNSStub{
+		serverID: "localhost",
+		zones: map[string]*powerDNSZone{
+			"example.com.": {ID: "example.com.", Name: "example.com."},
+		},
+	}
+	client := newStubClient(t, stub.handler())
+	creds := map[string]string{
+		CredentialKeyAPIURL: "http://pdns:8081/",
+		CredentialKeyAPIKey: "test",
+	}
+
+	prov, err := GetProvider(ctx, api.PowerDNSProvider, "", creds, nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+
+	// names are sent in canonical form, deletes are per rrset
+	stub.patches = nil
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "AAAA", "fd00::1", 300, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	require.Equal(t, "www.example.com", records[0].Name)
+	err = prov.DeleteDNSRecord(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 4, len(stub.patches))
+	require.Equal(t, "www.example.com.", stub.patches[0].Name)
+	require.Equal(t, powerDNSChangeReplace, stub.patches[0].ChangeType)
+	require.Equal(t, powerDNSChangeDelete, stub.patches[2].ChangeType)
+	require.Equal(t, powerDNSChangeDelete, stub.patches[3].ChangeType)
+
+	// the change author is recorded as an rrset comment
+	author, err := GetProvider(ctx, api.PowerDNSProvider, "", creds, nil, WithHTTPClient(client), WithChangeAuthor("alice"))
+	require.Nil(t, err)
+	err = author.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	comments := stub.patches[len(stub.patches)-1].Comments
+	require.Equal(t, []powerDNSComment{{Content: "last changed by alice", Account: "alice"}}, comments)
+
+	// disabled records are not returned
+	stub.zones["example.com."].RRsets = []powerDNSRRset{{
+		Name: "off.example.com.",
+		Type: "A",
+		TTL:  300,
+		Records: []powerDNSRecord{
+			{Content: "10.0.0.1", Disabled: true},
+		},
+	}}
+	records, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 0, len(records))
+
+	_, err = prov.GetDNSRecords(ctx, "missing.com", "")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "no zone found")
+
+	// server id is configurable
+	creds[CredentialKeyServerID] = "other"
+	other, err := GetProvider(ctx, api.PowerDNSProvider, "", creds, nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	_, err = other.GetDNSRecords(ctx, "example.com", "")
+	require.NotNil(t, err)
+
+	_, err = GetProvider(ctx, api.PowerDNSProvider, "", map[string]string{CredentialKeyAPIKey: "test"}, nil)
+	require.NotNil(t, err)
+}
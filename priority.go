@@ -0,0 +1,145 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// parseUint16Fields parses the leading fields of content as 16 bit
+// unsigned integers, returning them and the final target field.
+func parseUint16Fields(rtype, content, form string, count int) ([]int, string, error) {
+	fields := strings.Fields(content)
+	if len(fields) != count+1 {
+		return nil, "", fmt.Errorf("%w, %s content %q must be %q", api.ErrInvalidContent, rtype, content, form)
+	}
+	values := []int{}
+	for _, field := range fields[:count] {
+		val, err := strconv.ParseUint(field, 10, 16)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w, %s content %q, %v", api.ErrInvalidContent, rtype, content, err)
+		}
+		values = append(values, int(val))
+	}
+	return values, fields[count], nil
+}
+
+// parseMXContent splits MX record content in "priority target" form,
+// as passed to CreateOrUpdateDNSRecord, checking that the priority is
+// in range and the target is a valid host name.
+func parseMXContent(content string) (int, string, error) {
+	values, target, err := parseUint16Fields(api.RecordTypeMX, content, "priority target", 1)
+	if err != nil {
+		return 0, "", err
+	}
+	if !api.IsHostname(target) {
+		return 0, "", fmt.Errorf("%w, MX target %q is not a valid host name", api.ErrInvalidContent, target)
+	}
+	return values[0], target, nil
+}
+
+// parseSRVContent splits SRV record content in "priority weight port
+// target" form, as passed to CreateOrUpdateDNSRecord.
+func parseSRVContent(content string) (int, int, int, string, error) {
+	values, target, err := parseUint16Fields(api.RecordTypeSRV, content, "priority weight port target", 3)
+	if err != nil {
+		return 0, 0, 0, "", err
+	}
+	return values[0], values[1], values[2], target, nil
+}
+
+// checkSRVName checks that the name follows the _service._proto.name
+// convention required for SRV records.
+func checkSRVName(name string) error {
+	labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+	if len(labels) < 3 || len(labels[0]) < 2 || len(labels[1]) < 2 || labels[0][0] != '_' || labels[1][0] != '_' {
+		return fmt.Errorf("invalid SRV record name %q, must be of the form _service._proto.name", name)
+	}
+	return nil
+}
+
+type priorityKey struct {
+	priority int
+	weight   int
+	port     int
+}
+
+// splitPriorityRecord converts an MX or SRV record read from a backend
+// that stores values in presentation format into one record per
+// distinct priority (and weight and port for SRV), with those fields
+// set and Content holding only the targets. Other records, and values
+// that cannot be parsed, are returned as is.
+func splitPriorityRecord(record api.Record) []api.Record {
+	if record.Type != api.RecordTypeMX && record.Type != api.RecordTypeSRV {
+		return []api.Record{record}
+	}
+	records := []api.Record{}
+	index := map[priorityKey]int{}
+	for _, content := range record.Content {
+		key := priorityKey{}
+		target := content
+		var err error
+		if record.Type == api.RecordTypeMX {
+			key.priority, target, err = parseMXContent(content)
+		} else {
+			key.priority, key.weight, key.port, target, err = parseSRVContent(content)
+		}
+		if err != nil {
+			key, target = priorityKey{}, content
+		}
+		if ii, ok := index[key]; ok {
+			records[ii].Content = append(records[ii].Content, target)
+			continue
+		}
+		index[key] = len(records)
+		split := record
+		split.Content = []string{target}
+		split.Priority = key.priority
+		split.Weight = key.weight
+		split.Port = key.port
+		records = append(records, split)
+	}
+	return records
+}
+
+// mxRRData returns MX content in the "priority target." presentation
+// form required by backends that store MX records as a single string,
+// with the target made fully qualified.
+func mxRRData(content string) (string, error) {
+	priority, target, err := parseMXContent(content)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %s", priority, fqdnTarget(target)), nil
+}
+
+// srvRRData returns SRV content in the "priority weight port target."
+// presentation form, with the target made fully qualified.
+func srvRRData(content string) (string, error) {
+	priority, weight, port, target, err := parseSRVContent(content)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %d %d %s", priority, weight, port, fqdnTarget(target)), nil
+}
+
+// fqdnTarget returns the host name with a single trailing dot.
+func fqdnTarget(target string) string {
+	return strings.TrimRight(target, ".") + "."
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityContent(t *testing.T) {
+	priority, target, err := parseMXContent("10 mail.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 10, priority)
+	require.Equal(t, "mail.example.com", target)
+
+	for _, content := range []string{"mail.example.com", "high mail.example.com", "70000 mail.example.com", "10 a b"} {
+		_, _, err = parseMXContent(content)
+		require.NotNil(t, err, content)
+	}
+
+	rrdata, err := mxRRData("20   mail.example.com")
+	require.Nil(t, err)
+	require.Equal(t, "20 mail.example.com.", rrdata)
+
+	// values are grouped by priority
+	records := splitPriorityRecord(api.Record{
+		Type:    "MX",
+		Name:    "example.com",
+		Content: []string{"10 mx1.example.com.", "20 mx2.example.com.", "10 mx3.example.com."},
+		TTL:     300,
+	})
+	require.Equal(t, []api.Record{{
+		Type:     "MX",
+		Name:     "example.com",
+		Content:  []string{"mx1.example.com.", "mx3.example.com."},
+		TTL:      300,
+		Priority: 10,
+	}, {
+		Type:     "MX",
+		Name:     "example.com",
+		Content:  []string{"mx2.example.com."},
+		TTL:      300,
+		Priority: 20,
+	}}, records)
+
+	priority, weight, port, target, err := parseSRVContent("10 60 5060 sip.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []int{10, 60, 5060}, []int{priority, weight, port})
+	require.Equal(t, "sip.example.com", target)
+	_, _, _, _, err = parseSRVContent("10 60 sip.example.com")
+	require.NotNil(t, err)
+	rrdata, err = srvRRData("10 60 5060 sip.example.com")
+	require.Nil(t, err)
+	require.Equal(t, "10 60 5060 sip.example.com.", rrdata)
+
+	records = splitPriorityRecord(api.Record{
+		Type:    "SRV",
+		Name:    "_sip._tcp.example.com",
+		Content: []string{"10 60 5060 a.example.com.", "10 20 5060 b.example.com."},
+	})
+	require.Equal(t, 2, len(records))
+	require.Equal(t, api.Record{Type: "SRV", Name: "_sip._tcp.example.com", Content: []string{"a.example.com."}, Priority: 10, Weight: 60, Port: 5060}, records[0])
+	require.Equal(t, 20, records[1].Weight)
+
+	require.Nil(t, checkSRVName("_sip._tcp.example.com"))
+	require.Nil(t, checkSRVName("_sip._udp.example.com."))
+	for _, name := range []string{"sip.example.com", "_sip.example.com", "_sip._tcp", "_._tcp.example.com"} {
+		require.NotNil(t, checkSRVName(name), name)
+	}
+
+	// other types are unchanged
+	txt := api.Record{Type: "TXT", Name: "example.com", Content: []string{"10 not mx"}}
+	require.Equal(t, []api.Record{txt}, splitPriorityRecord(txt))
+}
+
+func TestMXContent(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockProvider("example.com")
+	expected := []api.Record{{
+		Type:     "MX",
+		Name:     "example.com",
+		Content:  []string{"mail.example.com."},
+		TTL:      300,
+		Priority: 10,
+	}}
+
+	// combined string content
+	err := mock.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, expected, mock.Dump("example.com"))
+
+	// separate priority field, or both forms in a record
+	for _, content := range []string{"mail.example.com", "10 mail.example.com"} {
+		mock = NewMockProvider("example.com")
+		err = BatchCreateOrUpdateDNSRecords(ctx, mock, "example.com", []api.Record{{
+			Type:     "mx",
+			Name:     "example.com",
+			Content:  []string{content},
+			TTL:      300,
+			Priority: 10,
+		}})
+		require.Nil(t, err, content)
+		require.Equal(t, expected, mock.Dump("example.com"), content)
+	}
+
+	// out of range priorities and invalid targets are rejected
+	for _, content := range []string{"65536 mail.example.com", "-1 mail.example.com", "10 mail_server.example.com", "10 -mail.example.com", "10 mail..example.com"} {
+		err = mock.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", content, 300, false)
+		require.True(t, errors.Is(err, api.ErrInvalidContent), content)
+	}
+	err = BatchCreateOrUpdateDNSRecords(ctx, mock, "example.com", []api.Record{{
+		Type:     "MX",
+		Name:     "example.com",
+		Content:  []string{"mail.example.com"},
+		Priority: 70000,
+	}})
+	require.True(t, errors.Is(err, api.ErrInvalidContent))
+
+	// null MX
+	err = mock.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "0 .", 300, false)
+	require.Nil(t, err)
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/miekg/dns"
+)
+
+// DefaultPollInterval is how often WaitForPropagation queries the
+// nameservers.
+const DefaultPollInterval = 2 * time.Second
+
+// Resolver looks up the records WaitForPropagation needs.
+type Resolver interface {
+	// LookupNS returns the authoritative nameservers of the zone,
+	// as host:port.
+	LookupNS(ctx context.Context, zone string) ([]string, error)
+	// Lookup queries the nameserver directly, without recursion,
+	// for the records of the name and type. Values are returned in
+	// presentation format, except TXT values which are unquoted.
+	Lookup(ctx context.Context, nameserver, name, rtype string) ([]string, error)
+}
+
+type propagationOptions struct {
+	resolver Resolver
+	interval time.Duration
+	anyNS    bool
+}
+
+type PropagationOption func(opts *propagationOptions)
+
+// WithPollInterval sets how often the nameservers are queried.
+func WithPollInterval(interval time.Duration) PropagationOption {
+	return func(opts *propagationOptions) {
+		opts.interval = interval
+	}
+}
+
+// WithAnyNameserver waits only until one authoritative nameserver
+// returns the expected value, rather than all of them.
+func WithAnyNameserver() PropagationOption {
+	return func(opts *propagationOptions) {
+		opts.anyNS = true
+	}
+}
+
+// WithResolver replaces the resolver used to find and query the
+// nameservers, which by default uses DNS.
+func WithResolver(resolver Resolver) PropagationOption {
+	return func(opts *propagationOptions) {
+		opts.resolver = resolver
+	}
+}
+
+// WaitForPropagation waits until the authoritative nameservers of the
+// zone return the expected content among the records of the name and
+// type, or the context is done. The content is given as to
+// CreateOrUpdateDNSRecord. Nameservers are found from the zone's NS
+// records, and each is queried directly, so answers are not cached.
+func WaitForPropagation(ctx context.Context, zone, name, rtype, expectedContent string, ops ...PropagationOption) error {
+	opts := propagationOptions{
+		resolver: dnsResolver{},
+		interval: DefaultPollInterval,
+	}
+	for _, op := range ops {
+		op(&opts)
+	}
+	rtype = strings.ToUpper(rtype)
+	nameservers, err := opts.resolver.LookupNS(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("cannot look up nameservers of %s, %v", zone, err)
+	}
+	if len(nameservers) == 0 {
+		return fmt.Errorf("no nameservers found for %s", zone)
+	}
+	pending := nameservers
+	for {
+		waiting := []string{}
+		for _, ns := range pending {
+			values, err := opts.resolver.Lookup(ctx, ns, name, rtype)
+			if err != nil || !containsContent(rtype, values, expectedContent) {
+				waiting = append(waiting, ns)
+			}
+		}
+		if len(waiting) == 0 || opts.anyNS && len(waiting) < len(pending) {
+			return nil
+		}
+		// nameservers that have the record are not queried again
+		pending = waiting
+		timer := time.NewTimer(opts.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s record %s not propagated to %s, %w", rtype, name, strings.Join(pending, ", "), ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// containsContent reports whether the values include the expected
+// content. Host names are compared case-insensitively and with or
+// without a trailing dot.
+func containsContent(rtype string, values []string, expected string) bool {
+	if rtype == api.RecordTypeTXT {
+		expected = txtValue(expected)
+		for _, value := range values {
+			if value == expected {
+				return true
+			}
+		}
+		return false
+	}
+	normalize := func(content string) string {
+		fields := strings.Fields(strings.ToLower(content))
+		for ii := range fields {
+			fields[ii] = strings.TrimSuffix(fields[ii], ".")
+		}
+		return strings.Join(fields, " ")
+	}
+	expected = normalize(expected)
+	for _, value := range values {
+		if normalize(value) == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// dnsResolver finds nameservers with the system resolver and queries
+// them directly over DNS.
+type dnsResolver struct{}
+
+func (dnsResolver) LookupNS(ctx context.Context, zone string) ([]string, error) {
+	records, err := net.DefaultResolver.LookupNS(ctx, dns.Fqdn(zone))
+	if err != nil {
+		return nil, err
+	}
+	nameservers := []string{}
+	for _, ns := range records {
+		nameservers = append(nameservers, net.JoinHostPort(strings.TrimSuffix(ns.Host, "."), "53"))
+	}
+	return nameservers, nil
+}
+
+func (dnsResolver) Lookup(ctx context.Context, nameserver, name, rtype string) ([]string, error) {
+	qtype, ok := dns.StringToType[rtype]
+	if !ok {
+		return nil, fmt.Errorf("invalid record type %q", rtype)
+	}
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.RecursionDesired = false
+	client := dns.Client{}
+	resp, _, err := client.ExchangeContext(ctx, msg, nameserver)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("nameserver %s returned %s", nameserver, dns.RcodeToString[resp.Rcode])
+	}
+	values := []string{}
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype != qtype {
+			continue
+		}
+		if txt, ok := rr.(*dns.TXT); ok {
+			values = append(values, strings.Join(txt.Txt, ""))
+			continue
+		}
+		values = append(values, rrContent(rr))
+	}
+	return values, nil
+}
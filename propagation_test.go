@@ -0,0 +1,106 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// delayedResolver returns the values from each nameserver only once
+// its delay has passed since the resolver was created.
+type delayedResolver struct {
+	mu      sync.Mutex
+	start   time.Time
+	delays  map[string]time.Duration
+	values  []string
+	queries map[string]int
+}
+
+func newDelayedResolver(delays map[string]time.Duration, values ...string) *delayedResolver {
+	return &delayedResolver{
+		start:   time.Now(),
+		delays:  delays,
+		values:  values,
+		queries: map[string]int{},
+	}
+}
+
+func (s *delayedResolver) LookupNS(ctx context.Context, zone string) ([]string, error) {
+	nameservers := []string{}
+	for _, ns := range []string{"ns1.example.com:53", "ns2.example.com:53"} {
+		if _, ok := s.delays[ns]; ok {
+			nameservers = append(nameservers, ns)
+		}
+	}
+	return nameservers, nil
+}
+
+func (s *delayedResolver) Lookup(ctx context.Context, nameserver, name, rtype string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries[nameserver]++
+	if time.Since(s.start) < s.delays[nameserver] {
+		return nil, nil
+	}
+	return s.values, nil
+}
+
+func TestWaitForPropagation(t *testing.T) {
+	ctx := context.Background()
+	interval := WithPollInterval(5 * time.Millisecond)
+
+	// waits for all nameservers by default
+	resolver := newDelayedResolver(map[string]time.Duration{
+		"ns1.example.com:53": 0,
+		"ns2.example.com:53": 30 * time.Millisecond,
+	}, "10 mail.example.com.")
+	start := time.Now()
+	err := WaitForPropagation(ctx, "example.com", "example.com", "mx", "10 MAIL.example.com", WithResolver(resolver), interval)
+	require.Nil(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	require.Equal(t, 1, resolver.queries["ns1.example.com:53"])
+	require.Greater(t, resolver.queries["ns2.example.com:53"], 1)
+
+	// or only for the first one to answer
+	resolver = newDelayedResolver(map[string]time.Duration{
+		"ns1.example.com:53": time.Hour,
+		"ns2.example.com:53": 10 * time.Millisecond,
+	}, "v=spf1 -all")
+	err = WaitForPropagation(ctx, "example.com", "example.com", "TXT", `"v=spf1 -all"`, WithResolver(resolver), interval, WithAnyNameserver())
+	require.Nil(t, err)
+
+	// and gives up when the context is done
+	resolver = newDelayedResolver(map[string]time.Duration{
+		"ns1.example.com:53": 0,
+		"ns2.example.com:53": time.Hour,
+	}, "10.0.0.1")
+	tctx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	err = WaitForPropagation(tctx, "example.com", "www.example.com", "A", "10.0.0.1", WithResolver(resolver), interval)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	require.Contains(t, err.Error(), "A record www.example.com not propagated to ns2.example.com:53")
+
+	// a different value never matches
+	tctx, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err = WaitForPropagation(tctx, "example.com", "www.example.com", "A", "10.0.0.2", WithResolver(resolver), interval, WithAnyNameserver())
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
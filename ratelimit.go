@@ -0,0 +1,57 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// maxRateLimitBody limits how much of a rate limited response body is
+// kept in the error.
+const maxRateLimitBody = 4096
+
+// rateLimitTransport turns 429 Too Many Requests responses into an
+// *api.RateLimitError with the response's Retry-After. The backend
+// clients report rate limiting in different ways, and some drop the
+// headers, so it is detected before the response reaches them. The
+// clients pass transport errors through wrapped, so errors.As finds
+// it in the error of the provider operation.
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+func (s *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := s.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxRateLimitBody))
+	delay, _ := retryAfter(resp.Header.Get("Retry-After"))
+	return nil, &api.RateLimitError{
+		RetryAfter: delay,
+		Err: &httpError{
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       strings.TrimSpace(string(body)),
+		},
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/edgexr/dnsproviders/api"
+	golangsdk "github.com/opentelekomcloud/gophertelekomcloud"
+	"github.com/stretchr/testify/require"
+)
+
+// tooManyRequests rejects every request with 429 Too Many Requests,
+// with the Retry-After header if set.
+func tooManyRequests(retryAfter string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"message":"slow down"}`))
+	})
+}
+
+func TestRateLimitError(t *testing.T) {
+	ctx := context.Background()
+	newProviders := map[string]func(t *testing.T, client *http.Client) api.Provider{
+		"cloudflare": func(t *testing.T, client *http.Client) api.Provider {
+			// the provider's own retries are disabled to keep the
+			// test fast, they are not needed to find the error
+			opts := getOptions([]Option{WithHTTPClient(client)})
+			cfapi, err := cloudflare.NewWithAPIToken("test",
+				cloudflare.HTTPClient(opts.httpClient()),
+				cloudflare.UsingRateLimit(10000),
+				cloudflare.UsingRetryPolicy(0, 0, 0))
+			require.Nil(t, err)
+			return &CloudflareAPI{
+				api:    cfapi,
+				logger: slog.Default(),
+			}
+		},
+		"otc": func(t *testing.T, client *http.Client) api.Provider {
+			opts := getOptions([]Option{WithHTTPClient(client)})
+			provider := &golangsdk.ProviderClient{
+				HTTPClient: *opts.httpClient(),
+			}
+			return &OTC{
+				client: provider,
+				dns: &golangsdk.ServiceClient{
+					ProviderClient: provider,
+					Endpoint:       otcTestEndpoint,
+				},
+				logger: slog.Default(),
+			}
+		},
+		"hetzner": func(t *testing.T, client *http.Client) api.Provider {
+			prov, err := NewHetznerProvider(ctx, "", map[string]string{"token": "test"}, nil, WithHTTPClient(client))
+			require.Nil(t, err)
+			return prov
+		},
+	}
+	for name, newProvider := range newProviders {
+		t.Run(name, func(t *testing.T) {
+			prov := newProvider(t, newStubClient(t, tooManyRequests("30")))
+			_, err := prov.GetDNSRecords(ctx, "example.com", "")
+			rle := &api.RateLimitError{}
+			require.True(t, errors.As(err, &rle), "%v", err)
+			require.Equal(t, 30*time.Second, rle.RetryAfter)
+			require.Equal(t, http.StatusTooManyRequests, errorStatusCode(err))
+			require.Contains(t, err.Error(), "slow down")
+
+			prov = newProvider(t, newStubClient(t, tooManyRequests("")))
+			_, err = prov.GetDNSRecords(ctx, "example.com", "")
+			require.True(t, errors.As(err, &rle), "%v", err)
+			require.Equal(t, time.Duration(0), rle.RetryAfter)
+		})
+	}
+
+	// Google Cloud DNS looks up the project's zones when created
+	creds := map[string]string{
+		projectID: "test-project",
+	}
+	_, err := NewGoogleCloudDNSProvider(ctx, "", creds, slog.Default(), WithHTTPClient(newStubClient(t, tooManyRequests("30"))))
+	rle := &api.RateLimitError{}
+	require.True(t, errors.As(err, &rle), "%v", err)
+	require.Equal(t, 30*time.Second, rle.RetryAfter)
+	_, err = NewGoogleCloudDNSProvider(ctx, "", creds, slog.Default(), WithHTTPClient(newStubClient(t, tooManyRequests(""))))
+	require.True(t, errors.As(err, &rle), "%v", err)
+	require.Equal(t, time.Duration(0), rle.RetryAfter)
+}
+
+func TestRateLimitErrorAfterRetries(t *testing.T) {
+	ctx := context.Background()
+	var requests atomic.Int32
+	client := newStubClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		tooManyRequests("0").ServeHTTP(w, r)
+	}))
+	creds := map[string]string{"token": "test"}
+	prov, err := GetProvider(ctx, api.HetznerProvider, "example.com", creds, nil, WithHTTPClient(client), WithRetry(2, time.Millisecond))
+	require.Nil(t, err)
+	// the error is only returned once the retries are used up
+	_, err = prov.GetDNSRecords(ctx, "example.com", "")
+	rle := &api.RateLimitError{}
+	require.True(t, errors.As(err, &rle), "%v", err)
+	require.Equal(t, time.Duration(0), rle.RetryAfter)
+	require.Equal(t, int32(2), requests.Load())
+}
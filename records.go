@@ -0,0 +1,121 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// GetDNSRecord returns the one record of the name and type. It returns
+// an error wrapping api.ErrRecordNotFound if there is none, and one
+// wrapping api.ErrMultipleRecords if several match, which happens for
+// MX and SRV records of different priorities and for records with
+// routing variants.
+func GetDNSRecord(ctx context.Context, prov api.Provider, zone, name, rtype string) (api.Record, error) {
+	if name == "" {
+		return api.Record{}, fmt.Errorf("no name specified")
+	}
+	if rtype == "" {
+		return api.Record{}, fmt.Errorf("no record type specified")
+	}
+	records, err := prov.GetDNSRecords(ctx, zone, name)
+	if err != nil {
+		return api.Record{}, err
+	}
+	matches := []api.Record{}
+	for _, record := range records {
+		if strings.EqualFold(record.Type, rtype) {
+			matches = append(matches, record)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return api.Record{}, fmt.Errorf("%w: zone %s name %s type %s", api.ErrRecordNotFound, zone, name, rtype)
+	case 1:
+		return matches[0], nil
+	}
+	return api.Record{}, fmt.Errorf("%w: zone %s name %s type %s has %d records", api.ErrMultipleRecords, zone, name, rtype, len(matches))
+}
+
+// UpdateDNSRecordByID sets the content and TTL of the record with the
+// id, as read back in Record.ID, if the provider implements
+// api.RecordIDManager. Otherwise it returns an error wrapping
+// api.ErrUnsupported.
+func UpdateDNSRecordByID(ctx context.Context, prov api.Provider, zone, id, content string, ttl int) error {
+	manager, ok := prov.(api.RecordIDManager)
+	if !ok {
+		return fmt.Errorf("%w, cannot update DNS record %s for zone %s by id", api.ErrUnsupported, id, zone)
+	}
+	return manager.UpdateDNSRecordByID(ctx, zone, id, content, ttl)
+}
+
+// DeleteDNSRecordByID deletes the record with the id, as read back in
+// Record.ID, if the provider implements api.RecordIDManager.
+// Otherwise it returns an error wrapping api.ErrUnsupported.
+func DeleteDNSRecordByID(ctx context.Context, prov api.Provider, zone, id string) error {
+	manager, ok := prov.(api.RecordIDManager)
+	if !ok {
+		return fmt.Errorf("%w, cannot delete DNS record %s for zone %s by id", api.ErrUnsupported, id, zone)
+	}
+	return manager.DeleteDNSRecordByID(ctx, zone, id)
+}
+
+// CreateOrUpdateDNSRecordResult changes the existing record if found,
+// or adds a new one, and returns the record as GetDNSRecords reports
+// it. Providers that implement api.RecordResultWriter return the
+// record they stored. Otherwise the record is read back, picking the
+// MX or SRV record with the priority, weight and port of the content,
+// and an error wrapping api.ErrMultipleRecords is returned if it is
+// still ambiguous.
+func CreateOrUpdateDNSRecordResult(ctx context.Context, prov api.Provider, zone, name, rtype, content string, ttl int, proxy bool) (api.Record, error) {
+	if writer, ok := prov.(api.RecordResultWriter); ok {
+		return writer.CreateOrUpdateDNSRecordResult(ctx, zone, name, rtype, content, ttl, proxy)
+	}
+	if err := prov.CreateOrUpdateDNSRecord(ctx, zone, name, rtype, content, ttl, proxy); err != nil {
+		return api.Record{}, err
+	}
+	records, err := prov.GetDNSRecords(ctx, zone, name)
+	if err != nil {
+		return api.Record{}, err
+	}
+	want := api.Record{}
+	switch strings.ToUpper(rtype) {
+	case api.RecordTypeMX:
+		want.Priority, _, err = parseMXContent(content)
+	case api.RecordTypeSRV:
+		want.Priority, want.Weight, want.Port, _, err = parseSRVContent(content)
+	}
+	if err != nil {
+		return api.Record{}, err
+	}
+	matches := []api.Record{}
+	for _, record := range records {
+		if !strings.EqualFold(record.Type, rtype) || record.Priority != want.Priority || record.Weight != want.Weight || record.Port != want.Port {
+			continue
+		}
+		matches = append(matches, record)
+	}
+	switch len(matches) {
+	case 0:
+		return api.Record{}, fmt.Errorf("%w: zone %s name %s type %s not found after update", api.ErrRecordNotFound, zone, name, rtype)
+	case 1:
+		return matches[0], nil
+	}
+	return api.Record{}, fmt.Errorf("%w: zone %s name %s type %s has %d records", api.ErrMultipleRecords, zone, name, rtype, len(matches))
+}
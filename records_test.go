@@ -0,0 +1,99 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDNSRecord(t *testing.T) {
+	ctx := context.Background()
+	prov := NewMockProvider("example.com")
+	prov.Seed("example.com", api.Record{
+		Name:    "www.example.com",
+		Type:    api.RecordTypeA,
+		Content: []string{"10.0.0.1", "10.0.0.2"},
+		TTL:     300,
+	}, api.Record{
+		Name:    "www.example.com",
+		Type:    api.RecordTypeAAAA,
+		Content: []string{"fd00::1"},
+		TTL:     300,
+	})
+
+	// one
+	record, err := GetDNSRecord(ctx, prov, "example.com", "www.example.com", "a")
+	require.Nil(t, err)
+	require.Equal(t, api.RecordTypeA, record.Type)
+	require.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, record.Content)
+
+	// zero
+	_, err = GetDNSRecord(ctx, prov, "example.com", "www.example.com", "TXT")
+	require.ErrorIs(t, err, api.ErrRecordNotFound)
+	_, err = GetDNSRecord(ctx, prov, "example.com", "other.example.com", "A")
+	require.ErrorIs(t, err, api.ErrRecordNotFound)
+	_, err = GetDNSRecord(ctx, prov, "example.net", "www.example.net", "A")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+
+	// many; the mock keeps one record per name and type, so this is
+	// checked with MX records split by priority
+	stub := newGCDNSStub("example.com")
+	gcdns := newGCDNSTestProvider(t, stub)
+	err = gcdns.CreateOrUpdateDNSRecordSet(ctx, "example.com", "example.com", "MX", []string{"10 mail1.example.com", "20 mail2.example.com"}, 300, false)
+	require.Nil(t, err)
+	_, err = GetDNSRecord(ctx, gcdns, "example.com", "example.com", "MX")
+	require.ErrorIs(t, err, api.ErrMultipleRecords)
+	require.Contains(t, err.Error(), "has 2 records")
+}
+
+func TestCreateOrUpdateDNSRecordResult(t *testing.T) {
+	ctx := context.Background()
+
+	// the mock is read back after the update
+	mock := NewMockProvider("example.com")
+	record, err := CreateOrUpdateDNSRecordResult(ctx, mock, "example.com", "www.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, api.Record{
+		Name:    "www.example.com",
+		Type:    api.RecordTypeA,
+		Content: []string{"10.0.0.1"},
+		TTL:     300,
+		ID:      "www.example.com/A",
+	}, record)
+	record, err = CreateOrUpdateDNSRecordResult(ctx, mock, "example.com", "example.com", "mx", "10 mail.example.com", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, api.RecordTypeMX, record.Type)
+	require.Equal(t, 10, record.Priority)
+	_, err = CreateOrUpdateDNSRecordResult(ctx, mock, "example.net", "www.example.net", "A", "10.0.0.1", 300, false)
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+
+	// DigitalOcean returns the record it stored, with its id
+	stub := newDOStub()
+	client := newStubClient(t, stub.handler())
+	prov, err := GetProvider(ctx, api.DigitalOceanProvider, "", map[string]string{"token": "test"}, nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	for _, content := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.2"} {
+		record, err = CreateOrUpdateDNSRecordResult(ctx, prov, "example.com", "www.example.com", "A", content, 300, false)
+		require.Nil(t, err)
+		records, err := prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+		require.Nil(t, err)
+		require.Equal(t, []api.Record{record}, records)
+		require.NotEmpty(t, record.ID)
+	}
+}
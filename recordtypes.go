@@ -0,0 +1,133 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+var (
+	// presentationRecordTypes are supported by backends that take the
+	// record content in zone file presentation format, for example
+	// "10 mail.example.com." for MX.
+	presentationRecordTypes = []string{
+		api.RecordTypeA,
+		api.RecordTypeAAAA,
+		"CAA",
+		api.RecordTypeCNAME,
+		"DS",
+		api.RecordTypeMX,
+		api.RecordTypeNS,
+		api.RecordTypePTR,
+		api.RecordTypeSRV,
+		"SSHFP",
+		"TLSA",
+		api.RecordTypeTXT,
+	}
+	// contentOnlyRecordTypes need nothing beyond the record content.
+	// Backends that model other record data, such as the MX priority,
+	// as separate fields add those types individually.
+	contentOnlyRecordTypes = []string{
+		api.RecordTypeA,
+		api.RecordTypeAAAA,
+		api.RecordTypeCNAME,
+		api.RecordTypeNS,
+		api.RecordTypeTXT,
+	}
+	cloudflareRecordTypes   = append(slices.Clone(contentOnlyRecordTypes), api.RecordTypeMX, api.RecordTypePTR, api.RecordTypeSRV)
+	digitalOceanRecordTypes = append(slices.Clone(contentOnlyRecordTypes), api.RecordTypeMX, api.RecordTypeSRV)
+	otcRecordTypes          = []string{
+		api.RecordTypeA,
+		api.RecordTypeAAAA,
+		"CAA",
+		api.RecordTypeCNAME,
+		api.RecordTypeMX,
+		api.RecordTypeNS,
+		api.RecordTypePTR,
+		api.RecordTypeSRV,
+		api.RecordTypeTXT,
+	}
+	hetznerRecordTypes = []string{
+		api.RecordTypeA,
+		api.RecordTypeAAAA,
+		"CAA",
+		api.RecordTypeCNAME,
+		"DS",
+		api.RecordTypeMX,
+		api.RecordTypeNS,
+		api.RecordTypePTR,
+		api.RecordTypeSRV,
+		"TLSA",
+		api.RecordTypeTXT,
+	}
+	ns1RecordTypes      = append(slices.Clone(contentOnlyRecordTypes), api.RecordTypeMX, api.RecordTypePTR, api.RecordTypeSRV)
+	linodeRecordTypes   = append(slices.Clone(contentOnlyRecordTypes), api.RecordTypeMX, api.RecordTypePTR)
+	vultrRecordTypes    = append(slices.Clone(contentOnlyRecordTypes), api.RecordTypeMX, api.RecordTypeSRV)
+	scalewayRecordTypes = append(slices.Clone(contentOnlyRecordTypes), api.RecordTypeMX, api.RecordTypePTR)
+	// ovhRecordTypes are presentationRecordTypes other than DS, which
+	// OVH derives from the DNSSEC keys of the zone
+	ovhRecordTypes = slices.DeleteFunc(slices.Clone(presentationRecordTypes), func(rtype string) bool {
+		return rtype == "DS"
+	})
+	bunnyRecordTypes     = append(slices.Clone(contentOnlyRecordTypes), api.RecordTypeMX, api.RecordTypePTR, api.RecordTypeSRV)
+	namecheapRecordTypes = append(slices.Clone(contentOnlyRecordTypes), "CAA", api.RecordTypeMX)
+	dnsimpleRecordTypes  = append(slices.Clone(contentOnlyRecordTypes), api.RecordTypeALIAS, "CAA", api.RecordTypeMX, api.RecordTypePTR, api.RecordTypeSRV)
+	porkbunRecordTypes   = append(slices.Clone(contentOnlyRecordTypes), api.RecordTypeALIAS, "CAA", api.RecordTypeMX, api.RecordTypeSRV)
+)
+
+// checkProxy returns an error wrapping api.ErrProxyNotSupported if
+// proxying is requested from a provider that cannot proxy.
+func checkProxy(typ api.ProviderType, proxy bool) error {
+	if proxy {
+		return fmt.Errorf("%w by %s dns provider", api.ErrProxyNotSupported, typ)
+	}
+	return nil
+}
+
+// checkDelegation returns an error wrapping api.ErrUnsupported for
+// NS records at the zone apex, for backends that manage those
+// themselves. NS records can still be set on subnames to delegate
+// them.
+func checkDelegation(zone, name, rtype string) error {
+	if strings.EqualFold(rtype, api.RecordTypeNS) && isSystemRecord(zone, name, api.RecordTypeNS) {
+		return fmt.Errorf("%w: NS records at the apex of %s are managed by the provider, only subnames can be delegated", api.ErrUnsupported, strings.TrimSuffix(zone, "."))
+	}
+	return nil
+}
+
+// validateContent checks the values and TTL of a write with
+// api.ValidateRecord, so malformed content fails before any backend
+// call rather than with a backend error.
+func validateContent(name, rtype string, ttl int, contents ...string) error {
+	return api.ValidateRecord(api.Record{Name: name, Type: rtype, Content: contents, TTL: ttl})
+}
+
+// checkRecord returns an error wrapping api.ErrRecordTypeUnsupported
+// if rtype is not one of the supported types, or an error if the name
+// is not valid for the type. Providers call this before making any
+// backend calls.
+func checkRecord(name, rtype string, supported []string) error {
+	if !slices.Contains(supported, strings.ToUpper(rtype)) {
+		return fmt.Errorf("%w: %s", api.ErrRecordTypeUnsupported, rtype)
+	}
+	if strings.EqualFold(rtype, api.RecordTypeSRV) {
+		return checkSRVName(name)
+	}
+	return nil
+}
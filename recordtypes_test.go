@@ -0,0 +1,143 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportedRecordTypes(t *testing.T) {
+	ctx := context.Background()
+
+	// Zero value providers have no backend clients, so any backend
+	// call would panic rather than return the expected error.
+	tests := []struct {
+		name        string
+		prov        api.Provider
+		supported   []string
+		unsupported string
+	}{{
+		name:        "cloudflare",
+		prov:        &CloudflareAPI{},
+		supported:   []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT", "PTR", "SRV"},
+		unsupported: "CAA",
+	}, {
+		name:        "googleclouddns",
+		prov:        &CloudDNS{},
+		supported:   presentationRecordTypes,
+		unsupported: "HINFO",
+	}, {
+		name:        "otc",
+		prov:        OTC{},
+		supported:   []string{"A", "AAAA", "CAA", "CNAME", "MX", "NS", "PTR", "SRV", "TXT"},
+		unsupported: "TLSA",
+	}, {
+		name:        "digitalocean",
+		prov:        &DigitalOcean{},
+		supported:   []string{"A", "AAAA", "CNAME", "MX", "NS", "SRV", "TXT"},
+		unsupported: "CAA",
+	}, {
+		name:        "hetzner",
+		prov:        &Hetzner{},
+		supported:   []string{"A", "AAAA", "CAA", "CNAME", "DS", "MX", "NS", "PTR", "SRV", "TLSA", "TXT"},
+		unsupported: "SSHFP",
+	}, {
+		name:        "rfc2136",
+		prov:        &RFC2136{},
+		supported:   []string{"A", "AAAA", "CAA", "CNAME", "DS", "MX", "NS", "PTR", "SRV", "SSHFP", "TLSA", "TXT"},
+		unsupported: "HINFO",
+	}, {
+		name:        "powerdns",
+		prov:        &PowerDNS{},
+		supported:   presentationRecordTypes,
+		unsupported: "HINFO",
+	}, {
+		name:        "gandi",
+		prov:        &Gandi{},
+		supported:   presentationRecordTypes,
+		unsupported: "HINFO",
+	}, {
+		name:        "ns1",
+		prov:        &NS1{},
+		supported:   []string{"A", "AAAA", "CNAME", "MX", "NS", "PTR", "SRV", "TXT"},
+		unsupported: "CAA",
+	}, {
+		name:        "linode",
+		prov:        &Linode{},
+		supported:   []string{"A", "AAAA", "CNAME", "MX", "NS", "PTR", "TXT"},
+		unsupported: "SRV",
+	}, {
+		name:        "desec",
+		prov:        &DeSEC{},
+		supported:   presentationRecordTypes,
+		unsupported: "HINFO",
+	}, {
+		name:        "vultr",
+		prov:        &Vultr{},
+		supported:   []string{"A", "AAAA", "CNAME", "MX", "NS", "SRV", "TXT"},
+		unsupported: "CAA",
+	}, {
+		name:        "scaleway",
+		prov:        &Scaleway{},
+		supported:   []string{"A", "AAAA", "CNAME", "MX", "NS", "PTR", "TXT"},
+		unsupported: "SRV",
+	}, {
+		name:        "ovh",
+		prov:        &OVH{},
+		supported:   []string{"A", "AAAA", "CAA", "CNAME", "MX", "NS", "PTR", "SRV", "SSHFP", "TLSA", "TXT"},
+		unsupported: "DS",
+	}, {
+		name:        "bunny",
+		prov:        &Bunny{},
+		supported:   []string{"A", "AAAA", "CNAME", "NS", "TXT", "MX", "PTR", "SRV"},
+		unsupported: "CAA",
+	}, {
+		name:        "namecheap",
+		prov:        &Namecheap{},
+		supported:   []string{"A", "AAAA", "CNAME", "NS", "TXT", "CAA", "MX"},
+		unsupported: "SRV",
+	}, {
+		name:        "dnsimple",
+		prov:        &DNSimple{},
+		supported:   []string{"A", "AAAA", "CNAME", "NS", "TXT", "ALIAS", "CAA", "MX", "PTR", "SRV"},
+		unsupported: "SSHFP",
+	}, {
+		name:        "porkbun",
+		prov:        &Porkbun{},
+		supported:   []string{"A", "AAAA", "CNAME", "NS", "TXT", "ALIAS", "CAA", "MX", "SRV"},
+		unsupported: "PTR",
+	}, {
+		name:        "mock",
+		prov:        NewMockProvider(),
+		supported:   presentationRecordTypes,
+		unsupported: "HINFO",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lister, ok := test.prov.(api.RecordTypeLister)
+			require.True(t, ok)
+			require.ElementsMatch(t, test.supported, lister.SupportedRecordTypes())
+
+			err := test.prov.CreateOrUpdateDNSRecord(ctx, "example.com", "x.example.com", test.unsupported, "content", 300, false)
+			require.True(t, errors.Is(err, api.ErrRecordTypeUnsupported))
+			require.Contains(t, err.Error(), test.unsupported)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRetryDelay caps the delay between attempts, including delays
+// requested by Retry-After.
+const maxRetryDelay = time.Minute
+
+// retryTransport retries requests that failed transiently, as
+// configured by WithRetry.
+type retryTransport struct {
+	base        http.RoundTripper
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+func (s *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := s.base.RoundTrip(req)
+		if err != nil || attempt >= s.maxAttempts || !retryable(req, resp) {
+			return resp, err
+		}
+		// the request body has been consumed, so it must be
+		// replayable to try again
+		next := req
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+			next = req.Clone(ctx)
+			next.Body = body
+		}
+		delay := s.delay(attempt, resp)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		req = next
+	}
+}
+
+// retryable reports whether the request may be tried again. Rate
+// limited requests were not processed, so are always retried, but
+// server errors only for idempotent methods, since the change may
+// have been applied.
+func retryable(req *http.Request, resp *http.Response) bool {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns how long to wait after the given attempt, preferring
+// the response's Retry-After.
+func (s *retryTransport) delay(attempt int, resp *http.Response) time.Duration {
+	if delay, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+		return min(delay, maxRetryDelay)
+	}
+	delay := s.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	// wait between half and all of the backoff, so that clients
+	// that failed together do not retry together
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int64N(half+1))
+}
+
+// retryAfter parses a Retry-After value given either in seconds or as
+// an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
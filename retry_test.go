@@ -0,0 +1,164 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// failingTransport fails the first requests with the given status,
+// then succeeds, recording the bodies it received.
+type failingTransport struct {
+	failures   int
+	status     int
+	retryAfter string
+	calls      int
+	bodies     []string
+}
+
+func (s *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.calls++
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		s.bodies = append(s.bodies, string(body))
+	}
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("ok")),
+	}
+	if s.calls <= s.failures {
+		resp.StatusCode = s.status
+		if s.retryAfter != "" {
+			resp.Header.Set("Retry-After", s.retryAfter)
+		}
+	}
+	return resp, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	ctx := context.Background()
+	newRequest := func(ctx context.Context, method, body string) *http.Request {
+		req, err := http.NewRequestWithContext(ctx, method, "https://dns.example.com/zones", nil)
+		require.Nil(t, err)
+		if body != "" {
+			req, err = http.NewRequestWithContext(ctx, method, "https://dns.example.com/zones", bytes.NewBufferString(body))
+			require.Nil(t, err)
+		}
+		return req
+	}
+
+	// rate limited twice, then succeeds
+	base := &failingTransport{failures: 2, status: http.StatusTooManyRequests}
+	rt := &retryTransport{base: base, maxAttempts: 3, baseDelay: time.Millisecond}
+	resp, err := rt.RoundTrip(newRequest(ctx, http.MethodGet, ""))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 3, base.calls)
+
+	// gives up after the maximum attempts
+	base = &failingTransport{failures: 5, status: http.StatusTooManyRequests}
+	rt = &retryTransport{base: base, maxAttempts: 3, baseDelay: time.Millisecond}
+	resp, err = rt.RoundTrip(newRequest(ctx, http.MethodGet, ""))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, 3, base.calls)
+
+	// rate limited creates are retried with the same body
+	base = &failingTransport{failures: 2, status: http.StatusTooManyRequests, retryAfter: "0"}
+	rt = &retryTransport{base: base, maxAttempts: 3, baseDelay: time.Hour}
+	resp, err = rt.RoundTrip(newRequest(ctx, http.MethodPost, `{"name":"www"}`))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []string{`{"name":"www"}`, `{"name":"www"}`, `{"name":"www"}`}, base.bodies)
+
+	// server errors are only retried for idempotent methods
+	base = &failingTransport{failures: 1, status: http.StatusServiceUnavailable}
+	rt = &retryTransport{base: base, maxAttempts: 3, baseDelay: time.Millisecond}
+	resp, err = rt.RoundTrip(newRequest(ctx, http.MethodPost, `{}`))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, 1, base.calls)
+	resp, err = rt.RoundTrip(newRequest(ctx, http.MethodDelete, ""))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, base.calls)
+
+	// waiting stops when the context is done
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	base = &failingTransport{failures: 1, status: http.StatusTooManyRequests, retryAfter: "30"}
+	rt = &retryTransport{base: base, maxAttempts: 3, baseDelay: time.Millisecond}
+	start := time.Now()
+	_, err = rt.RoundTrip(newRequest(ctx, http.MethodGet, ""))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Equal(t, 1, base.calls)
+}
+
+func TestRetryAfter(t *testing.T) {
+	delay, ok := retryAfter("2")
+	require.True(t, ok)
+	require.Equal(t, 2*time.Second, delay)
+	delay, ok = retryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), delay)
+	_, ok = retryAfter("")
+	require.False(t, ok)
+	_, ok = retryAfter("soon")
+	require.False(t, ok)
+
+	rt := &retryTransport{baseDelay: 100 * time.Millisecond}
+	resp := &http.Response{Header: http.Header{}}
+	for attempt := 1; attempt <= 3; attempt++ {
+		backoff := rt.baseDelay << (attempt - 1)
+		delay := rt.delay(attempt, resp)
+		require.GreaterOrEqual(t, delay, backoff/2)
+		require.LessOrEqual(t, delay, backoff)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+	stub := &hetznerStub{
+		zones: []hetznerZone{{ID: "z1", Name: "example.com"}},
+	}
+	var requests atomic.Int32
+	client := newStubClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		stub.handler().ServeHTTP(w, r)
+	}))
+	creds := map[string]string{"token": "test"}
+	prov, err := GetProvider(ctx, api.HetznerProvider, "example.com", creds, nil, WithHTTPClient(client), WithRetry(3, time.Millisecond))
+	require.Nil(t, err)
+	_, err = prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	// two rate limited attempts, the zone lookup and the record listing
+	require.Equal(t, int32(4), requests.Load())
+}
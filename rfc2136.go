@@ -0,0 +1,344 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/miekg/dns"
+)
+
+const (
+	CredentialKeyNameserver    = "nameserver"
+	CredentialKeyTSIGKeyName   = "tsigKeyName"
+	CredentialKeyTSIGSecret    = "tsigSecret"
+	CredentialKeyTSIGAlgorithm = "tsigAlgorithm"
+	CredentialKeyTransport     = "transport"
+	rfc2136DefaultPort         = "53"
+	rfc2136TsigFudge           = 300
+)
+
+// RFC2136 manages DNS records on a nameserver such as BIND or Knot
+// using RFC 2136 dynamic updates, optionally signed with TSIG.
+// Records are read back via zone transfer (AXFR), which is always
+// done over TCP regardless of the configured transport.
+type RFC2136 struct {
+	nameserver    string
+	transport     string
+	tsigKeyName   string
+	tsigSecret    string
+	tsigAlgorithm string
+	logger        api.Logger
+}
+
+// NewRFC2136Provider creates a new RFC 2136 dynamic update provider.
+// The nameserver is required, the TSIG key is optional, and the
+// transport may be "tcp" (the default) or "udp".
+func NewRFC2136Provider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*RFC2136, error) {
+	return NewRFC2136ProviderWithCredentials(ctx, zone, rfc2136CredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewRFC2136ProviderWithCredentials creates a new RFC 2136 dynamic
+// update provider from typed credentials.
+func NewRFC2136ProviderWithCredentials(ctx context.Context, zone string, creds RFC2136Credentials, logger api.Logger, ops ...Option) (*RFC2136, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	nameserver := creds.Nameserver
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		nameserver = net.JoinHostPort(nameserver, rfc2136DefaultPort)
+	}
+	transport := strings.ToLower(creds.Transport)
+	switch transport {
+	case "":
+		transport = "tcp"
+	case "tcp", "udp":
+	default:
+		return nil, fmt.Errorf("invalid rfc2136 transport %q, must be tcp or udp", transport)
+	}
+	s := &RFC2136{
+		nameserver: nameserver,
+		transport:  transport,
+		logger:     logger,
+	}
+	if creds.TSIGKeyName != "" {
+		s.tsigKeyName = dns.Fqdn(creds.TSIGKeyName)
+		s.tsigSecret = creds.TSIGSecret
+		s.tsigAlgorithm = dns.HmacSHA256
+		if alg := creds.TSIGAlgorithm; alg != "" {
+			s.tsigAlgorithm = dns.Fqdn(strings.ToLower(alg))
+		}
+	}
+	return s, nil
+}
+
+func (s *RFC2136) tsigSecrets() map[string]string {
+	if s.tsigKeyName == "" {
+		return nil
+	}
+	return map[string]string{s.tsigKeyName: s.tsigSecret}
+}
+
+func (s *RFC2136) sign(msg *dns.Msg) {
+	if s.tsigKeyName != "" {
+		msg.SetTsig(s.tsigKeyName, s.tsigAlgorithm, rfc2136TsigFudge, time.Now().Unix())
+	}
+}
+
+// rcodeError converts an unsuccessful response code into an error.
+func rcodeError(rcode int) error {
+	switch rcode {
+	case dns.RcodeSuccess:
+		return nil
+	case dns.RcodeServerFailure:
+		return fmt.Errorf("nameserver failed to process the request (SERVFAIL)")
+	case dns.RcodeNotAuth:
+		return fmt.Errorf("nameserver is not authoritative for the zone or rejected the TSIG key (NOTAUTH)")
+	case dns.RcodeRefused:
+		return fmt.Errorf("nameserver refused the request (REFUSED)")
+	case dns.RcodeNotZone:
+		return fmt.Errorf("%w on the nameserver (NOTZONE)", api.ErrZoneNotFound)
+	}
+	return fmt.Errorf("nameserver returned %s", dns.RcodeToString[rcode])
+}
+
+func (s *RFC2136) update(ctx context.Context, msg *dns.Msg) error {
+	s.sign(msg)
+	client := dns.Client{
+		Net:        s.transport,
+		TsigSecret: s.tsigSecrets(),
+	}
+	resp, _, err := client.ExchangeContext(ctx, msg, s.nameserver)
+	// error responses may not be signed, so report the rcode
+	// rather than the TSIG verification failure
+	if resp != nil && resp.Rcode != dns.RcodeSuccess {
+		return rcodeError(resp.Rcode)
+	}
+	return err
+}
+
+// transfer returns all records in the zone via AXFR.
+func (s *RFC2136) transfer(ctx context.Context, zone string) ([]dns.RR, error) {
+	msg := new(dns.Msg)
+	msg.SetAxfr(dns.Fqdn(zone))
+	s.sign(msg)
+	transfer := dns.Transfer{
+		TsigSecret: s.tsigSecrets(),
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline)
+		transfer.DialTimeout = timeout
+		transfer.ReadTimeout = timeout
+	}
+	envelopes, err := transfer.In(msg, s.nameserver)
+	if err != nil {
+		return nil, fmt.Errorf("zone transfer of %s failed, %v", zone, err)
+	}
+	rrs := []dns.RR{}
+	for env := range envelopes {
+		if env.Error != nil {
+			return nil, fmt.Errorf("zone transfer of %s failed, %v", zone, env.Error)
+		}
+		rrs = append(rrs, env.RR...)
+	}
+	// a transfer begins and ends with the SOA record
+	if len(rrs) > 1 && rrs[len(rrs)-1].Header().Rrtype == dns.TypeSOA {
+		rrs = rrs[:len(rrs)-1]
+	}
+	return rrs, nil
+}
+
+// rrContent returns the presentation format of the record data.
+func rrContent(rr dns.RR) string {
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
+
+// GetDNSRecords returns a list of DNS records for the zone, grouping
+// records of the same name and type. If name is provided, that is
+// used as a filter.
+func (s *RFC2136) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	rrs, err := s.transfer(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	grouped := []api.Record{}
+	index := map[string]int{}
+	for _, rr := range rrs {
+		hdr := rr.Header()
+		rrName := strings.TrimSuffix(hdr.Name, ".")
+		if name != "" && !strings.EqualFold(strings.TrimSuffix(name, "."), rrName) {
+			continue
+		}
+		rtype := dns.TypeToString[hdr.Rrtype]
+		key := strings.ToLower(rrName) + "/" + rtype
+		if ii, ok := index[key]; ok {
+			grouped[ii].Content = append(grouped[ii].Content, rrContent(rr))
+			continue
+		}
+		index[key] = len(grouped)
+		grouped = append(grouped, api.Record{
+			Type:    rtype,
+			Name:    rrName,
+			Content: []string{rrContent(rr)},
+			TTL:     int(hdr.Ttl),
+			System:  isSystemRecord(zone, rrName, rtype),
+		})
+	}
+	records := []api.Record{}
+	for _, record := range grouped {
+		records = append(records, fromPresentation(record)...)
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *RFC2136) SupportedRecordTypes() []string {
+	return slices.Clone(presentationRecordTypes)
+}
+
+// Capabilities reports only the record types, since zones must be
+// configured on the nameserver itself.
+func (s *RFC2136) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes: s.SupportedRecordTypes(),
+	}
+}
+
+// CreateOrUpdateDNSRecord replaces any existing records of the same
+// name and type with the new record in a single dynamic update.
+func (s *RFC2136) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.RFC2136Provider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, presentationRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	rrdata, err := toPresentation(rtype, content)
+	if err != nil {
+		return err
+	}
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(name), ttl, strings.ToUpper(rtype), rrdata))
+	if err != nil {
+		return fmt.Errorf("invalid %s record for %s, %v", rtype, name, err)
+	}
+	msg := new(dns.Msg)
+	msg.SetUpdate(dns.Fqdn(zone))
+	msg.RemoveRRset([]dns.RR{rr})
+	msg.Insert([]dns.RR{rr})
+	s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+	if err := s.update(ctx, msg); err != nil {
+		return fmt.Errorf("cannot update DNS record for zone %s name %s, %w", zone, name, err)
+	}
+	return nil
+}
+
+// Close is a no-op, since each update and transfer uses its own
+// connection.
+func (s *RFC2136) Close() error {
+	return nil
+}
+
+// ListZones is not supported, since RFC 2136 has no way to enumerate
+// the zones of a nameserver.
+func (s *RFC2136) ListZones(ctx context.Context) ([]api.Zone, error) {
+	return nil, fmt.Errorf("%w, rfc2136 nameservers cannot list zones", api.ErrUnsupported)
+}
+
+// CreateZone is not supported, since zones must be configured on the
+// nameserver itself.
+func (s *RFC2136) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	return api.Zone{}, fmt.Errorf("%w, rfc2136 nameservers cannot create zones", api.ErrUnsupported)
+}
+
+// DeleteZone is not supported, since zones must be configured on the
+// nameserver itself.
+func (s *RFC2136) DeleteZone(ctx context.Context, zone string) error {
+	return fmt.Errorf("%w, rfc2136 nameservers cannot delete zones", api.ErrUnsupported)
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *RFC2136) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	if name == "" {
+		return fmt.Errorf("no name specified to delete")
+	}
+	msg := new(dns.Msg)
+	msg.SetUpdate(dns.Fqdn(zone))
+	msg.RemoveName([]dns.RR{&dns.ANY{
+		Hdr: dns.RR_Header{
+			Name: dns.Fqdn(name),
+		},
+	}})
+	if err := s.update(ctx, msg); err != nil {
+		return fmt.Errorf("cannot delete DNS records for zone %s name %s, %w", zone, name, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted. Dynamic updates do not report what they
+// removed, so the records are counted from a zone transfer first.
+func (s *RFC2136) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	rrs, err := s.transfer(ctx, zone)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, rr := range rrs {
+		if strings.EqualFold(rr.Header().Name, dns.Fqdn(name)) {
+			count++
+		}
+	}
+	if err := s.DeleteDNSRecord(ctx, zone, name); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteDNSRecordByType deletes only the rrset of the given type for
+// the name.
+func (s *RFC2136) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if name == "" {
+		return fmt.Errorf("no name specified to delete")
+	}
+	rrtype, ok := dns.StringToType[strings.ToUpper(rtype)]
+	if !ok {
+		return fmt.Errorf("invalid record type %q to delete", rtype)
+	}
+	msg := new(dns.Msg)
+	msg.SetUpdate(dns.Fqdn(zone))
+	msg.RemoveRRset([]dns.RR{&dns.ANY{
+		Hdr: dns.RR_Header{
+			Name:   dns.Fqdn(name),
+			Rrtype: rrtype,
+		},
+	}})
+	if err := s.update(ctx, msg); err != nil {
+		return fmt.Errorf("cannot delete %s DNS records for zone %s name %s, %w", rtype, zone, name, err)
+	}
+	return nil
+}
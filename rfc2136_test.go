@@ -0,0 +1,205 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTsigKey    = "test-key."
+	testTsigSecret = "c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0"
+)
+
+// rfc2136Stub is a minimal authoritative server supporting signed
+// dynamic updates and zone transfers for a single zone.
+type rfc2136Stub struct {
+	mu    sync.Mutex
+	zone  string
+	rrs   []dns.RR
+	rcode int // if set, all updates fail with this rcode
+}
+
+func (s *rfc2136Stub) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	if tsig := req.IsTsig(); tsig != nil {
+		if w.TsigStatus() != nil {
+			resp.SetRcode(req, dns.RcodeNotAuth)
+			w.WriteMsg(resp)
+			return
+		}
+		resp.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+	} else {
+		resp.SetRcode(req, dns.RcodeNotAuth)
+		w.WriteMsg(resp)
+		return
+	}
+
+	switch {
+	case req.Opcode == dns.OpcodeUpdate:
+		if s.rcode != dns.RcodeSuccess {
+			resp.SetRcode(req, s.rcode)
+			break
+		}
+		for _, rr := range req.Ns {
+			hdr := rr.Header()
+			switch hdr.Class {
+			case dns.ClassANY:
+				s.remove(hdr.Name, hdr.Rrtype)
+			case dns.ClassINET:
+				s.rrs = append(s.rrs, rr)
+			}
+		}
+	case len(req.Question) == 1 && req.Question[0].Qtype == dns.TypeAXFR:
+		soa, _ := dns.NewRR(s.zone + " 3600 IN SOA ns.example.com. admin.example.com. 1 3600 600 86400 300")
+		resp.Answer = append([]dns.RR{soa}, s.rrs...)
+		resp.Answer = append(resp.Answer, soa)
+	default:
+		resp.SetRcode(req, dns.RcodeNotImplemented)
+	}
+	w.WriteMsg(resp)
+}
+
+// remove deletes the records with the name and type, or all records
+// for the name if rrtype is ANY.
+func (s *rfc2136Stub) remove(name string, rrtype uint16) {
+	kept := []dns.RR{}
+	for _, rr := range s.rrs {
+		hdr := rr.Header()
+		if strings.EqualFold(hdr.Name, name) && (rrtype == dns.TypeANY || rrtype == hdr.Rrtype) {
+			continue
+		}
+		kept = append(kept, rr)
+	}
+	s.rrs = kept
+}
+
+func startRFC2136Stub(t *testing.T, stub *rfc2136Stub) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	server := &dns.Server{
+		Listener:   listener,
+		Handler:    stub,
+		TsigSecret: map[string]string{testTsigKey: testTsigSecret},
+		// the default rejects update messages
+		MsgAcceptFunc: func(dh dns.Header) dns.MsgAcceptAction {
+			return dns.MsgAccept
+		},
+	}
+	started := make(chan struct{})
+	server.NotifyStartedFunc = func() { close(started) }
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+	return listener.Addr().String()
+}
+
+func TestRFC2136Stub(t *testing.T) {
+	ctx := context.Background()
+	stub := &rfc2136Stub{zone: "example.com."}
+	addr := startRFC2136Stub(t, stub)
+
+	creds := map[string]string{
+		CredentialKeyNameserver:  addr,
+		CredentialKeyTSIGKeyName: testTsigKey,
+		CredentialKeyTSIGSecret:  testTsigSecret,
+	}
+	prov, err := GetProvider(ctx, api.RFC2136Provider, "", creds, nil)
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, "example.com")
+
+	// records of the same name and type are grouped
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "multi.example.com", "A", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "multi.example.com", "AAAA", "fd00::1", 300, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "multi.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+
+	// long TXT values round trip through the wire format
+	long := "v=spf1 " + strings.Repeat("include:_spf.example.com ", 15) + "~all"
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "txt.example.com", "TXT", long, 300, false)
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "txt.example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, []string{long}, records[0].Content)
+
+	// the apex SOA from the transfer is flagged as a system record
+	records, err = prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "SOA", records[0].Type)
+	require.True(t, records[0].System)
+
+	// bad TSIG secret is rejected
+	creds[CredentialKeyTSIGSecret] = "d3JvbmdzZWNyZXQ="
+	badProv, err := GetProvider(ctx, api.RFC2136Provider, "", creds, nil)
+	require.Nil(t, err)
+	err = badProv.CreateOrUpdateDNSRecord(ctx, "example.com", "bad.example.com", "A", "10.0.0.1", 300, false)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "NOTAUTH")
+
+	// server failures are reported
+	creds[CredentialKeyTSIGSecret] = testTsigSecret
+	stub.rcode = dns.RcodeServerFailure
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "fail.example.com", "A", "10.0.0.1", 300, false)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "SERVFAIL")
+	stub.rcode = dns.RcodeNotAuth
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "fail.example.com", "A", "10.0.0.1", 300, false)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "NOTAUTH")
+	stub.rcode = dns.RcodeNotZone
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "fail.example.com", "A", "10.0.0.1", 300, false)
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+}
+
+func TestRFC2136Credentials(t *testing.T) {
+	ctx := context.Background()
+	_, err := GetProvider(ctx, api.RFC2136Provider, "", map[string]string{}, nil)
+	require.NotNil(t, err)
+
+	prov, err := NewRFC2136Provider(ctx, "", map[string]string{
+		CredentialKeyNameserver: "ns.example.com",
+		CredentialKeyTransport:  "UDP",
+	}, nil)
+	require.Nil(t, err)
+	require.Equal(t, "ns.example.com:53", prov.nameserver)
+	require.Equal(t, "udp", prov.transport)
+
+	_, err = NewRFC2136Provider(ctx, "", map[string]string{
+		CredentialKeyNameserver: "ns.example.com",
+		CredentialKeyTransport:  "quic",
+	}, nil)
+	require.NotNil(t, err)
+
+	_, err = prov.CreateZone(ctx, "example.com")
+	require.True(t, errors.Is(err, api.ErrUnsupported))
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// txtChunkSize is the maximum length of a single TXT character-string.
+const txtChunkSize = 255
+
+// toPresentation converts content as passed to CreateOrUpdateDNSRecord
+// into zone file presentation format, for backends that store record
+// data that way.
+func toPresentation(rtype, content string) (string, error) {
+	switch strings.ToUpper(rtype) {
+	case api.RecordTypeMX:
+		return mxRRData(content)
+	case api.RecordTypeSRV:
+		return srvRRData(content)
+	case api.RecordTypeTXT:
+		return txtRRData(content), nil
+	case api.RecordTypeCNAME, api.RecordTypeNS, api.RecordTypePTR:
+		return fqdnTarget(content), nil
+	}
+	return content, nil
+}
+
+// hasHostTarget reports whether the content of records of the type
+// is, or after splitting by priority ends with, a host name.
+func hasHostTarget(rtype string) bool {
+	switch rtype {
+	case api.RecordTypeCNAME, api.RecordTypeALIAS, api.RecordTypeNS, api.RecordTypePTR, api.RecordTypeMX, api.RecordTypeSRV:
+		return true
+	}
+	return false
+}
+
+// canonicalTargets makes the host names in the content of a record
+// read from a backend fully qualified, with a single trailing dot,
+// which is the form GetDNSRecords returns for every provider whether
+// or not the backend stores the dot. MX and SRV records must already
+// be split so Content holds only the targets.
+func canonicalTargets(record api.Record) api.Record {
+	if !hasHostTarget(record.Type) {
+		return record
+	}
+	content := make([]string, len(record.Content))
+	for ii, target := range record.Content {
+		content[ii] = fqdnTarget(target)
+	}
+	record.Content = content
+	return record
+}
+
+// fromPresentation converts a record read from a backend that stores
+// record data in presentation format into the form returned by
+// GetDNSRecords. TXT values are unquoted, MX and SRV records are
+// split by priority, and the Unicode form of the name is set.
+func fromPresentation(record api.Record) []api.Record {
+	if record.Type == api.RecordTypeTXT {
+		content := make([]string, len(record.Content))
+		for ii, value := range record.Content {
+			content[ii] = parseTXTRRData(value)
+		}
+		record.Content = content
+	}
+	records := splitPriorityRecord(withUnicodeName(record))
+	for ii := range records {
+		records[ii] = canonicalTargets(records[ii])
+	}
+	return records
+}
+
+// txtValue returns the logical value of TXT content passed to
+// CreateOrUpdateDNSRecord, which may be given either unquoted or
+// already in presentation format.
+func txtValue(content string) string {
+	return parseTXTRRData(content)
+}
+
+// txtRRData returns the TXT value in presentation format, split into
+// quoted character-strings of at most 255 bytes. Content that is
+// already quoted is parsed first so it is not quoted twice.
+func txtRRData(content string) string {
+	value := txtValue(content)
+	chunks := []string{}
+	for len(value) > txtChunkSize {
+		chunks = append(chunks, quoteTXTChunk(value[:txtChunkSize]))
+		value = value[txtChunkSize:]
+	}
+	chunks = append(chunks, quoteTXTChunk(value))
+	return strings.Join(chunks, " ")
+}
+
+func quoteTXTChunk(chunk string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for ii := 0; ii < len(chunk); ii++ {
+		c := chunk[ii]
+		if c == '"' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// parseTXTRRData returns the logical value of TXT data in presentation
+// format by joining its character-strings. Data that does not start
+// with a quote is returned unchanged.
+func parseTXTRRData(rrdata string) string {
+	rrdata = strings.TrimSpace(rrdata)
+	if !strings.HasPrefix(rrdata, `"`) {
+		return rrdata
+	}
+	var sb strings.Builder
+	inQuote := false
+	for ii := 0; ii < len(rrdata); ii++ {
+		c := rrdata[ii]
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case c == '\\' && ii+1 < len(rrdata):
+			// \DDD is a decimal byte value, otherwise the next
+			// character is taken literally
+			if ii+3 < len(rrdata) {
+				if b, err := strconv.ParseUint(rrdata[ii+1:ii+4], 10, 8); err == nil {
+					sb.WriteByte(byte(b))
+					ii += 3
+					continue
+				}
+			}
+			ii++
+			sb.WriteByte(rrdata[ii])
+		case !inQuote && (c == ' ' || c == '\t'):
+			// separator between character-strings
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTXTRRData(t *testing.T) {
+	spf := "v=spf1 include:_spf.example.com ~all"
+	require.Equal(t, `"v=spf1 include:_spf.example.com ~all"`, txtRRData(spf))
+	// already quoted content is not quoted twice
+	require.Equal(t, `"v=spf1 include:_spf.example.com ~all"`, txtRRData(`"`+spf+`"`))
+	require.Equal(t, spf, parseTXTRRData(txtRRData(spf)))
+	require.Equal(t, spf, parseTXTRRData(spf))
+
+	// quotes and backslashes are escaped
+	tricky := `say "hi" \o/`
+	require.Equal(t, `"say \"hi\" \\o/"`, txtRRData(tricky))
+	require.Equal(t, tricky, parseTXTRRData(txtRRData(tricky)))
+	require.Equal(t, "a;b", parseTXTRRData(`"a\059b"`))
+
+	// long values are split into 255 byte character-strings
+	long := strings.Repeat("k", 600)
+	rrdata := txtRRData(long)
+	require.Equal(t, `"`+strings.Repeat("k", 255)+`" "`+strings.Repeat("k", 255)+`" "`+strings.Repeat("k", 90)+`"`, rrdata)
+	require.Equal(t, long, parseTXTRRData(rrdata))
+
+	// only TXT and MX content is converted
+	content, err := toPresentation("txt", "hello world")
+	require.Nil(t, err)
+	require.Equal(t, `"hello world"`, content)
+	content, err = toPresentation("A", "10.0.0.1")
+	require.Nil(t, err)
+	require.Equal(t, "10.0.0.1", content)
+
+	records := fromPresentation(api.Record{Type: "TXT", Name: "example.com", Content: []string{`"part one" "part two"`}})
+	require.Equal(t, []string{"part onepart two"}, records[0].Content)
+	records = fromPresentation(api.Record{Type: "A", Name: "example.com", Content: []string{`"10.0.0.1"`}})
+	require.Equal(t, []string{`"10.0.0.1"`}, records[0].Content)
+}
+
+func TestHostTargets(t *testing.T) {
+	ctx := context.Background()
+	cfStub := newCFStub("example.com")
+	gcStub := newGCDNSStub("example.com")
+	providers := map[string]struct {
+		prov    api.Provider
+		updates func() int
+	}{
+		"cloudflare": {
+			prov: newCFTestProvider(t, cfStub),
+			updates: func() int {
+				return cfStub.count(http.MethodPatch, "/dns_records/")
+			},
+		},
+		"googleclouddns": {
+			prov: newGCDNSTestProvider(t, gcStub),
+			updates: func() int {
+				return gcStub.count(http.MethodPatch, "/rrsets/")
+			},
+		},
+	}
+	for name, pt := range providers {
+		for _, tc := range []struct {
+			name, rtype, content string
+		}{
+			{"alias.example.com", "CNAME", "target.example.com"},
+			{"example.com", "MX", "10 mail.example.com"},
+			{"_sip._tcp.example.com", "SRV", "10 5 5060 sip.example.com"},
+		} {
+			err := pt.prov.CreateOrUpdateDNSRecord(ctx, "example.com", tc.name, tc.rtype, tc.content, 300, false)
+			require.Nil(t, err, name)
+			// the same target with a trailing dot is not a change
+			err = pt.prov.CreateOrUpdateDNSRecord(ctx, "example.com", tc.name, tc.rtype, tc.content+".", 300, false)
+			require.Nil(t, err, name)
+			require.Equal(t, 0, pt.updates(), name+" "+tc.rtype)
+
+			records, err := pt.prov.GetDNSRecords(ctx, "example.com", tc.name)
+			require.Nil(t, err, name)
+			require.Equal(t, 1, len(records), name)
+			target := tc.content[strings.LastIndex(tc.content, " ")+1:]
+			require.Equal(t, []string{target + "."}, records[0].Content, name)
+		}
+	}
+
+	require.Equal(t, "target.example.com.", fqdnTarget("target.example.com"))
+	require.Equal(t, "target.example.com.", fqdnTarget("target.example.com.."))
+}
@@ -0,0 +1,373 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+	domain "github.com/scaleway/scaleway-sdk-go/api/domain/v2beta1"
+	"github.com/scaleway/scaleway-sdk-go/scw"
+)
+
+const (
+	CredentialKeyAccessKey      = "accessKey"
+	CredentialKeyOrganizationID = "organizationID"
+	CredentialKeyProjectID      = "projectID"
+	CredentialKeySecretKey      = "secretKey"
+)
+
+// Scaleway manages DNS records via the Scaleway Domains and DNS API.
+// Scaleway stores record names relative to the DNS zone, with an
+// empty name for the apex, and applies each change set to a zone
+// atomically, so record sets are replaced in a single call.
+type Scaleway struct {
+	api       *domain.API
+	projectID string
+	logger    api.Logger
+	zone      string // zone the provider was configured for, if any
+}
+
+// NewScalewayProvider creates a new Scaleway DNS provider.
+func NewScalewayProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*Scaleway, error) {
+	return NewScalewayProviderWithCredentials(ctx, zone, scalewayCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewScalewayProviderWithCredentials creates a new Scaleway DNS
+// provider from typed credentials.
+func NewScalewayProviderWithCredentials(ctx context.Context, zone string, creds ScalewayCredentials, logger api.Logger, ops ...Option) (*Scaleway, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	opts := getOptions(ops)
+	clientOpts := []scw.ClientOption{
+		scw.WithAuth(creds.AccessKey, creds.SecretKey),
+		scw.WithDefaultProjectID(creds.ProjectID),
+	}
+	if creds.OrganizationID != "" {
+		clientOpts = append(clientOpts, scw.WithDefaultOrganizationID(creds.OrganizationID))
+	}
+	clientOpts = append(clientOpts, scw.WithHTTPClient(opts.httpClient()))
+	client, err := scw.NewClient(clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create scaleway client, %v", err)
+	}
+	return &Scaleway{
+		api:       domain.NewAPI(client),
+		projectID: creds.ProjectID,
+		logger:    logger,
+		zone:      zone,
+	}, nil
+}
+
+// scalewayNotFound checks if the error is a Scaleway not found error.
+func scalewayNotFound(err error) bool {
+	var nfErr *scw.ResourceNotFoundError
+	var respErr *scw.ResponseError
+	return errors.As(err, &nfErr) || errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *Scaleway) Close() error {
+	return nil
+}
+
+// ValidateCredentials lists at most one DNS zone of the project.
+func (s *Scaleway) ValidateCredentials(ctx context.Context) error {
+	pageSize := uint32(1)
+	_, err := s.api.ListDNSZones(&domain.ListDNSZonesRequest{
+		ProjectID: &s.projectID,
+		PageSize:  &pageSize,
+	}, scw.WithContext(ctx))
+	return credentialsError(err)
+}
+
+// scalewayZoneName returns the name of the DNS zone, which is a
+// subdomain of a domain for zones delegated from a parent zone.
+func scalewayZoneName(zone *domain.DNSZone) string {
+	if zone.Subdomain == "" {
+		return zone.Domain
+	}
+	return zone.Subdomain + "." + zone.Domain
+}
+
+// ListZones returns the DNS zones of the project. Scaleway identifies
+// zones by name.
+func (s *Scaleway) ListZones(ctx context.Context) ([]api.Zone, error) {
+	resp, err := s.api.ListDNSZones(&domain.ListDNSZonesRequest{
+		ProjectID: &s.projectID,
+	}, scw.WithContext(ctx), scw.WithAllPages())
+	if err != nil {
+		return nil, err
+	}
+	zones := []api.Zone{}
+	for _, dnsZone := range resp.DNSZones {
+		zones = append(zones, newZone(scalewayZoneName(dnsZone), ""))
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone creates the DNS zone in the project.
+func (s *Scaleway) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	dnsZone, err := s.api.CreateDNSZone(&domain.CreateDNSZoneRequest{
+		Domain:    strings.TrimSuffix(zone, "."),
+		ProjectID: s.projectID,
+	}, scw.WithContext(ctx))
+	if err != nil {
+		return api.Zone{}, fmt.Errorf("cannot create zone %s, %v", zone, err)
+	}
+	return newZone(scalewayZoneName(dnsZone), ""), nil
+}
+
+// DeleteZone deletes the DNS zone and all its records.
+func (s *Scaleway) DeleteZone(ctx context.Context, zone string) error {
+	_, err := s.api.DeleteDNSZone(&domain.DeleteDNSZoneRequest{
+		DNSZone:   strings.TrimSuffix(zone, "."),
+		ProjectID: s.projectID,
+	}, scw.WithContext(ctx))
+	if scalewayNotFound(err) {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete zone %s, %v", zone, err)
+	}
+	return nil
+}
+
+// scalewayName returns the name relative to the zone as Scaleway
+// stores it, with an empty name for the apex.
+func scalewayName(name, zone string) string {
+	relName := relativeName(name, zone)
+	if relName == apexName {
+		return ""
+	}
+	return relName
+}
+
+// listRecords returns the records of the zone, or only those of the
+// name if one is given.
+func (s *Scaleway) listRecords(ctx context.Context, zone, name string) ([]*domain.Record, error) {
+	req := &domain.ListDNSZoneRecordsRequest{
+		DNSZone: strings.TrimSuffix(zone, "."),
+	}
+	relName := ""
+	if name != "" {
+		// an empty name filter lists the whole zone, so the apex
+		// is filtered here
+		relName = scalewayName(name, zone)
+		req.Name = relName
+	}
+	resp, err := s.api.ListDNSZoneRecords(req, scw.WithContext(ctx), scw.WithAllPages())
+	if scalewayNotFound(err) {
+		return nil, fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return resp.Records, nil
+	}
+	records := []*domain.Record{}
+	for _, rec := range resp.Records {
+		if strings.EqualFold(rec.Name, relName) {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *Scaleway) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	srecords, err := s.listRecords(ctx, zone, name)
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, srec := range srecords {
+		recName := absoluteName(srec.Name, zone)
+		record := api.Record{
+			Type:    string(srec.Type),
+			Name:    recName,
+			Content: []string{srec.Data},
+			TTL:     int(srec.TTL),
+			System:  isSystemRecord(zone, recName, string(srec.Type)),
+		}
+		switch srec.Type {
+		case domain.RecordTypeMX:
+			record.Priority = int(srec.Priority)
+		case domain.RecordTypeTXT:
+			record.Content = []string{parseTXTRRData(srec.Data)}
+		}
+		records = append(records, canonicalTargets(withUnicodeName(record)))
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *Scaleway) SupportedRecordTypes() []string {
+	return slices.Clone(scalewayRecordTypes)
+}
+
+// Capabilities reports that Scaleway can manage zones.
+func (s *Scaleway) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// scalewayRecord converts content as passed to CreateOrUpdateDNSRecord
+// into a Scaleway record, which keeps the MX priority in a separate
+// field.
+func scalewayRecord(relName, rtype, content string, ttl int) (*domain.Record, error) {
+	record := &domain.Record{
+		Name: relName,
+		Type: domain.RecordType(rtype),
+		Data: content,
+		TTL:  uint32(ttl),
+	}
+	switch rtype {
+	case api.RecordTypeMX:
+		priority, target, err := parseMXContent(content)
+		if err != nil {
+			return nil, err
+		}
+		record.Priority = uint32(priority)
+		record.Data = fqdnTarget(target)
+	case api.RecordTypeTXT:
+		record.Data = txtRRData(content)
+	case api.RecordTypeCNAME, api.RecordTypeNS, api.RecordTypePTR:
+		record.Data = fqdnTarget(content)
+	}
+	return record, nil
+}
+
+// CreateOrUpdateDNSRecord replaces all values of the name and type
+// with the new record.
+func (s *Scaleway) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	return s.CreateOrUpdateDNSRecordSet(ctx, zone, name, rtype, []string{content}, ttl, proxy)
+}
+
+// CreateOrUpdateDNSRecordSet replaces the records of the name and
+// type with the contents in a single set change.
+func (s *Scaleway) CreateOrUpdateDNSRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) error {
+	if err := checkProxy(api.ScalewayProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, scalewayRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, contents...); err != nil {
+		return err
+	}
+	if len(contents) == 0 {
+		return fmt.Errorf("no content specified for %s record %s", rtype, name)
+	}
+	rtype = strings.ToUpper(rtype)
+	relName := scalewayName(name, zone)
+	records := []*domain.Record{}
+	for _, content := range contents {
+		record, err := scalewayRecord(relName, rtype, content, ttl)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", contents)
+	_, err := s.api.UpdateDNSZoneRecords(&domain.UpdateDNSZoneRecordsRequest{
+		DNSZone: strings.TrimSuffix(zone, "."),
+		Changes: []*domain.RecordChange{{
+			Set: &domain.RecordChangeSet{
+				IDFields: &domain.RecordIdentifier{
+					Name: relName,
+					Type: domain.RecordType(rtype),
+				},
+				Records: records,
+			},
+		}},
+		DisallowNewZoneCreation: true,
+	}, scw.WithContext(ctx))
+	if scalewayNotFound(err) {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+		return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, err)
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *Scaleway) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *Scaleway) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *Scaleway) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+// deleteRecords deletes the matching records by ID in a single change
+// set, so either all or none are deleted.
+func (s *Scaleway) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	srecords, err := s.listRecords(ctx, zone, name)
+	if err != nil {
+		return 0, err
+	}
+	changes := []*domain.RecordChange{}
+	for _, rec := range srecords {
+		if rtype != "" && !strings.EqualFold(string(rec.Type), rtype) {
+			continue
+		}
+		changes = append(changes, &domain.RecordChange{
+			Delete: &domain.RecordChangeDelete{ID: &rec.ID},
+		})
+	}
+	if len(changes) == 0 {
+		return 0, nil
+	}
+	_, err = s.api.UpdateDNSZoneRecords(&domain.UpdateDNSZoneRecordsRequest{
+		DNSZone:                 strings.TrimSuffix(zone, "."),
+		Changes:                 changes,
+		DisallowNewZoneCreation: true,
+	}, scw.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("cannot delete DNS records for zone %s name %s, %v", zone, name, err)
+	}
+	return len(changes), nil
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	domain "github.com/scaleway/scaleway-sdk-go/api/domain/v2beta1"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	scalewayTestAccessKey = "SCWXXXXXXXXXXXXXXXXX"
+	scalewayTestSecretKey = "11111111-1111-1111-1111-111111111111"
+	scalewayTestProjectID = "22222222-2222-2222-2222-222222222222"
+)
+
+// scalewayStub is a minimal in-memory implementation of the Scaleway
+// DNS zone records API, supporting set and delete changes.
+type scalewayStub struct {
+	mu      sync.Mutex
+	zones   map[string][]*domain.Record
+	nextID  int
+	patches int
+}
+
+func newScalewayStub(zones ...string) *scalewayStub {
+	s := &scalewayStub{zones: map[string][]*domain.Record{}}
+	for _, zone := range zones {
+		s.zones[zone] = []*domain.Record{}
+	}
+	return s
+}
+
+func (s *scalewayStub) notFound(w http.ResponseWriter, zone string) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{
+		"type":        "not_found",
+		"resource":    "dns_zone",
+		"resource_id": zone,
+	})
+}
+
+func (s *scalewayStub) apply(zone string, change *domain.RecordChange) {
+	kept := []*domain.Record{}
+	switch {
+	case change.Set != nil:
+		for _, rec := range s.zones[zone] {
+			if rec.Name != change.Set.IDFields.Name || rec.Type != change.Set.IDFields.Type {
+				kept = append(kept, rec)
+			}
+		}
+		for _, rec := range change.Set.Records {
+			s.nextID++
+			rec.ID = strconv.Itoa(s.nextID)
+			kept = append(kept, rec)
+		}
+	case change.Delete != nil:
+		for _, rec := range s.zones[zone] {
+			if rec.ID != *change.Delete.ID {
+				kept = append(kept, rec)
+			}
+		}
+	}
+	s.zones[zone] = kept
+}
+
+func (s *scalewayStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /domain/v2beta1/dns-zones", func(w http.ResponseWriter, r *http.Request) {
+		zones := []domain.DNSZone{}
+		for name := range s.zones {
+			zones = append(zones, domain.DNSZone{Domain: name, ProjectID: scalewayTestProjectID})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"dns_zones": zones, "total_count": len(zones)})
+	})
+	mux.HandleFunc("GET /domain/v2beta1/dns-zones/{zone}/records", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		all, ok := s.zones[zone]
+		if !ok {
+			s.notFound(w, zone)
+			return
+		}
+		records := []*domain.Record{}
+		for _, rec := range all {
+			if name := r.URL.Query().Get("name"); name == "" || rec.Name == name {
+				records = append(records, rec)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"records": records, "total_count": len(records)})
+	})
+	mux.HandleFunc("PATCH /domain/v2beta1/dns-zones/{zone}/records", func(w http.ResponseWriter, r *http.Request) {
+		zone := r.PathValue("zone")
+		req := domain.UpdateDNSZoneRecordsRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if _, ok := s.zones[zone]; !ok {
+			s.notFound(w, zone)
+			return
+		}
+		s.patches++
+		for _, change := range req.Changes {
+			s.apply(zone, change)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"records": []*domain.Record{}})
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("X-Auth-Token") != scalewayTestSecretKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type": "denied_authentication", "method": "api_key", "reason": "not_found"}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func scalewayTestCredentials() ScalewayCredentials {
+	return ScalewayCredentials{
+		AccessKey: scalewayTestAccessKey,
+		SecretKey: scalewayTestSecretKey,
+		ProjectID: scalewayTestProjectID,
+	}
+}
+
+func TestScalewayStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newScalewayStub("example.com")
+	client := newStubClient(t, stub.handler())
+	prov, err := GetProvider(ctx, api.ScalewayProvider, "", scalewayTestCredentials().ToMap(), nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com")
+	recordSetTest(t, ctx, prov.(api.RecordSetUpdater), "example.com", "set.example.com")
+
+	// the apex has an empty name, and the MX priority has its own
+	// field
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com", 300, false)
+	require.Nil(t, err)
+	rec := stub.zones["example.com"][len(stub.zones["example.com"])-1]
+	require.Equal(t, "", rec.Name)
+	require.Equal(t, "mail.example.com.", rec.Data)
+	require.Equal(t, uint32(10), rec.Priority)
+
+	// all records of a name are deleted in one change set
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "TXT", "v=spf1 -all", 300, false)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(records))
+	require.Equal(t, []string{"v=spf1 -all"}, records[1].Content)
+	stub.patches = 0
+	count, err := prov.(api.DeleteCounter).DeleteDNSRecordCount(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, 1, stub.patches)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "example.com"}}, zones)
+}
+
+func TestScalewayCredentials(t *testing.T) {
+	ctx := context.Background()
+	client := newStubClient(t, newScalewayStub("example.com").handler())
+
+	creds := scalewayTestCredentials()
+	err := ValidateCredentials(ctx, api.ScalewayProvider, creds.ToMap(), WithHTTPClient(client))
+	require.Nil(t, err)
+	creds.SecretKey = "33333333-3333-3333-3333-333333333333"
+	err = ValidateCredentials(ctx, api.ScalewayProvider, creds.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+
+	err = ScalewayCredentials{AccessKey: scalewayTestAccessKey, SecretKey: scalewayTestSecretKey}.Validate()
+	require.EqualError(t, err, "missing projectID key from scaleway dns provider credentials data")
+}
@@ -0,0 +1,151 @@
+package dnsimple
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexr/dnsproviders"
+	"github.com/edgexr/dnsproviders/api"
+)
+
+const (
+	credentialFile = "config.json"
+)
+
+var (
+	provider       *dnsproviders.DNSimple
+	testZone       = "filled-with-data-from-config.json"
+	testRecordName = fmt.Sprintf("test-%s", strings.Split(uuid.NewString(), "-")[0])
+	ipv4           = "80.0.0.0"
+	ipv4Alt        = "80.0.0.1"
+	ipv6           = "2001:db8:85a3::8a2e:370:7334"
+)
+
+func TestMain(m *testing.M) {
+	if _, err := os.Stat(credentialFile); errors.Is(err, os.ErrNotExist) {
+		log.Println("no credential file found, skipping tests for dnsimple")
+		os.Exit(0)
+	}
+
+	credentialData, err := readCredentials(credentialFile)
+	if err != nil {
+		panic(fmt.Sprintf("failed to read credential file: %v", err))
+	}
+
+	testZone = credentialData.TestZone
+	testRecordName = testRecordName + "." + testZone
+
+	dnsimple, err := dnsproviders.NewDNSimpleProviderWithCredentials(context.Background(), testZone, credentialData.DNSimpleCredentials, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	provider = dnsimple
+
+	os.Exit(m.Run())
+}
+
+func TestCreateRecord(t *testing.T) {
+	type testCase struct {
+		Zone          string
+		Type          string
+		Content       string
+		ExpectedError error
+	}
+
+	for name, tc := range map[string]testCase{
+		"no error create a-record": {
+			Zone:          testZone,
+			Type:          api.RecordTypeA,
+			Content:       ipv4Alt,
+			ExpectedError: nil,
+		},
+		"no error create aaaa-record": {
+			Zone:          testZone,
+			Type:          api.RecordTypeAAAA,
+			Content:       ipv6,
+			ExpectedError: nil,
+		},
+		"no error update a-record": {
+			Zone:          testZone,
+			Type:          api.RecordTypeA,
+			Content:       ipv4,
+			ExpectedError: nil,
+		},
+		"invalid zone": {
+			Zone:          "non-existing.example.com",
+			Type:          api.RecordTypeA,
+			Content:       ipv4,
+			ExpectedError: api.ErrZoneNotFound,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := provider.CreateOrUpdateDNSRecord(context.Background(), tc.Zone, testRecordName, tc.Type, tc.Content, 600, false)
+
+			if tc.ExpectedError == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, tc.ExpectedError)
+		})
+	}
+}
+
+func TestGetRecord(t *testing.T) {
+	records, err := provider.GetDNSRecords(context.Background(), testZone, testRecordName)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(records))
+
+	// make sure A-Record comes before AAAA-Record
+	sort.Slice(records, func(i, j int) bool {
+		return len(records[i].Type) < len(records[j].Type)
+	})
+
+	assert.Equal(t, testRecordName, records[0].Name)
+	assert.Equal(t, api.RecordTypeA, records[0].Type)
+	assert.Equal(t, 600, records[0].TTL)
+	assert.Equal(t, ipv4, records[0].Content[0])
+
+	assert.Equal(t, testRecordName, records[1].Name)
+	assert.Equal(t, api.RecordTypeAAAA, records[1].Type)
+	assert.Equal(t, 600, records[1].TTL)
+	assert.Equal(t, ipv6, records[1].Content[0])
+}
+
+func TestDeleteRecord(t *testing.T) {
+	count, err := provider.DeleteDNSRecordCount(context.Background(), testZone, testRecordName)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+type credentialsJson struct {
+	dnsproviders.DNSimpleCredentials
+	TestZone string `json:"testZone"`
+}
+
+func readCredentials(file string) (*credentialsJson, error) {
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var js credentialsJson
+	if err := json.Unmarshal(bytes, &js); err != nil {
+		return nil, err
+	}
+
+	return &js, nil
+}
@@ -0,0 +1,95 @@
+package googleclouddns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexr/dnsproviders"
+)
+
+const (
+	credentialFile = "config.json"
+)
+
+var (
+	provider *dnsproviders.CloudDNS
+	testZone = "filled-with-data-from-config.json"
+)
+
+func TestMain(m *testing.M) {
+	if _, err := os.Stat(credentialFile); errors.Is(err, os.ErrNotExist) {
+		log.Println("no credential file found, skipping tests for googleclouddns")
+		os.Exit(0)
+	}
+
+	credentialData, err := readCredentials(credentialFile)
+	if err != nil {
+		panic(fmt.Sprintf("failed to read credential file: %v", err))
+	}
+
+	testZone = credentialData.TestZone
+
+	clouddns, err := dnsproviders.NewGoogleCloudDNSProviderWithCredentials(context.Background(), testZone, credentialData.GoogleCloudCredentials, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	provider = clouddns
+
+	os.Exit(m.Run())
+}
+
+func TestEnableDisableDNSSEC(t *testing.T) {
+	ctx := context.Background()
+	status, err := provider.GetDNSSECStatus(ctx, testZone)
+	require.NoError(t, err)
+	if status.Enabled {
+		t.Skipf("DNSSEC of zone %s is %s, not changing it", testZone, status.State)
+	}
+
+	ds, err := provider.EnableDNSSEC(ctx, testZone)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = provider.DisableDNSSEC(ctx, testZone)
+	})
+	require.NotZero(t, ds.KeyTag)
+	require.NotZero(t, ds.Algorithm)
+	require.NotZero(t, ds.DigestType)
+	require.NotEmpty(t, ds.Digest)
+
+	status, err = provider.GetDNSSECStatus(ctx, testZone)
+	require.NoError(t, err)
+	require.Contains(t, status.DSRecords, ds.String())
+
+	err = provider.DisableDNSSEC(ctx, testZone)
+	require.NoError(t, err)
+	status, err = provider.GetDNSSECStatus(ctx, testZone)
+	require.NoError(t, err)
+	require.False(t, status.Enabled)
+}
+
+type credentialsJson struct {
+	dnsproviders.GoogleCloudCredentials
+	TestZone string `json:"testZone"`
+}
+
+func readCredentials(file string) (*credentialsJson, error) {
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var js credentialsJson
+	if err := json.Unmarshal(bytes, &js); err != nil {
+		return nil, err
+	}
+
+	return &js, nil
+}
@@ -45,7 +45,7 @@ func TestMain(m *testing.M) {
 
 	testZone = credentialData.TestZone
 
-	otc, err := dnsproviders.NewOtcProvider(context.Background(), testZone, credentialData.ToMap(), nil)
+	otc, err := dnsproviders.NewOtcProviderWithCredentials(context.Background(), testZone, credentialData.OTCCredentials, nil)
 	if err != nil {
 		panic(err)
 	}
@@ -117,11 +117,14 @@ func TestGetRecord(t *testing.T) {
 	assert.Equal(t, api.RecordTypeA, records[0].Type)
 	assert.Equal(t, 300, records[0].TTL)
 	assert.Equal(t, ipv4, records[0].Content[0])
+	// only TXT content is quoted
+	assert.NotContains(t, records[0].Content[0], `"`)
 
 	assert.Equal(t, testRecordName, records[1].Name)
 	assert.Equal(t, api.RecordTypeAAAA, records[1].Type)
 	assert.Equal(t, 300, records[1].TTL)
 	assert.Equal(t, ipv6, records[1].Content[0])
+	assert.NotContains(t, records[1].Content[0], `"`)
 }
 
 func TestDeleteRecord(t *testing.T) {
@@ -130,22 +133,8 @@ func TestDeleteRecord(t *testing.T) {
 }
 
 type credentialsJson struct {
-	TestZone   string `json:"testZone"`
-	Region     string `json:"region"`
-	DomainName string `json:"domainName"`
-	TenantName string `json:"tenantName"`
-	Username   string `json:"username"`
-	Password   string `json:"password"`
-}
-
-func (c credentialsJson) ToMap() map[string]string {
-	return map[string]string{
-		dnsproviders.CredentialKeyRegion:     c.Region,
-		dnsproviders.CredentialKeyTenantName: c.TenantName,
-		dnsproviders.CredentialKeyDomainName: c.DomainName,
-		dnsproviders.CredentialKeyUsername:   c.Username,
-		dnsproviders.CredentialKeyPassword:   c.Password,
-	}
+	dnsproviders.OTCCredentials
+	TestZone string `json:"testZone"`
 }
 
 func readCredentials(file string) (*credentialsJson, error) {
@@ -0,0 +1,32 @@
+//go:build integration
+
+package otc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDeleteZone(t *testing.T) {
+	ctx := context.Background()
+	zoneName := fmt.Sprintf("%s.%s", testRecordName, testZone)
+
+	zone, err := provider.CreateZone(ctx, zoneName)
+	require.NoError(t, err)
+	require.Equal(t, strings.TrimSuffix(zoneName, "."), zone.Name)
+	t.Cleanup(func() {
+		_ = provider.DeleteZone(ctx, zoneName)
+	})
+
+	err = provider.CreateOrUpdateDNSRecord(ctx, zoneName, "www", "A", ipv4, 300, false)
+	require.NoError(t, err)
+
+	err = provider.DeleteZone(ctx, zoneName)
+	require.NoError(t, err)
+	_, err = provider.GetDNSRecords(ctx, zoneName, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/edgexr/dnsproviders"
+
+// Span attributes set on the provider operation spans.
+const (
+	attrProvider   = attribute.Key("dns.provider")
+	attrZone       = attribute.Key("dns.zone")
+	attrName       = attribute.Key("dns.name")
+	attrRecordType = attribute.Key("dns.record_type")
+)
+
+// tracingOperation starts a span for each operation, with the zone,
+// name and record type the operation applies to as attributes. The
+// span is on the context passed to the backend, so spans of its HTTP
+// requests are nested under it.
+func tracingOperation(tracer trace.Tracer, provider api.ProviderType) operationFunc {
+	return func(ctx context.Context, op, zone, name, rtype string, fn func(ctx context.Context) error) error {
+		attrs := []attribute.KeyValue{}
+		if zone != "" {
+			attrs = zoneAttributes(zone, name, rtype)
+		}
+		attrs = append(attrs, attrProvider.String(string(provider)))
+		ctx, span := tracer.Start(ctx, "dnsproviders."+op, trace.WithAttributes(attrs...))
+		err := fn(ctx)
+		end(span, err)
+		return err
+	}
+}
+
+// end records the error, if any, and ends the span.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func zoneAttributes(zone, name, rtype string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attrZone.String(strings.TrimSuffix(zone, "."))}
+	if name != "" {
+		attrs = append(attrs, attrName.String(name))
+	}
+	if rtype != "" {
+		attrs = append(attrs, attrRecordType.String(strings.ToUpper(rtype)))
+	}
+	return attrs
+}
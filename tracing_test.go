@@ -0,0 +1,105 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanContextProvider records the span of the context each call
+// receives.
+type spanContextProvider struct {
+	*MockProvider
+	spanContext trace.SpanContext
+}
+
+func (s *spanContextProvider) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	s.spanContext = trace.SpanContextFromContext(ctx)
+	return s.MockProvider.GetDNSRecords(ctx, zone, name)
+}
+
+func TestWithTracer(t *testing.T) {
+	ctx := context.Background()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prov, err := GetProvider(ctx, api.MockProvider, "example.com", nil, nil, WithTracer(tp))
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com.", "www.example.com", "a", "10.0.0.1", 300, false)
+	require.Nil(t, err)
+	_, err = prov.GetDNSRecords(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	err = prov.DeleteDNSRecord(ctx, "example.org", "www.example.org")
+	require.NotNil(t, err)
+
+	spans := recorder.Ended()
+	require.Equal(t, 3, len(spans))
+	attrs := func(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+		m := map[attribute.Key]string{}
+		for _, kv := range span.Attributes() {
+			m[kv.Key] = kv.Value.AsString()
+		}
+		return m
+	}
+	require.Equal(t, "dnsproviders.CreateOrUpdateDNSRecord", spans[0].Name())
+	require.Equal(t, map[attribute.Key]string{
+		attrProvider:   "mock",
+		attrZone:       "example.com",
+		attrName:       "www.example.com",
+		attrRecordType: "A",
+	}, attrs(spans[0]))
+	require.Equal(t, codes.Unset, spans[0].Status().Code)
+	require.Equal(t, "dnsproviders.GetDNSRecords", spans[1].Name())
+	require.Equal(t, "www.example.com", attrs(spans[1])[attrName])
+	require.NotContains(t, attrs(spans[1]), attrRecordType)
+
+	// failures are recorded on the span
+	require.Equal(t, "dnsproviders.DeleteDNSRecord", spans[2].Name())
+	require.Equal(t, codes.Error, spans[2].Status().Code)
+	require.Equal(t, 1, len(spans[2].Events()))
+	require.Equal(t, "exception", spans[2].Events()[0].Name)
+
+	// the backend runs within the span
+	mock := &spanContextProvider{MockProvider: NewMockProvider("example.com")}
+	traced := &wrappedProvider{Provider: mock, around: tracingOperation(tp.Tracer(tracerName), api.MockProvider)}
+	_, err = traced.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	spans = recorder.Ended()
+	require.Equal(t, spans[len(spans)-1].SpanContext().SpanID(), mock.spanContext.SpanID())
+
+	// the optional interfaces of the backend are traced too
+	_, err = prov.(api.DeleteCounter).DeleteDNSRecordCount(ctx, "example.com", "www.example.com")
+	require.Nil(t, err)
+	_, err = prov.ListZones(ctx)
+	require.Nil(t, err)
+	spans = recorder.Ended()
+	require.Equal(t, "dnsproviders.DeleteDNSRecordCount", spans[len(spans)-2].Name())
+	require.Equal(t, map[attribute.Key]string{
+		attrProvider: "mock",
+		attrZone:     "example.com",
+		attrName:     "www.example.com",
+	}, attrs(spans[len(spans)-2]))
+	require.Equal(t, "dnsproviders.ListZones", spans[len(spans)-1].Name())
+	require.Equal(t, map[attribute.Key]string{attrProvider: "mock"}, attrs(spans[len(spans)-1]))
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"fmt"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// TTLPolicy selects what a provider does with a requested TTL that is
+// outside the range its backend allows.
+type TTLPolicy int
+
+const (
+	// TTLReject fails the write with an error wrapping
+	// api.ErrInvalidTTL. It is the default.
+	TTLReject TTLPolicy = iota
+	// TTLClamp raises or lowers the TTL to the nearest one allowed.
+	TTLClamp
+)
+
+// WithTTLPolicy sets what providers do with a requested TTL outside
+// the range their backend allows. It is applied by the providers whose
+// backends reject TTLs out of range, which are Cloudflare, deSEC,
+// DigitalOcean, Gandi and Namecheap. Linode rounds TTLs to the values
+// it allows instead, Porkbun raises them to its minimum itself, and
+// the other backends take any TTL.
+func WithTTLPolicy(policy TTLPolicy) Option {
+	return func(opts *options) {
+		opts.ttlPolicy = policy
+	}
+}
+
+// ttlRange is the range of TTLs a backend accepts, with no upper
+// bound if max is zero.
+type ttlRange struct {
+	min int
+	max int
+}
+
+// normalize applies the policy to a TTL outside the range. Zero
+// selects the backend's default and is returned unchanged.
+func (s TTLPolicy) normalize(typ api.ProviderType, ttl int, bounds ttlRange) (int, error) {
+	switch {
+	case ttl == 0:
+		return ttl, nil
+	case ttl < bounds.min:
+		if s == TTLClamp {
+			return bounds.min, nil
+		}
+		return 0, fmt.Errorf("%w, %s dns provider requires a ttl of at least %d, not %d", api.ErrInvalidTTL, typ, bounds.min, ttl)
+	case bounds.max > 0 && ttl > bounds.max:
+		if s == TTLClamp {
+			return bounds.max, nil
+		}
+		return 0, fmt.Errorf("%w, %s dns provider requires a ttl of at most %d, not %d", api.ErrInvalidTTL, typ, bounds.max, ttl)
+	}
+	return ttl, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLPolicy(t *testing.T) {
+	tests := []struct {
+		typ    api.ProviderType
+		bounds ttlRange
+	}{
+		{api.CloudflareProvider, cloudflareTTLs},
+		{api.DeSECProvider, desecTTLs},
+		{api.DigitalOceanProvider, digitalOceanTTLs},
+		{api.GandiProvider, gandiTTLs},
+		{api.NamecheapProvider, namecheapTTLs},
+	}
+	for _, test := range tests {
+		bounds := test.bounds
+		// zero selects the backend's default under either policy
+		for _, policy := range []TTLPolicy{TTLReject, TTLClamp} {
+			ttl, err := policy.normalize(test.typ, 0, bounds)
+			require.Nil(t, err)
+			require.Equal(t, 0, ttl)
+			for _, edge := range []int{bounds.min, bounds.max} {
+				if edge == 0 {
+					continue
+				}
+				ttl, err := policy.normalize(test.typ, edge, bounds)
+				require.Nil(t, err)
+				require.Equal(t, edge, ttl)
+			}
+		}
+
+		ttl, err := TTLClamp.normalize(test.typ, bounds.min-1, bounds)
+		require.Nil(t, err)
+		require.Equal(t, bounds.min, ttl)
+		_, err = TTLReject.normalize(test.typ, bounds.min-1, bounds)
+		require.ErrorIs(t, err, api.ErrInvalidTTL)
+		require.Contains(t, err.Error(), fmt.Sprintf("%s dns provider requires a ttl of at least %d", test.typ, bounds.min))
+
+		if bounds.max == 0 {
+			ttl, err := TTLReject.normalize(test.typ, 1<<30, bounds)
+			require.Nil(t, err)
+			require.Equal(t, 1<<30, ttl)
+			continue
+		}
+		ttl, err = TTLClamp.normalize(test.typ, bounds.max+1, bounds)
+		require.Nil(t, err)
+		require.Equal(t, bounds.max, ttl)
+		_, err = TTLReject.normalize(test.typ, bounds.max+1, bounds)
+		require.ErrorIs(t, err, api.ErrInvalidTTL)
+		require.Contains(t, err.Error(), fmt.Sprintf("%s dns provider requires a ttl of at most %d", test.typ, bounds.max))
+	}
+}
+
+func TestCloudflareTTL(t *testing.T) {
+	ctx := context.Background()
+	stub := newCFStub("example.com")
+	prov := newCFTestProvider(t, stub)
+
+	// no TTL and 1 both select automatic
+	for _, ttl := range []int{0, 1} {
+		err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "auto.example.com", "A", "10.0.0.1", ttl, false)
+		require.Nil(t, err)
+		records, err := prov.GetDNSRecords(ctx, "example.com", "auto.example.com")
+		require.Nil(t, err)
+		require.Equal(t, cloudflareAutoTTL, records[0].TTL)
+	}
+	// so asking for either again is not an update
+	patches := stub.count(http.MethodPatch, "/dns_records/")
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "auto.example.com", "A", "10.0.0.1", 0, false)
+	require.Nil(t, err)
+	require.Equal(t, patches, stub.count(http.MethodPatch, "/dns_records/"))
+
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "short.example.com", "A", "10.0.0.1", 30, false)
+	require.ErrorIs(t, err, api.ErrInvalidTTL)
+	err = prov.UpdateTTL(ctx, "example.com", "auto.example.com", "A", 100000)
+	require.ErrorIs(t, err, api.ErrInvalidTTL)
+
+	prov.ttlPolicy = TTLClamp
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "short.example.com", "A", "10.0.0.1", 30, false)
+	require.Nil(t, err)
+	err = prov.UpdateTTL(ctx, "example.com", "auto.example.com", "A", 100000)
+	require.Nil(t, err)
+	records, err := prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	ttls := map[string]int{}
+	for _, record := range records {
+		ttls[record.Name] = record.TTL
+	}
+	require.Equal(t, map[string]int{"auto.example.com": 86400, "short.example.com": 60}, ttls)
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// ValidateDesired checks a desired set of records for conflicts within
+// the set itself, independent of any provider. It flags CNAME records
+// that share a name with other records or have more than one value,
+// and records with the same name and type that disagree on content or
+// TTL. Names are compared case-insensitively, ignoring any trailing
+// dot. An empty result means no conflicts were found.
+func ValidateDesired(records []api.Record) []error {
+	errs := []error{}
+	byKey := map[string]api.Record{}
+	typesByName := map[string][]string{}
+	names := []string{}
+	for _, rec := range records {
+		name := strings.ToLower(strings.TrimSuffix(rec.Name, "."))
+		rtype := strings.ToUpper(rec.Type)
+		// MX and SRV records of different priorities, and routing
+		// variants, are separate records
+		key := fmt.Sprintf("%s/%s/%d/%d/%d", name, rtype, rec.Priority, rec.Weight, rec.Port)
+		if rec.RoutingPolicy != nil {
+			key += fmt.Sprintf("/%v", *rec.RoutingPolicy)
+		}
+		if prev, ok := byKey[key]; ok {
+			if !slices.Equal(prev.Content, rec.Content) || prev.TTL != rec.TTL {
+				errs = append(errs, fmt.Errorf("conflicting %s records for %s, content %v ttl %d and content %v ttl %d", rtype, rec.Name, prev.Content, prev.TTL, rec.Content, rec.TTL))
+			}
+			continue
+		}
+		byKey[key] = rec
+		if rtype == api.RecordTypeCNAME && len(rec.Content) > 1 {
+			errs = append(errs, fmt.Errorf("CNAME record for %s has multiple values %v", rec.Name, rec.Content))
+		}
+		if _, ok := typesByName[name]; !ok {
+			names = append(names, name)
+		}
+		typesByName[name] = append(typesByName[name], rtype)
+	}
+	for _, name := range names {
+		types := typesByName[name]
+		if len(types) > 1 && slices.Contains(types, api.RecordTypeCNAME) {
+			errs = append(errs, fmt.Errorf("CNAME record for %s cannot coexist with other record types %v", name, types))
+		}
+	}
+	return errs
+}
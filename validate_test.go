@@ -0,0 +1,129 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDesired(t *testing.T) {
+	// valid set, identical duplicates are allowed
+	errs := ValidateDesired([]api.Record{
+		{Type: "A", Name: "www.example.com", Content: []string{"10.0.0.1"}, TTL: 300},
+		{Type: "AAAA", Name: "www.example.com", Content: []string{"fd00::1"}, TTL: 300},
+		{Type: "CNAME", Name: "app.example.com", Content: []string{"www.example.com"}, TTL: 300},
+		{Type: "A", Name: "WWW.example.com.", Content: []string{"10.0.0.1"}, TTL: 300},
+		{Type: "MX", Name: "example.com", Content: []string{"mx1.example.com"}, TTL: 300, Priority: 10},
+		{Type: "MX", Name: "example.com", Content: []string{"mx2.example.com"}, TTL: 300, Priority: 20},
+	})
+	require.Empty(t, errs)
+
+	// CNAME and A for the same name
+	errs = ValidateDesired([]api.Record{
+		{Type: "CNAME", Name: "app.example.com", Content: []string{"www.example.com"}, TTL: 300},
+		{Type: "A", Name: "app.example.com.", Content: []string{"10.0.0.1"}, TTL: 300},
+	})
+	require.Equal(t, 1, len(errs))
+	require.Contains(t, errs[0].Error(), "cannot coexist")
+
+	// duplicate key with different content
+	errs = ValidateDesired([]api.Record{
+		{Type: "A", Name: "www.example.com", Content: []string{"10.0.0.1"}, TTL: 300},
+		{Type: "a", Name: "www.example.com", Content: []string{"10.0.0.2"}, TTL: 300},
+	})
+	require.Equal(t, 1, len(errs))
+	require.Contains(t, errs[0].Error(), "conflicting A records")
+
+	// multi-valued CNAME
+	errs = ValidateDesired([]api.Record{
+		{Type: "CNAME", Name: "app.example.com", Content: []string{"a.example.com", "b.example.com"}, TTL: 300},
+	})
+	require.Equal(t, 1, len(errs))
+	require.Contains(t, errs[0].Error(), "multiple values")
+}
+
+func TestValidateRecord(t *testing.T) {
+	tests := []struct {
+		rtype   string
+		content string
+		ttl     int
+		err     error
+	}{
+		{"A", "10.0.0.1", 300, nil},
+		{"a", "10.0.0.1", 0, nil},
+		{"A", "www.example.com", 300, api.ErrInvalidContent},
+		{"A", "fd00::1", 300, api.ErrInvalidContent},
+		{"A", "::ffff:10.0.0.1", 300, api.ErrInvalidContent},
+		{"A", "10.0.0.1", -1, api.ErrInvalidTTL},
+		{"AAAA", "fd00::1", 300, nil},
+		{"AAAA", "10.0.0.1", 300, api.ErrInvalidContent},
+		{"AAAA", "fd00::zz", 300, api.ErrInvalidContent},
+		{"CNAME", "www.example.com.", 300, nil},
+		{"CNAME", "_acme-challenge.example.net", 300, nil},
+		{"CNAME", "10.0.0.1", 300, api.ErrInvalidContent},
+		{"CNAME", "fd00::1", 300, api.ErrInvalidContent},
+		{"CNAME", "bad host.example.com", 300, api.ErrInvalidContent},
+		{"NS", "ns1.example.net", 300, nil},
+		{"NS", "_ns1.example.net", 300, api.ErrInvalidContent},
+		{"PTR", "host.example.com", 300, nil},
+		{"PTR", "10.0.0.1", 300, api.ErrInvalidContent},
+		{"MX", "10 mail.example.com", 300, nil},
+		{"MX", "mail.example.com", 300, nil},
+		{"MX", "0 .", 300, nil},
+		{"MX", "10 10.0.0.1", 300, api.ErrInvalidContent},
+		{"MX", "70000 mail.example.com", 300, api.ErrInvalidContent},
+		{"MX", "10 mail.example.com extra", 300, api.ErrInvalidContent},
+		{"SRV", "10 5 443 sip.example.com", 300, nil},
+		{"SRV", "10 5 70000 sip.example.com", 300, api.ErrInvalidContent},
+		{"SRV", "10 5 sip.example.com", 300, api.ErrInvalidContent},
+		{"TXT", "v=spf1 -all", 300, nil},
+		{"TXT", "", 300, nil},
+		{"TXT", strings.Repeat("a", 65000), 300, nil},
+		{"TXT", strings.Repeat("a", 66000), 300, api.ErrInvalidContent},
+		{"CAA", "0 issue \"letsencrypt.org\"", 300, nil},
+	}
+	for _, test := range tests {
+		rec := api.Record{Name: "www.example.com", Type: test.rtype, Content: []string{test.content}, TTL: test.ttl}
+		err := api.ValidateRecord(rec)
+		desc := test.rtype + " " + test.content
+		if test.err == nil {
+			require.Nil(t, err, desc)
+			continue
+		}
+		require.ErrorIs(t, err, test.err, desc)
+		verr := &api.ValidationError{}
+		require.True(t, errors.As(err, &verr), desc)
+		require.Equal(t, strings.ToUpper(test.rtype), verr.Type)
+		require.Equal(t, "www.example.com", verr.Name)
+	}
+
+	// every value is checked
+	err := api.ValidateRecord(api.Record{Name: "www.example.com", Type: "A", Content: []string{"10.0.0.1", "10.0.0"}})
+	require.ErrorIs(t, err, api.ErrInvalidContent)
+	require.Contains(t, err.Error(), `A record www.example.com content "10.0.0" is not an IPv4 address`)
+
+	// providers check content before any backend call
+	ctx := context.Background()
+	prov := NewMockProvider("example.com")
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "www.example.com", "CNAME", "10.0.0.1", 300, false)
+	require.ErrorIs(t, err, api.ErrInvalidContent)
+	require.Empty(t, prov.Dump("example.com"))
+}
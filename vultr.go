@@ -0,0 +1,395 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/vultr/govultr/v2"
+	"golang.org/x/oauth2"
+)
+
+// vultrPerPage is the largest page size the Vultr API allows.
+const vultrPerPage = 500
+
+// Vultr manages DNS records via the Vultr v2 API. Vultr stores record
+// names relative to the domain, with an empty name for the apex, and
+// keeps the priority of MX and SRV records in a separate field.
+type Vultr struct {
+	api    *govultr.Client
+	logger api.Logger
+	zone   string // zone the provider was configured for, if any
+}
+
+// vultrError is the error body of a failed Vultr call. govultr
+// returns the body as the error text, which loses the status code, so
+// it is parsed back out.
+type vultrError struct {
+	Message string `json:"error"`
+	Status  int    `json:"status"`
+}
+
+func (s *vultrError) Error() string {
+	return fmt.Sprintf("vultr error %d: %s", s.Status, s.Message)
+}
+
+// vultrAPIError converts an error returned by govultr into a
+// *vultrError if it holds an error body.
+func vultrAPIError(err error) error {
+	if err == nil {
+		return nil
+	}
+	verr := vultrError{}
+	if json.Unmarshal([]byte(err.Error()), &verr) == nil && verr.Status != 0 {
+		return &verr
+	}
+	return err
+}
+
+func isVultrStatus(err error, code int) bool {
+	var verr *vultrError
+	return errors.As(err, &verr) && verr.Status == code
+}
+
+// NewVultrProvider creates a new Vultr DNS provider.
+func NewVultrProvider(ctx context.Context, zone string, credentialsData map[string]string, logger api.Logger, ops ...Option) (*Vultr, error) {
+	return NewVultrProviderWithCredentials(ctx, zone, vultrCredentialsFromMap(credentialsData), logger, ops...)
+}
+
+// NewVultrProviderWithCredentials creates a new Vultr DNS provider
+// from typed credentials.
+func NewVultrProviderWithCredentials(ctx context.Context, zone string, creds VultrCredentials, logger api.Logger, ops ...Option) (*Vultr, error) {
+	logger = defaultLogger(logger)
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	opts := getOptions(ops)
+	// oauth2 uses the client in the context as its base transport
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, opts.httpClient())
+	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: creds.APIKey})
+	client := govultr.NewClient(oauth2.NewClient(ctx, tokenSource))
+	return &Vultr{
+		api:    client,
+		logger: logger,
+		zone:   zone,
+	}, nil
+}
+
+// Close is a no-op, since connections belong to the shared or caller
+// supplied HTTP client.
+func (s *Vultr) Close() error {
+	return nil
+}
+
+// ValidateCredentials reads the account the API key belongs to.
+func (s *Vultr) ValidateCredentials(ctx context.Context) error {
+	_, err := s.api.Account.Get(ctx)
+	return credentialsError(vultrAPIError(err))
+}
+
+// ListZones returns the domains of the account. Vultr identifies
+// domains by name.
+func (s *Vultr) ListZones(ctx context.Context) ([]api.Zone, error) {
+	zones := []api.Zone{}
+	opts := &govultr.ListOptions{PerPage: vultrPerPage}
+	for {
+		domains, meta, err := s.api.Domain.List(ctx, opts)
+		if err != nil {
+			return nil, vultrAPIError(err)
+		}
+		for _, domain := range domains {
+			zones = append(zones, newZone(domain.Domain, ""))
+		}
+		if meta == nil || meta.Links == nil || meta.Links.Next == "" {
+			break
+		}
+		opts.Cursor = meta.Links.Next
+	}
+	return listedZones(s.zone, zones)
+}
+
+// CreateZone adds the domain to the account, without any default
+// records.
+func (s *Vultr) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	domain, err := s.api.Domain.Create(ctx, &govultr.DomainReq{
+		Domain: strings.TrimSuffix(zone, "."),
+	})
+	if err != nil {
+		return api.Zone{}, fmt.Errorf("cannot create domain %s, %v", zone, vultrAPIError(err))
+	}
+	return newZone(domain.Domain, ""), nil
+}
+
+// DeleteZone deletes the domain and all its records.
+func (s *Vultr) DeleteZone(ctx context.Context, zone string) error {
+	err := vultrAPIError(s.api.Domain.Delete(ctx, strings.TrimSuffix(zone, ".")))
+	if isVultrStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete domain %s, %v", zone, err)
+	}
+	return nil
+}
+
+// listRecords returns all records in the zone, following the page
+// cursors.
+func (s *Vultr) listRecords(ctx context.Context, zone string) ([]govultr.DomainRecord, error) {
+	records := []govultr.DomainRecord{}
+	opts := &govultr.ListOptions{PerPage: vultrPerPage}
+	for {
+		page, meta, err := s.api.DomainRecord.List(ctx, strings.TrimSuffix(zone, "."), opts)
+		err = vultrAPIError(err)
+		if isVultrStatus(err, http.StatusNotFound) {
+			return nil, fmt.Errorf("%w for %s", api.ErrZoneNotFound, zone)
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, page...)
+		if meta == nil || meta.Links == nil || meta.Links.Next == "" {
+			return records, nil
+		}
+		opts.Cursor = meta.Links.Next
+	}
+}
+
+// vultrName returns the name relative to the zone as Vultr stores it,
+// with an empty name for the apex.
+func vultrName(name, zone string) string {
+	relName := relativeName(name, zone)
+	if relName == apexName {
+		return ""
+	}
+	return relName
+}
+
+func vultrRecordToRecord(vrec govultr.DomainRecord, zone string) api.Record {
+	recName := absoluteName(vrec.Name, zone)
+	record := api.Record{
+		Type:    vrec.Type,
+		Name:    recName,
+		Content: []string{vrec.Data},
+		TTL:     vrec.TTL,
+		System:  isSystemRecord(zone, recName, vrec.Type),
+	}
+	switch vrec.Type {
+	case api.RecordTypeMX:
+		record.Priority = vrec.Priority
+	case api.RecordTypeSRV:
+		// the data of SRV records is "weight port target"
+		record.Priority = vrec.Priority
+		if values, target, err := parseUint16Fields(api.RecordTypeSRV, vrec.Data, "weight port target", 2); err == nil {
+			record.Weight = values[0]
+			record.Port = values[1]
+			record.Content = []string{target}
+		}
+	case api.RecordTypeTXT:
+		record.Content = []string{parseTXTRRData(vrec.Data)}
+	}
+	return canonicalTargets(withUnicodeName(record))
+}
+
+// GetDNSRecords returns a list of DNS records for the zone.
+// If name is provided, that is used as a filter.
+func (s *Vultr) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	vrecords, err := s.listRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	records := []api.Record{}
+	for _, vrec := range vrecords {
+		record := vultrRecordToRecord(vrec, zone)
+		if name != "" && !strings.EqualFold(name, record.Name) {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// SupportedRecordTypes returns the record types that can be created.
+func (s *Vultr) SupportedRecordTypes() []string {
+	return slices.Clone(vultrRecordTypes)
+}
+
+// Capabilities reports that Vultr can manage zones.
+func (s *Vultr) Capabilities() api.Capabilities {
+	return api.Capabilities{
+		SupportedRecordTypes:   s.SupportedRecordTypes(),
+		SupportsZoneManagement: true,
+	}
+}
+
+// vultrData splits content as passed to CreateOrUpdateDNSRecord into
+// the data and priority fields of a Vultr record. The priority is nil
+// for types that have none.
+func vultrData(rtype, content string) (string, *int, error) {
+	switch rtype {
+	case api.RecordTypeMX:
+		priority, target, err := parseMXContent(content)
+		if err != nil {
+			return "", nil, err
+		}
+		return strings.TrimSuffix(target, "."), &priority, nil
+	case api.RecordTypeSRV:
+		priority, weight, port, target, err := parseSRVContent(content)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("%d %d %s", weight, port, strings.TrimSuffix(target, ".")), &priority, nil
+	case api.RecordTypeTXT:
+		return txtRRData(content), nil, nil
+	case api.RecordTypeCNAME, api.RecordTypeNS:
+		return strings.TrimSuffix(content, "."), nil, nil
+	}
+	return content, nil, nil
+}
+
+// CreateOrUpdateDNSRecord changes the existing record of the name and
+// type if found, or adds a new one. If there are several records, the
+// one already holding the content is kept, or else the first, and the
+// others are deleted.
+func (s *Vultr) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	if err := checkProxy(api.VultrProvider, proxy); err != nil {
+		return err
+	}
+	if err := checkRecord(name, rtype, vultrRecordTypes); err != nil {
+		return err
+	}
+	if err := validateContent(name, rtype, ttl, content); err != nil {
+		return err
+	}
+	rtype = strings.ToUpper(rtype)
+	data, priority, err := vultrData(rtype, content)
+	if err != nil {
+		return err
+	}
+	vrecords, err := s.listRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+	domain := strings.TrimSuffix(zone, ".")
+	relName := vultrName(name, zone)
+	req := govultr.DomainRecordReq{
+		Name:     relName,
+		Type:     rtype,
+		Data:     data,
+		TTL:      ttl,
+		Priority: priority,
+	}
+
+	existing := []govultr.DomainRecord{}
+	keep := -1
+	for _, r := range vrecords {
+		if r.Type != rtype || !strings.EqualFold(r.Name, relName) {
+			continue
+		}
+		if keep < 0 && r.Data == data {
+			keep = len(existing)
+		}
+		existing = append(existing, r)
+	}
+	if len(existing) == 0 {
+		if _, err := s.api.DomainRecord.Create(ctx, domain, &req); err != nil {
+			err = vultrAPIError(err)
+			s.logger.ErrorContext(ctx, "CreateOrUpdateDNSRecord failed", "zone", zone, "name", name, "err", err)
+			return fmt.Errorf("cannot create DNS record for zone %s, %v", zone, err)
+		}
+		return nil
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	r := existing[keep]
+	if r.Data == data && r.TTL == ttl && (priority == nil || r.Priority == *priority) {
+		s.logger.DebugContext(ctx, "CreateOrUpdateDNSRecord existing record matches", "name", name, "content", content)
+	} else {
+		s.logger.InfoContext(ctx, "CreateOrUpdateDNSRecord updating", "name", name, "content", content)
+		if err := s.api.DomainRecord.Update(ctx, domain, r.ID, &req); err != nil {
+			return fmt.Errorf("cannot update DNS record for zone %s name %s, %v", zone, name, vultrAPIError(err))
+		}
+	}
+	for ii, other := range existing {
+		if ii == keep {
+			continue
+		}
+		err := vultrAPIError(s.api.DomainRecord.Delete(ctx, domain, other.ID))
+		if err != nil && !isVultrStatus(err, http.StatusNotFound) {
+			return fmt.Errorf("delete DNS record %v failed, %v", other, err)
+		}
+	}
+	return nil
+}
+
+// DeleteDNSRecord deletes all DNS records for the name.
+func (s *Vultr) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	_, err := s.deleteRecords(ctx, zone, name, "")
+	return err
+}
+
+// DeleteDNSRecordCount deletes all DNS records for the name and
+// returns the number deleted.
+func (s *Vultr) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	return s.deleteRecords(ctx, zone, name, "")
+}
+
+// DeleteDNSRecordByType deletes only the DNS records of the given
+// type for the name.
+func (s *Vultr) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	if rtype == "" {
+		return fmt.Errorf("no record type specified to delete")
+	}
+	_, err := s.deleteRecords(ctx, zone, name, rtype)
+	return err
+}
+
+func (s *Vultr) deleteRecords(ctx context.Context, zone, name, rtype string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("no name specified to delete")
+	}
+	vrecords, err := s.listRecords(ctx, zone)
+	if err != nil {
+		return 0, err
+	}
+	domain := strings.TrimSuffix(zone, ".")
+	relName := vultrName(name, zone)
+	deleted := 0
+	for _, rec := range vrecords {
+		if !strings.EqualFold(rec.Name, relName) {
+			continue
+		}
+		if rtype != "" && !strings.EqualFold(rec.Type, rtype) {
+			continue
+		}
+		err := vultrAPIError(s.api.DomainRecord.Delete(ctx, domain, rec.ID))
+		if isVultrStatus(err, http.StatusNotFound) {
+			// already deleted by someone else
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("delete DNS record %v failed, %v", rec, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
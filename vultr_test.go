@@ -0,0 +1,235 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+	"github.com/vultr/govultr/v2"
+)
+
+// vultrStub is a minimal in-memory implementation of the Vultr
+// domains API. Record listings are split into pages of pageSize
+// records, linked by cursors.
+type vultrStub struct {
+	mu       sync.Mutex
+	domains  map[string][]govultr.DomainRecord
+	nextID   int
+	pageSize int
+	lists    int // number of record pages listed
+}
+
+func newVultrStub(domains ...string) *vultrStub {
+	s := &vultrStub{domains: map[string][]govultr.DomainRecord{}, pageSize: 100}
+	for _, domain := range domains {
+		s.domains[domain] = []govultr.DomainRecord{}
+	}
+	return s
+}
+
+func (s *vultrStub) error(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": message, "status": status})
+}
+
+func (s *vultrStub) setRecord(rec *govultr.DomainRecord, r *http.Request) {
+	req := govultr.DomainRecordReq{}
+	json.NewDecoder(r.Body).Decode(&req)
+	rec.Name = req.Name
+	if req.Type != "" {
+		rec.Type = req.Type
+	}
+	rec.Data = req.Data
+	rec.TTL = req.TTL
+	if req.Priority != nil {
+		rec.Priority = *req.Priority
+	}
+}
+
+func (s *vultrStub) find(domain, id string) int {
+	for ii, rec := range s.domains[domain] {
+		if rec.ID == id {
+			return ii
+		}
+	}
+	return -1
+}
+
+func (s *vultrStub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/account", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"account": {"email": "test@example.com"}}`))
+	})
+	mux.HandleFunc("GET /v2/domains", func(w http.ResponseWriter, r *http.Request) {
+		domains := []govultr.Domain{}
+		for name := range s.domains {
+			domains = append(domains, govultr.Domain{Domain: name})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"domains": domains})
+	})
+	mux.HandleFunc("GET /v2/domains/{domain}/records", func(w http.ResponseWriter, r *http.Request) {
+		records, ok := s.domains[r.PathValue("domain")]
+		if !ok {
+			s.error(w, http.StatusNotFound, "domain not found")
+			return
+		}
+		s.lists++
+		start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		end := min(start+s.pageSize, len(records))
+		next := ""
+		if end < len(records) {
+			next = strconv.Itoa(end)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"records": records[start:end],
+			"meta": map[string]interface{}{
+				"total": len(records),
+				"links": map[string]string{"next": next},
+			},
+		})
+	})
+	mux.HandleFunc("POST /v2/domains/{domain}/records", func(w http.ResponseWriter, r *http.Request) {
+		domain := r.PathValue("domain")
+		if _, ok := s.domains[domain]; !ok {
+			s.error(w, http.StatusNotFound, "domain not found")
+			return
+		}
+		s.nextID++
+		rec := govultr.DomainRecord{ID: "rec-" + strconv.Itoa(s.nextID)}
+		s.setRecord(&rec, r)
+		s.domains[domain] = append(s.domains[domain], rec)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"record": rec})
+	})
+	mux.HandleFunc("PATCH /v2/domains/{domain}/records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		domain := r.PathValue("domain")
+		ii := s.find(domain, r.PathValue("id"))
+		if ii < 0 {
+			s.error(w, http.StatusNotFound, "record not found")
+			return
+		}
+		s.setRecord(&s.domains[domain][ii], r)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /v2/domains/{domain}/records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		domain := r.PathValue("domain")
+		ii := s.find(domain, r.PathValue("id"))
+		if ii < 0 {
+			s.error(w, http.StatusNotFound, "record not found")
+			return
+		}
+		s.domains[domain] = append(s.domains[domain][:ii], s.domains[domain][ii+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer test" {
+			s.error(w, http.StatusUnauthorized, "Invalid API token.")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func newVultrTestProvider(t *testing.T, stub *vultrStub) api.Provider {
+	client := newStubClient(t, stub.handler())
+	prov, err := GetProvider(context.Background(), api.VultrProvider, "", VultrCredentials{APIKey: "test"}.ToMap(), nil, WithHTTPClient(client))
+	require.Nil(t, err)
+	return prov
+}
+
+func TestVultrStub(t *testing.T) {
+	ctx := context.Background()
+	stub := newVultrStub("example.com")
+	prov := newVultrTestProvider(t, stub)
+	ProviderTest(t, ctx, prov, "example.com")
+	zoneNotFoundTest(t, ctx, prov)
+	wildcardTest(t, ctx, prov, "example.com", "example.com", "*.example.com")
+	duplicateRecordsTest(t, ctx, prov, "example.com", "dup.example.com", func(content string) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.nextID++
+		stub.domains["example.com"] = append(stub.domains["example.com"], govultr.DomainRecord{ID: "rec-" + strconv.Itoa(stub.nextID), Type: "A", Name: "dup", Data: content, TTL: 300})
+	})
+
+	// the apex has an empty name, and priorities have their own field
+	err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", "example.com", "MX", "10 mail.example.com.", 300, false)
+	require.Nil(t, err)
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "_sip._tcp.example.com", "SRV", "10 20 5060 sip.example.com", 300, false)
+	require.Nil(t, err)
+	records := stub.domains["example.com"]
+	require.Equal(t, "", records[len(records)-2].Name)
+	require.Equal(t, "mail.example.com", records[len(records)-2].Data)
+	require.Equal(t, 10, records[len(records)-2].Priority)
+	require.Equal(t, "20 5060 sip.example.com", records[len(records)-1].Data)
+
+	got, err := prov.GetDNSRecords(ctx, "example.com", "_sip._tcp.example.com")
+	require.Nil(t, err)
+	require.Equal(t, []api.Record{{
+		Name:     "_sip._tcp.example.com",
+		Type:     "SRV",
+		Content:  []string{"sip.example.com."},
+		TTL:      300,
+		Priority: 10,
+		Weight:   20,
+		Port:     5060,
+	}}, got)
+
+	zones, err := prov.ListZones(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []api.Zone{{Name: "example.com", ID: "example.com"}}, zones)
+}
+
+func TestVultrPaging(t *testing.T) {
+	ctx := context.Background()
+	stub := newVultrStub("example.com")
+	stub.pageSize = 2
+	prov := newVultrTestProvider(t, stub)
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", name+".example.com", "A", "10.0.0.1", 300, false)
+		require.Nil(t, err)
+	}
+	stub.lists = 0
+	records, err := prov.GetDNSRecords(ctx, "example.com", "")
+	require.Nil(t, err)
+	require.Equal(t, 5, len(records))
+	require.Equal(t, 3, stub.lists)
+
+	// records on later pages are found for updates
+	err = prov.CreateOrUpdateDNSRecord(ctx, "example.com", "e.example.com", "A", "10.0.0.2", 300, false)
+	require.Nil(t, err)
+	require.Equal(t, 5, len(stub.domains["example.com"]))
+	require.Equal(t, "10.0.0.2", stub.domains["example.com"][4].Data)
+}
+
+func TestVultrCredentials(t *testing.T) {
+	ctx := context.Background()
+	client := newStubClient(t, newVultrStub("example.com").handler())
+
+	err := ValidateCredentials(ctx, api.VultrProvider, VultrCredentials{APIKey: "test"}.ToMap(), WithHTTPClient(client))
+	require.Nil(t, err)
+	err = ValidateCredentials(ctx, api.VultrProvider, VultrCredentials{APIKey: "wrong"}.ToMap(), WithHTTPClient(client))
+	require.ErrorIs(t, err, api.ErrInvalidCredentials)
+}
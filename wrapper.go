@@ -0,0 +1,314 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+// operationFunc runs one operation of a wrapped provider, as set up
+// by the call counting, timeout and tracing options. It is given the
+// name of the operation and the zone, record name and type it applies
+// to, each empty if not applicable, and calls fn with the context to
+// pass to the wrapped provider.
+type operationFunc func(ctx context.Context, op, zone, name, rtype string, fn func(ctx context.Context) error) error
+
+// wrappedProvider runs each operation of the provider it wraps
+// through around. It implements every optional api interface, so
+// wrapping does not hide the ones the wrapped provider implements.
+// Operations it does not implement fall back as the package helper
+// of the same name does, or fail with an error wrapping
+// api.ErrUnsupported, so Capabilities rather than a type assertion
+// tells what the backend supports.
+type wrappedProvider struct {
+	api.Provider
+	around operationFunc
+}
+
+var (
+	_ api.CredentialValidator = (*wrappedProvider)(nil)
+	_ api.ZoneManager         = (*wrappedProvider)(nil)
+	_ api.RecordTypeLister    = (*wrappedProvider)(nil)
+	_ api.ChangeTracker       = (*wrappedProvider)(nil)
+	_ api.BatchUpdater        = (*wrappedProvider)(nil)
+	_ api.RecordResultWriter  = (*wrappedProvider)(nil)
+	_ api.RecordIDManager     = (*wrappedProvider)(nil)
+	_ api.RecordSetUpdater    = (*wrappedProvider)(nil)
+	_ api.DeleteCounter       = (*wrappedProvider)(nil)
+	_ api.TTLUpdater          = (*wrappedProvider)(nil)
+	_ api.DNSSECStatusReader  = (*wrappedProvider)(nil)
+	_ api.DNSSECManager       = (*wrappedProvider)(nil)
+	_ api.ZoneInfoReader      = (*wrappedProvider)(nil)
+	_ api.RecordPager         = (*wrappedProvider)(nil)
+)
+
+func (s *wrappedProvider) GetDNSRecords(ctx context.Context, zone, name string) ([]api.Record, error) {
+	var records []api.Record
+	err := s.around(ctx, "GetDNSRecords", zone, name, "", func(ctx context.Context) (err error) {
+		records, err = s.Provider.GetDNSRecords(ctx, zone, name)
+		return err
+	})
+	return records, err
+}
+
+func (s *wrappedProvider) CreateOrUpdateDNSRecord(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) error {
+	return s.around(ctx, "CreateOrUpdateDNSRecord", zone, name, rtype, func(ctx context.Context) error {
+		return s.Provider.CreateOrUpdateDNSRecord(ctx, zone, name, rtype, content, ttl, proxy)
+	})
+}
+
+func (s *wrappedProvider) DeleteDNSRecord(ctx context.Context, zone, name string) error {
+	return s.around(ctx, "DeleteDNSRecord", zone, name, "", func(ctx context.Context) error {
+		return s.Provider.DeleteDNSRecord(ctx, zone, name)
+	})
+}
+
+func (s *wrappedProvider) DeleteDNSRecordByType(ctx context.Context, zone, name, rtype string) error {
+	return s.around(ctx, "DeleteDNSRecordByType", zone, name, rtype, func(ctx context.Context) error {
+		return s.Provider.DeleteDNSRecordByType(ctx, zone, name, rtype)
+	})
+}
+
+func (s *wrappedProvider) ListZones(ctx context.Context) ([]api.Zone, error) {
+	var zones []api.Zone
+	err := s.around(ctx, "ListZones", "", "", "", func(ctx context.Context) (err error) {
+		zones, err = s.Provider.ListZones(ctx)
+		return err
+	})
+	return zones, err
+}
+
+// ValidateCredentials lists the zones if the wrapped provider cannot
+// validate its credentials itself, as ValidateCredentials does.
+func (s *wrappedProvider) ValidateCredentials(ctx context.Context) error {
+	return s.around(ctx, "ValidateCredentials", "", "", "", func(ctx context.Context) error {
+		if validator, ok := s.Provider.(api.CredentialValidator); ok {
+			return validator.ValidateCredentials(ctx)
+		}
+		_, err := s.Provider.ListZones(ctx)
+		return credentialsError(err)
+	})
+}
+
+func (s *wrappedProvider) CreateZone(ctx context.Context, zone string) (api.Zone, error) {
+	var created api.Zone
+	err := s.around(ctx, "CreateZone", zone, "", "", func(ctx context.Context) (err error) {
+		manager, ok := s.Provider.(api.ZoneManager)
+		if !ok {
+			return fmt.Errorf("%w, cannot create zone %s", api.ErrUnsupported, zone)
+		}
+		created, err = manager.CreateZone(ctx, zone)
+		return err
+	})
+	return created, err
+}
+
+func (s *wrappedProvider) DeleteZone(ctx context.Context, zone string) error {
+	return s.around(ctx, "DeleteZone", zone, "", "", func(ctx context.Context) error {
+		manager, ok := s.Provider.(api.ZoneManager)
+		if !ok {
+			return fmt.Errorf("%w, cannot delete zone %s", api.ErrUnsupported, zone)
+		}
+		return manager.DeleteZone(ctx, zone)
+	})
+}
+
+// SupportedRecordTypes returns the record types reported by
+// Capabilities if the wrapped provider does not list them itself.
+func (s *wrappedProvider) SupportedRecordTypes() []string {
+	if lister, ok := s.Provider.(api.RecordTypeLister); ok {
+		return lister.SupportedRecordTypes()
+	}
+	return s.Provider.Capabilities().SupportedRecordTypes
+}
+
+// CreateOrUpdateDNSRecordChange returns a nil handle if the wrapped
+// provider applies its changes immediately.
+func (s *wrappedProvider) CreateOrUpdateDNSRecordChange(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) (*api.ChangeHandle, error) {
+	var handle *api.ChangeHandle
+	err := s.around(ctx, "CreateOrUpdateDNSRecordChange", zone, name, rtype, func(ctx context.Context) (err error) {
+		if tracker, ok := s.Provider.(api.ChangeTracker); ok {
+			handle, err = tracker.CreateOrUpdateDNSRecordChange(ctx, zone, name, rtype, content, ttl, proxy)
+			return err
+		}
+		return s.Provider.CreateOrUpdateDNSRecord(ctx, zone, name, rtype, content, ttl, proxy)
+	})
+	return handle, err
+}
+
+// DeleteDNSRecordChange returns a nil handle if the wrapped provider
+// applies its changes immediately.
+func (s *wrappedProvider) DeleteDNSRecordChange(ctx context.Context, zone, name string) (*api.ChangeHandle, error) {
+	var handle *api.ChangeHandle
+	err := s.around(ctx, "DeleteDNSRecordChange", zone, name, "", func(ctx context.Context) (err error) {
+		if tracker, ok := s.Provider.(api.ChangeTracker); ok {
+			handle, err = tracker.DeleteDNSRecordChange(ctx, zone, name)
+			return err
+		}
+		return s.Provider.DeleteDNSRecord(ctx, zone, name)
+	})
+	return handle, err
+}
+
+// WaitForChange returns straight away for a nil handle if the wrapped
+// provider applies its changes immediately.
+func (s *wrappedProvider) WaitForChange(ctx context.Context, handle *api.ChangeHandle) error {
+	zone := ""
+	if handle != nil {
+		zone = handle.Zone
+	}
+	return s.around(ctx, "WaitForChange", zone, "", "", func(ctx context.Context) error {
+		if tracker, ok := s.Provider.(api.ChangeTracker); ok {
+			return tracker.WaitForChange(ctx, handle)
+		}
+		if handle != nil {
+			return fmt.Errorf("%w, cannot wait for change %s of zone %s", api.ErrUnsupported, handle.ID, handle.Zone)
+		}
+		return nil
+	})
+}
+
+func (s *wrappedProvider) BatchCreateOrUpdateDNSRecords(ctx context.Context, zone string, records []api.Record) error {
+	return s.around(ctx, "BatchCreateOrUpdateDNSRecords", zone, "", "", func(ctx context.Context) error {
+		return BatchCreateOrUpdateDNSRecords(ctx, s.Provider, zone, records)
+	})
+}
+
+func (s *wrappedProvider) CreateOrUpdateDNSRecordResult(ctx context.Context, zone, name, rtype, content string, ttl int, proxy bool) (api.Record, error) {
+	var record api.Record
+	err := s.around(ctx, "CreateOrUpdateDNSRecordResult", zone, name, rtype, func(ctx context.Context) (err error) {
+		record, err = CreateOrUpdateDNSRecordResult(ctx, s.Provider, zone, name, rtype, content, ttl, proxy)
+		return err
+	})
+	return record, err
+}
+
+func (s *wrappedProvider) UpdateDNSRecordByID(ctx context.Context, zone, id, content string, ttl int) error {
+	return s.around(ctx, "UpdateDNSRecordByID", zone, "", "", func(ctx context.Context) error {
+		return UpdateDNSRecordByID(ctx, s.Provider, zone, id, content, ttl)
+	})
+}
+
+func (s *wrappedProvider) DeleteDNSRecordByID(ctx context.Context, zone, id string) error {
+	return s.around(ctx, "DeleteDNSRecordByID", zone, "", "", func(ctx context.Context) error {
+		return DeleteDNSRecordByID(ctx, s.Provider, zone, id)
+	})
+}
+
+// CreateOrUpdateDNSRecordSet sets a single value with
+// CreateOrUpdateDNSRecord if the wrapped provider cannot set several.
+func (s *wrappedProvider) CreateOrUpdateDNSRecordSet(ctx context.Context, zone, name, rtype string, contents []string, ttl int, proxy bool) error {
+	return s.around(ctx, "CreateOrUpdateDNSRecordSet", zone, name, rtype, func(ctx context.Context) error {
+		if setter, ok := s.Provider.(api.RecordSetUpdater); ok {
+			return setter.CreateOrUpdateDNSRecordSet(ctx, zone, name, rtype, contents, ttl, proxy)
+		}
+		switch len(contents) {
+		case 0:
+			return fmt.Errorf("no content specified for %s record %s", rtype, name)
+		case 1:
+			return s.Provider.CreateOrUpdateDNSRecord(ctx, zone, name, rtype, contents[0], ttl, proxy)
+		}
+		return fmt.Errorf("provider cannot set multiple values %v", contents)
+	})
+}
+
+// DeleteDNSRecordCount counts the values read back before the delete
+// if the wrapped provider does not report them itself.
+func (s *wrappedProvider) DeleteDNSRecordCount(ctx context.Context, zone, name string) (int, error) {
+	deleted := 0
+	err := s.around(ctx, "DeleteDNSRecordCount", zone, name, "", func(ctx context.Context) (err error) {
+		if counter, ok := s.Provider.(api.DeleteCounter); ok {
+			deleted, err = counter.DeleteDNSRecordCount(ctx, zone, name)
+			return err
+		}
+		records, err := s.Provider.GetDNSRecords(ctx, zone, name)
+		if err != nil {
+			return err
+		}
+		if err := s.Provider.DeleteDNSRecord(ctx, zone, name); err != nil {
+			return err
+		}
+		for _, record := range records {
+			deleted += len(record.Content)
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+func (s *wrappedProvider) UpdateTTL(ctx context.Context, zone, name, rtype string, ttl int) error {
+	return s.around(ctx, "UpdateTTL", zone, name, rtype, func(ctx context.Context) error {
+		return UpdateTTL(ctx, s.Provider, zone, name, rtype, ttl)
+	})
+}
+
+func (s *wrappedProvider) GetDNSSECStatus(ctx context.Context, zone string) (api.DNSSECStatus, error) {
+	var status api.DNSSECStatus
+	err := s.around(ctx, "GetDNSSECStatus", zone, "", "", func(ctx context.Context) (err error) {
+		reader, ok := s.Provider.(api.DNSSECStatusReader)
+		if !ok {
+			return fmt.Errorf("%w, cannot get DNSSEC status for zone %s", api.ErrUnsupported, zone)
+		}
+		status, err = reader.GetDNSSECStatus(ctx, zone)
+		return err
+	})
+	return status, err
+}
+
+func (s *wrappedProvider) EnableDNSSEC(ctx context.Context, zone string) (api.DSRecord, error) {
+	var ds api.DSRecord
+	err := s.around(ctx, "EnableDNSSEC", zone, "", "", func(ctx context.Context) (err error) {
+		ds, err = EnableDNSSEC(ctx, s.Provider, zone)
+		return err
+	})
+	return ds, err
+}
+
+func (s *wrappedProvider) DisableDNSSEC(ctx context.Context, zone string) error {
+	return s.around(ctx, "DisableDNSSEC", zone, "", "", func(ctx context.Context) error {
+		return DisableDNSSEC(ctx, s.Provider, zone)
+	})
+}
+
+func (s *wrappedProvider) GetZone(ctx context.Context, zone string) (api.ZoneInfo, error) {
+	var info api.ZoneInfo
+	err := s.around(ctx, "GetZone", zone, "", "", func(ctx context.Context) (err error) {
+		reader, ok := s.Provider.(api.ZoneInfoReader)
+		if !ok {
+			return fmt.Errorf("%w, cannot get zone %s", api.ErrUnsupported, zone)
+		}
+		info, err = reader.GetZone(ctx, zone)
+		return err
+	})
+	return info, err
+}
+
+// ListDNSRecordPages passes the whole zone as one page if the wrapped
+// provider cannot list it a page at a time.
+func (s *wrappedProvider) ListDNSRecordPages(ctx context.Context, zone string, fn func([]api.Record) error) error {
+	return s.around(ctx, "ListDNSRecordPages", zone, "", "", func(ctx context.Context) error {
+		if pager, ok := s.Provider.(api.RecordPager); ok {
+			return pager.ListDNSRecordPages(ctx, zone, fn)
+		}
+		records, err := s.Provider.GetDNSRecords(ctx, zone, "")
+		if err != nil {
+			return err
+		}
+		return fn(records)
+	})
+}
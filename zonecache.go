@@ -0,0 +1,167 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+const (
+	zoneCacheSize      = 1024
+	sharedZoneCacheTTL = 10 * time.Minute
+)
+
+// sharedZoneCache is the zone ID cache shared by all provider
+// instances created with WithSharedZoneCache.
+var sharedZoneCache = newZoneIDCache(zoneCacheSize, sharedZoneCacheTTL)
+
+// zoneIDCache is a size bounded, thread-safe LRU cache of zone IDs.
+// Entries expire after the TTL so renamed or deleted zones are
+// eventually resolved again. A nil cache caches nothing.
+type zoneIDCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+}
+
+type zoneIDEntry struct {
+	key     string
+	id      string
+	expires time.Time
+}
+
+func newZoneIDCache(size int, ttl time.Duration) *zoneIDCache {
+	return &zoneIDCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// zoneCacheKey builds a cache key from the provider type, the scope
+// zone IDs are unique within, such as a project or region, and the
+// zone name.
+func zoneCacheKey(parts ...string) string {
+	for ii := range parts {
+		parts[ii] = strings.ToLower(strings.TrimSuffix(parts[ii], "."))
+	}
+	return strings.Join(parts, "/")
+}
+
+func (c *zoneIDCache) get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*zoneIDEntry)
+	if c.now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return entry.id, true
+}
+
+func (c *zoneIDCache) remove(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+func (c *zoneIDCache) put(key, id string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*zoneIDEntry)
+		entry.id = id
+		entry.expires = expires
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&zoneIDEntry{
+		key:     key,
+		id:      id,
+		expires: expires,
+	})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*zoneIDEntry).key)
+	}
+}
+
+// withZoneID calls fn with the ID of the zone, taken from the cache
+// entry for the key or else found by lookup and cached. A cached ID is
+// stale if the zone has since been deleted and created again, so if fn
+// fails with a cached ID the entry is dropped and the zone is looked
+// up again. If the zone now has a different ID, fn is retried once
+// with it. If the zone is gone the lookup's error is returned, and
+// otherwise fn's error.
+func (c *zoneIDCache) withZoneID(ctx context.Context, key string, lookup func() (string, error), fn func(zoneID string) error) error {
+	id, cached := c.get(key)
+	if !cached {
+		var err error
+		id, err = lookup()
+		if err != nil {
+			return err
+		}
+		c.put(key, id)
+	}
+	err := fn(id)
+	if err == nil || !cached || ctx.Err() != nil {
+		return err
+	}
+	c.remove(key)
+	newID, lookupErr := lookup()
+	if lookupErr != nil {
+		if errors.Is(lookupErr, api.ErrZoneNotFound) {
+			return lookupErr
+		}
+		return err
+	}
+	c.put(key, newID)
+	if newID == id {
+		return err
+	}
+	return fn(newID)
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZoneIDCache(t *testing.T) {
+	now := time.Now()
+	cache := newZoneIDCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.put("a", "1")
+	cache.put("b", "2")
+	// a is now the most recently used, so b is evicted
+	_, ok := cache.get("a")
+	require.True(t, ok)
+	cache.put("c", "3")
+	_, ok = cache.get("b")
+	require.False(t, ok)
+	id, ok := cache.get("c")
+	require.True(t, ok)
+	require.Equal(t, "3", id)
+
+	// entries expire after the TTL
+	now = now.Add(2 * time.Minute)
+	_, ok = cache.get("a")
+	require.False(t, ok)
+	require.Equal(t, 1, cache.order.Len())
+
+	// a nil cache caches nothing
+	var disabled *zoneIDCache
+	disabled.put("a", "1")
+	_, ok = disabled.get("a")
+	require.False(t, ok)
+}
+
+func TestZoneIDCacheConcurrent(t *testing.T) {
+	cache := newZoneIDCache(16, time.Minute)
+	wg := sync.WaitGroup{}
+	for ii := 0; ii < 8; ii++ {
+		wg.Add(1)
+		go func(ii int) {
+			defer wg.Done()
+			for jj := 0; jj < 100; jj++ {
+				key := strconv.Itoa((ii + jj) % 32)
+				cache.put(key, key)
+				if id, ok := cache.get(key); ok {
+					require.Equal(t, key, id)
+				}
+			}
+		}(ii)
+	}
+	wg.Wait()
+	require.LessOrEqual(t, cache.order.Len(), 16)
+	require.Equal(t, cache.order.Len(), len(cache.entries))
+}
+
+func TestSharedZoneCache(t *testing.T) {
+	ctx := context.Background()
+	zone := "shared-cache.example.com"
+	stub := &hetznerStub{
+		zones: []hetznerZone{{ID: "z1", Name: zone}},
+	}
+	var zoneLookups atomic.Int32
+	client := newStubClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/zones" {
+			zoneLookups.Add(1)
+		}
+		stub.handler().ServeHTTP(w, r)
+	}))
+	creds := map[string]string{"token": "test"}
+
+	// instances without the option resolve the zone themselves
+	for ii := 0; ii < 2; ii++ {
+		prov, err := GetProvider(ctx, api.HetznerProvider, zone, creds, nil, WithHTTPClient(client))
+		require.Nil(t, err)
+		_, err = prov.GetDNSRecords(ctx, zone, "")
+		require.Nil(t, err)
+	}
+	require.Equal(t, int32(2), zoneLookups.Load())
+
+	// a second instance sharing the cache reuses the zone ID
+	for ii := 0; ii < 2; ii++ {
+		prov, err := GetProvider(ctx, api.HetznerProvider, zone, creds, nil, WithHTTPClient(client), WithSharedZoneCache())
+		require.Nil(t, err)
+		_, err = prov.GetDNSRecords(ctx, zone, "")
+		require.Nil(t, err)
+	}
+	require.Equal(t, int32(3), zoneLookups.Load())
+}
+
+func TestZoneCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	cfStub := newCFStub("example.com")
+	cf := newCFTestProvider(t, cfStub)
+	cf.zoneCache = getOptions([]Option{WithZoneCache(time.Minute)}).zoneCache
+	cf.zoneCache.now = func() time.Time { return now }
+	// zone lookups list the zones by name
+	cfLookups := func() int {
+		return cfStub.count(http.MethodGet, "/zones") - cfStub.count(http.MethodGet, "/zones/")
+	}
+
+	otcStub := newOTCStub("example.com.")
+	otc := newOTCTestProvider(t, otcStub)
+	otc.zoneCache = getOptions([]Option{WithZoneCache(time.Minute)}).zoneCache
+	otc.zoneCache.now = func() time.Time { return now }
+	otcLookups := func() int {
+		return otcStub.count(http.MethodGet, "/v2/zones") - otcStub.count(http.MethodGet, "/v2/zones/")
+	}
+
+	// recreating a zone gives it a new ID
+	cfRecreate := func() {
+		cfStub.mu.Lock()
+		defer cfStub.mu.Unlock()
+		cfStub.zones["example.com"] = "zone2"
+	}
+	otcRecreate := func() {
+		otcStub.mu.Lock()
+		defer otcStub.mu.Unlock()
+		otcStub.zones[0].ID = "zone2"
+	}
+
+	tests := []struct {
+		prov     api.Provider
+		zone     string
+		lookups  func() int
+		recreate func()
+	}{
+		{cf, "example.com", cfLookups, cfRecreate},
+		{otc, "example.com.", otcLookups, otcRecreate},
+	}
+	for _, test := range tests {
+		// the second operation reuses the cached zone ID
+		for ii := 0; ii < 2; ii++ {
+			_, err := test.prov.GetDNSRecords(ctx, test.zone, "")
+			require.Nil(t, err)
+		}
+		require.Equal(t, 1, test.lookups(), test.zone)
+	}
+
+	// expired entries are looked up again
+	now = now.Add(2 * time.Minute)
+	for _, test := range tests {
+		_, err := test.prov.GetDNSRecords(ctx, test.zone, "")
+		require.Nil(t, err)
+		require.Equal(t, 2, test.lookups(), test.zone)
+	}
+
+	// a stale cached ID is dropped and the zone looked up again
+	for _, test := range tests {
+		test.recreate()
+		err := test.prov.CreateOrUpdateDNSRecord(ctx, test.zone, "www", "A", "192.0.2.1", 300, false)
+		require.Nil(t, err, test.zone)
+		require.Equal(t, 3, test.lookups(), test.zone)
+		records, err := test.prov.GetDNSRecords(ctx, test.zone, "www")
+		require.Nil(t, err, test.zone)
+		require.Len(t, records, 1, test.zone)
+		require.Equal(t, 3, test.lookups(), test.zone)
+	}
+
+	// a zone that is gone is reported as not found
+	cfRemove := func() {
+		cfStub.mu.Lock()
+		defer cfStub.mu.Unlock()
+		delete(cfStub.zones, "example.com")
+	}
+	otcRemove := func() {
+		otcStub.mu.Lock()
+		defer otcStub.mu.Unlock()
+		otcStub.zones = nil
+	}
+	cfRemove()
+	otcRemove()
+	for _, test := range tests {
+		_, err := test.prov.GetDNSRecords(ctx, test.zone, "")
+		require.ErrorIs(t, err, api.ErrZoneNotFound, test.zone)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/miekg/dns"
+)
+
+// ExportZone returns all records in the zone as an RFC 1035 zone file,
+// for backups and migrations. Names are fully qualified, and each
+// value of a multi-valued record is written on its own line.
+func ExportZone(ctx context.Context, prov api.Provider, zone string) (string, error) {
+	records, err := prov.GetDNSRecords(ctx, zone, "")
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "$ORIGIN %s\n", dns.Fqdn(zone))
+	for _, record := range records {
+		for _, content := range record.Content {
+			rr, err := zoneFileRR(record, content)
+			if err != nil {
+				return "", fmt.Errorf("export of %s record %s failed, %v", record.Type, record.Name, err)
+			}
+			sb.WriteString(rr.String())
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String(), nil
+}
+
+// importOptions are the settings of ImportZone.
+type importOptions struct {
+	sync          bool
+	systemRecords bool
+}
+
+// ImportOption configures ImportZone.
+type ImportOption func(opts *importOptions)
+
+// WithImportSync deletes the records in the zone whose name and type
+// are not in the zone file, so the zone matches the file. By default
+// the import only adds and updates records.
+func WithImportSync() ImportOption {
+	return func(opts *importOptions) {
+		opts.sync = true
+	}
+}
+
+// WithImportSystemRecords also imports the NS records at the zone
+// apex, which are otherwise skipped, and left alone by WithImportSync,
+// since most providers manage them themselves. The SOA record is
+// always skipped as no provider allows it to be set.
+func WithImportSystemRecords() ImportOption {
+	return func(opts *importOptions) {
+		opts.systemRecords = true
+	}
+}
+
+// ImportZone parses an RFC 1035 zone file, as written by ExportZone,
+// and creates or updates its records in the zone. All values of a
+// name and type are set together with BatchCreateOrUpdateDNSRecords.
+// Relative names in the file are relative to the zone.
+func ImportZone(ctx context.Context, prov api.Provider, zone string, r io.Reader, ops ...ImportOption) error {
+	opts := importOptions{}
+	for _, op := range ops {
+		op(&opts)
+	}
+
+	records := []api.Record{}
+	index := map[string]int{}
+	zp := dns.NewZoneParser(r, dns.Fqdn(zone), "")
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		hdr := rr.Header()
+		name := strings.TrimSuffix(hdr.Name, ".")
+		rtype := dns.TypeToString[hdr.Rrtype]
+		if rtype == "SOA" || !opts.systemRecords && isSystemRecord(zone, name, rtype) {
+			continue
+		}
+		content := strings.TrimPrefix(rr.String(), hdr.String())
+		if rtype == api.RecordTypeTXT {
+			content = parseTXTRRData(content)
+		}
+		key := zoneFileKey(name, rtype)
+		if ii, ok := index[key]; ok {
+			records[ii].Content = append(records[ii].Content, content)
+			continue
+		}
+		index[key] = len(records)
+		records = append(records, api.Record{
+			Name:    name,
+			Type:    rtype,
+			Content: []string{content},
+			TTL:     int(hdr.Ttl),
+		})
+	}
+	if err := zp.Err(); err != nil {
+		return fmt.Errorf("invalid zone file, %v", err)
+	}
+
+	var existing []api.Record
+	if opts.sync {
+		// read the zone first so a missing zone fails before any
+		// change is made
+		var err error
+		existing, err = prov.GetDNSRecords(ctx, zone, "")
+		if err != nil {
+			return err
+		}
+	}
+	if len(records) > 0 {
+		if err := BatchCreateOrUpdateDNSRecords(ctx, prov, zone, records); err != nil {
+			return err
+		}
+	}
+	deleted := map[string]bool{}
+	for _, record := range existing {
+		key := zoneFileKey(record.Name, record.Type)
+		if _, ok := index[key]; ok || deleted[key] {
+			continue
+		}
+		if record.Type == "SOA" || !opts.systemRecords && (record.System || isSystemRecord(zone, record.Name, record.Type)) {
+			continue
+		}
+		if err := prov.DeleteDNSRecordByType(ctx, zone, record.Name, record.Type); err != nil {
+			return fmt.Errorf("delete of %s record %s failed, %v", record.Type, record.Name, err)
+		}
+		deleted[key] = true
+	}
+	return nil
+}
+
+// zoneFileKey identifies the record set of a name and type.
+func zoneFileKey(name, rtype string) string {
+	return strings.ToLower(strings.TrimSuffix(name, ".")) + "/" + rtype
+}
+
+// zoneFileRR returns one value of the record as a resource record.
+func zoneFileRR(record api.Record, content string) (dns.RR, error) {
+	rdata, err := toPresentation(record.Type, writeContent(record, content))
+	if err != nil {
+		return nil, err
+	}
+	return dns.NewRR(fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(record.Name), record.TTL, record.Type, rdata))
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+// newZoneFileTestProvider returns a mock provider seeded with a
+// representative record set for the zone file tests.
+func newZoneFileTestProvider(t *testing.T) *MockProvider {
+	ctx := context.Background()
+	prov := NewMockProvider("example.com")
+	prov.Seed("example.com", api.Record{
+		Name:    "www.example.com",
+		Type:    api.RecordTypeA,
+		Content: []string{"10.0.0.1", "10.0.0.2"},
+		TTL:     300,
+	})
+	for _, rec := range []struct {
+		name, rtype, content string
+	}{
+		{"www.example.com", api.RecordTypeAAAA, "2001:db8::1"},
+		{"web.example.com", api.RecordTypeCNAME, "www.example.com"},
+		{"example.com", api.RecordTypeMX, "10 mail.example.com"},
+		{"example.com", api.RecordTypeTXT, `v=spf1 include:"_spf.example.net" ~all`},
+		{"_sip._tcp.example.com", api.RecordTypeSRV, "10 60 5060 sip.example.com"},
+	} {
+		err := prov.CreateOrUpdateDNSRecord(ctx, "example.com", rec.name, rec.rtype, rec.content, 3600, false)
+		require.Nil(t, err)
+	}
+	return prov
+}
+
+func TestExportZone(t *testing.T) {
+	ctx := context.Background()
+	prov := newZoneFileTestProvider(t)
+
+	out, err := ExportZone(ctx, prov, "example.com")
+	require.Nil(t, err)
+	golden, err := os.ReadFile("testdata/example.com.zone")
+	require.Nil(t, err)
+	require.Equal(t, string(golden), out)
+
+	_, err = ExportZone(ctx, prov, "example.net")
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+}
+
+const importZoneFile = `$ORIGIN example.com.
+$TTL 3600
+@	IN	SOA	ns1.example.net. hostmaster.example.com. 1 7200 3600 1209600 3600
+@	IN	NS	ns1.example.net.
+@	IN	MX	10 mail
+@	IN	TXT	"v=spf1 " "-all"
+www	300	IN	A	10.0.0.1
+web	IN	CNAME	www
+_sip._tcp	IN	SRV	10 60 5060 sip.example.com.
+`
+
+func TestImportZone(t *testing.T) {
+	ctx := context.Background()
+	prov := NewMockProvider("example.com")
+	prov.Seed("example.com", api.Record{
+		Name:    "old.example.com",
+		Type:    api.RecordTypeA,
+		Content: []string{"10.0.0.9"},
+		TTL:     300,
+	}, api.Record{
+		Name:    "example.com",
+		Type:    "NS",
+		Content: []string{"ns1.provider.net."},
+		TTL:     86400,
+	})
+
+	// additive import keeps other records and skips the apex SOA
+	// and NS records
+	err := ImportZone(ctx, prov, "example.com", strings.NewReader(importZoneFile))
+	require.Nil(t, err)
+	records := prov.Dump("example.com")
+	require.Equal(t, []api.Record{{
+		Name:     "_sip._tcp.example.com",
+		Type:     api.RecordTypeSRV,
+		Content:  []string{"sip.example.com."},
+		TTL:      3600,
+		Priority: 10,
+		Weight:   60,
+		Port:     5060,
+	}, {
+		Name:     "example.com",
+		Type:     api.RecordTypeMX,
+		Content:  []string{"mail.example.com."},
+		TTL:      3600,
+		Priority: 10,
+	}, {
+		Name:    "example.com",
+		Type:    "NS",
+		Content: []string{"ns1.provider.net."},
+		TTL:     86400,
+		System:  true,
+	}, {
+		Name:    "example.com",
+		Type:    api.RecordTypeTXT,
+		Content: []string{"v=spf1 -all"},
+		TTL:     3600,
+	}, {
+		Name:    "old.example.com",
+		Type:    api.RecordTypeA,
+		Content: []string{"10.0.0.9"},
+		TTL:     300,
+	}, {
+		Name:    "web.example.com",
+		Type:    api.RecordTypeCNAME,
+		Content: []string{"www.example.com."},
+		TTL:     3600,
+	}, {
+		Name:    "www.example.com",
+		Type:    api.RecordTypeA,
+		Content: []string{"10.0.0.1"},
+		TTL:     300,
+	}}, records)
+
+	// sync removes records not in the file but leaves the system
+	// records alone
+	err = ImportZone(ctx, prov, "example.com", strings.NewReader(importZoneFile), WithImportSync())
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "old.example.com")
+	require.Nil(t, err)
+	require.Empty(t, records)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 3, len(records))
+
+	// system records are imported if asked for
+	err = ImportZone(ctx, prov, "example.com", strings.NewReader(importZoneFile), WithImportSystemRecords())
+	require.Nil(t, err)
+	records, err = prov.GetDNSRecords(ctx, "example.com", "example.com")
+	require.Nil(t, err)
+	require.Equal(t, 3, len(records))
+	require.Equal(t, "NS", records[1].Type)
+	require.Equal(t, []string{"ns1.example.net."}, records[1].Content)
+
+	err = ImportZone(ctx, prov, "example.com", strings.NewReader("www IN A not-an-ip\n"))
+	require.ErrorContains(t, err, "invalid zone file")
+
+	err = ImportZone(ctx, prov, "example.net", strings.NewReader(importZoneFile), WithImportSync())
+	require.ErrorIs(t, err, api.ErrZoneNotFound)
+}
+
+func TestImportZoneRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	stub := newGCDNSStub("example.com")
+	prov := newGCDNSTestProvider(t, stub)
+
+	// multi-valued records are set in one batch
+	golden, err := os.ReadFile("testdata/example.com.zone")
+	require.Nil(t, err)
+	err = ImportZone(ctx, prov, "example.com", strings.NewReader(string(golden)))
+	require.Nil(t, err)
+	require.Equal(t, 1, stub.count(http.MethodPost, "/changes"))
+
+	out, err := ExportZone(ctx, prov, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, string(golden), out)
+}
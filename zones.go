@@ -0,0 +1,95 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/edgexr/dnsproviders/api"
+)
+
+var (
+	_ api.ZoneManager = (*CloudDNS)(nil)
+	_ api.ZoneManager = (*CloudflareAPI)(nil)
+	_ api.ZoneManager = OTC{}
+	_ api.ZoneManager = (*DigitalOcean)(nil)
+	_ api.ZoneManager = (*Hetzner)(nil)
+	_ api.ZoneManager = (*PowerDNS)(nil)
+	_ api.ZoneManager = (*Gandi)(nil)
+	_ api.ZoneManager = (*NS1)(nil)
+	_ api.ZoneManager = (*Linode)(nil)
+	_ api.ZoneManager = (*DeSEC)(nil)
+	_ api.ZoneManager = (*Vultr)(nil)
+	_ api.ZoneManager = (*Scaleway)(nil)
+	_ api.ZoneManager = (*OVH)(nil)
+	_ api.ZoneManager = (*Bunny)(nil)
+	_ api.ZoneManager = (*Namecheap)(nil)
+	_ api.ZoneManager = (*DNSimple)(nil)
+	_ api.ZoneManager = (*Porkbun)(nil)
+	_ api.ZoneManager = (*RFC2136)(nil)
+	_ api.ZoneManager = (*MockProvider)(nil)
+
+	_ api.ZoneInfoReader = (*CloudDNS)(nil)
+	_ api.ZoneInfoReader = (*CloudflareAPI)(nil)
+	_ api.ZoneInfoReader = OTC{}
+)
+
+// newZone returns the zone with the trailing dot stripped from the
+// name. An empty id defaults to the name.
+func newZone(name, id string) api.Zone {
+	name = strings.TrimSuffix(name, ".")
+	if id == "" {
+		id = name
+	}
+	return api.Zone{
+		Name: name,
+		ID:   id,
+	}
+}
+
+// nameServers returns the name server host names without trailing
+// dots.
+func nameServers(hosts []string) []string {
+	servers := []string{}
+	for _, host := range hosts {
+		servers = append(servers, strings.TrimSuffix(host, "."))
+	}
+	return servers
+}
+
+// listedZones sorts the zones listed by a provider configured for
+// zone, after checking an empty listing with noZonesAccessible.
+func listedZones(zone string, zones []api.Zone) ([]api.Zone, error) {
+	if err := noZonesAccessible(zone, len(zones)); err != nil {
+		return nil, err
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].Name < zones[j].Name
+	})
+	return zones, nil
+}
+
+// noZonesAccessible checks the number of zones listed by a provider
+// configured for zone. An empty listing is an error if a zone was
+// configured, since the credentials are then expected to see at least
+// that zone. Otherwise an empty listing is genuinely empty.
+func noZonesAccessible(zone string, listed int) error {
+	if listed == 0 && zone != "" {
+		return fmt.Errorf("%w, the credentials may lack permission to list zone %s", api.ErrNoZonesAccessible, zone)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2024 EdgeXR, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsproviders
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/edgexr/dnsproviders/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoZonesAccessible(t *testing.T) {
+	// empty but authorized, no zone was expected to be visible
+	require.Nil(t, noZonesAccessible("", 0))
+
+	// empty due to scope, the configured zone should have been listed
+	err := noZonesAccessible("example.com", 0)
+	require.True(t, errors.Is(err, api.ErrNoZonesAccessible))
+	require.Contains(t, err.Error(), "example.com")
+
+	require.Nil(t, noZonesAccessible("example.com", 1))
+	require.Nil(t, noZonesAccessible("", 2))
+}